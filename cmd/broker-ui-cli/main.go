@@ -0,0 +1,20 @@
+// Command broker-ui-cli is the command line of broker-ui without the desktop
+// app. It doesn't link the UI toolkit, so it builds and runs on machines
+// without a display or OpenGL, such as CI images.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/devalexandre/broker-ui/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,218 @@
+// Package alerts evaluates alert rules against the traffic of a subscription.
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/jsonpath"
+)
+
+// Kind is the condition an alert rule checks
+type Kind string
+
+const (
+	// KindRegex fires when a payload matches a regular expression
+	KindRegex Kind = "regex"
+	// KindJSONPath fires when a JSON payload matches a JSONPath expression
+	KindJSONPath Kind = "jsonpath"
+	// KindSilence fires when no message arrives for Threshold seconds
+	KindSilence Kind = "silence"
+	// KindRate fires when more than Threshold messages arrive per second
+	KindRate Kind = "rate"
+)
+
+// Kinds lists the rule kinds in display order
+var Kinds = []Kind{KindRegex, KindJSONPath, KindSilence, KindRate}
+
+// Rule is an alert rule of a subscription
+type Rule struct {
+	ID   int
+	Name string
+	Kind Kind
+	// Expression is the regular expression or JSONPath expression of message rules
+	Expression string
+	// Threshold is the silence in seconds or the rate in messages per second
+	Threshold float64
+	// Cooldown is the minimum time between two firings of the rule
+	Cooldown time.Duration
+	Enabled  bool
+}
+
+// Validate checks that the rule can be evaluated
+func (r Rule) Validate() error {
+	_, err := compile(r)
+	return err
+}
+
+// Firing is a rule that fired
+type Firing struct {
+	Rule Rule
+	// Subject is the subject of the message that fired the rule, if any
+	Subject string
+	// Detail describes why the rule fired
+	Detail string
+	At     time.Time
+}
+
+// ruleState is a compiled rule and its firing history
+type ruleState struct {
+	rule      Rule
+	regex     *regexp.Regexp
+	expr      *jsonpath.Expression
+	lastFired time.Time
+	// silent is set once a silence rule fired for the current quiet period
+	silent bool
+}
+
+// compile prepares a rule for evaluation
+func compile(r Rule) (*ruleState, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("alert rule name is required")
+	}
+	if r.Cooldown < 0 {
+		return nil, fmt.Errorf("alert rule %s: cooldown must not be negative", r.Name)
+	}
+
+	state := &ruleState{rule: r}
+	var err error
+	switch r.Kind {
+	case KindRegex:
+		if state.regex, err = regexp.Compile(r.Expression); err != nil {
+			return nil, fmt.Errorf("alert rule %s: %w", r.Name, err)
+		}
+	case KindJSONPath:
+		if state.expr, err = jsonpath.Compile(r.Expression); err != nil {
+			return nil, fmt.Errorf("alert rule %s: %w", r.Name, err)
+		}
+	case KindSilence, KindRate:
+		if r.Threshold <= 0 {
+			return nil, fmt.Errorf("alert rule %s: threshold must be positive", r.Name)
+		}
+	default:
+		return nil, fmt.Errorf("alert rule %s: unknown kind %q", r.Name, r.Kind)
+	}
+	return state, nil
+}
+
+// fire records a firing unless the rule is cooling down
+func (s *ruleState) fire(at time.Time, subject, detail string) (Firing, bool) {
+	if !s.lastFired.IsZero() && at.Sub(s.lastFired) < s.rule.Cooldown {
+		return Firing{}, false
+	}
+	s.lastFired = at
+	return Firing{Rule: s.rule, Subject: subject, Detail: detail, At: at}, true
+}
+
+// Monitor evaluates the enabled rules of one subscription. Message rules are
+// checked by Observe; silence and rate rules by Tick, which should be called
+// about once a second.
+type Monitor struct {
+	mutex       sync.Mutex
+	rules       []*ruleState
+	lastMessage time.Time
+	lastTick    time.Time
+	count       uint64
+}
+
+// NewMonitor compiles the enabled rules. Invalid rules are left out and
+// reported in the returned error, alongside a monitor for the others.
+func NewMonitor(rules []Rule, now time.Time) (*Monitor, error) {
+	m := &Monitor{lastMessage: now, lastTick: now}
+	var errs []error
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		state, err := compile(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.rules = append(m.rules, state)
+	}
+	return m, errors.Join(errs...)
+}
+
+// Len returns the number of rules being evaluated
+func (m *Monitor) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.rules)
+}
+
+// Observe evaluates the message rules against a received message
+func (m *Monitor) Observe(subject string, data []byte, at time.Time) []Firing {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.lastMessage = at
+	m.count++
+
+	var firings []Firing
+	for _, s := range m.rules {
+		var detail string
+		switch s.rule.Kind {
+		case KindRegex:
+			if !s.regex.Match(data) {
+				continue
+			}
+			detail = fmt.Sprintf("payload matches %s", s.rule.Expression)
+		case KindJSONPath:
+			// Payloads that aren't JSON simply don't match
+			if ok, _ := s.expr.MatchJSON(data); !ok {
+				continue
+			}
+			detail = fmt.Sprintf("payload matches %s", s.expr)
+		case KindSilence:
+			s.silent = false
+			continue
+		default:
+			continue
+		}
+		if firing, ok := s.fire(at, subject, detail); ok {
+			firings = append(firings, firing)
+		}
+	}
+	return firings
+}
+
+// Tick evaluates the silence and rate rules at now
+func (m *Monitor) Tick(now time.Time) []Firing {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	elapsed := now.Sub(m.lastTick)
+	if elapsed <= 0 {
+		return nil
+	}
+	rate := float64(m.count) / elapsed.Seconds()
+	m.count = 0
+	m.lastTick = now
+	quiet := now.Sub(m.lastMessage)
+
+	var firings []Firing
+	for _, s := range m.rules {
+		switch s.rule.Kind {
+		case KindRate:
+			if rate <= s.rule.Threshold {
+				continue
+			}
+			if firing, ok := s.fire(now, "", fmt.Sprintf("rate %.1f msgs/s above %g msgs/s", rate, s.rule.Threshold)); ok {
+				firings = append(firings, firing)
+			}
+		case KindSilence:
+			if s.silent || quiet.Seconds() < s.rule.Threshold {
+				continue
+			}
+			if firing, ok := s.fire(now, "", fmt.Sprintf("no message for %v", quiet.Round(time.Second))); ok {
+				s.silent = true
+				firings = append(firings, firing)
+			}
+		}
+	}
+	return firings
+}
@@ -0,0 +1,102 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageRulesRespectCooldown(t *testing.T) {
+	start := time.Now()
+	m, err := NewMonitor([]Rule{
+		{ID: 1, Name: "errors", Kind: KindJSONPath, Expression: "$.level == 'error'", Cooldown: time.Minute, Enabled: true},
+		{ID: 2, Name: "timeouts", Kind: KindRegex, Expression: "timeout", Enabled: true},
+		{ID: 3, Name: "disabled", Kind: KindRegex, Expression: ".", Enabled: false},
+	}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len = %d, want the 2 enabled rules", m.Len())
+	}
+
+	firings := m.Observe("app.logs", []byte(`{"level":"error","msg":"timeout"}`), start)
+	if len(firings) != 2 || firings[0].Rule.ID != 1 || firings[0].Subject != "app.logs" {
+		t.Fatalf("firings = %+v, want both rules on app.logs", firings)
+	}
+
+	// The JSONPath rule is cooling down; the regex rule has no cooldown
+	firings = m.Observe("app.logs", []byte(`{"level":"error","msg":"timeout"}`), start.Add(30*time.Second))
+	if len(firings) != 1 || firings[0].Rule.ID != 2 {
+		t.Fatalf("firings during cooldown = %+v, want only the regex rule", firings)
+	}
+
+	firings = m.Observe("app.logs", []byte(`{"level":"error"}`), start.Add(61*time.Second))
+	if len(firings) != 1 || firings[0].Rule.ID != 1 {
+		t.Fatalf("firings after cooldown = %+v, want the JSONPath rule", firings)
+	}
+
+	if firings := m.Observe("app.logs", []byte("not json"), start.Add(2*time.Minute)); len(firings) != 0 {
+		t.Errorf("non-JSON payload fired %+v", firings)
+	}
+}
+
+func TestSilenceFiresOncePerQuietPeriod(t *testing.T) {
+	start := time.Now()
+	m, err := NewMonitor([]Rule{{ID: 1, Name: "quiet", Kind: KindSilence, Threshold: 60, Enabled: true}}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if firings := m.Tick(start.Add(59 * time.Second)); len(firings) != 0 {
+		t.Fatalf("fired before the threshold: %+v", firings)
+	}
+	if firings := m.Tick(start.Add(60 * time.Second)); len(firings) != 1 {
+		t.Fatalf("firings = %+v, want one after 60s of silence", firings)
+	}
+	if firings := m.Tick(start.Add(90 * time.Second)); len(firings) != 0 {
+		t.Fatalf("fired twice for one quiet period: %+v", firings)
+	}
+
+	// A message ends the quiet period
+	m.Observe("a", nil, start.Add(100*time.Second))
+	if firings := m.Tick(start.Add(170 * time.Second)); len(firings) != 1 {
+		t.Errorf("firings = %+v, want one for the next quiet period", firings)
+	}
+}
+
+func TestRateRule(t *testing.T) {
+	start := time.Now()
+	m, err := NewMonitor([]Rule{{ID: 1, Name: "flood", Kind: KindRate, Threshold: 1000, Enabled: true}}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 500 {
+		m.Observe("a", nil, start)
+	}
+	if firings := m.Tick(start.Add(time.Second)); len(firings) != 0 {
+		t.Fatalf("500 msgs/s fired %+v", firings)
+	}
+
+	for range 2001 {
+		m.Observe("a", nil, start.Add(time.Second))
+	}
+	firings := m.Tick(start.Add(2 * time.Second))
+	if len(firings) != 1 {
+		t.Fatalf("firings = %+v, want one above 1000 msgs/s", firings)
+	}
+}
+
+func TestNewMonitorReportsInvalidRules(t *testing.T) {
+	m, err := NewMonitor([]Rule{
+		{Name: "bad regex", Kind: KindRegex, Expression: "(", Enabled: true},
+		{Name: "no threshold", Kind: KindRate, Enabled: true},
+		{Name: "good", Kind: KindRegex, Expression: "x", Enabled: true},
+	}, time.Now())
+	if err == nil {
+		t.Error("invalid rules were not reported")
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d, want the valid rule only", m.Len())
+	}
+}
@@ -0,0 +1,361 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/services"
+	"golang.org/x/net/websocket"
+)
+
+const testToken = "secret"
+
+// fakeProvider delivers messages handed to it by the test
+type fakeProvider struct {
+	mutex    sync.Mutex
+	handlers map[string]messaging.MessageHandler
+}
+
+func (f *fakeProvider) Connect(url string) error                  { return nil }
+func (f *fakeProvider) Publish(subject string, data []byte) error { return nil }
+func (f *fakeProvider) Close() error                              { return nil }
+func (f *fakeProvider) IsConnected() bool                         { return true }
+func (f *fakeProvider) GetProviderType() messaging.ProviderType   { return messaging.ProviderNATS }
+
+func (f *fakeProvider) Capabilities() messaging.Capabilities {
+	return messaging.Capabilities{Wildcards: messaging.WildcardsNATS, Names: messaging.NamesTokens, SubjectKind: "subject"}
+}
+
+func (f *fakeProvider) Subscribe(pattern string, handler messaging.MessageHandler) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.handlers[pattern] = handler
+	return nil
+}
+
+func (f *fakeProvider) Unsubscribe(pattern string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.handlers, pattern)
+	return nil
+}
+
+func (f *fakeProvider) deliver(pattern string, msg *messaging.Message) {
+	f.mutex.Lock()
+	handler := f.handlers[pattern]
+	f.mutex.Unlock()
+	handler(msg)
+}
+
+// newTestServer starts an API over a fresh database with one saved server
+func newTestServer(t *testing.T) (*Server, *services.MessageService) {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	serverRepo := database.NewServerRepository(db.GetDB())
+	topicRepo := database.NewTopicRepository(db.GetDB())
+	subscriptionRepo := database.NewSubscriptionRepository(db.GetDB())
+	if err := serverRepo.Save("local", "localhost:4222", messaging.ProviderNATS); err != nil {
+		t.Fatal(err)
+	}
+
+	messageService := services.NewMessageService(topicRepo, subscriptionRepo)
+	s := NewServer(services.NewServerService(serverRepo, topicRepo, subscriptionRepo), messageService)
+	if err := s.Start("127.0.0.1:0", testToken); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s, messageService
+}
+
+// get sends an authorized GET request
+func get(t *testing.T, s *Server, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://"+s.Addr()+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestStartRequiresLoopbackAndToken(t *testing.T) {
+	s := &Server{}
+	if err := s.Start("0.0.0.0:0", testToken); err == nil {
+		t.Error("a non-loopback address was accepted")
+	}
+	if err := s.Start("127.0.0.1:0", " "); err == nil {
+		t.Error("an empty token was accepted")
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp, err := http.Get("http://" + s.Addr() + "/api/servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp, err = http.Get("http://" + s.Addr() + "/api/servers?token=" + testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("with query token: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+s.Addr()+"/api/servers", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Host = "attacker.example"
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign host: status %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestServersAndPublishRequireConnection(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp := get(t, s, "/api/servers")
+	var servers []ServerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(servers) != 1 || servers[0].Name != "local" || servers[0].Connected {
+		t.Fatalf("servers = %+v", servers)
+	}
+
+	body := strings.NewReader(`{"subject":"orders","data":"{}"}`)
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/api/servers/%d/publish", s.Addr(), servers[0].ID), body)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("publish on a disconnected server: status %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	s, messageService := newTestServer(t)
+	provider := &fakeProvider{handlers: make(map[string]messaging.MessageHandler)}
+	if err := messageService.Subscribe(provider, "orders", "orders.>", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// SSE, limited to the orders subscription
+	resp := get(t, s, "/api/stream?sub=orders")
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+
+	// WebSocket, for every subscription
+	ws, err := websocket.Dial(fmt.Sprintf("ws://%s/api/ws?token=%s", s.Addr(), testToken), "", "http://127.0.0.1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	waitForClients(t, s, 2)
+
+	provider.deliver("orders.>", &messaging.Message{Subject: "orders.created", Data: []byte(`{"id":1}`)})
+
+	var event Message
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if event.Subscription != "orders" || event.Subject != "orders.created" || event.Data != `{"id":1}` {
+		t.Errorf("SSE event = %+v", event)
+	}
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var frame Message
+	if err := websocket.JSON.Receive(ws, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Subject != "orders.created" {
+		t.Errorf("WebSocket frame = %+v", frame)
+	}
+}
+
+// waitForClients waits until n stream clients are registered
+func waitForClients(t *testing.T, s *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.hub.mutex.Lock()
+		count := len(s.hub.clients)
+		s.hub.mutex.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%d stream clients did not connect", n)
+}
+
+// send sends an authorized request with a JSON body
+func send(t *testing.T, s *Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://"+s.Addr()+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// connectMemoryServer saves and connects a memory server
+func connectMemoryServer(t *testing.T, s *Server) (models.Server, messaging.MessagingProvider) {
+	t.Helper()
+	if err := s.serverService.SaveServer("mem", "memory://"+t.Name(), messaging.ProviderMemory); err != nil {
+		t.Fatal(err)
+	}
+	servers, err := s.serverService.GetAllServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := servers[len(servers)-1]
+	if err := s.serverService.ConnectToServer(mem.ID, mem.URL, mem.ProviderType); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.serverService.DisconnectFromServer(mem.ID) })
+	provider, _ := s.serverService.GetMessagingProvider(mem.ID)
+	return mem, provider
+}
+
+func TestSubscriptionsKeepUINames(t *testing.T) {
+	s, messageService := newTestServer(t)
+	mem, provider := connectMemoryServer(t, s)
+
+	// A subscription tab of the UI, with its queue
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := messageService.Subscribe(provider, "orders", "orders.>", queue); err != nil {
+		t.Fatal(err)
+	}
+
+	path := fmt.Sprintf("/api/servers/%d/subscriptions", mem.ID)
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"name":"orders","pattern":"orders.created"}`, http.StatusConflict},
+		{`{"name":"audit","pattern":"orders.*","policy":"block"}`, http.StatusBadRequest},
+		{`{"name":"audit","pattern":"orders.*"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if resp := send(t, s, http.MethodPost, path, tt.body); resp.StatusCode != tt.status {
+			t.Errorf("POST %s: status %d, want %d", tt.body, resp.StatusCode, tt.status)
+		}
+	}
+
+	if resp := send(t, s, http.MethodDelete, "/api/subscriptions/orders", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("DELETE of a UI subscription: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := send(t, s, http.MethodDelete, "/api/subscriptions/audit", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE audit: status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+
+	// The memory broker delivers on the subscription's goroutine
+	provider.Publish("orders.created", []byte("{}"))
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats, ok := messageService.GetSubscriptionStats()["orders"]
+		if ok && stats.Received == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("UI subscription stats = %+v, %v; want it to keep receiving", stats, ok)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSavedSubscriptionMovesToUI(t *testing.T) {
+	s, messageService := newTestServer(t)
+	mem, provider := connectMemoryServer(t, s)
+
+	path := fmt.Sprintf("/api/servers/%d/subscriptions", mem.ID)
+	body := `{"name":"orders","pattern":"orders.>","save":true,"policy":"block"}`
+	if resp := send(t, s, http.MethodPost, path, body); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST %s: status %d", body, resp.StatusCode)
+	}
+	saved, err := s.serverService.GetSubscriptionsForServer(mem.ID)
+	if err != nil || len(saved) != 1 || saved[0].BackpressurePolicy != messaging.PolicyBlock {
+		t.Fatalf("saved subscriptions = %+v, %v", saved, err)
+	}
+
+	// Opening the saved subscription in the UI takes it over
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{Policy: messaging.PolicyBlock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := messageService.Subscribe(provider, "orders", "orders.>", queue); err != nil {
+		t.Fatalf("UI subscription of a saved API subscription: %v", err)
+	}
+	if resp := send(t, s, http.MethodDelete, "/api/subscriptions/orders", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("DELETE after the UI took over: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	provider.Publish("orders.created", []byte("{}"))
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats, ok := messageService.GetSubscriptionStats()["orders"]
+		if ok && stats.Received == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("UI subscription stats = %+v, %v; want it to receive", stats, ok)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
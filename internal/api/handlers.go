@@ -0,0 +1,374 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+// maxBodySize limits request bodies
+const maxBodySize = 16 << 20
+
+// ServerInfo is a saved server
+type ServerInfo struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Provider  string `json:"provider"`
+	Connected bool   `json:"connected"`
+}
+
+// PublishRequest is the body of a publish call. Data is sent as is, or
+// DataBase64 is decoded for binary payloads.
+type PublishRequest struct {
+	Subject    string            `json:"subject"`
+	Data       string            `json:"data,omitempty"`
+	DataBase64 string            `json:"dataBase64,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// SubscriptionRequest is the body of a create subscription call
+type SubscriptionRequest struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Policy is the backpressure policy of the saved subscription's tab.
+	// The API streams messages without a queue, so it requires Save.
+	Policy string `json:"policy,omitempty"`
+	// Save keeps the subscription with the server. Opening it in the UI
+	// moves it to the tab, and the streams keep its messages under the name.
+	Save bool `json:"save,omitempty"`
+}
+
+// SubscriptionInfo is a saved or API-started subscription
+type SubscriptionInfo struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Policy  string `json:"policy,omitempty"`
+	Saved   bool   `json:"saved"`
+	// Active is set for subscriptions started through the API
+	Active bool `json:"active"`
+}
+
+// Message is a received message as streamed to clients. Text payloads are
+// kept in data and binary payloads in dataBase64.
+type Message struct {
+	Subscription string            `json:"subscription"`
+	Subject      string            `json:"subject"`
+	Data         string            `json:"data,omitempty"`
+	DataBase64   string            `json:"dataBase64,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Timestamp    time.Time         `json:"timestamp"`
+}
+
+// newMessage converts a received message
+func newMessage(subName string, msg *messaging.Message) Message {
+	m := Message{Subscription: subName, Subject: msg.Subject, Headers: msg.Headers, Timestamp: time.Now()}
+	if msg.Timestamp != 0 {
+		m.Timestamp = time.Unix(0, msg.Timestamp)
+	}
+	if utf8.Valid(msg.Data) {
+		m.Data = string(msg.Data)
+	} else {
+		m.DataBase64 = base64.StdEncoding.EncodeToString(msg.Data)
+	}
+	return m
+}
+
+// Handler returns the API routes behind the token check
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/servers", s.listServers)
+	mux.HandleFunc("POST /api/servers/{id}/connect", s.connectServer)
+	mux.HandleFunc("POST /api/servers/{id}/publish", s.publish)
+	mux.HandleFunc("GET /api/servers/{id}/subscriptions", s.listSubscriptions)
+	mux.HandleFunc("POST /api/servers/{id}/subscriptions", s.createSubscription)
+	mux.HandleFunc("DELETE /api/subscriptions/{name}", s.stopSubscription)
+	mux.HandleFunc("GET /api/stream", s.streamSSE)
+	mux.Handle("GET /api/ws", s.streamWebSocket())
+	return s.authorize(mux)
+}
+
+// listServers returns the saved servers and whether they are connected
+func (s *Server) listServers(w http.ResponseWriter, r *http.Request) {
+	servers, err := s.serverService.GetAllServers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	infos := make([]ServerInfo, len(servers))
+	for i, server := range servers {
+		provider, ok := s.serverService.GetMessagingProvider(server.ID)
+		infos[i] = ServerInfo{
+			ID:        server.ID,
+			Name:      server.Name,
+			URL:       server.URL,
+			Provider:  string(server.ProviderType),
+			Connected: ok && provider.IsConnected(),
+		}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// connectServer connects a saved server unless it is connected already
+func (s *Server) connectServer(w http.ResponseWriter, r *http.Request) {
+	server, ok := s.findServer(w, r)
+	if !ok {
+		return
+	}
+	if provider, ok := s.serverService.GetMessagingProvider(server.ID); !ok || !provider.IsConnected() {
+		if err := s.serverService.ConnectToServer(server.ID, server.URL, server.ProviderType); err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, ServerInfo{ID: server.ID, Name: server.Name, URL: server.URL, Provider: string(server.ProviderType), Connected: true})
+}
+
+// publish sends a message through a connected server
+func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
+	server, ok := s.findServer(w, r)
+	if !ok {
+		return
+	}
+	var req PublishRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Subject) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("subject is required"))
+		return
+	}
+	data := []byte(req.Data)
+	if req.DataBase64 != "" {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(req.DataBase64); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid dataBase64: %w", err))
+			return
+		}
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the payload is empty"))
+		return
+	}
+
+	provider, ok := s.connectedProvider(w, server)
+	if !ok {
+		return
+	}
+	if err := s.messageService.PublishMessageWithHeaders(provider, req.Subject, data, req.Headers); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"subject": req.Subject, "bytes": len(data)})
+}
+
+// listSubscriptions returns the saved subscriptions of a server and the ones
+// started through the API
+func (s *Server) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	server, ok := s.findServer(w, r)
+	if !ok {
+		return
+	}
+	saved, err := s.serverService.GetSubscriptionsForServer(server.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	infos := []SubscriptionInfo{}
+	listed := make(map[string]bool)
+	for _, sub := range saved {
+		_, active := s.active[sub.SubName]
+		infos = append(infos, SubscriptionInfo{
+			Name:    sub.SubName,
+			Pattern: sub.SubjectPattern,
+			Policy:  string(sub.BackpressurePolicy),
+			Saved:   true,
+			Active:  active,
+		})
+		listed[sub.SubName] = true
+	}
+	for name, sub := range s.active {
+		if sub.serverID == server.ID && !listed[name] {
+			infos = append(infos, SubscriptionInfo{Name: name, Pattern: sub.pattern, Active: true})
+		}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// createSubscription starts a subscription on a connected server; its
+// messages are available from the streams under its name
+func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
+	server, ok := s.findServer(w, r)
+	if !ok {
+		return
+	}
+	var req SubscriptionRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.Name, req.Pattern = strings.TrimSpace(req.Name), strings.TrimSpace(req.Pattern)
+	if req.Name == "" || req.Pattern == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("name and pattern are required"))
+		return
+	}
+	policy := messaging.PolicyDropOldest
+	if req.Policy != "" && !req.Save {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("policy applies to saved subscriptions only"))
+		return
+	}
+	if req.Policy != "" {
+		policy = messaging.BackpressurePolicy(req.Policy)
+		if !messaging.IsValidBackpressurePolicy(policy) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported backpressure policy: %s", req.Policy))
+			return
+		}
+	}
+
+	provider, ok := s.connectedProvider(w, server)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.active[req.Name]; exists {
+		writeError(w, http.StatusConflict, fmt.Errorf("subscription %s is already active", req.Name))
+		return
+	}
+	// The API reads messages from the streams, so no queue is needed.
+	// Subscribe rejects names running in the UI, whose queue and metrics
+	// the API subscription would take over.
+	if err := s.messageService.Subscribe(provider, req.Name, req.Pattern, nil); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if req.Save {
+		if err := s.messageService.SaveSubscription(server.ID, req.Name, req.Pattern, policy); err != nil {
+			s.messageService.Unsubscribe(provider, req.Name, req.Pattern)
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.active[req.Name] = activeSubscription{serverID: server.ID, pattern: req.Pattern, provider: provider}
+	if req.Save {
+		// The UI tab subscribes to the same pattern on the same connection,
+		// so the API subscription makes way for it
+		name := req.Name
+		s.messageService.HandOff(name, func() { s.handOver(name) })
+	}
+
+	info := SubscriptionInfo{Name: req.Name, Pattern: req.Pattern, Saved: req.Save, Active: true}
+	if req.Save {
+		info.Policy = string(policy)
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+// stopSubscription stops a subscription started through the API
+func (s *Server) stopSubscription(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	s.mutex.Lock()
+	sub, ok := s.active[name]
+	delete(s.active, name)
+	s.mutex.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("subscription %s was not started through the API", name))
+		return
+	}
+	if err := s.messageService.Unsubscribe(sub.provider, name, sub.pattern); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handOver stops an API subscription that a UI tab takes over
+func (s *Server) handOver(name string) {
+	s.mutex.Lock()
+	sub, ok := s.active[name]
+	delete(s.active, name)
+	s.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := s.messageService.Unsubscribe(sub.provider, name, sub.pattern); err != nil {
+		log.Printf("Error handing API subscription %s over to the UI: %v", name, err)
+	}
+}
+
+// findServer returns the saved server named by the id path value
+func (s *Server) findServer(w http.ResponseWriter, r *http.Request) (models.Server, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid server id %q", r.PathValue("id")))
+		return models.Server{}, false
+	}
+	servers, err := s.serverService.GetAllServers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return models.Server{}, false
+	}
+	for _, server := range servers {
+		if server.ID == id {
+			return server, true
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("server %d not found", id))
+	return models.Server{}, false
+}
+
+// connectedProvider returns the connection of a server, failing when it is not connected
+func (s *Server) connectedProvider(w http.ResponseWriter, server models.Server) (messaging.MessagingProvider, bool) {
+	provider, ok := s.serverService.GetMessagingProvider(server.ID)
+	if !ok || !provider.IsConnected() {
+		writeError(w, http.StatusConflict, fmt.Errorf("server %s is not connected; POST /api/servers/%d/connect first", server.Name, server.ID))
+		return nil, false
+	}
+	return provider, true
+}
+
+// readJSON decodes a request body, answering 400 when it is invalid
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
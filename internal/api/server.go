@@ -0,0 +1,195 @@
+// Package api serves a local HTTP API to drive the app from scripts and test
+// suites. It listens on loopback addresses only, requires a bearer token and
+// works on the same services as the UI, so both see the same connections and
+// subscriptions.
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/services"
+)
+
+// DefaultAddress is the default listen address of the API
+const DefaultAddress = "127.0.0.1:9465"
+
+// activeSubscription is a subscription started through the API
+type activeSubscription struct {
+	serverID int
+	pattern  string
+	provider messaging.MessagingProvider
+}
+
+// Server serves the API over HTTP
+type Server struct {
+	serverService  *services.ServerService
+	messageService *services.MessageService
+	hub            *hub
+
+	mutex  sync.Mutex
+	server *http.Server
+	addr   string
+	token  string
+	// active holds the subscriptions started through the API by name
+	active map[string]activeSubscription
+}
+
+// NewServer creates a stopped API server. Messages of every subscription,
+// whether started by the UI or the API, are available to its streams.
+func NewServer(serverService *services.ServerService, messageService *services.MessageService) *Server {
+	s := &Server{
+		serverService:  serverService,
+		messageService: messageService,
+		hub:            newHub(),
+		active:         make(map[string]activeSubscription),
+	}
+	messageService.AddObserver(s.hub.publish)
+	return s
+}
+
+// GenerateToken returns a random token for the API
+func GenerateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Start listens on a loopback addr, replacing a running listener. Requests
+// must carry token.
+func (s *Server) Start(addr, token string) error {
+	if err := checkLoopback(addr); err != nil {
+		return err
+	}
+	if strings.TrimSpace(token) == "" {
+		return fmt.Errorf("an API token is required")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.token = token
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("API stopped: %v", err)
+		}
+	}()
+
+	s.server = server
+	s.addr = listener.Addr().String()
+	log.Printf("Serving API on http://%s/api", s.addr)
+	return nil
+}
+
+// Stop closes the listener and the streams and stops the subscriptions
+// started through the API
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stop()
+}
+
+func (s *Server) stop() {
+	if s.server == nil {
+		return
+	}
+	for name, sub := range s.active {
+		if err := s.messageService.Unsubscribe(sub.provider, name, sub.pattern); err != nil {
+			log.Printf("Error stopping API subscription %s: %v", name, err)
+		}
+	}
+	s.active = make(map[string]activeSubscription)
+	s.hub.closeAll()
+
+	if err := s.server.Close(); err != nil {
+		log.Printf("Error stopping API: %v", err)
+	}
+	log.Printf("Stopped serving API on %s", s.addr)
+	s.server = nil
+	s.addr = ""
+}
+
+// Addr returns the address being served, or "" when stopped
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addr
+}
+
+// currentToken returns the token requests must carry
+func (s *Server) currentToken() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.token
+}
+
+// checkLoopback rejects listen addresses reachable from other machines
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %s: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("the API only listens on loopback addresses such as %s", DefaultAddress)
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether host names the local machine
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authorize checks that a request comes from this machine with the token.
+// The token is read from the Authorization header, or from the token query
+// parameter for browser EventSource and WebSocket clients.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The Host check stops web pages from reaching the API through DNS rebinding
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopbackHost(host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("the API only accepts loopback host names"))
+			return
+		}
+
+		given := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			given = bearer
+		}
+		token := s.currentToken()
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid API token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
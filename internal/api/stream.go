@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"golang.org/x/net/websocket"
+)
+
+// clientBuffer is how many messages a slow stream client may fall behind
+// before messages are dropped for it
+const clientBuffer = 256
+
+// keepAliveInterval is how often idle SSE streams send a comment
+const keepAliveInterval = 15 * time.Second
+
+// client is a connected stream
+type client struct {
+	// subs limits the stream to some subscriptions; empty means all
+	subs     map[string]bool
+	messages chan Message
+}
+
+// hub fans received messages out to the stream clients
+type hub struct {
+	mutex   sync.Mutex
+	clients map[*client]struct{}
+}
+
+// newHub creates a hub without clients
+func newHub() *hub {
+	return &hub{clients: make(map[*client]struct{})}
+}
+
+// publish hands a received message to the interested clients without
+// blocking the provider; it is registered as a MessageService observer
+func (h *hub) publish(subName string, msg *messaging.Message) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var converted *Message
+	for c := range h.clients {
+		if len(c.subs) > 0 && !c.subs[subName] {
+			continue
+		}
+		if converted == nil {
+			m := newMessage(subName, msg)
+			converted = &m
+		}
+		select {
+		case c.messages <- *converted:
+		default:
+			// The client is too slow; drop rather than stall the subscription
+		}
+	}
+}
+
+// add registers a client for some subscriptions, or all when subs is empty
+func (h *hub) add(subs []string) *client {
+	c := &client{subs: make(map[string]bool, len(subs)), messages: make(chan Message, clientBuffer)}
+	for _, sub := range subs {
+		c.subs[sub] = true
+	}
+
+	h.mutex.Lock()
+	h.clients[c] = struct{}{}
+	h.mutex.Unlock()
+	return c
+}
+
+// remove unregisters a client and closes its channel
+func (h *hub) remove(c *client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.messages)
+	}
+}
+
+// closeAll ends every stream
+func (h *hub) closeAll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.messages)
+	}
+}
+
+// streamSSE streams received messages as server-sent events. The sub query
+// parameter, which may be repeated, limits the stream to some subscriptions.
+func (s *Server) streamSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	c := s.hub.add(r.URL.Query()["sub"])
+	defer s.hub.remove(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case msg, ok := <-c.messages:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// streamWebSocket streams received messages as JSON text frames, limited
+// like streamSSE by the sub query parameter
+func (s *Server) streamWebSocket() http.Handler {
+	return websocket.Server{
+		// Requests are already checked for the host and the token, so any
+		// origin, including none for non-browser clients, is accepted
+		Handshake: func(*websocket.Config, *http.Request) error {
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			c := s.hub.add(ws.Request().URL.Query()["sub"])
+			defer s.hub.remove(c)
+
+			// Client frames are ignored; reading detects the client going away
+			gone := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, ws)
+				close(gone)
+			}()
+
+			for {
+				select {
+				case <-gone:
+					return
+				case msg, ok := <-c.messages:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, msg); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,216 @@
+// Package bench generates publish load against a messaging provider and
+// measures throughput, errors, publish latency and end-to-end latency.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/templating"
+)
+
+// PublishFunc publishes one message
+type PublishFunc func(subject string, data []byte) error
+
+// Config describes a benchmark run
+type Config struct {
+	Subject string
+	// Count is the number of messages to publish, 0 to run for Duration
+	Count int
+	// Duration limits the run; with Count it stops at whichever comes first
+	Duration time.Duration
+	// Rate is the target total rate in messages per second, 0 for unlimited
+	Rate float64
+	// Publishers is the number of concurrent publishers
+	Publishers int
+	// PayloadSize is the size of generated payloads when Template is empty
+	PayloadSize int
+	// Template is rendered for every message with its sequence number
+	Template string
+	// Vars are the user variables of Template
+	Vars map[string]string
+}
+
+// Validate checks that the configuration describes a finite run
+func (c Config) Validate() error {
+	if c.Subject == "" {
+		return fmt.Errorf("benchmark subject is required")
+	}
+	if c.Count < 0 || c.Duration < 0 || c.Rate < 0 || c.PayloadSize < 0 {
+		return fmt.Errorf("benchmark count, duration, rate and payload size can't be negative")
+	}
+	if c.Count == 0 && c.Duration == 0 {
+		return fmt.Errorf("benchmark needs a message count or a duration")
+	}
+	if c.Publishers < 1 {
+		return fmt.Errorf("benchmark needs at least one publisher")
+	}
+	if c.Template != "" {
+		if err := templating.Parse(c.Template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Progress is a snapshot of a running benchmark
+type Progress struct {
+	Sent    uint64
+	Errors  uint64
+	Elapsed time.Duration
+}
+
+// Result is the outcome of a benchmark run
+type Result struct {
+	Config     Config
+	StartedAt  time.Time
+	Elapsed    time.Duration
+	Sent       uint64
+	Errors     uint64
+	Bytes      uint64
+	Throughput float64
+	ByteRate   float64
+	Latency    Latency
+	// FirstError is the first publish or render error, if any
+	FirstError string
+}
+
+// Run publishes messages as described by cfg until the count or duration is
+// reached or ctx is cancelled. progress, if not nil, is called about every
+// interval from a separate goroutine.
+func Run(ctx context.Context, publish PublishFunc, cfg Config, interval time.Duration, progress func(Progress)) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var fixed []byte
+	if cfg.Template == "" {
+		fixed = bytes.Repeat([]byte("x"), cfg.PayloadSize)
+	}
+
+	var (
+		next     atomic.Int64
+		sent     atomic.Uint64
+		errors   atomic.Uint64
+		sentSize atomic.Uint64
+		errOnce  sync.Once
+		firstErr string
+	)
+	recordError := func(err error) {
+		errors.Add(1)
+		errOnce.Do(func() { firstErr = err.Error() })
+	}
+
+	start := time.Now()
+	latencies := NewReservoir(ReservoirSize)
+
+	var wg sync.WaitGroup
+	for range cfg.Publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				// Messages are numbered across publishers so the rate is shared
+				i := next.Add(1)
+				if cfg.Count > 0 && i > int64(cfg.Count) {
+					return
+				}
+				if cfg.Rate > 0 {
+					due := start.Add(time.Duration(float64(i-1) / cfg.Rate * float64(time.Second)))
+					if !sleepUntil(ctx, due) {
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				data := fixed
+				if cfg.Template != "" {
+					var err error
+					data, err = templating.Render(cfg.Template, templating.Context{Seq: i, Vars: cfg.Vars})
+					if err != nil {
+						recordError(err)
+						continue
+					}
+				}
+
+				begin := time.Now()
+				err := publish(cfg.Subject, data)
+				latencies.Add(time.Since(begin))
+				if err != nil {
+					recordError(err)
+					continue
+				}
+				sent.Add(1)
+				sentSize.Add(uint64(len(data)))
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	if progress != nil && interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					progress(Progress{Sent: sent.Load(), Errors: errors.Load(), Elapsed: time.Since(start)})
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	elapsed := time.Since(start)
+
+	result := Result{
+		Config:     cfg,
+		StartedAt:  start,
+		Elapsed:    elapsed,
+		Sent:       sent.Load(),
+		Errors:     errors.Load(),
+		Bytes:      sentSize.Load(),
+		Latency:    latencies.Summary(),
+		FirstError: firstErr,
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.Throughput = float64(result.Sent) / seconds
+		result.ByteRate = float64(result.Bytes) / seconds
+	}
+	if progress != nil {
+		progress(Progress{Sent: result.Sent, Errors: result.Errors, Elapsed: elapsed})
+	}
+	return result, nil
+}
+
+// sleepUntil waits for t and reports false if ctx is done first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,139 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunPublishesCount(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	publish := func(subject string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[string(data)] = true
+		return nil
+	}
+
+	cfg := Config{Subject: "bench.test", Count: 500, Publishers: 4, Template: `{"seq":{{seq}}}`}
+	result, err := Run(context.Background(), publish, cfg, 0, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Sent != 500 || result.Errors != 0 {
+		t.Fatalf("Sent = %d, Errors = %d, want 500 and 0", result.Sent, result.Errors)
+	}
+	if len(seen) != 500 {
+		t.Errorf("distinct payloads = %d, want 500", len(seen))
+	}
+	if result.Latency.Count != 500 || result.Latency.P50 > result.Latency.P99 {
+		t.Errorf("unexpected latency summary %+v", result.Latency)
+	}
+}
+
+func TestRunCountsErrors(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	publish := func(subject string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls%2 == 0 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+
+	result, err := Run(context.Background(), publish, Config{Subject: "s", Count: 10, Publishers: 1, PayloadSize: 16}, 0, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Sent != 5 || result.Errors != 5 || result.Bytes != 80 {
+		t.Errorf("Sent = %d, Errors = %d, Bytes = %d, want 5, 5 and 80", result.Sent, result.Errors, result.Bytes)
+	}
+	if result.FirstError != "broker unavailable" {
+		t.Errorf("FirstError = %q", result.FirstError)
+	}
+}
+
+func TestRunHonorsRateAndDuration(t *testing.T) {
+	publish := func(string, []byte) error { return nil }
+
+	cfg := Config{Subject: "s", Duration: 300 * time.Millisecond, Rate: 100, Publishers: 3}
+	result, err := Run(context.Background(), publish, cfg, 0, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// 100 msgs/s for 0.3s is about 30 messages
+	if result.Sent < 20 || result.Sent > 40 {
+		t.Errorf("Sent = %d, want about 30", result.Sent)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, cfg := range []Config{
+		{Count: 1, Publishers: 1},
+		{Subject: "s", Publishers: 1},
+		{Subject: "s", Count: 1},
+		{Subject: "s", Count: 1, Publishers: 1, Template: "{{nope}}"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", cfg)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		samples[i] = time.Duration(100-i) * time.Millisecond
+	}
+	latency := Summarize(samples)
+	if latency.Min != time.Millisecond || latency.Max != 100*time.Millisecond {
+		t.Errorf("Min = %v, Max = %v", latency.Min, latency.Max)
+	}
+	if latency.P50 != 50*time.Millisecond || latency.P99 != 99*time.Millisecond {
+		t.Errorf("P50 = %v, P99 = %v", latency.P50, latency.P99)
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	r := NewReservoir(100)
+	for i := 1; i <= 10000; i++ {
+		r.Add(time.Duration(i) * time.Microsecond)
+	}
+	if len(r.samples) != 100 {
+		t.Errorf("kept %d samples, want 100", len(r.samples))
+	}
+
+	latency := r.Summary()
+	if latency.Count != 10000 || latency.Min != time.Microsecond || latency.Max != 10*time.Millisecond {
+		t.Errorf("Count = %d, Min = %v, Max = %v", latency.Count, latency.Min, latency.Max)
+	}
+	if latency.Mean != 5000500*time.Nanosecond {
+		t.Errorf("Mean = %v, want the exact mean", latency.Mean)
+	}
+	// The sampled median of a uniform spread lands near the middle
+	if latency.P50 < 3*time.Millisecond || latency.P50 > 7*time.Millisecond {
+		t.Errorf("P50 = %v, want about 5ms", latency.P50)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	result := Result{Config: Config{Subject: "orders", Count: 10, Publishers: 2}, Sent: 10, Latency: Latency{P50: 1500 * time.Microsecond}}
+	data, err := CSV([]Result{result})
+	if err != nil {
+		t.Fatalf("CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("CSV has %d lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[0], "p50_ms") || !strings.Contains(lines[1], ",orders,10,") || !strings.Contains(lines[1], "1.500") {
+		t.Errorf("unexpected CSV:\n%s", data)
+	}
+}
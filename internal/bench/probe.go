@@ -0,0 +1,372 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StampMode selects where a probe stamps its sequence number and timestamp
+type StampMode string
+
+const (
+	// StampHeader stamps messages with headers, keeping the payload opaque
+	StampHeader StampMode = "header"
+	// StampEnvelope stamps messages with a JSON envelope payload
+	StampEnvelope StampMode = "envelope"
+)
+
+// Probe headers used by StampHeader
+const (
+	ProbeIDHeader     = "X-Probe-Id"
+	ProbeSeqHeader    = "X-Probe-Seq"
+	ProbeSentAtHeader = "X-Probe-Sent-At"
+)
+
+// HistogramBounds are the upper bounds of the latency histogram buckets; the
+// last bucket counts everything slower
+var HistogramBounds = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// HistogramLabel returns the label of histogram bucket i
+func HistogramLabel(i int) string {
+	if i >= len(HistogramBounds) {
+		return ">" + HistogramBounds[len(HistogramBounds)-1].String()
+	}
+	return "≤" + HistogramBounds[i].String()
+}
+
+// envelope is the payload of StampEnvelope messages
+type envelope struct {
+	Probe   string `json:"probe"`
+	Seq     int64  `json:"seq"`
+	SentAt  int64  `json:"sentAt"`
+	Padding string `json:"padding,omitempty"`
+}
+
+// ProbeConfig describes a latency probe run
+type ProbeConfig struct {
+	// PublishSubject receives the stamped messages
+	PublishSubject string
+	// ReceiveSubject is subscribed to, the same as PublishSubject or a mirror of it
+	ReceiveSubject string
+	// Count is the number of messages to send, 0 to run for Duration
+	Count    int
+	Duration time.Duration
+	// Rate is the number of messages per second
+	Rate  float64
+	Stamp StampMode
+	// PayloadSize pads messages to about this many bytes
+	PayloadSize int
+	// Drain is how long to wait for late messages after the last send
+	Drain time.Duration
+}
+
+// Validate checks that the configuration describes a finite, paced run
+func (c ProbeConfig) Validate() error {
+	if c.PublishSubject == "" || c.ReceiveSubject == "" {
+		return fmt.Errorf("probe publish and receive subjects are required")
+	}
+	if c.Count < 0 || c.Duration < 0 || c.PayloadSize < 0 || c.Drain < 0 {
+		return fmt.Errorf("probe count, duration, payload size and drain can't be negative")
+	}
+	if c.Count == 0 && c.Duration == 0 {
+		return fmt.Errorf("probe needs a message count or a duration")
+	}
+	if c.Rate <= 0 {
+		return fmt.Errorf("probe rate must be positive")
+	}
+	if c.Stamp != StampHeader && c.Stamp != StampEnvelope {
+		return fmt.Errorf("unknown probe stamp mode %q", c.Stamp)
+	}
+	return nil
+}
+
+// ProbeStats is a snapshot of a latency probe
+type ProbeStats struct {
+	Sent       uint64
+	Received   uint64
+	Duplicates uint64
+	Reordered  uint64
+	// Lost counts sent messages not received; while running it includes
+	// messages still in flight
+	Lost      uint64
+	Errors    uint64
+	Latency   Latency
+	Histogram []uint64
+	Elapsed   time.Duration
+	Done      bool
+}
+
+// Probe stamps outgoing messages and measures the ones that come back
+type Probe struct {
+	id      string
+	mutex   sync.Mutex
+	sent    uint64
+	errors  uint64
+	seen    map[int64]bool
+	maxSeq  int64
+	dups    uint64
+	reorder uint64
+	latency *Reservoir
+	buckets []uint64
+	start   time.Time
+	end     time.Time
+}
+
+// NewProbe creates a probe with a unique ID, so concurrent probes on the same
+// subject ignore each other's messages
+func NewProbe() *Probe {
+	return &Probe{
+		id:      uuid.NewString(),
+		seen:    make(map[int64]bool),
+		latency: NewReservoir(ReservoirSize),
+		buckets: make([]uint64, len(HistogramBounds)+1),
+		start:   time.Now(),
+	}
+}
+
+// ID returns the probe ID
+func (p *Probe) ID() string {
+	return p.id
+}
+
+// Stamp returns the payload and headers of message seq sent at t
+func (p *Probe) Stamp(seq int64, t time.Time, mode StampMode, size int) ([]byte, map[string]string, error) {
+	if mode == StampHeader {
+		headers := map[string]string{
+			ProbeIDHeader:     p.id,
+			ProbeSeqHeader:    strconv.FormatInt(seq, 10),
+			ProbeSentAtHeader: strconv.FormatInt(t.UnixNano(), 10),
+		}
+		// Most brokers drop empty messages
+		return bytes.Repeat([]byte("x"), max(size, 1)), headers, nil
+	}
+
+	env := envelope{Probe: p.id, Seq: seq, SentAt: t.UnixNano()}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	if padding := size - len(data) - len(`,"padding":""`); padding > 0 {
+		env.Padding = string(bytes.Repeat([]byte("x"), padding))
+		data, err = json.Marshal(env)
+	}
+	return data, nil, err
+}
+
+// MarkSent records a successful send
+func (p *Probe) MarkSent() {
+	p.mutex.Lock()
+	p.sent++
+	p.mutex.Unlock()
+}
+
+// MarkError records a failed send
+func (p *Probe) MarkError() {
+	p.mutex.Lock()
+	p.errors++
+	p.mutex.Unlock()
+}
+
+// Observe measures a received message and reports whether it belongs to the probe
+func (p *Probe) Observe(data []byte, headers map[string]string, receivedAt time.Time) bool {
+	seq, sentAt, ok := p.parse(data, headers)
+	if !ok {
+		return false
+	}
+
+	latency := receivedAt.Sub(time.Unix(0, sentAt))
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.seen[seq] {
+		p.dups++
+		return true
+	}
+	p.seen[seq] = true
+	if seq < p.maxSeq {
+		p.reorder++
+	} else {
+		p.maxSeq = seq
+	}
+
+	p.latency.Add(latency)
+	bucket := len(HistogramBounds)
+	for i, bound := range HistogramBounds {
+		if latency <= bound {
+			bucket = i
+			break
+		}
+	}
+	p.buckets[bucket]++
+	return true
+}
+
+// parse extracts the stamp of a message of this probe
+func (p *Probe) parse(data []byte, headers map[string]string) (int64, int64, bool) {
+	if headers[ProbeIDHeader] == p.id {
+		seq, err := strconv.ParseInt(headers[ProbeSeqHeader], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		sentAt, err := strconv.ParseInt(headers[ProbeSentAtHeader], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		return seq, sentAt, true
+	}
+
+	if !bytes.Contains(data, []byte(p.id)) {
+		return 0, 0, false
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Probe != p.id {
+		return 0, 0, false
+	}
+	return env.Seq, env.SentAt, true
+}
+
+// pending reports whether sent messages have not arrived yet, without the
+// cost of computing percentiles
+func (p *Probe) pending() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return uint64(len(p.seen)) < p.sent
+}
+
+// Stats returns a snapshot of the probe. It sorts the kept latency samples,
+// so it is meant for progress updates and the final result.
+func (p *Probe) Stats() ProbeStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := ProbeStats{
+		Sent:       p.sent,
+		Received:   uint64(len(p.seen)),
+		Duplicates: p.dups,
+		Reordered:  p.reorder,
+		Errors:     p.errors,
+		Latency:    p.latency.Summary(),
+		Histogram:  append([]uint64(nil), p.buckets...),
+		Elapsed:    time.Since(p.start),
+		Done:       !p.end.IsZero(),
+	}
+	if stats.Done {
+		stats.Elapsed = p.end.Sub(p.start)
+	}
+	if stats.Sent > stats.Received {
+		stats.Lost = stats.Sent - stats.Received
+	}
+	return stats
+}
+
+// finish marks the probe as done
+func (p *Probe) finish() {
+	p.mutex.Lock()
+	p.end = time.Now()
+	p.mutex.Unlock()
+}
+
+// ProbeTransport connects a probe to a broker
+type ProbeTransport struct {
+	// Publish sends a message; headers are nil for envelope stamps
+	Publish func(subject string, data []byte, headers map[string]string) error
+	// Subscribe delivers the messages of subject to handler until the
+	// returned function is called
+	Subscribe func(subject string, handler func(data []byte, headers map[string]string)) (func(), error)
+}
+
+// RunProbe sends stamped messages at the configured rate, measures the ones
+// received on the receive subject and waits cfg.Drain for late messages.
+// progress, if not nil, is called about every interval.
+func RunProbe(ctx context.Context, transport ProbeTransport, cfg ProbeConfig, interval time.Duration, progress func(ProbeStats)) (ProbeStats, error) {
+	if err := cfg.Validate(); err != nil {
+		return ProbeStats{}, err
+	}
+
+	probe := NewProbe()
+	stop, err := transport.Subscribe(cfg.ReceiveSubject, func(data []byte, headers map[string]string) {
+		probe.Observe(data, headers, time.Now())
+	})
+	if err != nil {
+		return ProbeStats{}, fmt.Errorf("failed to subscribe to %s: %w", cfg.ReceiveSubject, err)
+	}
+	defer stop()
+
+	done := make(chan struct{})
+	defer close(done)
+	if progress != nil && interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					progress(probe.Stats())
+				}
+			}
+		}()
+	}
+
+	sendCtx := ctx
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+	for seq := int64(1); cfg.Count == 0 || seq <= int64(cfg.Count); seq++ {
+		due := start.Add(time.Duration(float64(seq-1) / cfg.Rate * float64(time.Second)))
+		if !sleepUntil(sendCtx, due) || sendCtx.Err() != nil {
+			break
+		}
+
+		now := time.Now()
+		data, headers, err := probe.Stamp(seq, now, cfg.Stamp, cfg.PayloadSize)
+		if err == nil {
+			err = transport.Publish(cfg.PublishSubject, data, headers)
+		}
+		if err != nil {
+			probe.MarkError()
+			continue
+		}
+		probe.MarkSent()
+	}
+
+	// Wait for late messages unless everything arrived or the run was cancelled
+	drainCtx, cancel := context.WithTimeout(ctx, cfg.Drain)
+	defer cancel()
+	for drainCtx.Err() == nil && probe.pending() {
+		sleepUntil(drainCtx, time.Now().Add(10*time.Millisecond))
+	}
+
+	probe.finish()
+	stats := probe.Stats()
+	if progress != nil {
+		progress(stats)
+	}
+	return stats, nil
+}
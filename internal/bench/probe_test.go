@@ -0,0 +1,109 @@
+package bench
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopback is a transport that delivers published messages to the subscriber,
+// optionally dropping or holding some of them
+type loopback struct {
+	mutex   sync.Mutex
+	handler func(data []byte, headers map[string]string)
+	sent    int
+	drop    func(n int) bool
+	held    [][]byte
+	hold    func(n int) bool
+}
+
+func (l *loopback) transport() ProbeTransport {
+	return ProbeTransport{
+		Publish: func(subject string, data []byte, headers map[string]string) error {
+			l.mutex.Lock()
+			l.sent++
+			n := l.sent
+			handler := l.handler
+			l.mutex.Unlock()
+
+			switch {
+			case l.drop != nil && l.drop(n):
+			case l.hold != nil && l.hold(n):
+				l.mutex.Lock()
+				l.held = append(l.held, data)
+				l.mutex.Unlock()
+			default:
+				handler(data, headers)
+				// Held messages arrive after the next one, out of order
+				l.mutex.Lock()
+				held := l.held
+				l.held = nil
+				l.mutex.Unlock()
+				for _, h := range held {
+					handler(h, nil)
+				}
+			}
+			return nil
+		},
+		Subscribe: func(subject string, handler func(data []byte, headers map[string]string)) (func(), error) {
+			l.mutex.Lock()
+			l.handler = handler
+			l.mutex.Unlock()
+			return func() {}, nil
+		},
+	}
+}
+
+func TestRunProbeMeasuresEveryMessage(t *testing.T) {
+	for _, mode := range []StampMode{StampHeader, StampEnvelope} {
+		l := &loopback{}
+		cfg := ProbeConfig{PublishSubject: "probe", ReceiveSubject: "probe", Count: 50, Rate: 1000, Stamp: mode, PayloadSize: 128}
+		stats, err := RunProbe(context.Background(), l.transport(), cfg, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: RunProbe: %v", mode, err)
+		}
+		if stats.Sent != 50 || stats.Received != 50 || stats.Lost != 0 || stats.Reordered != 0 {
+			t.Errorf("%s: unexpected stats %+v", mode, stats)
+		}
+		var total uint64
+		for _, n := range stats.Histogram {
+			total += n
+		}
+		if total != 50 || !stats.Done {
+			t.Errorf("%s: histogram total = %d, done = %v", mode, total, stats.Done)
+		}
+	}
+}
+
+func TestRunProbeCountsLossAndReordering(t *testing.T) {
+	l := &loopback{
+		drop: func(n int) bool { return n%10 == 0 },
+		hold: func(n int) bool { return n%10 == 5 },
+	}
+	cfg := ProbeConfig{PublishSubject: "probe", ReceiveSubject: "probe", Count: 40, Rate: 2000, Stamp: StampEnvelope, Drain: 50 * time.Millisecond}
+	stats, err := RunProbe(context.Background(), l.transport(), cfg, 0, nil)
+	if err != nil {
+		t.Fatalf("RunProbe: %v", err)
+	}
+	if stats.Lost != 4 || stats.Received != 36 || stats.Reordered != 4 {
+		t.Errorf("Lost = %d, Received = %d, Reordered = %d, want 4, 36 and 4", stats.Lost, stats.Received, stats.Reordered)
+	}
+}
+
+func TestProbeIgnoresForeignMessages(t *testing.T) {
+	a, b := NewProbe(), NewProbe()
+	data, headers, err := a.Stamp(1, time.Now(), StampHeader, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Observe(data, headers, time.Now()) {
+		t.Error("probe observed another probe's message")
+	}
+	if !a.Observe(data, headers, time.Now()) || !a.Observe(data, headers, time.Now()) {
+		t.Error("probe ignored its own message")
+	}
+	if stats := a.Stats(); stats.Received != 1 || stats.Duplicates != 1 {
+		t.Errorf("Received = %d, Duplicates = %d, want 1 and 1", stats.Received, stats.Duplicates)
+	}
+}
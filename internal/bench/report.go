@@ -0,0 +1,127 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Report is the exported form of a result, with latencies in milliseconds
+type Report struct {
+	StartedAt   time.Time          `json:"startedAt"`
+	Subject     string             `json:"subject"`
+	Count       int                `json:"count,omitempty"`
+	Duration    string             `json:"duration,omitempty"`
+	Rate        float64            `json:"rate,omitempty"`
+	Publishers  int                `json:"publishers"`
+	PayloadSize int                `json:"payloadSize,omitempty"`
+	Template    string             `json:"template,omitempty"`
+	ElapsedMs   float64            `json:"elapsedMs"`
+	Sent        uint64             `json:"sent"`
+	Errors      uint64             `json:"errors"`
+	Bytes       uint64             `json:"bytes"`
+	Throughput  float64            `json:"throughput"`
+	ByteRate    float64            `json:"byteRate"`
+	LatencyMs   map[string]float64 `json:"latencyMs"`
+	FirstError  string             `json:"firstError,omitempty"`
+}
+
+// latencyColumns orders the latency fields of exports
+var latencyColumns = []string{"min", "mean", "p50", "p90", "p95", "p99", "max"}
+
+// Report converts a result to its exported form
+func (r Result) Report() Report {
+	report := Report{
+		StartedAt:   r.StartedAt,
+		Subject:     r.Config.Subject,
+		Count:       r.Config.Count,
+		Rate:        r.Config.Rate,
+		Publishers:  r.Config.Publishers,
+		PayloadSize: r.Config.PayloadSize,
+		Template:    r.Config.Template,
+		ElapsedMs:   milliseconds(r.Elapsed),
+		Sent:        r.Sent,
+		Errors:      r.Errors,
+		Bytes:       r.Bytes,
+		Throughput:  r.Throughput,
+		ByteRate:    r.ByteRate,
+		FirstError:  r.FirstError,
+		LatencyMs: map[string]float64{
+			"min":  milliseconds(r.Latency.Min),
+			"mean": milliseconds(r.Latency.Mean),
+			"p50":  milliseconds(r.Latency.P50),
+			"p90":  milliseconds(r.Latency.P90),
+			"p95":  milliseconds(r.Latency.P95),
+			"p99":  milliseconds(r.Latency.P99),
+			"max":  milliseconds(r.Latency.Max),
+		},
+	}
+	if r.Config.Duration > 0 {
+		report.Duration = r.Config.Duration.String()
+	}
+	return report
+}
+
+// JSON exports results as an indented JSON array
+func JSON(results []Result) ([]byte, error) {
+	reports := make([]Report, len(results))
+	for i, r := range results {
+		reports[i] = r.Report()
+	}
+	return json.MarshalIndent(reports, "", "  ")
+}
+
+// CSV exports results with a header row, one row per result
+func CSV(results []Result) ([]byte, error) {
+	header := []string{"started_at", "subject", "count", "duration", "rate", "publishers", "payload_size",
+		"elapsed_ms", "sent", "errors", "bytes", "throughput", "byte_rate"}
+	for _, column := range latencyColumns {
+		header = append(header, column+"_ms")
+	}
+	header = append(header, "first_error")
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		report := r.Report()
+		row := []string{
+			report.StartedAt.Format(time.RFC3339),
+			report.Subject,
+			strconv.Itoa(report.Count),
+			report.Duration,
+			formatFloat(report.Rate),
+			strconv.Itoa(report.Publishers),
+			strconv.Itoa(report.PayloadSize),
+			formatFloat(report.ElapsedMs),
+			strconv.FormatUint(report.Sent, 10),
+			strconv.FormatUint(report.Errors, 10),
+			strconv.FormatUint(report.Bytes, 10),
+			formatFloat(report.Throughput),
+			formatFloat(report.ByteRate),
+		}
+		for _, column := range latencyColumns {
+			row = append(row, formatFloat(report.LatencyMs[column]))
+		}
+		row = append(row, report.FirstError)
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
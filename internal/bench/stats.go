@@ -0,0 +1,126 @@
+package bench
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Latency summarizes a set of latency samples
+type Latency struct {
+	Count int
+	Min   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// Summarize computes the latency summary of samples, which it sorts in place
+func Summarize(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+	slices.Sort(samples)
+
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+
+	return Latency{
+		Count: len(samples),
+		Min:   samples[0],
+		Mean:  total / time.Duration(len(samples)),
+		P50:   Percentile(samples, 50),
+		P90:   Percentile(samples, 90),
+		P95:   Percentile(samples, 95),
+		P99:   Percentile(samples, 99),
+		Max:   samples[len(samples)-1],
+	}
+}
+
+// Percentile returns the nearest-rank percentile p (0-100) of sorted samples
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+// ReservoirSize is how many latency samples a run keeps for percentiles;
+// the percentiles of longer runs are estimated from a uniform sample
+const ReservoirSize = 100000
+
+// Reservoir keeps a uniform random sample of at most size latencies, with
+// the exact count, mean, min and max of all of them, so that long runs use
+// bounded memory. It is safe for concurrent use.
+type Reservoir struct {
+	mutex   sync.Mutex
+	size    int
+	samples []time.Duration
+	count   int
+	total   time.Duration
+	min     time.Duration
+	max     time.Duration
+}
+
+// NewReservoir creates a reservoir keeping at most size samples
+func NewReservoir(size int) *Reservoir {
+	return &Reservoir{size: max(size, 1)}
+}
+
+// Add records a latency
+func (r *Reservoir) Add(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.count++
+	r.total += d
+	if r.count == 1 || d < r.min {
+		r.min = d
+	}
+	if d > r.max {
+		r.max = d
+	}
+
+	if len(r.samples) < r.size {
+		r.samples = append(r.samples, d)
+		return
+	}
+	// Algorithm R: the latency replaces a kept one with probability size/count
+	if i := rand.IntN(r.count); i < r.size {
+		r.samples[i] = d
+	}
+}
+
+// Count returns how many latencies were added
+func (r *Reservoir) Count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.count
+}
+
+// Summary summarizes the latencies added so far. Percentiles are exact until
+// more than size latencies are added.
+func (r *Reservoir) Summary() Latency {
+	r.mutex.Lock()
+	samples := slices.Clone(r.samples)
+	count, total, low, high := r.count, r.total, r.min, r.max
+	r.mutex.Unlock()
+
+	latency := Summarize(samples)
+	if count > 0 {
+		latency.Count = count
+		latency.Mean = total / time.Duration(count)
+		latency.Min = low
+		latency.Max = high
+	}
+	return latency
+}
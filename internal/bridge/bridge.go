@@ -0,0 +1,294 @@
+// Package bridge forwards messages received on one broker to another,
+// rewriting subjects and optionally transforming payloads.
+package bridge
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/scripting"
+	"github.com/devalexandre/broker-ui/internal/templating"
+)
+
+// Mapping rewrites subjects matching From into To. From uses NATS-style
+// wildcards ("*" for one token, a trailing ">" for the rest) and To refers
+// to the matched wildcards as $1, $2, ...
+type Mapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Map returns the rewritten subject and whether the mapping applies
+func (m Mapping) Map(subject string) (string, bool) {
+	pattern := strings.Split(m.From, ".")
+	tokens := strings.Split(subject, ".")
+
+	var captures []string
+	for i, token := range pattern {
+		if token == ">" {
+			if i != len(pattern)-1 || len(tokens) <= i {
+				return "", false
+			}
+			captures = append(captures, strings.Join(tokens[i:], "."))
+			tokens = tokens[:i+1]
+			break
+		}
+		if i >= len(tokens) {
+			return "", false
+		}
+		if token == "*" {
+			captures = append(captures, tokens[i])
+		} else if token != tokens[i] {
+			return "", false
+		}
+	}
+	if len(pattern) != len(tokens) {
+		return "", false
+	}
+
+	mapped := m.To
+	// Replace the highest references first so $1 doesn't eat $10
+	for i := len(captures); i >= 1; i-- {
+		mapped = strings.ReplaceAll(mapped, fmt.Sprintf("$%d", i), captures[i-1])
+	}
+	return mapped, true
+}
+
+// MapSubject applies the first matching mapping; unmatched subjects are kept
+func MapSubject(mappings []Mapping, subject string) string {
+	mapped, _ := mapSubject(mappings, subject)
+	return mapped
+}
+
+// mapSubject applies the first matching mapping and reports whether one matched
+func mapSubject(mappings []Mapping, subject string) (string, bool) {
+	for _, m := range mappings {
+		if mapped, ok := m.Map(subject); ok {
+			return mapped, true
+		}
+	}
+	return subject, false
+}
+
+// targetPattern returns the subjects To can produce as a pattern: references
+// to a "*" capture become "*", and a reference to a ">" capture ends it with ">"
+func (m Mapping) targetPattern() string {
+	var multi []bool
+	for _, token := range strings.Split(m.From, ".") {
+		if token == "*" || token == ">" {
+			multi = append(multi, token == ">")
+		}
+	}
+
+	tokens := strings.Split(m.To, ".")
+	for i, token := range tokens {
+		if !strings.Contains(token, "$") {
+			continue
+		}
+		tokens[i] = "*"
+		for ref := len(multi); ref >= 1; ref-- {
+			if multi[ref-1] && strings.Contains(token, fmt.Sprintf("$%d", ref)) {
+				return strings.Join(append(tokens[:i], ">"), ".")
+			}
+		}
+	}
+	return strings.Join(tokens, ".")
+}
+
+// CheckLoop reports an error when a mapping can publish into sourcePattern.
+// A bridge to its own server would forward those messages again, forever.
+func CheckLoop(sourcePattern string, mappings []Mapping) error {
+	if len(mappings) == 0 {
+		return fmt.Errorf("a bridge to the same server needs mappings to other subjects")
+	}
+	for _, m := range mappings {
+		if patternsOverlap(m.targetPattern(), sourcePattern) {
+			return fmt.Errorf("mapping %s -> %s publishes into %s, which the bridge forwards again", m.From, m.To, sourcePattern)
+		}
+	}
+	return nil
+}
+
+// patternsOverlap reports whether some subject matches both NATS-style patterns
+func patternsOverlap(a, b string) bool {
+	aTokens, bTokens := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aTokens) && i < len(bTokens); i++ {
+		if aTokens[i] == ">" || bTokens[i] == ">" {
+			return true
+		}
+		if aTokens[i] != "*" && bTokens[i] != "*" && aTokens[i] != bTokens[i] {
+			return false
+		}
+	}
+	return len(aTokens) == len(bTokens)
+}
+
+// ParseMappings parses mappings written one "from -> to" per line. Blank
+// lines and lines starting with # are ignored.
+func ParseMappings(text string) ([]Mapping, error) {
+	var mappings []Mapping
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "->")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("line %d: expected from -> to, got %q", line, entry)
+		}
+		mappings = append(mappings, Mapping{From: from, To: to})
+	}
+	return mappings, scanner.Err()
+}
+
+// FormatMappings renders mappings in the format read by ParseMappings
+func FormatMappings(mappings []Mapping) string {
+	lines := make([]string, len(mappings))
+	for i, m := range mappings {
+		lines[i] = fmt.Sprintf("%s -> %s", m.From, m.To)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Config describes what a bridge forwards
+type Config struct {
+	Mappings []Mapping
+	// Transform is an optional payload template. It sees the message as
+	// {{.subject}} and {{.payload}}, and headers as {{var "header.Name"}}.
+	Transform string
+	// Script is optional JavaScript run first on each message. It may change
+	// the message, drop it by returning false, or publish() to the target.
+	Script        string
+	ScriptTimeout time.Duration
+	// DropUnmapped drops subjects no mapping matches instead of keeping
+	// them, as a bridge to its own server must
+	DropUnmapped bool
+}
+
+// Validate checks that the transform template parses and the script compiles
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Script) != "" {
+		if _, err := scripting.Compile(c.Script, c.ScriptTimeout); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(c.Transform) == "" {
+		return nil
+	}
+	return templating.Parse(c.Transform)
+}
+
+// Stats counts the messages handled by a forwarder
+type Stats struct {
+	Forwarded     uint64
+	Failed        uint64
+	Dropped       uint64
+	LastForwarded time.Time
+	LastError     string
+}
+
+// PublishFunc publishes a forwarded message; headers may be nil
+type PublishFunc func(subject string, data []byte, headers map[string]string) error
+
+// Forwarder rewrites and republishes messages. It is safe for concurrent use.
+type Forwarder struct {
+	cfg     Config
+	publish PublishFunc
+	script  *scripting.Script
+
+	mutex sync.Mutex
+	stats Stats
+}
+
+// NewForwarder creates a forwarder publishing through publish
+func NewForwarder(cfg Config, publish PublishFunc) (*Forwarder, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	f := &Forwarder{cfg: cfg, publish: publish}
+	if strings.TrimSpace(cfg.Script) != "" {
+		script, err := scripting.Compile(cfg.Script, cfg.ScriptTimeout)
+		if err != nil {
+			return nil, err
+		}
+		f.script = script
+	}
+	return f, nil
+}
+
+// Forward runs the script, then maps, transforms and publishes a message
+func (f *Forwarder) Forward(subject string, data []byte, headers map[string]string) error {
+	var err error
+	dropped := false
+	if f.script != nil {
+		var result scripting.Result
+		result, err = f.script.Run(scripting.Message{Subject: subject, Data: data, Headers: headers},
+			scripting.Host{Publish: f.publish})
+		if err != nil {
+			err = fmt.Errorf("script failed on %s: %w", subject, err)
+		}
+		subject, data, headers = result.Message.Subject, result.Message.Data, result.Message.Headers
+		dropped = result.Drop
+	}
+	if err == nil && !dropped {
+		target, mapped := mapSubject(f.cfg.Mappings, subject)
+		if mapped || !f.cfg.DropUnmapped {
+			err = f.forward(subject, target, data, headers)
+		} else {
+			dropped = true
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if dropped {
+		f.stats.Dropped++
+		return nil
+	}
+	if err != nil {
+		f.stats.Failed++
+		f.stats.LastError = err.Error()
+		return err
+	}
+	f.stats.Forwarded++
+	f.stats.LastForwarded = time.Now()
+	return nil
+}
+
+// forward transforms the payload if configured and publishes it to target
+func (f *Forwarder) forward(subject, target string, data []byte, headers map[string]string) error {
+	if strings.TrimSpace(f.cfg.Transform) != "" {
+		vars := map[string]string{"subject": subject, "payload": string(data)}
+		for name, value := range headers {
+			vars["header."+name] = value
+		}
+
+		f.mutex.Lock()
+		seq := int64(f.stats.Forwarded + f.stats.Failed + 1)
+		f.mutex.Unlock()
+
+		transformed, err := templating.Render(f.cfg.Transform, templating.Context{Seq: seq, Vars: vars})
+		if err != nil {
+			return fmt.Errorf("transform of %s failed: %w", subject, err)
+		}
+		data = transformed
+	}
+
+	if err := f.publish(target, data, headers); err != nil {
+		return fmt.Errorf("forwarding %s to %s failed: %w", subject, target, err)
+	}
+	return nil
+}
+
+// Stats returns the current counts
+func (f *Forwarder) Stats() Stats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.stats
+}
@@ -0,0 +1,177 @@
+package bridge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMapSubject(t *testing.T) {
+	mappings := []Mapping{
+		{From: "orders.*.created", To: "debug.created.$1"},
+		{From: "events.>", To: "mirror.$1"},
+		{From: "a.*.b.*", To: "$2.$1"},
+	}
+	tests := map[string]string{
+		"orders.eu.created": "debug.created.eu",
+		"events.user.login": "mirror.user.login",
+		"a.x.b.y":           "y.x",
+		"orders.eu.updated": "orders.eu.updated",
+		"events":            "events",
+	}
+	for subject, want := range tests {
+		if got := MapSubject(mappings, subject); got != want {
+			t.Errorf("MapSubject(%s) = %s, want %s", subject, got, want)
+		}
+	}
+}
+
+func TestCheckLoop(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		mappings []Mapping
+		loops    bool
+	}{
+		{"no mappings", "orders.>", nil, true},
+		{"other prefix", "orders.>", []Mapping{{From: "orders.>", To: "debug.orders.$1"}}, false},
+		{"same prefix", "orders.>", []Mapping{{From: "orders.eu.>", To: "orders.us.$1"}}, true},
+		{"static target inside", "orders.*", []Mapping{{From: "orders.eu", To: "orders.copy"}}, true},
+		{"static target outside", "orders.*", []Mapping{{From: "orders.eu", To: "orders.eu.copy"}}, false},
+		{"single capture stays one token", "orders.*.created", []Mapping{{From: "orders.*.created", To: "orders.$1"}}, false},
+		{"multi capture spans tokens", "orders.*.created", []Mapping{{From: "x.>", To: "orders.$1"}}, true},
+		{"any mapping looping", "a.*", []Mapping{{From: "a.x", To: "b.x"}, {From: "a.*", To: "a.$1"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckLoop(tt.pattern, tt.mappings); (err != nil) != tt.loops {
+				t.Errorf("CheckLoop(%s, %v) = %v, want loop %v", tt.pattern, tt.mappings, err, tt.loops)
+			}
+		})
+	}
+}
+
+func TestForwarderDropsUnmapped(t *testing.T) {
+	var published []string
+	f, err := NewForwarder(Config{
+		Mappings:     []Mapping{{From: "orders.>", To: "debug.$1"}},
+		DropUnmapped: true,
+	}, func(subject string, data []byte, headers map[string]string) error {
+		published = append(published, subject)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, subject := range []string{"orders.created", "debug.created"} {
+		if err := f.Forward(subject, []byte("{}"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(published) != 1 || published[0] != "debug.created" {
+		t.Errorf("published = %v, want only the mapped subject", published)
+	}
+	if stats := f.Stats(); stats.Forwarded != 1 || stats.Dropped != 1 {
+		t.Errorf("stats = %+v, want 1 forwarded and 1 dropped", stats)
+	}
+}
+
+func TestParseMappings(t *testing.T) {
+	mappings, err := ParseMappings("# comment\norders.> -> debug.$1\n\n  a -> b  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 2 || mappings[0].From != "orders.>" || mappings[1].To != "b" {
+		t.Fatalf("mappings = %+v", mappings)
+	}
+	if FormatMappings(mappings) != "orders.> -> debug.$1\na -> b" {
+		t.Errorf("FormatMappings = %q", FormatMappings(mappings))
+	}
+
+	if _, err := ParseMappings("orders.>"); err == nil {
+		t.Error("a line without -> was accepted")
+	}
+}
+
+func TestForwarderTransformsAndCounts(t *testing.T) {
+	var gotSubject, gotData string
+	fail := false
+	f, err := NewForwarder(Config{
+		Mappings:  []Mapping{{From: "prod.>", To: "local.$1"}},
+		Transform: `{"from":"{{.subject}}","seq":{{seq}},"tenant":"{{var "header.X-Tenant"}}","data":{{.payload}}}`,
+	}, func(subject string, data []byte, headers map[string]string) error {
+		if fail {
+			return errors.New("broker down")
+		}
+		gotSubject, gotData = subject, string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Forward("prod.orders", []byte(`{"id":1}`), map[string]string{"X-Tenant": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotSubject != "local.orders" {
+		t.Errorf("subject = %s, want local.orders", gotSubject)
+	}
+	if want := `{"from":"prod.orders","seq":1,"tenant":"acme","data":{"id":1}}`; gotData != want {
+		t.Errorf("data = %s, want %s", gotData, want)
+	}
+
+	fail = true
+	if err := f.Forward("prod.orders", []byte(`{}`), map[string]string{"X-Tenant": "acme"}); err == nil {
+		t.Error("publish failure was not reported")
+	}
+	// The template needs the header
+	fail = false
+	if err := f.Forward("prod.orders", []byte(`{}`), nil); err == nil {
+		t.Error("transform failure was not reported")
+	}
+
+	stats := f.Stats()
+	if stats.Forwarded != 1 || stats.Failed != 2 || stats.LastError == "" {
+		t.Errorf("stats = %+v, want 1 forwarded and 2 failed", stats)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{Transform: "{{.payload"}).Validate(); err == nil {
+		t.Error("invalid transform was accepted")
+	}
+}
+
+func TestForwarderRunsScript(t *testing.T) {
+	var published []string
+	f, err := NewForwarder(Config{
+		Mappings: []Mapping{{From: "prod.>", To: "local.$1"}},
+		Script: `if (msg.subject === "prod.noise") return false;
+publish("audit", {seen: msg.subject});
+msg.data = msg.data.toUpperCase();`,
+	}, func(subject string, data []byte, headers map[string]string) error {
+		published = append(published, subject+" "+string(data))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Forward("prod.orders", []byte("abc"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Forward("prod.noise", []byte("x"), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`audit {"seen":"prod.orders"}`, "local.orders ABC"}
+	if len(published) != len(want) || published[0] != want[0] || published[1] != want[1] {
+		t.Errorf("published = %q, want %q", published, want)
+	}
+	if stats := f.Stats(); stats.Forwarded != 1 || stats.Dropped != 1 {
+		t.Errorf("stats = %+v, want 1 forwarded and 1 dropped", stats)
+	}
+
+	if err := (Config{Script: "return ("}).Validate(); err == nil {
+		t.Error("invalid script was accepted")
+	}
+}
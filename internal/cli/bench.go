@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/bench"
+)
+
+// progressLine is printed while a benchmark runs when -progress is set
+type progressLine struct {
+	Sent      uint64  `json:"sent"`
+	Errors    uint64  `json:"errors"`
+	ElapsedMs float64 `json:"elapsedMs"`
+}
+
+// runBench runs a publish benchmark and prints its report
+func runBench(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("bench", "bench -server NAME|ID -subject SUBJECT [-count N | -duration D] [-rate R] [-publishers P] [-size BYTES | -template TEXT]")
+	serverRef := fs.String("server", "", "saved server `name or ID`")
+	var cfg bench.Config
+	fs.StringVar(&cfg.Subject, "subject", "", "`subject` or topic to publish to")
+	fs.IntVar(&cfg.Count, "count", 0, "number of messages to publish")
+	fs.DurationVar(&cfg.Duration, "duration", 0, "how long to publish")
+	fs.Float64Var(&cfg.Rate, "rate", 0, "target messages per second, 0 for unlimited")
+	fs.IntVar(&cfg.Publishers, "publishers", 1, "number of concurrent publishers")
+	fs.IntVar(&cfg.PayloadSize, "size", 128, "payload size in `bytes`")
+	fs.StringVar(&cfg.Template, "template", "", "payload `template` rendered for each message, e.g. {\"seq\":{{seq}}}")
+	progress := fs.Duration("progress", 0, "print progress lines at this `interval`, 0 for none")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return usagef("%v", err)
+	}
+
+	_, provider, err := e.connect(*serverRef)
+	if err != nil {
+		return err
+	}
+	defer e.closeProvider(provider)
+
+	var onProgress func(bench.Progress)
+	interval := time.Second
+	if *progress > 0 {
+		interval = *progress
+		onProgress = func(p bench.Progress) {
+			e.emit(progressLine{Sent: p.Sent, Errors: p.Errors, ElapsedMs: float64(p.Elapsed) / float64(time.Millisecond)})
+		}
+	}
+
+	result, err := e.messages.RunBenchmark(ctx, provider, cfg, interval, onProgress)
+	if err != nil {
+		return err
+	}
+	return e.emit(result.Report())
+}
@@ -0,0 +1,332 @@
+// Package cli implements the headless broker-ui commands. They share the
+// database, services and providers of the desktop app, print JSON lines to
+// stdout and need no display.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/services"
+)
+
+// DefaultDBPath is the database shared with the desktop app
+const DefaultDBPath = "./nats_servers.db"
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// command is a subcommand; run receives the arguments after its name
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+// commands lists the subcommands in the order shown by help
+var commands = []command{
+	{"servers", "list, add or remove saved servers", runServers},
+	{"pub", "publish a message", runPub},
+	{"sub", "print received messages as JSON lines", runSub},
+	{"request", "send a request and print the reply", runRequest},
+	{"replay", "republish messages printed by sub", runReplay},
+	{"bench", "run a publish benchmark and print its report", runBench},
+	{"test", "run scenario files and report pass/fail", runTest},
+}
+
+// usageError is a command line mistake; Run prints usage and exits with ExitUsage
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// usagef returns a usageError
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// globalOptions are the flags given before the command
+type globalOptions struct {
+	dbPath  string
+	verbose bool
+}
+
+// parseGlobal parses the global flags and returns the command and its arguments
+func parseGlobal(args []string, output io.Writer) (globalOptions, []string, error) {
+	var options globalOptions
+	fs := flag.NewFlagSet("broker-ui", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&options.dbPath, "db", DefaultDBPath, "database `path` shared with the desktop app")
+	fs.BoolVar(&options.verbose, "v", false, "log provider and service activity to stderr")
+	if err := fs.Parse(args); err != nil {
+		return options, nil, err
+	}
+	return options, fs.Args(), nil
+}
+
+// Handles reports whether the arguments name a command, so the caller should
+// run the CLI instead of the desktop app
+func Handles(args []string) bool {
+	_, rest, err := parseGlobal(args, io.Discard)
+	if err != nil || len(rest) == 0 {
+		return false
+	}
+	if rest[0] == "help" {
+		return true
+	}
+	_, ok := findCommand(rest[0])
+	return ok
+}
+
+// findCommand returns the command with a name
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// Run executes a command line and returns the process exit code
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	options, rest, err := parseGlobal(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if len(rest) == 0 || rest[0] == "help" {
+		printUsage(stderr)
+		return ExitOK
+	}
+	cmd, ok := findCommand(rest[0])
+	if !ok {
+		fmt.Fprintf(stderr, "broker-ui: unknown command %q\n", rest[0])
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	// Providers and services log every message; keep stderr quiet by default
+	previous := log.Writer()
+	if options.verbose {
+		log.SetOutput(stderr)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+	defer log.SetOutput(previous)
+
+	e, err := openEnv(options.dbPath, stdin, stdout, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "broker-ui: %v\n", err)
+		return ExitError
+	}
+	defer e.close()
+
+	err = cmd.run(ctx, e, rest[1:])
+	var usage usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "broker-ui %s: %v\n", cmd.name, err)
+		return ExitUsage
+	case isFlagError(err):
+		// The flag package has already printed the error and the usage
+		return ExitUsage
+	default:
+		fmt.Fprintf(stderr, "broker-ui %s: %v\n", cmd.name, err)
+		return ExitError
+	}
+}
+
+// flagError wraps errors returned by flag parsing
+type flagError struct {
+	err error
+}
+
+func (e flagError) Error() string {
+	return e.err.Error()
+}
+
+func (e flagError) Unwrap() error {
+	return e.err
+}
+
+// isFlagError reports whether err came from flag parsing
+func isFlagError(err error) bool {
+	var fe flagError
+	return errors.As(err, &fe)
+}
+
+// parseFlags parses command flags, marking failures as flag errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return flagError{err: err}
+	}
+	return nil
+}
+
+// printUsage lists the global flags and the commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: broker-ui [-db path] [-v] <command> [flags]")
+	fmt.Fprintln(w, "Without a command the desktop app starts; broker-ui-cli has the commands only.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run broker-ui <command> -h for the flags of a command.")
+}
+
+// env holds what commands share: the output streams, the database and the services
+type env struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	encoder *json.Encoder
+	// emitMutex keeps lines whole when emitted from several goroutines
+	emitMutex sync.Mutex
+	db        *database.Database
+	servers   *services.ServerService
+	messages  *services.MessageService
+	codecs    *services.CodecService
+	scenarios *services.ScenarioService
+}
+
+// openEnv opens the database and creates the services like the desktop app does
+func openEnv(dbPath string, stdin io.Reader, stdout, stderr io.Writer) (*env, error) {
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", dbPath, err)
+	}
+
+	serverRepo := database.NewServerRepository(db.GetDB())
+	topicRepo := database.NewTopicRepository(db.GetDB())
+	subscriptionRepo := database.NewSubscriptionRepository(db.GetDB())
+	protoSchemaRepo := database.NewProtoSchemaRepository(db.GetDB())
+	protoMappingRepo := database.NewProtoMappingRepository(db.GetDB())
+
+	serverService := services.NewServerService(serverRepo, topicRepo, subscriptionRepo)
+	messageService := services.NewMessageService(topicRepo, subscriptionRepo)
+
+	return &env{
+		stdin:     stdin,
+		stdout:    stdout,
+		stderr:    stderr,
+		encoder:   json.NewEncoder(stdout),
+		db:        db,
+		servers:   serverService,
+		messages:  messageService,
+		codecs:    services.NewCodecService(serverRepo, protoSchemaRepo, protoMappingRepo),
+		scenarios: services.NewScenarioService(serverService, messageService),
+	}, nil
+}
+
+// close closes the database
+func (e *env) close() {
+	if err := e.db.Close(); err != nil {
+		fmt.Fprintf(e.stderr, "broker-ui: error closing database: %v\n", err)
+	}
+}
+
+// emit prints a value as one JSON line
+func (e *env) emit(v any) error {
+	e.emitMutex.Lock()
+	defer e.emitMutex.Unlock()
+	return e.encoder.Encode(v)
+}
+
+// newFlagSet creates the flag set of a command
+func (e *env) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: broker-ui %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// findServer returns the saved server with a name or ID
+func (e *env) findServer(ref string) (models.Server, error) {
+	if ref == "" {
+		return models.Server{}, usagef("-server is required")
+	}
+	servers, err := e.servers.GetAllServers()
+	if err != nil {
+		return models.Server{}, err
+	}
+	id, idErr := strconv.Atoi(ref)
+	for _, server := range servers {
+		if server.Name == ref || (idErr == nil && server.ID == id) {
+			return server, nil
+		}
+	}
+	return models.Server{}, fmt.Errorf("server %q not found; see broker-ui servers list", ref)
+}
+
+// connect opens a connection to a saved server; the caller closes the provider
+func (e *env) connect(ref string) (models.Server, messaging.MessagingProvider, error) {
+	server, err := e.findServer(ref)
+	if err != nil {
+		return server, nil, err
+	}
+	provider, err := e.servers.OpenProvider(server.ProviderType, server.URL)
+	if err != nil {
+		return server, nil, fmt.Errorf("server %s: %w", server.Name, err)
+	}
+	return server, provider, nil
+}
+
+// closeProvider closes a connection opened by connect
+func (e *env) closeProvider(provider messaging.MessagingProvider) {
+	if err := provider.Close(); err != nil {
+		fmt.Fprintf(e.stderr, "broker-ui: error closing connection: %v\n", err)
+	}
+}
+
+// headerFlag collects repeated -header Name=value flags
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	pairs := make([]string, 0, len(h))
+	for name, value := range h {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q is not Name=value", value)
+	}
+	h[strings.TrimSpace(name)] = v
+	return nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// run executes a command line against a database and returns its output
+func run(t *testing.T, dbPath string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), append([]string{"-db", dbPath}, args...), strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestHandles(t *testing.T) {
+	tests := map[string]bool{
+		"":                      false,
+		"pub -subject x":        true,
+		"-db x.db servers list": true,
+		"help":                  true,
+		"-psn_0_12345":          false,
+		"unknown":               false,
+	}
+	for line, want := range tests {
+		if got := Handles(strings.Fields(line)); got != want {
+			t.Errorf("Handles(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestServersCommands(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	code, out, stderr := run(t, dbPath, "servers", "add", "-name", "local", "-url", "localhost:4222")
+	if code != ExitOK {
+		t.Fatalf("servers add exited with %d: %s", code, stderr)
+	}
+	var added serverLine
+	if err := json.Unmarshal([]byte(out), &added); err != nil {
+		t.Fatal(err)
+	}
+	if added.Name != "local" || added.Provider != string(messaging.ProviderNATS) || added.ID == 0 {
+		t.Errorf("added = %+v", added)
+	}
+
+	if code, _, _ := run(t, dbPath, "servers", "add", "-name", "local", "-url", "other:4222"); code != ExitError {
+		t.Errorf("duplicate server name exited with %d, want %d", code, ExitError)
+	}
+	if code, _, _ := run(t, dbPath, "servers", "add", "-name", "x", "-url", "y", "-provider", "Carrier pigeon"); code != ExitUsage {
+		t.Errorf("unsupported provider exited with %d, want %d", code, ExitUsage)
+	}
+
+	_, out, _ = run(t, dbPath, "servers", "list")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"name":"local"`) {
+		t.Errorf("servers list = %q", out)
+	}
+
+	if code, _, stderr := run(t, dbPath, "servers", "rm", "local"); code != ExitOK {
+		t.Fatalf("servers rm exited with %d: %s", code, stderr)
+	}
+	if _, out, _ = run(t, dbPath, "servers", "list"); out != "" {
+		t.Errorf("servers list after rm = %q", out)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	tests := [][]string{
+		{"pub"},
+		{"pub", "-subject", "x", "-unknown"},
+		{"sub", "-server", "missing"},
+		{"servers"},
+		{"test"},
+	}
+	for _, args := range tests {
+		if code, _, _ := run(t, dbPath, args...); code != ExitUsage {
+			t.Errorf("%v exited with %d, want %d", args, code, ExitUsage)
+		}
+	}
+	if code, _, stderr := run(t, dbPath, "pub", "-server", "missing", "-subject", "x", "-data", "y"); code != ExitError || !strings.Contains(stderr, "not found") {
+		t.Errorf("unknown server exited with %d: %s", code, stderr)
+	}
+}
+
+func TestMessageLineRoundTrip(t *testing.T) {
+	binary := []byte{0xff, 0x00, 0x10}
+	received := time.Unix(1700000000, 0)
+	for _, data := range [][]byte{[]byte(`{"id":1}`), binary} {
+		line := newMessageLine(messaging.Message{Subject: "orders", Data: data, Timestamp: received.UnixNano()})
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded messageLine
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		payload, err := decoded.payload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(payload, data) || !decoded.Timestamp.Equal(received) {
+			t.Errorf("round trip of %q gave %q at %v", data, payload, decoded.Timestamp)
+		}
+	}
+}
+
+func TestHeaderFlag(t *testing.T) {
+	headers := headerFlag{}
+	if err := headers.Set("X-Tenant=acme=1"); err != nil {
+		t.Fatal(err)
+	}
+	if headers["X-Tenant"] != "acme=1" {
+		t.Errorf("headers = %v", headers)
+	}
+	if err := headers.Set("novalue"); err == nil {
+		t.Error("a header without = was accepted")
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// messageLine is the JSON form of a message printed by sub and request and
+// read back by replay. Text payloads are kept in data and binary payloads
+// in dataBase64.
+type messageLine struct {
+	Subject    string            `json:"subject"`
+	Data       string            `json:"data,omitempty"`
+	DataBase64 string            `json:"dataBase64,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Decoded    json.RawMessage   `json:"decoded,omitempty"`
+	Schema     string            `json:"schema,omitempty"`
+	Violations []string          `json:"violations,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+}
+
+// newMessageLine converts a received message
+func newMessageLine(msg messaging.Message) messageLine {
+	line := messageLine{
+		Subject:    msg.Subject,
+		Headers:    msg.Headers,
+		Schema:     msg.Schema,
+		Violations: msg.Violations,
+		Timestamp:  time.Unix(0, msg.Timestamp),
+	}
+	if utf8.Valid(msg.Data) {
+		line.Data = string(msg.Data)
+	} else {
+		line.DataBase64 = base64.StdEncoding.EncodeToString(msg.Data)
+	}
+	if len(msg.Decoded) > 0 && json.Valid(msg.Decoded) {
+		line.Decoded = msg.Decoded
+	}
+	return line
+}
+
+// payload returns the raw payload of the line
+func (l messageLine) payload() ([]byte, error) {
+	if l.DataBase64 != "" {
+		data, err := base64.StdEncoding.DecodeString(l.DataBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataBase64: %w", err)
+		}
+		return data, nil
+	}
+	return []byte(l.Data), nil
+}
+
+// publishedLine is printed for every message sent by pub and replay
+type publishedLine struct {
+	Subject string `json:"subject"`
+	Bytes   int    `json:"bytes"`
+}
+
+// readPayload returns the payload given by -data or -file, where -file - reads stdin
+func (e *env) readPayload(data, file string) ([]byte, error) {
+	switch {
+	case data != "" && file != "":
+		return nil, usagef("use either -data or -file")
+	case file == "-":
+		return io.ReadAll(e.stdin)
+	case file != "":
+		return os.ReadFile(file)
+	}
+	return []byte(data), nil
+}
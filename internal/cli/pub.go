@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// runPub publishes a payload one or more times
+func runPub(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("pub", "pub -server NAME|ID -subject SUBJECT [-data TEXT | -file PATH] [-header Name=value]... [-count N]")
+	serverRef := fs.String("server", "", "saved server `name or ID`")
+	subject := fs.String("subject", "", "`subject` or topic to publish to")
+	data := fs.String("data", "", "payload `text`")
+	file := fs.String("file", "", "read the payload from `path`, - for stdin")
+	count := fs.Int("count", 1, "number of times to publish")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "`Name=value` header, may be repeated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *subject == "" {
+		return usagef("-subject is required")
+	}
+	if *count < 1 {
+		return usagef("-count must be at least 1")
+	}
+	payload, err := e.readPayload(*data, *file)
+	if err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return usagef("the payload is empty; use -data or -file")
+	}
+
+	_, provider, err := e.connect(*serverRef)
+	if err != nil {
+		return err
+	}
+	defer e.closeProvider(provider)
+
+	for i := 0; i < *count; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.messages.PublishMessageWithHeaders(provider, *subject, payload, headers); err != nil {
+			return err
+		}
+		if err := e.emit(publishedLine{Subject: *subject, Bytes: len(payload)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRequest sends a request and prints the reply
+func runRequest(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("request", "request -server NAME|ID -subject SUBJECT [-data TEXT | -file PATH] [-header Name=value]... [-timeout 2s]")
+	serverRef := fs.String("server", "", "saved server `name or ID`")
+	subject := fs.String("subject", "", "`subject` to send the request to")
+	data := fs.String("data", "", "payload `text`")
+	file := fs.String("file", "", "read the payload from `path`, - for stdin")
+	timeout := fs.Duration("timeout", 2*time.Second, "how long to wait for the reply")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "`Name=value` header, may be repeated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *subject == "" {
+		return usagef("-subject is required")
+	}
+	payload, err := e.readPayload(*data, *file)
+	if err != nil {
+		return err
+	}
+
+	server, provider, err := e.connect(*serverRef)
+	if err != nil {
+		return err
+	}
+	defer e.closeProvider(provider)
+
+	reply, err := e.messages.Request(provider, *subject, payload, headers, *timeout)
+	if err != nil {
+		return err
+	}
+	if err := e.codecs.Decode(server.ID, reply); err != nil {
+		fmt.Fprintf(e.stderr, "broker-ui request: error decoding reply: %v\n", err)
+	}
+	return e.emit(newMessageLine(*reply))
+}
+
+// runReplay republishes messages printed by sub, optionally keeping their timing
+func runReplay(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("replay", "replay -server NAME|ID [-file PATH] [-subject SUBJECT] [-speed 1]")
+	serverRef := fs.String("server", "", "saved server `name or ID`")
+	file := fs.String("file", "-", "JSON lines written by sub, - for stdin")
+	subject := fs.String("subject", "", "publish every message to this `subject` instead of its own")
+	speed := fs.Float64("speed", 0, "replay `factor` of the recorded timing, 0 for as fast as possible")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *speed < 0 {
+		return usagef("-speed can't be negative")
+	}
+
+	input := e.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	_, provider, err := e.connect(*serverRef)
+	if err != nil {
+		return err
+	}
+	defer e.closeProvider(provider)
+	_, supportsHeaders := provider.(messaging.HeaderPublisher)
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var previous time.Time
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line messageLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		payload, err := line.payload()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if *speed > 0 && !previous.IsZero() && line.Timestamp.After(previous) {
+			gap := time.Duration(float64(line.Timestamp.Sub(previous)) / *speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		previous = line.Timestamp
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		target := line.Subject
+		if *subject != "" {
+			target = *subject
+		}
+		headers := line.Headers
+		if !supportsHeaders {
+			headers = nil
+		}
+		if err := e.messages.PublishMessageWithHeaders(provider, target, payload, headers); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if err := e.emit(publishedLine{Subject: target, Bytes: len(payload)}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
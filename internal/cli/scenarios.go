@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/scenario"
+)
+
+// scenarioLine is printed for each scenario run by test
+type scenarioLine struct {
+	Scenario   string     `json:"scenario"`
+	File       string     `json:"file,omitempty"`
+	Server     string     `json:"server"`
+	Passed     bool       `json:"passed"`
+	DurationMs float64    `json:"durationMs"`
+	Steps      []stepLine `json:"steps"`
+}
+
+// stepLine is the outcome of a step in a scenarioLine
+type stepLine struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	DurationMs float64  `json:"durationMs"`
+	Failures   []string `json:"failures,omitempty"`
+}
+
+// newScenarioLine converts a scenario result for printing
+func newScenarioLine(server string, r scenario.Result) scenarioLine {
+	line := scenarioLine{
+		Scenario:   r.Scenario,
+		File:       r.File,
+		Server:     server,
+		Passed:     r.Passed(),
+		DurationMs: milliseconds(r.Duration),
+	}
+	for _, step := range r.Steps {
+		line.Steps = append(line.Steps, stepLine{
+			Name:       step.Name,
+			Status:     string(step.Status),
+			DurationMs: milliseconds(step.Duration),
+			Failures:   step.Failures,
+		})
+	}
+	return line
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// runTest runs scenario files and fails when any scenario fails
+func runTest(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("test", "test [-server NAME|ID] [-junit PATH] [-format json|text] FILE...")
+	serverRef := fs.String("server", "", "saved server `name or ID`, instead of the server named in each file")
+	junitPath := fs.String("junit", "", "write a JUnit XML report to `path`")
+	format := fs.String("format", "json", "output `format`: json lines or text")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("no scenario files given")
+	}
+	if *format != "json" && *format != "text" {
+		return usagef("unknown format %q", *format)
+	}
+
+	// Load every file first, so a typo doesn't surface halfway through a run
+	scenarios := make([]*scenario.Scenario, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		sc, err := scenario.Load(path)
+		if err != nil {
+			return err
+		}
+		if *serverRef == "" && sc.Server == "" {
+			return usagef("%s names no server; use -server", path)
+		}
+		scenarios = append(scenarios, sc)
+	}
+
+	var results []scenario.Result
+	failed := 0
+	for _, sc := range scenarios {
+		ref := *serverRef
+		if ref == "" {
+			ref = sc.Server
+		}
+		server, err := e.findServer(ref)
+		if err != nil {
+			return err
+		}
+		result, err := e.scenarios.Run(ctx, server, sc)
+		if err != nil {
+			return err
+		}
+		if !result.Passed() {
+			failed++
+		}
+		results = append(results, result)
+		if *format == "json" {
+			if err := e.emit(newScenarioLine(server.Name, result)); err != nil {
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if *format == "text" {
+		fmt.Fprint(e.stdout, scenario.Format(results))
+	}
+
+	if *junitPath != "" {
+		report, err := scenario.JUnit(results)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*junitPath, report, 0o644); err != nil {
+			return fmt.Errorf("failed to write JUnit report: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(results))
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+// serverLine is the JSON form of a saved server
+type serverLine struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Provider string `json:"provider"`
+}
+
+// newServerLine converts a saved server
+func newServerLine(server models.Server) serverLine {
+	return serverLine{ID: server.ID, Name: server.Name, URL: server.URL, Provider: string(server.ProviderType)}
+}
+
+// runServers dispatches servers list, add and rm
+func runServers(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("servers needs list, add or rm")
+	}
+	switch args[0] {
+	case "list", "ls":
+		return serversList(e, args[1:])
+	case "add":
+		return serversAdd(e, args[1:])
+	case "rm", "remove":
+		return serversRemove(e, args[1:])
+	}
+	return usagef("unknown servers command %q; use list, add or rm", args[0])
+}
+
+// serversList prints the saved servers
+func serversList(e *env, args []string) error {
+	fs := e.newFlagSet("servers list", "servers list")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	servers, err := e.servers.GetAllServers()
+	if err != nil {
+		return err
+	}
+	for _, server := range servers {
+		if err := e.emit(newServerLine(server)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serversAdd saves a server and prints it
+func serversAdd(e *env, args []string) error {
+	fs := e.newFlagSet("servers add", "servers add -name NAME -url URL [-provider NATS]")
+	name := fs.String("name", "", "server `name`")
+	url := fs.String("url", "", "server `url`")
+	provider := fs.String("provider", string(messaging.ProviderNATS), "provider `type`: "+strings.Join(e.servers.GetSupportedProviders(), ", "))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" || strings.TrimSpace(*url) == "" {
+		return usagef("-name and -url are required")
+	}
+
+	supported := false
+	for _, p := range e.servers.GetSupportedProviders() {
+		if p == *provider {
+			supported = true
+		}
+	}
+	if !supported {
+		return usagef("unsupported provider %q; use one of %s", *provider, strings.Join(e.servers.GetSupportedProviders(), ", "))
+	}
+	if _, err := e.findServer(*name); err == nil {
+		return fmt.Errorf("a server named %s already exists", *name)
+	}
+
+	if err := e.servers.SaveServer(*name, *url, messaging.ProviderType(*provider)); err != nil {
+		return fmt.Errorf("error saving server: %w", err)
+	}
+	server, err := e.findServer(*name)
+	if err != nil {
+		return err
+	}
+	return e.emit(newServerLine(server))
+}
+
+// serversRemove deletes a server by name or ID
+func serversRemove(e *env, args []string) error {
+	fs := e.newFlagSet("servers rm", "servers rm NAME|ID")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("servers rm needs one server name or ID")
+	}
+
+	server, err := e.findServer(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := e.servers.DeleteServer(server.ID); err != nil {
+		return fmt.Errorf("error deleting server: %w", err)
+	}
+	return e.emit(map[string]any{"removed": newServerLine(server)})
+}
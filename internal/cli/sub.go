@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// runSub prints received messages as JSON lines until -count messages were
+// printed, -timeout passed or the command is interrupted
+func runSub(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("sub", "sub -server NAME|ID -subject PATTERN [-count N] [-timeout 10s]")
+	serverRef := fs.String("server", "", "saved server `name or ID`")
+	pattern := fs.String("subject", "", "subject `pattern`, topic or queue to subscribe to")
+	count := fs.Int("count", 0, "stop after this many messages, 0 for no limit")
+	timeout := fs.Duration("timeout", 0, "stop after this long, 0 for no limit")
+	policy := fs.String("policy", string(messaging.PolicyBlock), "backpressure `policy` when output falls behind")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *pattern == "" {
+		return usagef("-subject is required")
+	}
+	if *count < 0 || *timeout < 0 {
+		return usagef("-count and -timeout can't be negative")
+	}
+
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{Policy: messaging.BackpressurePolicy(*policy)})
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	server, provider, err := e.connect(*serverRef)
+	if err != nil {
+		return err
+	}
+	defer e.closeProvider(provider)
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	subName := fmt.Sprintf("cli-%s", *pattern)
+	if err := e.messages.Subscribe(provider, subName, *pattern, queue); err != nil {
+		return fmt.Errorf("error subscribing to %s: %w", *pattern, err)
+	}
+
+	// Unsubscribing closes the queue, which ends the loop below
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			if err := e.messages.Unsubscribe(provider, subName, *pattern); err != nil {
+				fmt.Fprintf(e.stderr, "broker-ui sub: error unsubscribing: %v\n", err)
+			}
+		})
+	}
+	defer unsubscribe()
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+
+	printed := 0
+	for {
+		msg, ok := queue.Pop()
+		if !ok {
+			break
+		}
+		if err := e.codecs.Decode(server.ID, &msg); err != nil {
+			fmt.Fprintf(e.stderr, "broker-ui sub: error decoding message on %s: %v\n", msg.Subject, err)
+		}
+		if err := e.emit(newMessageLine(msg)); err != nil {
+			return err
+		}
+		printed++
+		if *count > 0 && printed >= *count {
+			break
+		}
+	}
+
+	if stats := queue.Stats(); stats.Dropped > 0 {
+		fmt.Fprintf(e.stderr, "broker-ui sub: %d messages dropped by the %s policy\n", stats.Dropped, *policy)
+	}
+	return nil
+}
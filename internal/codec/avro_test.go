@@ -0,0 +1,158 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const orderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "shop",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "amount", "type": "double"}
+	]
+}`
+
+// newTestRegistry starts a Schema Registry stand-in that knows schema 42 as
+// the latest "orders-value" schema and counts the requests it serves
+func newTestRegistry(t *testing.T) (*SchemaRegistryClient, *int32) {
+	t.Helper()
+
+	var requests int32
+	schemaJSON, _ := json.Marshal(orderSchema)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schemas/ids/42", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+		w.Write([]byte(`{"schema":` + string(schemaJSON) + `}`))
+	})
+	mux.HandleFunc("/subjects/orders-value/versions/latest", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"subject":"orders-value","version":3,"id":42,"schema":` + string(schemaJSON) + `}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return NewSchemaRegistryClient(server.URL), &requests
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	client, _ := newTestRegistry(t)
+	avroCodec := NewAvroCodec(client, "orders")
+
+	encoded, err := avroCodec.Encode([]byte(`{"id":"o-1","amount":9.5}`))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	if !IsConfluentFramed(encoded) {
+		t.Fatalf("encoded payload is not Confluent-framed: %v", encoded[:5])
+	}
+	if id, _ := ConfluentSchemaID(encoded); id != 42 {
+		t.Errorf("schema ID = %d, want 42", id)
+	}
+
+	decoded, err := avroCodec.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var order struct {
+		ID     string  `json:"id"`
+		Amount float64 `json:"amount"`
+	}
+	if err := json.Unmarshal(decoded, &order); err != nil {
+		t.Fatalf("decoded payload is not JSON: %s", decoded)
+	}
+	if order.ID != "o-1" || order.Amount != 9.5 {
+		t.Errorf("decoded %+v, want id o-1 and amount 9.5", order)
+	}
+}
+
+func TestAvroDecodeCachesSchemaByID(t *testing.T) {
+	client, requests := newTestRegistry(t)
+	avroCodec := NewAvroCodec(client, "orders")
+
+	// 0x00, schema 42, then the Avro body for {"id":"a","amount":1}
+	payload := []byte{0, 0, 0, 0, 42, 2, 'a', 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}
+
+	for i := 0; i < 3; i++ {
+		if _, err := avroCodec.Decode(payload); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("registry served %d requests, want 1", got)
+	}
+}
+
+func TestRegistryFallsBackToAvro(t *testing.T) {
+	client, _ := newTestRegistry(t)
+	registry := NewRegistry()
+	registry.SetSchemaRegistry(client)
+
+	framed, name, err := registry.Encode("orders", []byte(`{"id":"o-2","amount":1}`))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if name != "avro:orders-value" {
+		t.Errorf("codec name = %q, want avro:orders-value", name)
+	}
+
+	decoded, _, err := registry.Decode("orders", framed)
+	if err != nil || decoded == nil {
+		t.Fatalf("Decode = %s, %v", decoded, err)
+	}
+
+	// Payloads without the Confluent framing are left alone
+	decoded, name, err = registry.Decode("orders", []byte(`{"plain":true}`))
+	if decoded != nil || name != "" || err != nil {
+		t.Errorf("unframed payload decoded as %s (%q, %v)", decoded, name, err)
+	}
+
+	// Subjects without a registered schema publish unchanged
+	raw := []byte(`{"plain":true}`)
+	encoded, name, err := registry.Encode("payments", raw)
+	if err != nil || name != "" || string(encoded) != string(raw) {
+		t.Errorf("Encode without schema = %s, %q, %v", encoded, name, err)
+	}
+}
+
+func TestSchemaRegistryUnknownID(t *testing.T) {
+	client, requests := newTestRegistry(t)
+	client.retryInterval = 200 * time.Millisecond
+
+	// The miss is remembered, so payloads that only look framed don't
+	// query the registry each time
+	for i := 0; i < 3; i++ {
+		_, err := client.CodecByID(7)
+		if !errors.Is(err, ErrSchemaNotFound) {
+			t.Errorf("CodecByID(7) error = %v, want ErrSchemaNotFound", err)
+		}
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("registry served %d requests, want 1", got)
+	}
+
+	// It is asked again once the retry interval has passed
+	time.Sleep(250 * time.Millisecond)
+	client.CodecByID(7)
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("registry served %d requests after the retry interval, want 2", got)
+	}
+}
@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// Codec converts message payloads between a wire format and JSON
+type Codec interface {
+	// Name describes the codec and schema, e.g. "protobuf:orders.v1.Order"
+	Name() string
+
+	// Decode converts a wire-format payload into JSON
+	Decode(data []byte) ([]byte, error)
+
+	// Encode converts a JSON document into the wire format
+	Encode(jsonData []byte) ([]byte, error)
+}
+
+// Mapping binds a subject/topic pattern to a codec
+type Mapping struct {
+	Pattern string
+	Codec   Codec
+}
+
+// Registry resolves the codec for a subject from an ordered list of mappings.
+// The first matching mapping wins; subjects without a mapping fall back to
+// Confluent-framed Avro when a Schema Registry is configured.
+type Registry struct {
+	mappings       []Mapping
+	schemaRegistry *SchemaRegistryClient
+	mutex          sync.RWMutex
+}
+
+// NewRegistry creates an empty codec registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add appends a mapping
+func (r *Registry) Add(pattern string, codec Codec) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.mappings = append(r.mappings, Mapping{Pattern: pattern, Codec: codec})
+}
+
+// SetSchemaRegistry configures the Schema Registry used for unmapped subjects
+func (r *Registry) SetSchemaRegistry(client *SchemaRegistryClient) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.schemaRegistry = client
+}
+
+// Resolve returns the codec mapped to subject, if any
+func (r *Registry) Resolve(subject string) (Codec, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, mapping := range r.mappings {
+		if messaging.MatchSubject(mapping.Pattern, subject) {
+			return mapping.Codec, true
+		}
+	}
+
+	if r.schemaRegistry != nil {
+		return NewAvroCodec(r.schemaRegistry, subject), true
+	}
+	return nil, false
+}
+
+// Decode converts data to JSON with the codec mapped to subject. It returns
+// the codec name, or an empty name when no codec is mapped.
+func (r *Registry) Decode(subject string, data []byte) ([]byte, string, error) {
+	c, ok := r.Resolve(subject)
+	if !ok {
+		return nil, "", nil
+	}
+	if detector, ok := c.(Detector); ok && !detector.Accepts(data) {
+		return nil, "", nil
+	}
+
+	decoded, err := c.Decode(data)
+	if err != nil {
+		return nil, c.Name(), fmt.Errorf("failed to decode %s payload: %w", c.Name(), err)
+	}
+	return decoded, c.Name(), nil
+}
+
+// Encode converts JSON to the wire format with the codec mapped to subject.
+// Without a mapping, or when the Schema Registry has no schema for the
+// subject, the input is returned unchanged.
+func (r *Registry) Encode(subject string, jsonData []byte) ([]byte, string, error) {
+	c, ok := r.Resolve(subject)
+	if !ok {
+		return jsonData, "", nil
+	}
+
+	encoded, err := c.Encode(jsonData)
+	if errors.Is(err, ErrSchemaNotFound) {
+		return jsonData, "", nil
+	}
+	if err != nil {
+		return nil, c.Name(), fmt.Errorf("failed to encode %s payload: %w", c.Name(), err)
+	}
+	return encoded, c.Name(), nil
+}
@@ -0,0 +1,144 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ProtoSource is a schema supplied by the user, either .proto source text or
+// a serialized FileDescriptorSet (as produced by protoc --descriptor_set_out)
+type ProtoSource struct {
+	Name          string
+	Content       []byte
+	DescriptorSet bool
+}
+
+// ProtoTypes holds the message types found in a set of protobuf schemas
+type ProtoTypes struct {
+	messages map[protoreflect.FullName]protoreflect.MessageDescriptor
+}
+
+// LoadProtoTypes compiles .proto sources and decodes descriptor sets. .proto
+// files may import each other by name and import the well-known types.
+func LoadProtoTypes(sources []ProtoSource) (*ProtoTypes, error) {
+	types := &ProtoTypes{messages: make(map[protoreflect.FullName]protoreflect.MessageDescriptor)}
+
+	protoFiles := make(map[string]string)
+	var protoNames []string
+	for _, source := range sources {
+		if source.DescriptorSet {
+			if err := types.addDescriptorSet(source); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		protoFiles[source.Name] = string(source.Content)
+		protoNames = append(protoNames, source.Name)
+	}
+
+	if len(protoNames) == 0 {
+		return types, nil
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(protoFiles),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), protoNames...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto files: %w", err)
+	}
+	for _, file := range files {
+		types.addMessages(file.Messages())
+	}
+
+	return types, nil
+}
+
+// addDescriptorSet registers the messages of a serialized FileDescriptorSet
+func (t *ProtoTypes) addDescriptorSet(source ProtoSource) error {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(source.Content, &set); err != nil {
+		return fmt.Errorf("failed to parse descriptor set %s: %w", source.Name, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %w", source.Name, err)
+	}
+
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		t.addMessages(file.Messages())
+		return true
+	})
+	return nil
+}
+
+// addMessages registers messages and their nested messages
+func (t *ProtoTypes) addMessages(messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		if message.IsMapEntry() {
+			continue
+		}
+		t.messages[message.FullName()] = message
+		t.addMessages(message.Messages())
+	}
+}
+
+// MessageTypes returns the fully-qualified names of all known message types, sorted
+func (t *ProtoTypes) MessageTypes() []string {
+	names := make([]string, 0, len(t.messages))
+	for name := range t.messages {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Codec returns a codec for the named message type
+func (t *ProtoTypes) Codec(messageType string) (Codec, error) {
+	descriptor, ok := t.messages[protoreflect.FullName(messageType)]
+	if !ok {
+		return nil, fmt.Errorf("unknown protobuf message type: %s", messageType)
+	}
+	return &ProtoCodec{descriptor: descriptor}, nil
+}
+
+// ProtoCodec converts between the protobuf wire format and protobuf JSON
+type ProtoCodec struct {
+	descriptor protoreflect.MessageDescriptor
+}
+
+// Name returns the codec name
+func (c *ProtoCodec) Name() string {
+	return "protobuf:" + string(c.descriptor.FullName())
+}
+
+// Decode converts a protobuf payload to JSON
+func (c *ProtoCodec) Decode(data []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(c.descriptor)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(message)
+}
+
+// Encode converts a JSON document to a protobuf payload
+func (c *ProtoCodec) Encode(jsonData []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(c.descriptor)
+	if err := protojson.Unmarshal(jsonData, message); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
@@ -0,0 +1,251 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const moneyProto = `syntax = "proto3";
+package shop.v1;
+
+message Money {
+  string currency = 1;
+  int64 cents = 2;
+}
+`
+
+const orderProto = `syntax = "proto3";
+package shop.v1;
+
+import "money.proto";
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_PAID = 1;
+  }
+  message Line {
+    string sku = 1;
+    int32 quantity = 2;
+  }
+
+  string id = 1;
+  Status status = 2;
+  repeated Line lines = 3;
+  map<string, string> labels = 4;
+  Money total = 5;
+  google.protobuf.Timestamp created_at = 6;
+}
+`
+
+// orderJSON uses every kind of field of shop.v1.Order, in protojson form
+const orderJSON = `{
+	"id": "o-1",
+	"status": "STATUS_PAID",
+	"lines": [{"sku": "apple", "quantity": 3}, {"sku": "pear", "quantity": 1}],
+	"labels": {"channel": "web"},
+	"total": {"currency": "EUR", "cents": "1250"},
+	"createdAt": "2024-05-01T10:00:00Z"
+}`
+
+var orderSources = []ProtoSource{
+	{Name: "money.proto", Content: []byte(moneyProto)},
+	{Name: "order.proto", Content: []byte(orderProto)},
+}
+
+// descriptorSet compiles .proto sources into a serialized FileDescriptorSet
+// that includes their imports, as protoc --include_imports does
+func descriptorSet(t *testing.T, sources []ProtoSource) []byte {
+	t.Helper()
+	files := make(map[string]string)
+	var names []string
+	for _, source := range sources {
+		files[source.Name] = string(source.Content)
+		names = append(names, source.Name)
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(files),
+		}),
+	}
+	compiled, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Imports come before the files that import them
+	set := &descriptorpb.FileDescriptorSet{}
+	added := make(map[string]bool)
+	var add func(file protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if added[file.Path()] {
+			return
+		}
+		added[file.Path()] = true
+		for i := 0; i < file.Imports().Len(); i++ {
+			add(file.Imports().Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+	}
+	for _, file := range compiled {
+		add(file)
+	}
+
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// assertSameJSON compares JSON documents regardless of formatting
+func assertSameJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("not JSON: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestProtoTypes(t *testing.T) {
+	types, err := LoadProtoTypes(orderSources)
+	if err != nil {
+		t.Fatalf("LoadProtoTypes: %v", err)
+	}
+
+	// Nested messages are listed, map entries are not
+	want := []string{"shop.v1.Money", "shop.v1.Order", "shop.v1.Order.Line"}
+	if got := types.MessageTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MessageTypes = %v, want %v", got, want)
+	}
+
+	protoCodec, err := types.Codec("shop.v1.Order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protoCodec.Name() != "protobuf:shop.v1.Order" {
+		t.Errorf("Name = %q", protoCodec.Name())
+	}
+	if _, err := types.Codec("shop.v1.Missing"); err == nil {
+		t.Error("Codec of an unknown type succeeded")
+	}
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	setTypes, err := LoadProtoTypes([]ProtoSource{
+		{Name: "order.pb", Content: descriptorSet(t, orderSources), DescriptorSet: true},
+	})
+	if err != nil {
+		t.Fatalf("LoadProtoTypes(descriptor set): %v", err)
+	}
+	sourceTypes, err := LoadProtoTypes(orderSources)
+	if err != nil {
+		t.Fatalf("LoadProtoTypes(sources): %v", err)
+	}
+
+	codecs := make(map[string]Codec)
+	for name, types := range map[string]*ProtoTypes{"proto": sourceTypes, "descriptor set": setTypes} {
+		codecs[name], err = types.Codec("shop.v1.Order")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	// Both kinds of schema encode and decode each other's payloads
+	for encoder, encodeCodec := range codecs {
+		encoded, err := encodeCodec.Encode([]byte(orderJSON))
+		if err != nil {
+			t.Fatalf("%s Encode: %v", encoder, err)
+		}
+		for decoder, decodeCodec := range codecs {
+			decoded, err := decodeCodec.Decode(encoded)
+			if err != nil {
+				t.Fatalf("%s Decode of %s payload: %v", decoder, encoder, err)
+			}
+			assertSameJSON(t, decoded, orderJSON)
+		}
+	}
+}
+
+func TestProtoCodecErrors(t *testing.T) {
+	types, err := LoadProtoTypes(orderSources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protoCodec, _ := types.Codec("shop.v1.Order")
+
+	for _, document := range []string{`{"unknown": 1}`, `{"id": 1}`, `{"status": "STATUS_LOST"}`, `not json`} {
+		if _, err := protoCodec.Encode([]byte(document)); err == nil {
+			t.Errorf("Encode(%s) succeeded", document)
+		}
+	}
+	// Field 1 declared as a string but truncated
+	if _, err := protoCodec.Decode([]byte{0x0a, 0x05, 'o'}); err == nil {
+		t.Error("Decode of a truncated payload succeeded")
+	}
+}
+
+func TestLoadProtoTypesErrors(t *testing.T) {
+	// order.proto alone, without the files it imports
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet(t, orderSources), &set); err != nil {
+		t.Fatal(err)
+	}
+	set.File = set.File[len(set.File)-1:]
+	orderOnly, err := proto.Marshal(&set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sources []ProtoSource
+		// want is part of the expected error
+		want string
+	}{
+		{
+			"syntax error",
+			[]ProtoSource{{Name: "bad.proto", Content: []byte(`syntax = "proto3"; message {`)}},
+			"failed to compile proto files",
+		},
+		{
+			"missing import",
+			[]ProtoSource{{Name: "order.proto", Content: []byte(orderProto)}},
+			"failed to compile proto files",
+		},
+		{
+			"not a descriptor set",
+			[]ProtoSource{{Name: "order.pb", Content: []byte("not protobuf"), DescriptorSet: true}},
+			"failed to parse descriptor set order.pb",
+		},
+		{
+			"descriptor set without imports",
+			[]ProtoSource{{Name: "order.pb", Content: orderOnly, DescriptorSet: true}},
+			"invalid descriptor set order.pb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadProtoTypes(tt.sources)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadProtoTypes error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// ErrSchemaNotFound is returned when the registry has no schema for a subject or ID
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// lookupTimeout bounds a schema lookup while decoding. Received messages wait
+// on it, so it is much shorter than the client timeout used when publishing.
+const lookupTimeout = 2 * time.Second
+
+// lookupRetryInterval is how long a failed schema lookup is remembered
+// before the registry is asked again
+const lookupRetryInterval = 30 * time.Second
+
+// failedLookup is a remembered schema lookup error
+type failedLookup struct {
+	err   error
+	until time.Time
+}
+
+// SchemaRegistryClient fetches Avro schemas from a Confluent-compatible Schema
+// Registry. Credentials may be given as user info in the URL.
+type SchemaRegistryClient struct {
+	baseURL    string
+	httpClient *http.Client
+	byID       map[int]*goavro.Codec
+	// failed remembers lookups by ID that failed, so that a stream of
+	// payloads that merely look framed doesn't query the registry for each
+	failed        map[int]failedLookup
+	retryInterval time.Duration
+	mutex         sync.Mutex
+}
+
+// registrySchema is the subset of a Schema Registry response used here
+type registrySchema struct {
+	ID         int    `json:"id"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// NewSchemaRegistryClient creates a client for the registry at baseURL
+func NewSchemaRegistryClient(baseURL string) *SchemaRegistryClient {
+	return &SchemaRegistryClient{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		byID:          make(map[int]*goavro.Codec),
+		failed:        make(map[int]failedLookup),
+		retryInterval: lookupRetryInterval,
+	}
+}
+
+// CodecByID returns the Avro codec for a schema ID, caching it since IDs are
+// immutable. Failed lookups are cached too, until the retry interval passes.
+func (c *SchemaRegistryClient) CodecByID(id int) (*goavro.Codec, error) {
+	c.mutex.Lock()
+	avroCodec, ok := c.byID[id]
+	failed, hasFailed := c.failed[id]
+	c.mutex.Unlock()
+	if ok {
+		return avroCodec, nil
+	}
+	if hasFailed && time.Now().Before(failed.until) {
+		return nil, failed.err
+	}
+
+	avroCodec, err := c.lookup(id)
+	c.mutex.Lock()
+	if err != nil {
+		c.failed[id] = failedLookup{err: err, until: time.Now().Add(c.retryInterval)}
+	} else {
+		delete(c.failed, id)
+	}
+	c.mutex.Unlock()
+	return avroCodec, err
+}
+
+// lookup fetches and compiles the schema with an ID
+func (c *SchemaRegistryClient) lookup(id int) (*goavro.Codec, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	var schema registrySchema
+	if err := c.get(ctx, fmt.Sprintf("/schemas/ids/%d", id), &schema); err != nil {
+		return nil, err
+	}
+	schema.ID = id
+
+	return c.cache(schema)
+}
+
+// LatestCodec returns the ID and codec of the latest schema registered under a registry subject
+func (c *SchemaRegistryClient) LatestCodec(subject string) (int, *goavro.Codec, error) {
+	var schema registrySchema
+	if err := c.get(context.Background(), "/subjects/"+url.PathEscape(subject)+"/versions/latest", &schema); err != nil {
+		return 0, nil, err
+	}
+
+	avroCodec, err := c.cache(schema)
+	if err != nil {
+		return 0, nil, err
+	}
+	return schema.ID, avroCodec, nil
+}
+
+// cache compiles a schema and stores it by ID
+func (c *SchemaRegistryClient) cache(schema registrySchema) (*goavro.Codec, error) {
+	if schema.SchemaType != "" && schema.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %s, only AVRO is supported", schema.ID, schema.SchemaType)
+	}
+
+	avroCodec, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %d: %w", schema.ID, err)
+	}
+
+	c.mutex.Lock()
+	c.byID[schema.ID] = avroCodec
+	c.mutex.Unlock()
+	return avroCodec, nil
+}
+
+// get performs a registry GET request and decodes the JSON response
+func (c *SchemaRegistryClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("invalid schema registry URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("schema registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrSchemaNotFound, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var registryError struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&registryError)
+		return fmt.Errorf("schema registry returned %s for %s: %s", resp.Status, path, registryError.Message)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid schema registry response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/alerts"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type AlertRepository struct {
+	db *sql.DB
+}
+
+// NewAlertRepository creates a new alert rule and alert log repository
+func NewAlertRepository(db *sql.DB) *AlertRepository {
+	return &AlertRepository{db: db}
+}
+
+// SaveRule inserts a rule, or updates it when it has an ID, and returns its ID
+func (r *AlertRepository) SaveRule(rule models.AlertRule) (int, error) {
+	cooldown := int64(rule.Cooldown / time.Second)
+
+	if rule.ID > 0 {
+		_, err := r.db.Exec("UPDATE alert_rules SET name = ?, kind = ?, expression = ?, threshold = ?, cooldown_seconds = ?, enabled = ? WHERE id = ?",
+			rule.Name, string(rule.Kind), rule.Expression, rule.Threshold, cooldown, rule.Enabled, rule.ID)
+		if err != nil {
+			return 0, err
+		}
+		log.Println("Alert rule updated:", rule.Name)
+		return rule.ID, nil
+	}
+
+	result, err := r.db.Exec("INSERT INTO alert_rules(sub_id, name, kind, expression, threshold, cooldown_seconds, enabled) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		rule.SubscriptionID, rule.Name, string(rule.Kind), rule.Expression, rule.Threshold, cooldown, rule.Enabled)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("Alert rule saved:", rule.Name)
+	return int(id), nil
+}
+
+// GetRules loads the rules of a subscription ordered by name
+func (r *AlertRepository) GetRules(subID int) ([]models.AlertRule, error) {
+	rows, err := r.db.Query("SELECT id, name, kind, COALESCE(expression, ''), COALESCE(threshold, 0), COALESCE(cooldown_seconds, 0), COALESCE(enabled, 1) FROM alert_rules WHERE sub_id = ? ORDER BY name", subID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		var rule models.AlertRule
+		var kind string
+		var cooldown int64
+		err := rows.Scan(&rule.ID, &rule.Name, &kind, &rule.Expression, &rule.Threshold, &cooldown, &rule.Enabled)
+		if err != nil {
+			return nil, err
+		}
+		rule.SubscriptionID = subID
+		rule.Kind = alerts.Kind(kind)
+		rule.Cooldown = time.Duration(cooldown) * time.Second
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// SetRuleEnabled enables or disables a rule
+func (r *AlertRepository) SetRuleEnabled(ruleID int, enabled bool) error {
+	_, err := r.db.Exec("UPDATE alert_rules SET enabled = ? WHERE id = ?", enabled, ruleID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Alert rule %d enabled: %v", ruleID, enabled)
+	return nil
+}
+
+// DeleteRule deletes a rule from the database
+func (r *AlertRepository) DeleteRule(ruleID int) error {
+	_, err := r.db.Exec("DELETE FROM alert_rules WHERE id = ?", ruleID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Alert rule deleted:", ruleID)
+	return nil
+}
+
+// DeleteRules deletes all rules of a subscription
+func (r *AlertRepository) DeleteRules(subID int) error {
+	_, err := r.db.Exec("DELETE FROM alert_rules WHERE sub_id = ?", subID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Alert rules deleted for sub:", subID)
+	return nil
+}
+
+// AppendAlert adds an entry to the alert log
+func (r *AlertRepository) AppendAlert(alert models.Alert) error {
+	_, err := r.db.Exec("INSERT INTO alert_log(rule_id, rule_name, sub_name, subject, detail, payload, fired_at) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		alert.RuleID, alert.RuleName, alert.SubName, alert.Subject, alert.Detail, alert.Payload, alert.FiredAt.UnixNano())
+	return err
+}
+
+// GetAlerts loads the latest entries of the alert log, newest first
+func (r *AlertRepository) GetAlerts(limit int) ([]models.Alert, error) {
+	rows, err := r.db.Query("SELECT id, rule_id, rule_name, sub_name, COALESCE(subject, ''), detail, COALESCE(payload, ''), fired_at FROM alert_log ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.Alert
+	for rows.Next() {
+		var a models.Alert
+		var firedAt int64
+		err := rows.Scan(&a.ID, &a.RuleID, &a.RuleName, &a.SubName, &a.Subject, &a.Detail, &a.Payload, &firedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.FiredAt = time.Unix(0, firedAt)
+		entries = append(entries, a)
+	}
+
+	return entries, nil
+}
+
+// ClearAlerts empties the alert log
+func (r *AlertRepository) ClearAlerts() error {
+	_, err := r.db.Exec("DELETE FROM alert_log")
+	if err != nil {
+		return err
+	}
+
+	log.Println("Alert log cleared")
+	return nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/bridge"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type BridgeRepository struct {
+	db *sql.DB
+}
+
+// NewBridgeRepository creates a new bridge repository
+func NewBridgeRepository(db *sql.DB) *BridgeRepository {
+	return &BridgeRepository{db: db}
+}
+
+// Save inserts a bridge, or updates it when it has an ID, and returns its ID
+func (r *BridgeRepository) Save(b models.Bridge) (int, error) {
+	mappings := b.Mappings
+	if mappings == nil {
+		mappings = []bridge.Mapping{}
+	}
+	mappingsJSON, err := json.Marshal(mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	if b.ID > 0 {
+		_, err := r.db.Exec("UPDATE bridges SET name = ?, source_server_id = ?, source_pattern = ?, target_server_id = ?, mappings = ?, transform = ?, script = ?, script_timeout_ms = ? WHERE id = ?",
+			b.Name, b.SourceServerID, b.SourcePattern, b.TargetServerID, string(mappingsJSON), b.Transform, b.Script, b.ScriptTimeout.Milliseconds(), b.ID)
+		if err != nil {
+			return 0, err
+		}
+		log.Println("Bridge updated:", b.Name)
+		return b.ID, nil
+	}
+
+	result, err := r.db.Exec("INSERT INTO bridges(name, source_server_id, source_pattern, target_server_id, mappings, transform, script, script_timeout_ms) VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		b.Name, b.SourceServerID, b.SourcePattern, b.TargetServerID, string(mappingsJSON), b.Transform, b.Script, b.ScriptTimeout.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("Bridge saved:", b.Name)
+	return int(id), nil
+}
+
+// GetAll loads all bridges ordered by name
+func (r *BridgeRepository) GetAll() ([]models.Bridge, error) {
+	rows, err := r.db.Query("SELECT id, name, source_server_id, source_pattern, target_server_id, COALESCE(mappings, '[]'), COALESCE(transform, ''), COALESCE(script, ''), COALESCE(script_timeout_ms, 0) FROM bridges ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bridges []models.Bridge
+	for rows.Next() {
+		var b models.Bridge
+		var mappings string
+		var scriptTimeout int64
+		err := rows.Scan(&b.ID, &b.Name, &b.SourceServerID, &b.SourcePattern, &b.TargetServerID, &mappings, &b.Transform, &b.Script, &scriptTimeout)
+		if err != nil {
+			return nil, err
+		}
+		b.ScriptTimeout = time.Duration(scriptTimeout) * time.Millisecond
+		if err := json.Unmarshal([]byte(mappings), &b.Mappings); err != nil {
+			log.Printf("Ignoring invalid mappings of bridge %s: %v", b.Name, err)
+		}
+		bridges = append(bridges, b)
+	}
+
+	return bridges, nil
+}
+
+// Delete deletes a bridge from the database
+func (r *BridgeRepository) Delete(bridgeID int) error {
+	_, err := r.db.Exec("DELETE FROM bridges WHERE id = ?", bridgeID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Bridge deleted:", bridgeID)
+	return nil
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type CollectionRepository struct {
+	db *sql.DB
+}
+
+// NewCollectionRepository creates a new collection repository
+func NewCollectionRepository(db *sql.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// Create saves a new collection and returns its ID
+func (r *CollectionRepository) Create(name string) (int, error) {
+	result, err := r.db.Exec("INSERT INTO collections(name) VALUES(?)", name)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("Collection saved:", name)
+	return int(id), nil
+}
+
+// GetAll loads all collections ordered by name
+func (r *CollectionRepository) GetAll() ([]models.Collection, error) {
+	rows, err := r.db.Query("SELECT id, name FROM collections ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []models.Collection
+	for rows.Next() {
+		var c models.Collection
+		err := rows.Scan(&c.ID, &c.Name)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+
+	return collections, nil
+}
+
+// Delete deletes a collection and its requests
+func (r *CollectionRepository) Delete(collectionID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM collection_requests WHERE collection_id = ?", collectionID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collections WHERE id = ?", collectionID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println("Collection deleted:", collectionID)
+	return nil
+}
+
+// SaveRequest inserts a request, or updates it when it has an ID, and returns its ID
+func (r *CollectionRepository) SaveRequest(request models.CollectionRequest) (int, error) {
+	headers := request.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return 0, err
+	}
+
+	if request.ID > 0 {
+		_, err := r.db.Exec("UPDATE collection_requests SET folder = ?, name = ?, provider_type = ?, server_url = ?, subject = ?, headers = ?, body = ? WHERE id = ?",
+			request.Folder, request.Name, string(request.ProviderType), request.ServerURL, request.Subject, string(headersJSON), request.Body, request.ID)
+		if err != nil {
+			return 0, err
+		}
+		log.Println("Collection request updated:", request.Name)
+		return request.ID, nil
+	}
+
+	result, err := r.db.Exec("INSERT INTO collection_requests(collection_id, folder, name, provider_type, server_url, subject, headers, body) VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		request.CollectionID, request.Folder, request.Name, string(request.ProviderType), request.ServerURL, request.Subject, string(headersJSON), request.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("Collection request saved:", request.Name)
+	return int(id), nil
+}
+
+// GetRequests loads the requests of a collection ordered by folder and name
+func (r *CollectionRepository) GetRequests(collectionID int) ([]models.CollectionRequest, error) {
+	rows, err := r.db.Query("SELECT id, COALESCE(folder, ''), name, provider_type, server_url, subject, COALESCE(headers, '{}'), body FROM collection_requests WHERE collection_id = ? ORDER BY folder, name", collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.CollectionRequest
+	for rows.Next() {
+		var req models.CollectionRequest
+		var providerTypeStr, headers string
+		err := rows.Scan(&req.ID, &req.Folder, &req.Name, &providerTypeStr, &req.ServerURL, &req.Subject, &headers, &req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(headers), &req.Headers); err != nil {
+			log.Printf("Ignoring invalid headers of request %s: %v", req.Name, err)
+		}
+		req.CollectionID = collectionID
+		req.ProviderType = messaging.ProviderType(providerTypeStr)
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+// DeleteRequest deletes a request from the database
+func (r *CollectionRepository) DeleteRequest(requestID int) error {
+	stmt, err := r.db.Prepare("DELETE FROM collection_requests WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(requestID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Collection request deleted:", requestID)
+	return nil
+}
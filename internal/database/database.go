@@ -51,12 +51,32 @@ func (d *Database) createTables() error {
 		log.Printf("Column provider_type may already exist: %v", err)
 	}
 
+	// Migration: add schema_registry_url column if it doesn't exist
+	_, err = d.db.Exec(`ALTER TABLE servers ADD COLUMN schema_registry_url TEXT DEFAULT ''`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column schema_registry_url may already exist: %v", err)
+	}
+
 	// Create topics table
 	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS topics (id INTEGER PRIMARY KEY AUTOINCREMENT, server_id INTEGER, topic_name TEXT)`)
 	if err != nil {
 		return err
 	}
 
+	// Migration: add JSON Schema columns if they don't exist
+	_, err = d.db.Exec(`ALTER TABLE topics ADD COLUMN json_schema TEXT DEFAULT ''`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column json_schema may already exist: %v", err)
+	}
+
+	_, err = d.db.Exec(`ALTER TABLE topics ADD COLUMN reject_invalid INTEGER DEFAULT 1`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column reject_invalid may already exist: %v", err)
+	}
+
 	// Create subs table
 	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS subs (id INTEGER PRIMARY KEY AUTOINCREMENT, server_id INTEGER, sub_name TEXT, subject_pattern TEXT)`)
 	if err != nil {
@@ -70,5 +90,108 @@ func (d *Database) createTables() error {
 		log.Printf("Column subject_pattern may already exist: %v", err)
 	}
 
+	// Migration: add backpressure_policy column if it doesn't exist
+	_, err = d.db.Exec(`ALTER TABLE subs ADD COLUMN backpressure_policy TEXT DEFAULT 'drop-oldest'`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column backpressure_policy may already exist: %v", err)
+	}
+
+	// Migration: add json_schema column if it doesn't exist
+	_, err = d.db.Exec(`ALTER TABLE subs ADD COLUMN json_schema TEXT DEFAULT ''`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column json_schema may already exist: %v", err)
+	}
+
+	// Migration: add filters column if it doesn't exist
+	_, err = d.db.Exec(`ALTER TABLE subs ADD COLUMN filters TEXT DEFAULT '[]'`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column filters may already exist: %v", err)
+	}
+
+	// Migration: add script columns if they don't exist
+	_, err = d.db.Exec(`ALTER TABLE subs ADD COLUMN script TEXT DEFAULT ''`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column script may already exist: %v", err)
+	}
+
+	_, err = d.db.Exec(`ALTER TABLE subs ADD COLUMN script_timeout_ms INTEGER DEFAULT 0`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column script_timeout_ms may already exist: %v", err)
+	}
+
+	// Create protobuf schema tables
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS proto_schemas (id INTEGER PRIMARY KEY AUTOINCREMENT, server_id INTEGER, name TEXT, content BLOB, descriptor_set INTEGER DEFAULT 0)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS proto_mappings (id INTEGER PRIMARY KEY AUTOINCREMENT, server_id INTEGER, subject_pattern TEXT, message_type TEXT)`)
+	if err != nil {
+		return err
+	}
+
+	// Create payload templates table
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS payload_templates (id INTEGER PRIMARY KEY AUTOINCREMENT, topic_id INTEGER, name TEXT, body TEXT, variables TEXT DEFAULT '{}', UNIQUE(topic_id, name))`)
+	if err != nil {
+		return err
+	}
+
+	// Create collection tables
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS collections (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS collection_requests (id INTEGER PRIMARY KEY AUTOINCREMENT, collection_id INTEGER, folder TEXT DEFAULT '', name TEXT, provider_type TEXT, server_url TEXT, subject TEXT, headers TEXT DEFAULT '{}', body TEXT)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS environments (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, variables TEXT DEFAULT '{}')`)
+	if err != nil {
+		return err
+	}
+
+	// Create bridges table
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS bridges (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, source_server_id INTEGER, source_pattern TEXT, target_server_id INTEGER, mappings TEXT DEFAULT '[]', transform TEXT DEFAULT '')`)
+	if err != nil {
+		return err
+	}
+
+	// Migration: add bridge script columns if they don't exist
+	_, err = d.db.Exec(`ALTER TABLE bridges ADD COLUMN script TEXT DEFAULT ''`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column script may already exist: %v", err)
+	}
+
+	_, err = d.db.Exec(`ALTER TABLE bridges ADD COLUMN script_timeout_ms INTEGER DEFAULT 0`)
+	if err != nil {
+		// Ignore error if column already exists
+		log.Printf("Column script_timeout_ms may already exist: %v", err)
+	}
+
+	// Create responders table
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS responders (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, server_id INTEGER, pattern TEXT, reply_template TEXT DEFAULT '', event_subject TEXT DEFAULT '', event_template TEXT DEFAULT '', headers TEXT DEFAULT '{}', delay_ms INTEGER DEFAULT 0, jitter_ms INTEGER DEFAULT 0, error_rate REAL DEFAULT 0, error_template TEXT DEFAULT '')`)
+	if err != nil {
+		return err
+	}
+
+	// Create alert tables
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS alert_rules (id INTEGER PRIMARY KEY AUTOINCREMENT, sub_id INTEGER, name TEXT, kind TEXT, expression TEXT DEFAULT '', threshold REAL DEFAULT 0, cooldown_seconds INTEGER DEFAULT 0, enabled INTEGER DEFAULT 1)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS alert_log (id INTEGER PRIMARY KEY AUTOINCREMENT, rule_id INTEGER, rule_name TEXT, sub_name TEXT, subject TEXT DEFAULT '', detail TEXT, payload TEXT DEFAULT '', fired_at INTEGER)`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type EnvironmentRepository struct {
+	db *sql.DB
+}
+
+// NewEnvironmentRepository creates a new environment repository
+func NewEnvironmentRepository(db *sql.DB) *EnvironmentRepository {
+	return &EnvironmentRepository{db: db}
+}
+
+// Save saves an environment, replacing the variables of an existing one with the same name
+func (r *EnvironmentRepository) Save(name string, variables map[string]string) error {
+	if name == "" {
+		return nil
+	}
+
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := r.db.Prepare(`INSERT INTO environments(name, variables) VALUES(?, ?)
+		ON CONFLICT(name) DO UPDATE SET variables = excluded.variables`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(name, string(vars))
+	if err != nil {
+		return err
+	}
+
+	log.Println("Environment saved:", name)
+	return nil
+}
+
+// GetAll loads all environments ordered by name
+func (r *EnvironmentRepository) GetAll() ([]models.Environment, error) {
+	rows, err := r.db.Query("SELECT id, name, COALESCE(variables, '{}') FROM environments ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var environments []models.Environment
+	for rows.Next() {
+		var e models.Environment
+		var vars string
+		err := rows.Scan(&e.ID, &e.Name, &vars)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(vars), &e.Variables); err != nil {
+			log.Printf("Ignoring invalid variables of environment %s: %v", e.Name, err)
+		}
+		environments = append(environments, e)
+	}
+
+	return environments, nil
+}
+
+// Delete deletes an environment from the database
+func (r *EnvironmentRepository) Delete(environmentID int) error {
+	stmt, err := r.db.Prepare("DELETE FROM environments WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(environmentID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Environment deleted:", environmentID)
+	return nil
+}
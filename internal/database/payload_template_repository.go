@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type PayloadTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewPayloadTemplateRepository creates a new payload template repository
+func NewPayloadTemplateRepository(db *sql.DB) *PayloadTemplateRepository {
+	return &PayloadTemplateRepository{db: db}
+}
+
+// Save saves a template, replacing any template of the same name for the topic
+func (r *PayloadTemplateRepository) Save(topicID int, name, body string, variables map[string]string) error {
+	if name == "" {
+		return nil
+	}
+
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := r.db.Prepare(`INSERT INTO payload_templates(topic_id, name, body, variables) VALUES(?, ?, ?, ?)
+		ON CONFLICT(topic_id, name) DO UPDATE SET body = excluded.body, variables = excluded.variables`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(topicID, name, body, string(vars))
+	if err != nil {
+		return err
+	}
+
+	log.Println("Payload template saved:", name)
+	return nil
+}
+
+// GetByTopicID loads the templates of a topic ordered by name
+func (r *PayloadTemplateRepository) GetByTopicID(topicID int) ([]models.PayloadTemplate, error) {
+	rows, err := r.db.Query("SELECT id, name, body, COALESCE(variables, '{}') FROM payload_templates WHERE topic_id = ? ORDER BY name", topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.PayloadTemplate
+	for rows.Next() {
+		var t models.PayloadTemplate
+		var vars string
+		err := rows.Scan(&t.ID, &t.Name, &t.Body, &vars)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(vars), &t.Variables); err != nil {
+			log.Printf("Ignoring invalid variables of template %s: %v", t.Name, err)
+		}
+		t.TopicID = topicID
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// Delete deletes a template from the database
+func (r *PayloadTemplateRepository) Delete(templateID int) error {
+	stmt, err := r.db.Prepare("DELETE FROM payload_templates WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(templateID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Payload template deleted:", templateID)
+	return nil
+}
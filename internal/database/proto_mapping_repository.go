@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type ProtoMappingRepository struct {
+	db *sql.DB
+}
+
+// NewProtoMappingRepository creates a new protobuf mapping repository
+func NewProtoMappingRepository(db *sql.DB) *ProtoMappingRepository {
+	return &ProtoMappingRepository{db: db}
+}
+
+// Save saves a subject pattern to message type mapping to the database
+func (r *ProtoMappingRepository) Save(serverID int, subjectPattern, messageType string) error {
+	if subjectPattern == "" || messageType == "" {
+		return nil
+	}
+
+	stmt, err := r.db.Prepare("INSERT INTO proto_mappings(server_id, subject_pattern, message_type) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(serverID, subjectPattern, messageType)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Proto mapping saved: %s -> %s", subjectPattern, messageType)
+	return nil
+}
+
+// GetByServerID loads protobuf mappings for a specific server in creation order
+func (r *ProtoMappingRepository) GetByServerID(serverID int) ([]models.ProtoMapping, error) {
+	rows, err := r.db.Query("SELECT id, subject_pattern, message_type FROM proto_mappings WHERE server_id = ? ORDER BY id", serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []models.ProtoMapping
+	for rows.Next() {
+		var m models.ProtoMapping
+		err := rows.Scan(&m.ID, &m.SubjectPattern, &m.MessageType)
+		if err != nil {
+			return nil, err
+		}
+		m.ServerID = serverID
+		mappings = append(mappings, m)
+	}
+
+	return mappings, nil
+}
+
+// Delete deletes a protobuf mapping from the database
+func (r *ProtoMappingRepository) Delete(mappingID int) error {
+	stmt, err := r.db.Prepare("DELETE FROM proto_mappings WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(mappingID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Proto mapping deleted:", mappingID)
+	return nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type ProtoSchemaRepository struct {
+	db *sql.DB
+}
+
+// NewProtoSchemaRepository creates a new protobuf schema repository
+func NewProtoSchemaRepository(db *sql.DB) *ProtoSchemaRepository {
+	return &ProtoSchemaRepository{db: db}
+}
+
+// Save saves a protobuf schema to the database
+func (r *ProtoSchemaRepository) Save(serverID int, name string, content []byte, descriptorSet bool) error {
+	if name == "" || len(content) == 0 {
+		return nil
+	}
+
+	stmt, err := r.db.Prepare("INSERT INTO proto_schemas(server_id, name, content, descriptor_set) VALUES(?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(serverID, name, content, descriptorSet)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Proto schema saved: %s (%d bytes)", name, len(content))
+	return nil
+}
+
+// GetByServerID loads protobuf schemas for a specific server
+func (r *ProtoSchemaRepository) GetByServerID(serverID int) ([]models.ProtoSchema, error) {
+	rows, err := r.db.Query("SELECT id, name, content, descriptor_set FROM proto_schemas WHERE server_id = ?", serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []models.ProtoSchema
+	for rows.Next() {
+		var s models.ProtoSchema
+		err := rows.Scan(&s.ID, &s.Name, &s.Content, &s.DescriptorSet)
+		if err != nil {
+			return nil, err
+		}
+		s.ServerID = serverID
+		schemas = append(schemas, s)
+	}
+
+	return schemas, nil
+}
+
+// Delete deletes a protobuf schema from the database
+func (r *ProtoSchemaRepository) Delete(schemaID int) error {
+	stmt, err := r.db.Prepare("DELETE FROM proto_schemas WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(schemaID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Proto schema deleted:", schemaID)
+	return nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type ResponderRepository struct {
+	db *sql.DB
+}
+
+// NewResponderRepository creates a new responder repository
+func NewResponderRepository(db *sql.DB) *ResponderRepository {
+	return &ResponderRepository{db: db}
+}
+
+// Save inserts a responder, or updates it when it has an ID, and returns its ID
+func (r *ResponderRepository) Save(m models.Responder) (int, error) {
+	headers := m.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.ID > 0 {
+		_, err := r.db.Exec("UPDATE responders SET name = ?, server_id = ?, pattern = ?, reply_template = ?, event_subject = ?, event_template = ?, headers = ?, delay_ms = ?, jitter_ms = ?, error_rate = ?, error_template = ? WHERE id = ?",
+			m.Name, m.ServerID, m.Pattern, m.ReplyTemplate, m.EventSubject, m.EventTemplate, string(headersJSON), m.Delay.Milliseconds(), m.Jitter.Milliseconds(), m.ErrorRate, m.ErrorTemplate, m.ID)
+		if err != nil {
+			return 0, err
+		}
+		log.Println("Responder updated:", m.Name)
+		return m.ID, nil
+	}
+
+	result, err := r.db.Exec("INSERT INTO responders(name, server_id, pattern, reply_template, event_subject, event_template, headers, delay_ms, jitter_ms, error_rate, error_template) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		m.Name, m.ServerID, m.Pattern, m.ReplyTemplate, m.EventSubject, m.EventTemplate, string(headersJSON), m.Delay.Milliseconds(), m.Jitter.Milliseconds(), m.ErrorRate, m.ErrorTemplate)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("Responder saved:", m.Name)
+	return int(id), nil
+}
+
+// GetAll loads all responders ordered by name
+func (r *ResponderRepository) GetAll() ([]models.Responder, error) {
+	rows, err := r.db.Query("SELECT id, name, server_id, pattern, COALESCE(reply_template, ''), COALESCE(event_subject, ''), COALESCE(event_template, ''), COALESCE(headers, '{}'), COALESCE(delay_ms, 0), COALESCE(jitter_ms, 0), COALESCE(error_rate, 0), COALESCE(error_template, '') FROM responders ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var responders []models.Responder
+	for rows.Next() {
+		var m models.Responder
+		var headers string
+		var delay, jitter int64
+		err := rows.Scan(&m.ID, &m.Name, &m.ServerID, &m.Pattern, &m.ReplyTemplate, &m.EventSubject, &m.EventTemplate, &headers, &delay, &jitter, &m.ErrorRate, &m.ErrorTemplate)
+		if err != nil {
+			return nil, err
+		}
+		m.Delay = time.Duration(delay) * time.Millisecond
+		m.Jitter = time.Duration(jitter) * time.Millisecond
+		if err := json.Unmarshal([]byte(headers), &m.Headers); err != nil {
+			log.Printf("Ignoring invalid headers of responder %s: %v", m.Name, err)
+		}
+		responders = append(responders, m)
+	}
+
+	return responders, nil
+}
+
+// Delete deletes a responder from the database
+func (r *ResponderRepository) Delete(responderID int) error {
+	_, err := r.db.Exec("DELETE FROM responders WHERE id = ?", responderID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Responder deleted:", responderID)
+	return nil
+}
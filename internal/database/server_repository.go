@@ -57,7 +57,7 @@ func (r *ServerRepository) Update(serverID int, name, url string, providerType m
 
 // GetAll loads all servers from the database
 func (r *ServerRepository) GetAll() ([]models.Server, error) {
-	rows, err := r.db.Query("SELECT id, name, url, COALESCE(provider_type, 'NATS') FROM servers")
+	rows, err := r.db.Query("SELECT id, name, url, COALESCE(provider_type, 'NATS'), COALESCE(schema_registry_url, '') FROM servers")
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +67,7 @@ func (r *ServerRepository) GetAll() ([]models.Server, error) {
 	for rows.Next() {
 		var s models.Server
 		var providerTypeStr string
-		err := rows.Scan(&s.ID, &s.Name, &s.URL, &providerTypeStr)
+		err := rows.Scan(&s.ID, &s.Name, &s.URL, &providerTypeStr, &s.SchemaRegistryURL)
 		if err != nil {
 			return nil, err
 		}
@@ -78,6 +78,33 @@ func (r *ServerRepository) GetAll() ([]models.Server, error) {
 	return servers, nil
 }
 
+// GetSchemaRegistryURL returns the Schema Registry URL configured for a server
+func (r *ServerRepository) GetSchemaRegistryURL(serverID int) (string, error) {
+	var registryURL string
+	err := r.db.QueryRow("SELECT COALESCE(schema_registry_url, '') FROM servers WHERE id = ?", serverID).Scan(&registryURL)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return registryURL, err
+}
+
+// SetSchemaRegistryURL updates the Schema Registry URL of a server
+func (r *ServerRepository) SetSchemaRegistryURL(serverID int, registryURL string) error {
+	stmt, err := r.db.Prepare("UPDATE servers SET schema_registry_url = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(registryURL, serverID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Schema registry updated for server:", serverID)
+	return nil
+}
+
 // Delete removes a server from the database
 func (r *ServerRepository) Delete(serverID int) error {
 	stmt, err := r.db.Prepare("DELETE FROM servers WHERE id = ?")
@@ -2,8 +2,12 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"log"
+	"time"
 
+	"github.com/devalexandre/broker-ui/internal/filter"
+	"github.com/devalexandre/broker-ui/internal/messaging"
 	"github.com/devalexandre/broker-ui/internal/models"
 )
 
@@ -17,29 +21,33 @@ func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
 }
 
 // Save saves a subscription to the database
-func (r *SubscriptionRepository) Save(serverID int, subName, subjectPattern string) error {
+func (r *SubscriptionRepository) Save(serverID int, subName, subjectPattern string, policy messaging.BackpressurePolicy) error {
 	if subName == "" || subjectPattern == "" {
 		return nil
 	}
 
-	stmt, err := r.db.Prepare("INSERT INTO subs(server_id, sub_name, subject_pattern) VALUES(?, ?, ?)")
+	if policy == "" {
+		policy = messaging.PolicyDropOldest
+	}
+
+	stmt, err := r.db.Prepare("INSERT INTO subs(server_id, sub_name, subject_pattern, backpressure_policy) VALUES(?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(serverID, subName, subjectPattern)
+	_, err = stmt.Exec(serverID, subName, subjectPattern, string(policy))
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Sub saved: %s (pattern: %s)", subName, subjectPattern)
+	log.Printf("Sub saved: %s (pattern: %s, policy: %s)", subName, subjectPattern, policy)
 	return nil
 }
 
 // GetByServerID loads subscriptions for a specific server
 func (r *SubscriptionRepository) GetByServerID(serverID int) ([]models.Subscription, error) {
-	rows, err := r.db.Query("SELECT id, sub_name, COALESCE(subject_pattern, '') FROM subs WHERE server_id = ?", serverID)
+	rows, err := r.db.Query("SELECT id, sub_name, COALESCE(subject_pattern, ''), COALESCE(backpressure_policy, 'drop-oldest'), COALESCE(json_schema, ''), COALESCE(filters, '[]'), COALESCE(script, ''), COALESCE(script_timeout_ms, 0) FROM subs WHERE server_id = ?", serverID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,17 +56,83 @@ func (r *SubscriptionRepository) GetByServerID(serverID int) ([]models.Subscript
 	var subs []models.Subscription
 	for rows.Next() {
 		var s models.Subscription
-		err := rows.Scan(&s.ID, &s.SubName, &s.SubjectPattern)
+		var policyStr, filters string
+		var scriptTimeout int64
+		err := rows.Scan(&s.ID, &s.SubName, &s.SubjectPattern, &policyStr, &s.JSONSchema, &filters, &s.Script, &scriptTimeout)
 		if err != nil {
 			return nil, err
 		}
+		s.ScriptTimeout = time.Duration(scriptTimeout) * time.Millisecond
+		if err := json.Unmarshal([]byte(filters), &s.Filters); err != nil {
+			log.Printf("Ignoring invalid filters of sub %s: %v", s.SubName, err)
+		}
 		s.ServerID = serverID
+		s.BackpressurePolicy = messaging.BackpressurePolicy(policyStr)
 		subs = append(subs, s)
 	}
 
 	return subs, nil
 }
 
+// SetJSONSchema attaches a JSON Schema to a subscription; an empty schema removes it
+func (r *SubscriptionRepository) SetJSONSchema(subID int, schema string) error {
+	stmt, err := r.db.Prepare("UPDATE subs SET json_schema = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(schema, subID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Sub schema updated:", subID)
+	return nil
+}
+
+// SetFilters saves the client-side filter rules of a subscription
+func (r *SubscriptionRepository) SetFilters(subID int, rules []filter.Rule) error {
+	if rules == nil {
+		rules = []filter.Rule{}
+	}
+	filters, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := r.db.Prepare("UPDATE subs SET filters = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(string(filters), subID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Sub filters updated:", subID)
+	return nil
+}
+
+// SetScript attaches a script to a subscription; an empty script removes it
+func (r *SubscriptionRepository) SetScript(subID int, script string, timeout time.Duration) error {
+	stmt, err := r.db.Prepare("UPDATE subs SET script = ?, script_timeout_ms = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(script, timeout.Milliseconds(), subID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Sub script updated:", subID)
+	return nil
+}
+
 // Delete deletes a subscription from the database
 func (r *SubscriptionRepository) Delete(subName string, serverID int) error {
 	stmt, err := r.db.Prepare("DELETE FROM subs WHERE sub_name = ? AND server_id = ?")
@@ -39,7 +39,7 @@ func (r *TopicRepository) Save(serverID int, topicName string) error {
 
 // GetByServerID loads topics for a specific server
 func (r *TopicRepository) GetByServerID(serverID int) ([]models.Topic, error) {
-	rows, err := r.db.Query("SELECT id, topic_name FROM topics WHERE server_id = ?", serverID)
+	rows, err := r.db.Query("SELECT id, topic_name, COALESCE(json_schema, ''), COALESCE(reject_invalid, 1) FROM topics WHERE server_id = ?", serverID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +48,7 @@ func (r *TopicRepository) GetByServerID(serverID int) ([]models.Topic, error) {
 	var topics []models.Topic
 	for rows.Next() {
 		var t models.Topic
-		err := rows.Scan(&t.ID, &t.TopicName)
+		err := rows.Scan(&t.ID, &t.TopicName, &t.JSONSchema, &t.RejectInvalid)
 		if err != nil {
 			return nil, err
 		}
@@ -59,6 +59,23 @@ func (r *TopicRepository) GetByServerID(serverID int) ([]models.Topic, error) {
 	return topics, nil
 }
 
+// SetJSONSchema attaches a JSON Schema to a topic; an empty schema removes it
+func (r *TopicRepository) SetJSONSchema(topicID int, schema string, rejectInvalid bool) error {
+	stmt, err := r.db.Prepare("UPDATE topics SET json_schema = ?, reject_invalid = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(schema, rejectInvalid, topicID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Topic schema updated:", topicID)
+	return nil
+}
+
 // Delete deletes a topic from the database
 func (r *TopicRepository) Delete(topicName string, serverID int) error {
 	stmt, err := r.db.Prepare("DELETE FROM topics WHERE topic_name = ? AND server_id = ?")
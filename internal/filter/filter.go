@@ -0,0 +1,204 @@
+// Package filter decides client-side which received messages are shown or
+// highlighted, without changing the broker subscription.
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/devalexandre/broker-ui/internal/jsonpath"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// Kind is what a filter rule matches against
+type Kind string
+
+const (
+	// KindSubject matches the subject with a glob such as orders.*.created or orders.>
+	KindSubject Kind = "subject"
+	// KindContains matches payloads containing a text
+	KindContains Kind = "contains"
+	// KindRegex matches payloads with a regular expression
+	KindRegex Kind = "regex"
+	// KindJSONPath matches JSON payloads with a JSONPath expression
+	KindJSONPath Kind = "jsonpath"
+	// KindHeader matches a header value, written as name=value
+	KindHeader Kind = "header"
+)
+
+// Kinds lists the rule kinds in display order
+var Kinds = []Kind{KindSubject, KindContains, KindRegex, KindJSONPath, KindHeader}
+
+// Action is what happens to matching messages
+type Action string
+
+const (
+	// ActionShow shows only messages matching every show rule
+	ActionShow Action = "show"
+	// ActionHide hides messages matching any hide rule
+	ActionHide Action = "hide"
+	// ActionHighlight highlights messages matching any highlight rule
+	ActionHighlight Action = "highlight"
+)
+
+// Actions lists the actions in display order
+var Actions = []Action{ActionShow, ActionHide, ActionHighlight}
+
+// Rule is a filter rule saved with a subscription
+type Rule struct {
+	Kind       Kind   `json:"kind"`
+	Expression string `json:"expression"`
+	Action     Action `json:"action"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// String describes the rule
+func (r Rule) String() string {
+	return fmt.Sprintf("%s %s %s", r.Action, r.Kind, r.Expression)
+}
+
+// matcher is a compiled rule condition
+type matcher func(msg *messaging.Message) bool
+
+// compiledRule is a rule ready for evaluation
+type compiledRule struct {
+	action Action
+	match  matcher
+}
+
+// Set is a compiled set of enabled rules
+type Set struct {
+	rules []compiledRule
+}
+
+// Compile compiles the enabled rules; it fails on the first invalid one
+func Compile(rules []Rule) (*Set, error) {
+	s := &Set{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		match, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", rule, err)
+		}
+		switch rule.Action {
+		case ActionShow, ActionHide, ActionHighlight:
+		default:
+			return nil, fmt.Errorf("filter %q: unknown action %q", rule, rule.Action)
+		}
+		s.rules = append(s.rules, compiledRule{action: rule.Action, match: match})
+	}
+	return s, nil
+}
+
+// compile builds the matcher of a rule
+func compile(rule Rule) (matcher, error) {
+	expression := rule.Expression
+	if expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+
+	switch rule.Kind {
+	case KindSubject:
+		if _, err := path.Match(expression, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob: %w", err)
+		}
+		return func(msg *messaging.Message) bool {
+			if messaging.MatchSubject(expression, msg.Subject) {
+				return true
+			}
+			matched, _ := path.Match(expression, msg.Subject)
+			return matched
+		}, nil
+
+	case KindContains:
+		needle := []byte(expression)
+		return func(msg *messaging.Message) bool {
+			return bytes.Contains(body(msg), needle)
+		}, nil
+
+	case KindRegex:
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg *messaging.Message) bool {
+			return re.Match(body(msg))
+		}, nil
+
+	case KindJSONPath:
+		expr, err := jsonpath.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg *messaging.Message) bool {
+			matched, _ := expr.MatchJSON(body(msg))
+			return matched
+		}, nil
+
+	case KindHeader:
+		name, value, ok := strings.Cut(expression, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("header filters are written as name=value")
+		}
+		value = strings.TrimSpace(value)
+		return func(msg *messaging.Message) bool {
+			for k, v := range msg.Headers {
+				if strings.EqualFold(k, name) && v == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown kind %q", rule.Kind)
+}
+
+// body returns the decoded payload when a codec produced one, else the raw payload
+func body(msg *messaging.Message) []byte {
+	if msg.Decoded != nil {
+		return msg.Decoded
+	}
+	return msg.Data
+}
+
+// Len returns the number of enabled rules
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Match reports whether a message is shown and whether it is highlighted.
+// A nil or empty set shows every message.
+func (s *Set) Match(msg messaging.Message) (visible, highlighted bool) {
+	if s == nil {
+		return true, false
+	}
+
+	visible = true
+	for _, rule := range s.rules {
+		switch rule.action {
+		case ActionShow:
+			if visible && !rule.match(&msg) {
+				visible = false
+			}
+		case ActionHide:
+			if visible && rule.match(&msg) {
+				visible = false
+			}
+		case ActionHighlight:
+			if !highlighted && rule.match(&msg) {
+				highlighted = true
+			}
+		}
+	}
+	return visible, highlighted
+}
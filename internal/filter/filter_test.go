@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+func TestSetMatch(t *testing.T) {
+	set, err := Compile([]Rule{
+		{Kind: KindSubject, Expression: "orders.>", Action: ActionShow, Enabled: true},
+		{Kind: KindHeader, Expression: "X-Env = test", Action: ActionHide, Enabled: true},
+		{Kind: KindJSONPath, Expression: "$.level == 'error'", Action: ActionHighlight, Enabled: true},
+		{Kind: KindContains, Expression: "never", Action: ActionShow, Enabled: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Len() != 3 {
+		t.Fatalf("Len = %d, want the 3 enabled rules", set.Len())
+	}
+
+	tests := []struct {
+		name      string
+		msg       messaging.Message
+		visible   bool
+		highlight bool
+	}{
+		{"shown", messaging.Message{Subject: "orders.eu.created", Data: []byte(`{"level":"info"}`)}, true, false},
+		{"highlighted", messaging.Message{Subject: "orders.eu", Data: []byte(`{"level":"error"}`)}, true, true},
+		{"other subject", messaging.Message{Subject: "payments.eu", Data: []byte(`{}`)}, false, false},
+		{"hidden header", messaging.Message{Subject: "orders.eu", Headers: map[string]string{"x-env": "test"}}, false, false},
+		{"decoded payload", messaging.Message{Subject: "orders.eu", Data: []byte{0x0a}, Decoded: []byte(`{"level":"error"}`)}, true, true},
+	}
+	for _, tt := range tests {
+		visible, highlight := set.Match(tt.msg)
+		if visible != tt.visible || highlight != tt.highlight {
+			t.Errorf("%s: Match = (%v, %v), want (%v, %v)", tt.name, visible, highlight, tt.visible, tt.highlight)
+		}
+	}
+}
+
+func TestSubjectGlob(t *testing.T) {
+	set, err := Compile([]Rule{{Kind: KindSubject, Expression: "orders.*.created", Action: ActionShow, Enabled: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for subject, want := range map[string]bool{
+		"orders.eu.created": true,
+		"orders.eu.updated": false,
+		"orders.created":    false,
+	} {
+		if visible, _ := set.Match(messaging.Message{Subject: subject}); visible != want {
+			t.Errorf("Match(%s) = %v, want %v", subject, visible, want)
+		}
+	}
+}
+
+func TestNilSetShowsEverything(t *testing.T) {
+	var set *Set
+	if visible, highlight := set.Match(messaging.Message{Subject: "a"}); !visible || highlight {
+		t.Errorf("nil set Match = (%v, %v), want (true, false)", visible, highlight)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, rule := range []Rule{
+		{Kind: KindRegex, Expression: "(", Action: ActionShow, Enabled: true},
+		{Kind: KindJSONPath, Expression: "level", Action: ActionShow, Enabled: true},
+		{Kind: KindHeader, Expression: "no-equals", Action: ActionShow, Enabled: true},
+		{Kind: KindSubject, Expression: "[", Action: ActionShow, Enabled: true},
+		{Kind: KindContains, Expression: "", Action: ActionShow, Enabled: true},
+		{Kind: KindContains, Expression: "x", Action: "drop", Enabled: true},
+	} {
+		if _, err := Compile([]Rule{rule}); err == nil {
+			t.Errorf("Compile(%v) succeeded, want an error", rule)
+		}
+	}
+}
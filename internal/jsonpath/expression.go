@@ -0,0 +1,218 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operators supported by expressions
+const (
+	OpExists   = ""
+	OpEqual    = "=="
+	OpNotEqual = "!="
+	OpLess     = "<"
+	OpLessEq   = "<="
+	OpGreater  = ">"
+	OpGreatEq  = ">="
+	OpMatches  = "=~"
+)
+
+// operators is ordered so that two-character operators are tried first
+var operators = []string{OpEqual, OpNotEqual, OpLessEq, OpGreatEq, OpMatches, OpLess, OpGreater}
+
+// Expression compares the values selected by a path with a literal, e.g.
+// "$.level == 'error'", "$.latency > 250" or "$.user.email =~ '@example'".
+// A bare path checks that it selects something.
+type Expression struct {
+	Path    Path
+	Op      string
+	Literal any
+	regex   *regexp.Regexp
+}
+
+// Compile parses an expression
+func Compile(expr string) (*Expression, error) {
+	s := strings.TrimSpace(expr)
+	pathEnd := scanPath(s)
+	path, err := Parse(s[:pathEnd])
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Expression{Path: path}
+	rest := strings.TrimSpace(s[pathEnd:])
+	if rest == "" {
+		return e, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			e.Op = op
+			break
+		}
+	}
+	if e.Op == "" {
+		return nil, fmt.Errorf("expression %q: expected an operator after the path", expr)
+	}
+
+	literal := strings.TrimSpace(rest[len(e.Op):])
+	if e.Literal, err = parseLiteral(literal); err != nil {
+		return nil, fmt.Errorf("expression %q: %w", expr, err)
+	}
+
+	if e.Op == OpMatches {
+		pattern, ok := e.Literal.(string)
+		if !ok {
+			return nil, fmt.Errorf("expression %q: =~ needs a quoted regular expression", expr)
+		}
+		if e.regex, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("expression %q: %w", expr, err)
+		}
+	}
+	return e, nil
+}
+
+// scanPath returns the length of the path at the start of s, stopping at
+// whitespace or an operator outside brackets
+func scanPath(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && strings.IndexByte(" \t=!<>", c) >= 0:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// parseLiteral parses a quoted string, number, boolean or null
+func parseLiteral(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value after the operator")
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return s[1 : len(s)-1], nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case s == "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q, quote strings", s)
+	}
+	return n, nil
+}
+
+// Match reports whether any value selected from doc satisfies the expression
+func (e *Expression) Match(doc any) bool {
+	for _, value := range e.Path.Select(doc) {
+		if e.compare(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchJSON decodes data and matches it; data that isn't JSON is an error
+func (e *Expression) MatchJSON(data []byte) (bool, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("payload is not JSON: %w", err)
+	}
+	return e.Match(doc), nil
+}
+
+// String returns the expression in its canonical form
+func (e *Expression) String() string {
+	if e.Op == OpExists {
+		return e.Path.String()
+	}
+	literal, _ := json.Marshal(e.Literal)
+	return fmt.Sprintf("%s %s %s", e.Path, e.Op, literal)
+}
+
+// compare applies the operator to one selected value
+func (e *Expression) compare(value any) bool {
+	switch e.Op {
+	case OpExists:
+		return true
+	case OpEqual:
+		return equal(value, e.Literal)
+	case OpNotEqual:
+		return !equal(value, e.Literal)
+	case OpMatches:
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		return e.regex.MatchString(s)
+	}
+
+	// Ordering compares numbers with numbers and strings with strings
+	cmp, ok := order(value, e.Literal)
+	if !ok {
+		return false
+	}
+	switch e.Op {
+	case OpLess:
+		return cmp < 0
+	case OpLessEq:
+		return cmp <= 0
+	case OpGreater:
+		return cmp > 0
+	case OpGreatEq:
+		return cmp >= 0
+	}
+	return false
+}
+
+// equal compares a JSON value with a literal; numbers compare numerically
+func equal(value, literal any) bool {
+	if cmp, ok := order(value, literal); ok {
+		return cmp == 0
+	}
+	return value == literal
+}
+
+// order compares two numbers or two strings
+func order(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
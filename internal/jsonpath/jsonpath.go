@@ -0,0 +1,171 @@
+// Package jsonpath selects values from decoded JSON documents with a small
+// subset of JSONPath: $, .name, ['name'], [index], [*], .* and ..name.
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is one step of a path
+type segment struct {
+	name      string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+// Path is a compiled JSONPath
+type Path struct {
+	source   string
+	segments []segment
+}
+
+// Parse compiles a path such as "$.items[0].name" or "$..id"
+func Parse(path string) (Path, error) {
+	p := Path{source: path}
+	s := strings.TrimSpace(path)
+	if !strings.HasPrefix(s, "$") {
+		return p, fmt.Errorf("jsonpath %q must start with $", path)
+	}
+	s = s[1:]
+
+	for s != "" {
+		recursive := false
+		switch {
+		case strings.HasPrefix(s, ".."):
+			recursive = true
+			s = s[2:]
+		case s[0] == '.':
+			s = s[1:]
+		case s[0] == '[':
+		default:
+			return p, fmt.Errorf("jsonpath %q: unexpected %q", path, s[0])
+		}
+
+		var seg segment
+		var err error
+		if strings.HasPrefix(s, "[") {
+			seg, s, err = parseBracket(s)
+			if err != nil {
+				return p, fmt.Errorf("jsonpath %q: %w", path, err)
+			}
+		} else {
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			if name == "" {
+				return p, fmt.Errorf("jsonpath %q: empty member name", path)
+			}
+			if name == "*" {
+				seg.wildcard = true
+			} else {
+				seg.name = name
+			}
+		}
+		seg.recursive = recursive
+		p.segments = append(p.segments, seg)
+	}
+
+	return p, nil
+}
+
+// parseBracket parses a leading [..] segment and returns the rest of s
+func parseBracket(s string) (segment, string, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return segment{}, s, fmt.Errorf("unterminated [")
+	}
+	inner := strings.TrimSpace(s[1:end])
+	rest := s[end+1:]
+
+	switch {
+	case inner == "*":
+		return segment{wildcard: true}, rest, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return segment{name: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, s, fmt.Errorf("invalid index %q", inner)
+	}
+	return segment{index: index, isIndex: true}, rest, nil
+}
+
+// String returns the path as written
+func (p Path) String() string {
+	return p.source
+}
+
+// Select returns the values of doc matched by the path. doc is a value
+// produced by encoding/json, i.e. made of maps, slices and scalars.
+func (p Path) Select(doc any) []any {
+	current := []any{doc}
+	for _, seg := range p.segments {
+		var next []any
+		for _, value := range current {
+			if seg.recursive {
+				for _, descendant := range descendants(value) {
+					next = append(next, seg.apply(descendant)...)
+				}
+			} else {
+				next = append(next, seg.apply(value)...)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// apply selects the children of value matched by the segment
+func (seg segment) apply(value any) []any {
+	switch v := value.(type) {
+	case map[string]any:
+		if seg.wildcard {
+			values := make([]any, 0, len(v))
+			for _, child := range v {
+				values = append(values, child)
+			}
+			return values
+		}
+		if child, ok := v[seg.name]; ok && !seg.isIndex {
+			return []any{child}
+		}
+	case []any:
+		if seg.wildcard {
+			return v
+		}
+		if seg.isIndex {
+			i := seg.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []any{v[i]}
+			}
+		}
+	}
+	return nil
+}
+
+// descendants returns value and all values nested in it
+func descendants(value any) []any {
+	values := []any{value}
+	switch v := value.(type) {
+	case map[string]any:
+		for _, child := range v {
+			values = append(values, descendants(child)...)
+		}
+	case []any:
+		for _, child := range v {
+			values = append(values, descendants(child)...)
+		}
+	}
+	return values
+}
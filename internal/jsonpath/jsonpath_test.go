@@ -0,0 +1,107 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const document = `{
+	"level": "error",
+	"latency": 320,
+	"user": {"email": "ada@example.com", "roles": ["admin", "dev"]},
+	"items": [{"id": 1, "name": "a b"}, {"id": 2}],
+	"ok": false
+}`
+
+func decode(t *testing.T) any {
+	t.Helper()
+	var doc any
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestSelect(t *testing.T) {
+	doc := decode(t)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"$", 1},
+		{"$.level", 1},
+		{"$.user.roles[1]", 1},
+		{"$.user.roles[-1]", 1},
+		{"$.user.roles[*]", 2},
+		{"$['user']['email']", 1},
+		{"$.items[*].id", 2},
+		{"$..id", 2},
+		{"$.missing", 0},
+		{"$.items[5]", 0},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.path)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.path, err)
+		}
+		if got := p.Select(doc); len(got) != tt.want {
+			t.Errorf("Select(%q) = %v, want %d values", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, path := range []string{"level", "$.", "$[", "$[x]", "$!"} {
+		if _, err := Parse(path); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestExpressionMatch(t *testing.T) {
+	doc := decode(t)
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"$.level == 'error'", true},
+		{`$.level=="warn"`, false},
+		{"$.level != 'warn'", true},
+		{"$.latency > 250", true},
+		{"$.latency <= 250", false},
+		{"$.ok == false", true},
+		{"$.user.email =~ '@example\\.com$'", true},
+		{"$.user.roles[*] == 'dev'", true},
+		{"$['items'][0]['name'] == 'a b'", true},
+		{"$.items[1].name", false},
+		{"$.user", true},
+		{"$.level > 1", false},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.expr, err)
+		}
+		if got := e.Match(doc); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{"$.level = 'x'", "$.level == error", "$.a =~ 3", "$.a =~ '('", "$.a =="} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestMatchJSONRejectsInvalidPayload(t *testing.T) {
+	e, err := Compile("$.level")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.MatchJSON([]byte("not json")); err == nil {
+		t.Error("MatchJSON accepted a non-JSON payload")
+	}
+}
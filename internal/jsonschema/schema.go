@@ -0,0 +1,220 @@
+// Package jsonschema validates JSON documents against JSON Schemas. It
+// supports the validation keywords shared by draft-07 and 2020-12, with
+// local "$ref" pointers such as "#/definitions/x" or "#/$defs/x".
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds schema recursion so that cyclic "$ref"s cannot loop forever
+const maxDepth = 256
+
+// Schema is a compiled JSON Schema. It is safe for concurrent use.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError describes one violation. Pointer is the JSON pointer of the
+// offending value in "#/a/0/b" form, "#" being the document root.
+type ValidationError struct {
+	Pointer string
+	Message string
+}
+
+// Error returns the violation as "pointer: message"
+func (e ValidationError) Error() string {
+	return e.Pointer + ": " + e.Message
+}
+
+// Compile parses a JSON Schema, checking its patterns and "$ref"s
+func Compile(data []byte) (*Schema, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, fmt.Errorf("invalid JSON schema: must be an object or a boolean")
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.prepare(root, "#", map[string]bool{"#": true}); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return s, nil
+}
+
+// Validate checks a JSON document against the schema and returns all
+// violations found. It fails only when the document is not valid JSON.
+func (s *Schema) Validate(document []byte) ([]ValidationError, error) {
+	instance, err := decode(document)
+	if err != nil {
+		return nil, fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	v := &validator{schema: s}
+	v.validate(s.root, instance, "#", 0)
+	return v.errors, nil
+}
+
+// decode parses JSON keeping numbers as json.Number and rejecting trailing data
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// Keywords whose values are subschemas, by shape. Only these are walked:
+// the values of other keywords, such as enum or const, are data, and the
+// keys of the maps are property names, not keywords.
+var (
+	schemaKeywords     = []string{"additionalItems", "additionalProperties", "contains", "else", "if", "items", "not", "propertyNames", "then"}
+	schemaListKeywords = []string{"allOf", "anyOf", "items", "oneOf", "prefixItems"}
+	schemaMapKeywords  = []string{"$defs", "definitions", "dependencies", "dependentSchemas", "patternProperties", "properties"}
+)
+
+// prepare walks a subschema keyword by keyword, compiling regular
+// expressions and checking that every "$ref" resolves. The targets of
+// "$ref"s are prepared too, wherever they are; seen stops cycles.
+func (s *Schema) prepare(node interface{}, location string, seen map[string]bool) error {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		// Boolean schemas have nothing to prepare
+		return nil
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if err := s.compilePattern(pattern); err != nil {
+			return fmt.Errorf("%s/pattern: %w", location, err)
+		}
+	}
+
+	if value, ok := schema["$ref"]; ok {
+		ref, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s/$ref: must be a string", location)
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s/$ref: %w", location, err)
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			if err := s.prepare(target, ref, seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range schemaKeywords {
+		if child, ok := schema[keyword]; ok {
+			if err := s.prepare(child, location+"/"+keyword, seen); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaListKeywords {
+		children, _ := schema[keyword].([]interface{})
+		for i, child := range children {
+			if err := s.prepare(child, location+"/"+keyword+"/"+strconv.Itoa(i), seen); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaMapKeywords {
+		children, _ := schema[keyword].(map[string]interface{})
+		for _, key := range sortedKeys(children) {
+			childLocation := location + "/" + keyword + "/" + escapePointer(key)
+			if keyword == "patternProperties" {
+				if err := s.compilePattern(key); err != nil {
+					return fmt.Errorf("%s: %w", childLocation, err)
+				}
+			}
+			if err := s.prepare(children[key], childLocation, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compilePattern compiles and caches an ECMA-262 style pattern
+func (s *Schema) compilePattern(pattern string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve returns the subschema a local "$ref" points to
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref pointers are supported, got %q", ref)
+	}
+
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	if fragment == "" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("unsupported $ref %q: anchors are not supported", ref)
+	}
+
+	node := s.root
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = unescapePointer(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return node, nil
+}
+
+// escapePointer escapes a JSON pointer reference token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// unescapePointer reverses escapePointer
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,147 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		// want lists the pointers of the expected violations, in order
+		want []string
+	}{
+		{"type matches", `{"type":"string"}`, `"a"`, nil},
+		{"type mismatch", `{"type":"string"}`, `1`, []string{"#"}},
+		{"integer is a number", `{"type":"number"}`, `3`, nil},
+		{"number is not an integer", `{"type":"integer"}`, `3.5`, []string{"#"}},
+		{"1.0 is an integer", `{"type":"integer"}`, `1.0`, nil},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"false schema", `false`, `{}`, []string{"#"}},
+		{"true schema", `true`, `[1,"a"]`, nil},
+		{"enum", `{"enum":["a",1]}`, `1.0`, nil},
+		{"enum mismatch", `{"enum":["a",1]}`, `"b"`, []string{"#"}},
+		{"const object", `{"const":{"a":[1]}}`, `{"a":[1]}`, nil},
+		{"minimum and multipleOf", `{"minimum":2,"multipleOf":0.5}`, `1.25`, []string{"#", "#"}},
+		{"exclusiveMaximum", `{"exclusiveMaximum":10}`, `10`, []string{"#"}},
+		{"string length counts runes", `{"maxLength":2}`, `"éé"`, nil},
+		{"pattern", `{"pattern":"^[a-z]+$"}`, `"abc1"`, []string{"#"}},
+		{
+			"nested pointers",
+			`{"properties":{"items":{"type":"array","items":{"required":["id"],"properties":{"id":{"type":"integer"}}}}}}`,
+			`{"items":[{"id":1},{"id":"2"},{}]}`,
+			[]string{"#/items/1/id", "#/items/2"},
+		},
+		{"escaped pointer", `{"additionalProperties":false}`, `{"a/b~c":1}`, []string{"#/a~1b~0c"}},
+		{"patternProperties", `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"x-a":"1","x-b":2,"y":3}`, []string{"#/x-b", "#/y"}},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,2,1.0]`, []string{"#"}},
+		{"tuple items", `{"prefixItems":[{"type":"string"}],"items":{"type":"integer"}}`, `["a",1,"b"]`, []string{"#/2"}},
+		{"contains", `{"contains":{"const":3},"maxContains":1}`, `[3,3]`, []string{"#"}},
+		{"dependentRequired", `{"dependentRequired":{"card":["billing"]}}`, `{"card":1}`, []string{"#"}},
+		{"ref to definitions", `{"definitions":{"id":{"type":"integer"}},"properties":{"id":{"$ref":"#/definitions/id"}}}`, `{"id":"x"}`, []string{"#/id"}},
+		{"ref to $defs", `{"$defs":{"name":{"minLength":2}},"items":{"$ref":"#/$defs/name"}}`, `["ab","a"]`, []string{"#/1"}},
+		{
+			"recursive ref",
+			`{"properties":{"value":{"type":"integer"},"next":{"$ref":"#"}}}`,
+			`{"value":1,"next":{"value":2,"next":{"value":"3"}}}`,
+			[]string{"#/next/next/value"},
+		},
+		{"allOf", `{"allOf":[{"type":"integer"},{"minimum":5}]}`, `3`, []string{"#"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"minimum":5}]}`, `7`, nil},
+		{"anyOf mismatch", `{"anyOf":[{"type":"string"},{"minimum":5}]}`, `3`, []string{"#"}},
+		{"oneOf", `{"oneOf":[{"type":"integer"},{"minimum":5}]}`, `3`, nil},
+		{"oneOf matches both", `{"oneOf":[{"type":"integer"},{"minimum":5}]}`, `7`, []string{"#"}},
+		{"not", `{"not":{"type":"null"}}`, `null`, []string{"#"}},
+		{"if then else", `{"if":{"properties":{"kind":{"const":"card"}}},"then":{"required":["last4"]},"else":{"required":["iban"]}}`, `{"kind":"card"}`, []string{"#"}},
+		{"format date-time", `{"format":"date-time"}`, `"2024-05-01T10:00:00Z"`, nil},
+		{"format date-time invalid", `{"format":"date-time"}`, `"2024-05-01 10:00"`, []string{"#"}},
+		{"format date", `{"format":"date"}`, `"2024-02-30"`, []string{"#"}},
+		{"format email", `{"format":"email"}`, `"Ann <ann@example.com>"`, []string{"#"}},
+		{"format uuid", `{"format":"uuid"}`, `"123e4567-e89b-12d3-a456-426614174000"`, nil},
+		{"format ipv4", `{"format":"ipv4"}`, `"::1"`, []string{"#"}},
+		{"format ipv6", `{"format":"ipv6"}`, `"::1"`, nil},
+		{"format hostname", `{"format":"hostname"}`, `"-bad.example"`, []string{"#"}},
+		{"unknown format", `{"format":"color"}`, `"red"`, nil},
+		{"format ignores other types", `{"format":"email"}`, `1`, nil},
+
+		// Keyword names used as property names are properties, not keywords
+		{"property named enum", `{"properties":{"enum":{"pattern":"^[A-Z]+$"}}}`, `{"enum":"abc"}`, []string{"#/enum"}},
+		{"property named const", `{"properties":{"const":{"type":"string"}}}`, `{"const":1}`, []string{"#/const"}},
+		{"property named $ref", `{"properties":{"$ref":{"type":"string"}}}`, `{"$ref":1}`, []string{"#/$ref"}},
+		{"pattern under a ref target", `{"x-shared":{"pattern":"^a"},"properties":{"name":{"$ref":"#/x-shared"}}}`, `{"name":"b"}`, []string{"#/name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			violations, err := schema.Validate([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			var pointers []string
+			for _, violation := range violations {
+				pointers = append(pointers, violation.Pointer)
+			}
+			if !reflect.DeepEqual(pointers, tt.want) {
+				t.Errorf("violations = %v, want pointers %v", violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		// want is part of the expected error
+		want string
+	}{
+		{"not JSON", `{"type":`, "invalid JSON schema"},
+		{"not a schema", `"string"`, "must be an object or a boolean"},
+		{"invalid pattern", `{"properties":{"a":{"pattern":"("}}}`, "#/properties/a/pattern"},
+		{"invalid pattern property", `{"patternProperties":{"[":{}}}`, "#/patternProperties/["},
+		{"pattern in allOf", `{"allOf":[{},{"pattern":"("}]}`, "#/allOf/1/pattern"},
+		{"unresolved ref", `{"items":{"$ref":"#/definitions/missing"}}`, "does not resolve"},
+		{"remote ref", `{"$ref":"https://example.com/schema.json"}`, "only local $ref"},
+		{"ref is not a string", `{"$ref":1}`, "#/$ref: must be a string"},
+		{"invalid pattern in ref target", `{"x":{"pattern":"("},"$ref":"#/x"}`, "#/x/pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileIgnoresData(t *testing.T) {
+	// Patterns and refs inside literal values are data, not schemas
+	for _, schema := range []string{
+		`{"enum":[{"pattern":"("}]}`,
+		`{"const":{"$ref":"#/missing"}}`,
+		`{"default":{"$ref":1},"examples":[{"pattern":"["}]}`,
+	} {
+		if _, err := Compile([]byte(schema)); err != nil {
+			t.Errorf("Compile(%s): %v", schema, err)
+		}
+	}
+}
+
+func TestValidateRejectsInvalidJSON(t *testing.T) {
+	schema, err := Compile([]byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, document := range []string{``, `{"a":`, `{} {}`} {
+		if _, err := schema.Validate([]byte(document)); err == nil {
+			t.Errorf("Validate(%q) succeeded", document)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// validator collects the violations of one document
+type validator struct {
+	schema *Schema
+	errors []ValidationError
+}
+
+// fail records a violation at pointer
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether instance matches schema without recording violations
+func (v *validator) valid(schema, instance interface{}, pointer string, depth int) bool {
+	probe := &validator{schema: v.schema}
+	probe.validate(schema, instance, pointer, depth)
+	return len(probe.errors) == 0
+}
+
+// validate checks instance against a (sub)schema
+func (v *validator) validate(schema, instance interface{}, pointer string, depth int) {
+	if depth > maxDepth {
+		v.fail(pointer, "schema nesting is too deep (cyclic $ref?)")
+		return
+	}
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(pointer, "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObjectSchema(s, instance, pointer, depth)
+	}
+}
+
+func (v *validator) validateObjectSchema(s map[string]interface{}, instance interface{}, pointer string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		// Compile already checked that the reference resolves
+		if target, err := v.schema.resolve(ref); err == nil {
+			v.validate(target, instance, pointer, depth+1)
+		}
+	}
+
+	if types, ok := s["type"]; ok {
+		v.checkType(types, instance, pointer)
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		matched := false
+		for _, candidate := range enum {
+			if equal(candidate, instance) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "must be one of %s", compact(enum))
+		}
+	}
+	if constant, ok := s["const"]; ok && !equal(constant, instance) {
+		v.fail(pointer, "must be %s", compact(constant))
+	}
+
+	switch value := instance.(type) {
+	case json.Number:
+		v.checkNumber(s, value, pointer)
+	case string:
+		v.checkString(s, value, pointer)
+	case []interface{}:
+		v.checkArray(s, value, pointer, depth)
+	case map[string]interface{}:
+		v.checkObject(s, value, pointer, depth)
+	}
+
+	v.checkCombinators(s, instance, pointer, depth)
+}
+
+// checkType handles "type" given as a string or a list of strings
+func (v *validator) checkType(types, instance interface{}, pointer string) {
+	var allowed []string
+	switch t := types.(type) {
+	case string:
+		allowed = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				allowed = append(allowed, name)
+			}
+		}
+	}
+
+	actual := typeOf(instance)
+	for _, name := range allowed {
+		if name == actual || (name == "number" && actual == "integer") {
+			return
+		}
+	}
+	v.fail(pointer, "expected %s, got %s", strings.Join(allowed, " or "), actual)
+}
+
+func (v *validator) checkNumber(s map[string]interface{}, value json.Number, pointer string) {
+	n, ok := toRat(value)
+	if !ok {
+		return
+	}
+
+	if limit, ok := toRat(s["minimum"]); ok && n.Cmp(limit) < 0 {
+		v.fail(pointer, "must be >= %s", s["minimum"])
+	}
+	if limit, ok := toRat(s["maximum"]); ok && n.Cmp(limit) > 0 {
+		v.fail(pointer, "must be <= %s", s["maximum"])
+	}
+
+	// Draft-04 used booleans that turn minimum/maximum exclusive
+	if limit, ok := toRat(s["exclusiveMinimum"]); ok && n.Cmp(limit) <= 0 {
+		v.fail(pointer, "must be > %s", s["exclusiveMinimum"])
+	} else if exclusive, _ := s["exclusiveMinimum"].(bool); exclusive {
+		if limit, ok := toRat(s["minimum"]); ok && n.Cmp(limit) == 0 {
+			v.fail(pointer, "must be > %s", s["minimum"])
+		}
+	}
+	if limit, ok := toRat(s["exclusiveMaximum"]); ok && n.Cmp(limit) >= 0 {
+		v.fail(pointer, "must be < %s", s["exclusiveMaximum"])
+	} else if exclusive, _ := s["exclusiveMaximum"].(bool); exclusive {
+		if limit, ok := toRat(s["maximum"]); ok && n.Cmp(limit) == 0 {
+			v.fail(pointer, "must be < %s", s["maximum"])
+		}
+	}
+
+	if divisor, ok := toRat(s["multipleOf"]); ok && divisor.Sign() > 0 {
+		if !new(big.Rat).Quo(n, divisor).IsInt() {
+			v.fail(pointer, "must be a multiple of %s", s["multipleOf"])
+		}
+	}
+}
+
+func (v *validator) checkString(s map[string]interface{}, value, pointer string) {
+	length := utf8.RuneCountInString(value)
+	if limit, ok := toInt(s["minLength"]); ok && length < limit {
+		v.fail(pointer, "must be at least %d characters long", limit)
+	}
+	if limit, ok := toInt(s["maxLength"]); ok && length > limit {
+		v.fail(pointer, "must be at most %d characters long", limit)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		if re := v.schema.patterns[pattern]; re != nil && !re.MatchString(value) {
+			v.fail(pointer, "does not match pattern %q", pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok && !validFormat(format, value) {
+		v.fail(pointer, "is not a valid %s", format)
+	}
+}
+
+func (v *validator) checkArray(s map[string]interface{}, items []interface{}, pointer string, depth int) {
+	if limit, ok := toInt(s["minItems"]); ok && len(items) < limit {
+		v.fail(pointer, "must have at least %d items", limit)
+	}
+	if limit, ok := toInt(s["maxItems"]); ok && len(items) > limit {
+		v.fail(pointer, "must have at most %d items", limit)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equal(items[i], items[j]) {
+					v.fail(pointer, "items %d and %d are equal, items must be unique", j, i)
+				}
+			}
+		}
+	}
+
+	// Tuple validation: "prefixItems" (2020-12) or "items" as a list (draft-07),
+	// with "items" or "additionalItems" applying to the remaining items
+	prefix, _ := s["prefixItems"].([]interface{})
+	rest, hasRest := s["items"]
+	if tuple, ok := s["items"].([]interface{}); ok {
+		prefix = tuple
+		rest, hasRest = s["additionalItems"]
+	}
+
+	for i, item := range items {
+		itemPointer := pointer + "/" + strconv.Itoa(i)
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPointer, depth+1)
+		} else if hasRest {
+			v.validate(rest, item, itemPointer, depth+1)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		matches := 0
+		for i, item := range items {
+			if v.valid(contains, item, pointer+"/"+strconv.Itoa(i), depth+1) {
+				matches++
+			}
+		}
+
+		minContains := 1
+		if limit, ok := toInt(s["minContains"]); ok {
+			minContains = limit
+		}
+		if matches < minContains {
+			v.fail(pointer, "must contain at least %d matching items, found %d", minContains, matches)
+		}
+		if limit, ok := toInt(s["maxContains"]); ok && matches > limit {
+			v.fail(pointer, "must contain at most %d matching items, found %d", limit, matches)
+		}
+	}
+}
+
+func (v *validator) checkObject(s map[string]interface{}, object map[string]interface{}, pointer string, depth int) {
+	if limit, ok := toInt(s["minProperties"]); ok && len(object) < limit {
+		v.fail(pointer, "must have at least %d properties", limit)
+	}
+	if limit, ok := toInt(s["maxProperties"]); ok && len(object) > limit {
+		v.fail(pointer, "must have at most %d properties", limit)
+	}
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := object[key]; !present {
+					v.fail(pointer, "missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patternProperties, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+
+	for _, key := range sortedKeys(object) {
+		value := object[key]
+		propertyPointer := pointer + "/" + escapePointer(key)
+
+		if hasPropertyNames && !v.valid(propertyNames, key, propertyPointer, depth+1) {
+			v.fail(propertyPointer, "property name %q is not allowed", key)
+		}
+
+		matched := false
+		if schema, ok := properties[key]; ok {
+			matched = true
+			v.validate(schema, value, propertyPointer, depth+1)
+		}
+		for pattern, schema := range patternProperties {
+			if re := v.schema.patterns[pattern]; re != nil && re.MatchString(key) {
+				matched = true
+				v.validate(schema, value, propertyPointer, depth+1)
+			}
+		}
+
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(propertyPointer, "additional property %q is not allowed", key)
+			} else {
+				v.validate(additional, value, propertyPointer, depth+1)
+			}
+		}
+	}
+
+	// "dependencies" (draft-07) was split into dependentRequired and dependentSchemas
+	dependencies := map[string]interface{}{}
+	for _, keyword := range []string{"dependencies", "dependentRequired", "dependentSchemas"} {
+		if deps, ok := s[keyword].(map[string]interface{}); ok {
+			for key, dep := range deps {
+				dependencies[key] = dep
+			}
+		}
+	}
+	for _, key := range sortedKeys(dependencies) {
+		if _, present := object[key]; !present {
+			continue
+		}
+		switch dep := dependencies[key].(type) {
+		case []interface{}:
+			for _, name := range dep {
+				if required, ok := name.(string); ok {
+					if _, present := object[required]; !present {
+						v.fail(pointer, "property %q is required when %q is present", required, key)
+					}
+				}
+			}
+		default:
+			v.validate(dep, object, pointer, depth+1)
+		}
+	}
+}
+
+func (v *validator) checkCombinators(s map[string]interface{}, instance interface{}, pointer string, depth int) {
+	if schemas, ok := s["allOf"].([]interface{}); ok {
+		for _, schema := range schemas {
+			v.validate(schema, instance, pointer, depth+1)
+		}
+	}
+
+	if schemas, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, schema := range schemas {
+			if v.valid(schema, instance, pointer, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "does not match any of the anyOf schemas")
+		}
+	}
+
+	if schemas, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, schema := range schemas {
+			if v.valid(schema, instance, pointer, depth+1) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(pointer, "matches %d of the oneOf schemas, expected exactly 1", matches)
+		}
+	}
+
+	if schema, ok := s["not"]; ok && v.valid(schema, instance, pointer, depth+1) {
+		v.fail(pointer, "must not match the \"not\" schema")
+	}
+
+	if condition, ok := s["if"]; ok {
+		if v.valid(condition, instance, pointer, depth+1) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, instance, pointer, depth+1)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			v.validate(otherwise, instance, pointer, depth+1)
+		}
+	}
+}
+
+// typeOf returns the JSON Schema type name of a decoded value
+func typeOf(value interface{}) string {
+	switch n := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if r, ok := toRat(n); ok && r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toRat converts a JSON number to an exact rational
+func toRat(value interface{}) (*big.Rat, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(n.String())
+}
+
+// toInt converts a non-negative integral JSON number to int
+func toInt(value interface{}) (int, bool) {
+	r, ok := toRat(value)
+	if !ok || !r.IsInt() || r.Sign() < 0 || !r.Num().IsInt64() {
+		return 0, false
+	}
+	return int(r.Num().Int64()), true
+}
+
+// equal compares decoded JSON values, treating 1 and 1.0 as equal
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ra, okA := toRat(x)
+		rb, okB := toRat(y)
+		return okA && okB && ra.Cmp(rb) == 0
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// compact renders a schema value for error messages
+func compact(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// sortedKeys returns map keys in order so that errors are reported deterministically
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+)
+
+// validFormat checks the common "format" values; unknown formats are accepted
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", value)
+		if err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", value)
+		}
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "hostname":
+		return len(value) <= 253 && hostnamePattern.MatchString(value)
+	}
+	return true
+}
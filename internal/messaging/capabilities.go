@@ -0,0 +1,169 @@
+package messaging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WildcardSyntax is how a provider matches subscription patterns to subjects
+type WildcardSyntax string
+
+const (
+	// WildcardsNone means patterns are exact names, such as queues or topics
+	WildcardsNone WildcardSyntax = "none"
+	// WildcardsNATS means "*" matches one dot-separated token and a trailing
+	// ">" matches the rest, as in MatchSubject
+	WildcardsNATS WildcardSyntax = "nats"
+)
+
+// NameSyntax is the rule subjects and patterns of a provider follow
+type NameSyntax string
+
+const (
+	// NamesAny accepts any name up to 255 bytes, such as RabbitMQ queues
+	NamesAny NameSyntax = "any"
+	// NamesTokens accepts dot-separated tokens without whitespace, such as
+	// NATS subjects
+	NamesTokens NameSyntax = "tokens"
+	// NamesResourceID accepts Google Cloud resource IDs: a letter followed by
+	// letters, digits and - _ . ~ + %, 3 to 255 characters long
+	NamesResourceID NameSyntax = "resource-id"
+)
+
+// AckMode is how received messages are acknowledged
+type AckMode string
+
+const (
+	// AckNone means messages are delivered at most once, without acknowledgement
+	AckNone AckMode = "none"
+	// AckOnDelivery means the broker considers a message acknowledged once it
+	// is delivered to the app
+	AckOnDelivery AckMode = "on delivery"
+	// AckAfterHandler means a message is acknowledged after its handler
+	// returns, and redelivered if the app stops before
+	AckAfterHandler AckMode = "after handler"
+)
+
+// Capabilities describes what a provider supports, so that dialogs and
+// services can adapt to it
+type Capabilities struct {
+	// Wildcards is the pattern syntax of Subscribe
+	Wildcards WildcardSyntax
+	// Names is the syntax of subjects and patterns
+	Names NameSyntax
+	// SubjectKind is what a subject names, such as "subject", "queue" or "topic"
+	SubjectKind string
+	// Headers is true when messages carry headers, attributes or properties
+	Headers bool
+	// Request is true when the provider can send a request and wait for its reply
+	Request bool
+	// Reply is true when the provider can answer a received request
+	Reply bool
+	// Ack is how received messages are acknowledged
+	Ack AckMode
+	// Replay is true when messages published while the app is not
+	// subscribed are kept and delivered once it subscribes again
+	Replay bool
+	// Ordered is true when the messages of one publisher arrive in order
+	Ordered bool
+	// Admin is true when the broker has an admin or monitoring API that
+	// describes its subjects, topics or queues, listed through Explorer
+	Admin bool
+}
+
+// CapabilityReporter is implemented by providers that declare their capabilities
+type CapabilityReporter interface {
+	// Capabilities returns what the provider supports
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the capabilities a provider declares. For providers
+// that declare none, they are derived from the optional interfaces it implements.
+func CapabilitiesOf(provider MessagingProvider) Capabilities {
+	if reporter, ok := provider.(CapabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+
+	_, headers := provider.(HeaderPublisher)
+	_, request := provider.(Requester)
+	_, reply := provider.(Replier)
+	return Capabilities{
+		Wildcards:   WildcardsNone,
+		Names:       NamesAny,
+		SubjectKind: "subject",
+		Headers:     headers,
+		Request:     request,
+		Reply:       reply,
+		Ack:         AckNone,
+	}
+}
+
+// resourceID matches Google Cloud resource IDs
+var resourceID = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._~+%-]{2,254}$`)
+
+// ValidateSubject checks a subject to publish to
+func (c Capabilities) ValidateSubject(subject string) error {
+	if err := c.validateName(subject); err != nil {
+		return err
+	}
+	if c.Wildcards == WildcardsNATS {
+		for _, token := range strings.Split(subject, ".") {
+			if token == "*" || token == ">" {
+				return fmt.Errorf("can't publish to wildcard %s %q", c.SubjectKind, subject)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePattern checks a subscription pattern
+func (c Capabilities) ValidatePattern(pattern string) error {
+	if err := c.validateName(pattern); err != nil {
+		return err
+	}
+
+	tokens := strings.Split(pattern, ".")
+	for i, token := range tokens {
+		switch {
+		case c.Wildcards == WildcardsNATS && token == ">" && i != len(tokens)-1:
+			return fmt.Errorf("invalid pattern %q: > must be the last token", pattern)
+		case c.Wildcards == WildcardsNone && (token == "*" || token == ">" || token == "#"):
+			return fmt.Errorf("invalid pattern %q: wildcards are not supported, subscribe to an exact %s name", pattern, c.SubjectKind)
+		}
+	}
+	return nil
+}
+
+// validateName checks a subject or pattern against the name syntax
+func (c Capabilities) validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name is empty", c.SubjectKind)
+	}
+
+	switch c.Names {
+	case NamesTokens:
+		for _, token := range strings.Split(name, ".") {
+			if token == "" || strings.ContainsAny(token, " \t\r\n") {
+				return fmt.Errorf("invalid %s %q: use dot-separated tokens without spaces", c.SubjectKind, name)
+			}
+		}
+	case NamesResourceID:
+		if !resourceID.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "goog") {
+			return fmt.Errorf("invalid %s %q: start with a letter and use 3 to 255 letters, digits or - _ . ~ + %%, not starting with goog", c.SubjectKind, name)
+		}
+	default:
+		if len(name) > 255 {
+			return fmt.Errorf("invalid %s %q: longer than 255 bytes", c.SubjectKind, name)
+		}
+	}
+	return nil
+}
+
+// PatternHint returns an example of subscription patterns, for placeholders
+func (c Capabilities) PatternHint() string {
+	if c.Wildcards == WildcardsNATS {
+		return "e.g. orders.created, orders.*, orders.>"
+	}
+	return fmt.Sprintf("Exact %s name, e.g. orders", c.SubjectKind)
+}
@@ -0,0 +1,82 @@
+package messaging
+
+import "testing"
+
+var (
+	natsCapabilities   = Capabilities{Wildcards: WildcardsNATS, Names: NamesTokens, SubjectKind: "subject"}
+	queueCapabilities  = Capabilities{Wildcards: WildcardsNone, Names: NamesAny, SubjectKind: "queue"}
+	pubsubCapabilities = Capabilities{Wildcards: WildcardsNone, Names: NamesResourceID, SubjectKind: "topic"}
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		caps    Capabilities
+		pattern string
+		valid   bool
+	}{
+		{natsCapabilities, "orders.created", true},
+		{natsCapabilities, "orders.*", true},
+		{natsCapabilities, "orders.>", true},
+		{natsCapabilities, "*.eu.>", true},
+		{natsCapabilities, "orders.>.created", false},
+		{natsCapabilities, "orders..created", false},
+		{natsCapabilities, "orders created", false},
+		{natsCapabilities, "", false},
+		{queueCapabilities, "orders", true},
+		{queueCapabilities, "amq.gen-JzTY20BRgKO", true},
+		{queueCapabilities, "orders created", true},
+		{queueCapabilities, "orders.*", false},
+		{queueCapabilities, "orders.#", false},
+		{pubsubCapabilities, "orders-created", true},
+		{pubsubCapabilities, "orders.*", false},
+		{pubsubCapabilities, "1orders", false},
+		{pubsubCapabilities, "ab", false},
+		{pubsubCapabilities, "google-orders", false},
+	}
+	for _, tt := range tests {
+		err := tt.caps.ValidatePattern(tt.pattern)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s pattern %q: err = %v, want valid %v", tt.caps.SubjectKind, tt.pattern, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateSubject(t *testing.T) {
+	tests := []struct {
+		caps    Capabilities
+		subject string
+		valid   bool
+	}{
+		{natsCapabilities, "orders.created", true},
+		{natsCapabilities, "orders.*", false},
+		{natsCapabilities, "orders.>", false},
+		{natsCapabilities, "orders.", false},
+		{queueCapabilities, "orders.*", true},
+		{queueCapabilities, string(make([]byte, 256)), false},
+		{pubsubCapabilities, "orders.created", true},
+	}
+	for _, tt := range tests {
+		err := tt.caps.ValidateSubject(tt.subject)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s %q: err = %v, want valid %v", tt.caps.SubjectKind, tt.subject, err, tt.valid)
+		}
+	}
+}
+
+// plainProvider implements only the required methods
+type plainProvider struct{ MessagingProvider }
+
+// headerProvider adds headers to plainProvider
+type headerProvider struct{ plainProvider }
+
+func (headerProvider) PublishWithHeaders(string, []byte, map[string]string) error { return nil }
+
+func TestCapabilitiesOfDerivesFromInterfaces(t *testing.T) {
+	caps := CapabilitiesOf(plainProvider{})
+	if caps.Wildcards != WildcardsNone || caps.Headers || caps.Request || caps.Reply {
+		t.Errorf("plain provider capabilities = %+v", caps)
+	}
+	if caps := CapabilitiesOf(headerProvider{}); !caps.Headers {
+		t.Errorf("header provider capabilities = %+v", caps)
+	}
+}
@@ -0,0 +1,572 @@
+// Package conformance checks that a MessagingProvider behaves the way the
+// services and views expect, whatever the broker behind it, and that it does
+// what its capabilities declare. Provider tests plug in with Run.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// Config describes the provider under test
+type Config struct {
+	// New creates an unconnected provider
+	New func() messaging.MessagingProvider
+	// URL is the address the providers connect to
+	URL string
+	// Timeout bounds how long a delivery may take, 5s by default
+	Timeout time.Duration
+	// Quiet is how long to wait for a message that must not arrive, 300ms by default
+	Quiet time.Duration
+	// AdminURL is passed to Explore, empty to derive it from URL
+	AdminURL string
+}
+
+// Run runs every conformance check against the provider
+func Run(t *testing.T, cfg Config) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Quiet <= 0 {
+		cfg.Quiet = 300 * time.Millisecond
+	}
+
+	// Subjects are unique to the run, so messages left on durable queues or
+	// topics by earlier runs are never received
+	s := &suite{
+		cfg:    cfg,
+		caps:   messaging.CapabilitiesOf(cfg.New()),
+		prefix: "conformance." + strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+
+	t.Run("Capabilities", s.capabilities)
+	t.Run("PublishReceive", s.publishReceive)
+	t.Run("Subscriptions", s.subscriptions)
+	t.Run("Wildcards", s.wildcards)
+	t.Run("UnsubscribeStopsDelivery", s.unsubscribeStopsDelivery)
+	t.Run("CloseIsIdempotent", s.closeIsIdempotent)
+	t.Run("ConcurrentPublish", s.concurrentPublish)
+	t.Run("Reconnect", s.reconnect)
+	t.Run("RequestReply", s.requestReply)
+	t.Run("Replay", s.replay)
+	t.Run("Ordering", s.ordering)
+	t.Run("Explore", s.explore)
+}
+
+// suite holds the state shared by the checks of a run
+type suite struct {
+	cfg    Config
+	caps   messaging.Capabilities
+	prefix string
+}
+
+// subject returns a subject of the run made of the given tokens
+func (s *suite) subject(tokens ...string) string {
+	return s.prefix + "." + strings.Join(tokens, ".")
+}
+
+// connect returns a connected provider, closed at the end of the check
+func (s *suite) connect(t *testing.T) messaging.MessagingProvider {
+	t.Helper()
+	provider := s.cfg.New()
+	if err := provider.Connect(s.cfg.URL); err != nil {
+		t.Fatalf("Connect(%q): %v", s.cfg.URL, err)
+	}
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+// capabilities checks the declared capabilities match the optional
+// interfaces of the provider and accept the subjects of the suite
+func (s *suite) capabilities(t *testing.T) {
+	provider := s.cfg.New()
+	_, headers := provider.(messaging.HeaderPublisher)
+	_, request := provider.(messaging.Requester)
+	_, reply := provider.(messaging.Replier)
+	_, admin := provider.(messaging.Explorer)
+
+	if s.caps.Headers != headers {
+		t.Errorf("Headers is %v, but implementing HeaderPublisher is %v", s.caps.Headers, headers)
+	}
+	if s.caps.Request != request {
+		t.Errorf("Request is %v, but implementing Requester is %v", s.caps.Request, request)
+	}
+	if s.caps.Reply != reply {
+		t.Errorf("Reply is %v, but implementing Replier is %v", s.caps.Reply, reply)
+	}
+	if s.caps.Admin != admin {
+		t.Errorf("Admin is %v, but implementing Explorer is %v", s.caps.Admin, admin)
+	}
+	if s.caps.SubjectKind == "" || s.caps.Wildcards == "" || s.caps.Names == "" || s.caps.Ack == "" {
+		t.Errorf("incomplete capabilities %+v", s.caps)
+	}
+
+	if err := s.caps.ValidateSubject(s.subject("orders", "created")); err != nil {
+		t.Errorf("ValidateSubject: %v", err)
+	}
+	if err := s.caps.ValidatePattern(s.subject("orders", "created")); err != nil {
+		t.Errorf("ValidatePattern: %v", err)
+	}
+	wildcard := s.subject("orders", "*")
+	if err := s.caps.ValidatePattern(wildcard); (err == nil) != (s.caps.Wildcards == messaging.WildcardsNATS) {
+		t.Errorf("ValidatePattern(%q) = %v with %s wildcards", wildcard, err, s.caps.Wildcards)
+	}
+}
+
+// publishReceive checks a message reaches a subscriber of another connection
+// with its subject, payload, headers and metadata
+func (s *suite) publishReceive(t *testing.T) {
+	publisher, subscriber := s.connect(t), s.connect(t)
+	subject := s.subject("publish")
+
+	in := newInbox()
+	if err := subscriber.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := publisher.Publish(subject, []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	msg := in.waitCount(t, 1, s.cfg.Timeout)[0]
+	if msg.Subject != subject || string(msg.Data) != `{"id":1}` {
+		t.Errorf("received %q on %q, want %q on %q", msg.Data, msg.Subject, `{"id":1}`, subject)
+	}
+	if msg.Provider != publisher.GetProviderType() {
+		t.Errorf("message provider is %q, want %q", msg.Provider, publisher.GetProviderType())
+	}
+	if msg.Timestamp == 0 {
+		t.Error("message has no timestamp")
+	}
+
+	if !s.caps.Headers {
+		return
+	}
+	headerPublisher := publisher.(messaging.HeaderPublisher)
+	if err := headerPublisher.PublishWithHeaders(subject, []byte("with headers"), map[string]string{"X-Conformance": "yes"}); err != nil {
+		t.Fatalf("PublishWithHeaders: %v", err)
+	}
+	msg = in.waitCount(t, 2, s.cfg.Timeout)[1]
+	if string(msg.Data) != "with headers" || msg.Headers["X-Conformance"] != "yes" {
+		t.Errorf("received %q with headers %v, want X-Conformance: yes", msg.Data, msg.Headers)
+	}
+}
+
+// subscriptions checks subscriptions are tracked by pattern
+func (s *suite) subscriptions(t *testing.T) {
+	provider := s.connect(t)
+	subject := s.subject("subscriptions")
+
+	if err := provider.Subscribe(subject, func(*messaging.Message) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := provider.Subscribe(subject, func(*messaging.Message) {}); err == nil {
+		t.Error("a second subscription to the same pattern was accepted")
+	}
+	if err := provider.Unsubscribe(s.subject("unknown")); err == nil {
+		t.Error("unsubscribing from an unknown pattern was accepted")
+	}
+}
+
+// wildcards checks which subjects a pattern delivers
+func (s *suite) wildcards(t *testing.T) {
+	publisher, subscriber := s.connect(t), s.connect(t)
+
+	patterns := []string{s.subject("orders", "eu", "created")}
+	want := map[string][]string{
+		patterns[0]: {s.subject("orders", "eu", "created")},
+	}
+	if s.caps.Wildcards == messaging.WildcardsNATS {
+		one, rest := s.subject("orders", "*"), s.subject("orders", ">")
+		patterns = append(patterns, one, rest)
+		want[one] = []string{s.subject("orders", "new")}
+		want[rest] = []string{s.subject("orders", "eu", "created"), s.subject("orders", "new")}
+	}
+
+	inboxes := make(map[string]*inbox)
+	for _, pattern := range patterns {
+		inboxes[pattern] = newInbox()
+		if err := subscriber.Subscribe(pattern, inboxes[pattern].handle); err != nil {
+			t.Fatalf("Subscribe(%q): %v", pattern, err)
+		}
+	}
+
+	subjects := []string{
+		s.subject("orders"),
+		s.subject("orders", "new"),
+		s.subject("orders", "eu", "created"),
+		s.subject("invoices", "new"),
+	}
+	for _, subject := range subjects {
+		if err := publisher.Publish(subject, []byte(subject)); err != nil {
+			t.Fatalf("Publish(%q): %v", subject, err)
+		}
+	}
+
+	for _, pattern := range patterns {
+		inboxes[pattern].waitCount(t, len(want[pattern]), s.cfg.Timeout)
+	}
+	time.Sleep(s.cfg.Quiet)
+	for _, pattern := range patterns {
+		var got []string
+		for _, msg := range inboxes[pattern].messages() {
+			got = append(got, msg.Subject)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(want[pattern], ",") {
+			t.Errorf("%s received %v, want %v", pattern, got, want[pattern])
+		}
+	}
+}
+
+// unsubscribeStopsDelivery checks nothing is delivered after Unsubscribe
+// returns and that the pattern can be subscribed again
+func (s *suite) unsubscribeStopsDelivery(t *testing.T) {
+	publisher, subscriber := s.connect(t), s.connect(t)
+	subject := s.subject("unsubscribe")
+
+	in := newInbox()
+	if err := subscriber.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := publisher.Publish(subject, []byte("before")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	in.waitCount(t, 1, s.cfg.Timeout)
+
+	if err := subscriber.Unsubscribe(subject); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if err := publisher.Publish(subject, []byte("after")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	time.Sleep(s.cfg.Quiet)
+	if got := in.messages(); len(got) != 1 {
+		t.Fatalf("received %d messages, want 1: the subscription still delivers after Unsubscribe", len(got))
+	}
+	if err := subscriber.Unsubscribe(subject); err == nil {
+		t.Error("unsubscribing twice was accepted")
+	}
+
+	// Brokers that keep messages for the subscriber may also deliver "after"
+	again := newInbox()
+	if err := subscriber.Subscribe(subject, again.handle); err != nil {
+		t.Fatalf("Subscribe after Unsubscribe: %v", err)
+	}
+	if err := publisher.Publish(subject, []byte("again")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	again.waitFor(t, "the message published after subscribing again", s.cfg.Timeout, func(messages []*messaging.Message) bool {
+		for _, msg := range messages {
+			if string(msg.Data) == "again" {
+				return true
+			}
+		}
+		return false
+	})
+	if got := in.messages(); len(got) != 1 {
+		t.Errorf("the first handler received %d messages after subscribing again, want 1", len(got))
+	}
+}
+
+// closeIsIdempotent checks Close may be called any number of times and
+// stops the provider
+func (s *suite) closeIsIdempotent(t *testing.T) {
+	if err := s.cfg.New().Close(); err != nil {
+		t.Errorf("Close before Connect: %v", err)
+	}
+
+	publisher, provider := s.connect(t), s.connect(t)
+	subject := s.subject("close")
+
+	in := newInbox()
+	if err := provider.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := provider.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := provider.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	if provider.IsConnected() {
+		t.Error("IsConnected is true after Close")
+	}
+	if err := provider.Publish(subject, []byte("closed")); err == nil {
+		t.Error("a closed provider published")
+	}
+	if err := provider.Subscribe(s.subject("close", "again"), in.handle); err == nil {
+		t.Error("a closed provider subscribed")
+	}
+
+	if err := publisher.Publish(subject, []byte("after close")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	time.Sleep(s.cfg.Quiet)
+	if got := in.messages(); len(got) != 0 {
+		t.Errorf("a closed provider received %d messages", len(got))
+	}
+}
+
+// concurrentPublish checks publishing from many goroutines loses nothing
+func (s *suite) concurrentPublish(t *testing.T) {
+	const publishers, perPublisher = 8, 25
+
+	publisher, subscriber := s.connect(t), s.connect(t)
+	subject := s.subject("concurrent")
+
+	in := newInbox()
+	if err := subscriber.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, publishers*perPublisher)
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				if err := publisher.Publish(subject, []byte(fmt.Sprintf("%d-%d", p, i))); err != nil {
+					errs <- err
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent Publish: %v", err)
+	}
+
+	received := make(map[string]bool)
+	for _, msg := range in.waitCount(t, publishers*perPublisher, s.cfg.Timeout) {
+		received[string(msg.Data)] = true
+	}
+	if len(received) != publishers*perPublisher {
+		t.Errorf("received %d distinct messages, want %d", len(received), publishers*perPublisher)
+	}
+}
+
+// reconnect checks Connect is a no-op on a connected provider and that a
+// closed provider can connect again
+func (s *suite) reconnect(t *testing.T) {
+	provider := s.connect(t)
+	subject := s.subject("reconnect")
+
+	first := newInbox()
+	if err := provider.Subscribe(subject, first.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := provider.Connect(s.cfg.URL); err != nil {
+		t.Fatalf("Connect while connected: %v", err)
+	}
+	if err := provider.Subscribe(subject, first.handle); err == nil {
+		t.Error("Connect while connected dropped the subscriptions")
+	}
+
+	if err := provider.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := provider.Connect(s.cfg.URL); err != nil {
+		t.Fatalf("Connect after Close: %v", err)
+	}
+	if !provider.IsConnected() {
+		t.Fatal("IsConnected is false after connecting again")
+	}
+
+	second := newInbox()
+	if err := provider.Subscribe(subject, second.handle); err != nil {
+		t.Fatalf("Subscribe after reconnecting: %v", err)
+	}
+	if err := provider.Publish(subject, []byte("reconnected")); err != nil {
+		t.Fatalf("Publish after reconnecting: %v", err)
+	}
+	second.waitCount(t, 1, s.cfg.Timeout)
+	if got := first.messages(); len(got) != 0 {
+		t.Errorf("a subscription from before Close received %d messages", len(got))
+	}
+}
+
+// requestReply checks a request is answered through Reply, for providers
+// that can send requests
+func (s *suite) requestReply(t *testing.T) {
+	if !s.caps.Request {
+		t.Skip("the provider can't send requests")
+	}
+	client, service := s.connect(t), s.connect(t)
+	subject := s.subject("request")
+
+	replier := service.(messaging.Replier)
+	if err := service.Subscribe(subject, func(msg *messaging.Message) {
+		replier.Reply(msg, append([]byte("re: "), msg.Data...), nil)
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	reply, err := client.(messaging.Requester).Request(subject, []byte("ping"), nil, s.cfg.Timeout)
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if string(reply.Data) != "re: ping" {
+		t.Errorf("reply is %q, want %q", reply.Data, "re: ping")
+	}
+
+	if _, err := client.(messaging.Requester).Request(s.subject("request", "nobody"), nil, nil, s.cfg.Quiet); err == nil {
+		t.Error("a request without responders did not fail")
+	}
+}
+
+// replay checks messages published while the app is not subscribed are
+// delivered once it subscribes again, for providers that keep them
+func (s *suite) replay(t *testing.T) {
+	if !s.caps.Replay {
+		t.Skip("the provider doesn't keep messages")
+	}
+	publisher, subscriber := s.connect(t), s.connect(t)
+	subject := s.subject("replay")
+
+	if err := subscriber.Subscribe(subject, func(*messaging.Message) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := subscriber.Unsubscribe(subject); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if err := publisher.Publish(subject, []byte("kept")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	in := newInbox()
+	if err := subscriber.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe again: %v", err)
+	}
+	if msg := in.waitCount(t, 1, s.cfg.Timeout)[0]; string(msg.Data) != "kept" {
+		t.Errorf("received %q, want %q", msg.Data, "kept")
+	}
+}
+
+// ordering checks the messages of one publisher arrive in order, for
+// providers that declare it
+func (s *suite) ordering(t *testing.T) {
+	if !s.caps.Ordered {
+		t.Skip("the provider doesn't order messages")
+	}
+	const count = 100
+
+	publisher, subscriber := s.connect(t), s.connect(t)
+	subject := s.subject("ordering")
+
+	in := newInbox()
+	if err := subscriber.Subscribe(subject, in.handle); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		if err := publisher.Publish(subject, []byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for i, msg := range in.waitCount(t, count, s.cfg.Timeout) {
+		if string(msg.Data) != strconv.Itoa(i) {
+			t.Fatalf("message %d is %q: messages arrived out of order", i, msg.Data)
+		}
+	}
+}
+
+// explore checks a subscribed subject is listed by Explore, for providers
+// with an admin API
+func (s *suite) explore(t *testing.T) {
+	if !s.caps.Admin {
+		t.Skip("the provider has no admin API")
+	}
+	provider := s.connect(t)
+	subject := s.subject("explore")
+	if err := provider.Subscribe(subject, func(*messaging.Message) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// Admin APIs may lag behind the broker
+	deadline := time.Now().Add(s.cfg.Timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+		entities, err := provider.(messaging.Explorer).Explore(ctx, s.cfg.AdminURL)
+		cancel()
+		if err != nil {
+			t.Fatalf("Explore: %v", err)
+		}
+		for _, entity := range entities {
+			if entity.Name == subject && entity.Subscribe == subject {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Explore did not list %s: %+v", subject, entities)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// inbox collects the messages delivered to a handler
+type inbox struct {
+	mutex    sync.Mutex
+	received []*messaging.Message
+	arrived  chan struct{}
+}
+
+func newInbox() *inbox {
+	return &inbox{arrived: make(chan struct{}, 1)}
+}
+
+// handle is the message handler of the inbox
+func (in *inbox) handle(msg *messaging.Message) {
+	in.mutex.Lock()
+	in.received = append(in.received, msg)
+	in.mutex.Unlock()
+
+	select {
+	case in.arrived <- struct{}{}:
+	default:
+	}
+}
+
+// messages returns the messages received so far
+func (in *inbox) messages() []*messaging.Message {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+	return append([]*messaging.Message(nil), in.received...)
+}
+
+// waitCount waits until at least n messages arrived
+func (in *inbox) waitCount(t *testing.T, n int, timeout time.Duration) []*messaging.Message {
+	t.Helper()
+	return in.waitFor(t, strconv.Itoa(n)+" messages", timeout, func(messages []*messaging.Message) bool {
+		return len(messages) >= n
+	})
+}
+
+// waitFor waits until done accepts the messages received, failing the check
+// after timeout
+func (in *inbox) waitFor(t *testing.T, what string, timeout time.Duration, done func([]*messaging.Message) bool) []*messaging.Message {
+	t.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		messages := in.messages()
+		if done(messages) {
+			return messages
+		}
+		select {
+		case <-in.arrived:
+		case <-timer.C:
+			t.Fatalf("timed out after %v waiting for %s, received %d messages", timeout, what, len(messages))
+		}
+	}
+}
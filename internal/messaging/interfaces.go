@@ -1,7 +1,9 @@
 package messaging
 
+import "time"
+
 // MessageHandler represents a function that handles incoming messages
-type MessageHandler func(subject string, data []byte)
+type MessageHandler func(msg *Message)
 
 // MessagingProvider defines the interface for messaging systems
 type MessagingProvider interface {
@@ -27,6 +29,55 @@ type MessagingProvider interface {
 	GetProviderType() ProviderType
 }
 
+// HeaderPublisher is implemented by providers that can attach headers (or
+// attributes/properties) to published messages
+type HeaderPublisher interface {
+	// PublishWithHeaders sends a message with headers to the specified subject/topic
+	PublishWithHeaders(subject string, data []byte, headers map[string]string) error
+}
+
+// Requester is implemented by providers with request/reply, where a request
+// waits for the first reply
+type Requester interface {
+	// Request sends a message and waits up to timeout for a reply
+	Request(subject string, data []byte, headers map[string]string, timeout time.Duration) (*Message, error)
+}
+
+// Replier is implemented by providers that can answer a received message on
+// its reply subject, such as a NATS reply inbox or an AMQP reply-to queue
+type Replier interface {
+	// Reply sends data to the reply subject of msg
+	Reply(msg *Message, data []byte, headers map[string]string) error
+}
+
+// ConnectionEvent is a change of a provider's connection state
+type ConnectionEvent int
+
+const (
+	// ConnectionLost is reported when the connection drops unexpectedly
+	ConnectionLost ConnectionEvent = iota
+	// ConnectionRestored is reported when the provider reconnects by itself
+	ConnectionRestored
+)
+
+// String returns the name of the event
+func (e ConnectionEvent) String() string {
+	switch e {
+	case ConnectionLost:
+		return "lost"
+	case ConnectionRestored:
+		return "restored"
+	}
+	return "unknown"
+}
+
+// ConnectionNotifier is implemented by providers that report connection
+// changes. The handler must be set before Connect.
+type ConnectionNotifier interface {
+	// OnConnectionEvent sets the handler of connection changes
+	OnConnectionEvent(handler func(event ConnectionEvent, err error))
+}
+
 // ProviderType represents different messaging provider types
 type ProviderType string
 
@@ -36,6 +87,7 @@ const (
 	ProviderKafka    ProviderType = "Kafka"
 	ProviderRedis    ProviderType = "Redis"
 	ProviderPubSub   ProviderType = "PUBSUB"
+	ProviderMemory   ProviderType = "Memory"
 )
 
 // ProviderFactory creates messaging providers
@@ -45,8 +97,19 @@ type ProviderFactory interface {
 
 // Message represents a message with metadata
 type Message struct {
-	Subject   string
-	Data      []byte
-	Provider  ProviderType
+	Subject string
+	Data    []byte
+	// Headers holds provider headers, attributes or properties; nil when there are none
+	Headers map[string]string
+	// Reply is the subject or queue a requester waits on, if any
+	Reply    string
+	Provider ProviderType
+	// Timestamp is the receive (or broker publish) time in Unix nanoseconds
 	Timestamp int64
+	// Decoded is a JSON rendering of Data produced by a schema codec, if any
+	Decoded []byte
+	// Schema names the codec that produced Decoded
+	Schema string
+	// Violations lists JSON Schema violations found in the payload, if validated
+	Violations []string
 }
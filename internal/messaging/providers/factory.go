@@ -24,6 +24,8 @@ func (f *Factory) CreateProvider(providerType messaging.ProviderType) (messaging
 		return NewRabbitMQProvider(), nil
 	case messaging.ProviderPubSub:
 		return NewPubSubProvider(), nil
+	case messaging.ProviderMemory:
+		return NewMemoryProvider(), nil
 	case messaging.ProviderKafka:
 		// TODO: Implement Kafka provider
 		return nil, fmt.Errorf("Kafka provider not implemented yet")
@@ -41,6 +43,7 @@ func (f *Factory) GetSupportedProviders() []messaging.ProviderType {
 		messaging.ProviderNATS,
 		messaging.ProviderRabbitMQ,
 		messaging.ProviderPubSub,
+		messaging.ProviderMemory,
 		// messaging.ProviderKafka,    // TODO: Uncomment when implemented
 		// messaging.ProviderRedis,    // TODO: Uncomment when implemented
 	}
@@ -51,6 +54,10 @@ func (f *Factory) DetectProviderFromURL(url string) messaging.ProviderType {
 	url = strings.ToLower(url)
 
 	// Check for specific protocols
+	if strings.HasPrefix(url, "memory://") {
+		return messaging.ProviderMemory
+	}
+
 	if strings.HasPrefix(url, "nats://") || strings.Contains(url, ":4222") {
 		return messaging.ProviderNATS
 	}
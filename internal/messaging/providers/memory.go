@@ -0,0 +1,403 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// memoryBroker routes messages between the memory providers connected to the
+// same URL, like a broker running inside the app
+type memoryBroker struct {
+	mutex sync.RWMutex
+	// subscribers are kept in subscription order, the order of delivery
+	subscribers []*memorySubscription
+}
+
+// memoryPendingLimit is the number of messages a subscription holds before
+// dropping new ones, as a NATS server does for slow consumers
+const memoryPendingLimit = 65536
+
+// memorySubscription is a pattern subscribed by a memory provider. Its
+// messages are handled in order on a goroutine of its own.
+type memorySubscription struct {
+	pattern string
+	handler messaging.MessageHandler
+
+	mutex   sync.Mutex
+	pending []messaging.Message
+	// slow is set while messages are dropped, to log once per episode
+	slow  bool
+	ready chan struct{}
+	done  chan struct{}
+}
+
+// newMemorySubscription starts the delivery goroutine of a subscription
+func newMemorySubscription(pattern string, handler messaging.MessageHandler) *memorySubscription {
+	sub := &memorySubscription{
+		pattern: pattern,
+		handler: handler,
+		ready:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go sub.run()
+	return sub
+}
+
+// push queues a message for the handler without waiting for it
+func (s *memorySubscription) push(msg messaging.Message) {
+	s.mutex.Lock()
+	if len(s.pending) >= memoryPendingLimit {
+		if !s.slow {
+			log.Printf("Slow memory subscriber on %s: dropping messages", s.pattern)
+		}
+		s.slow = true
+		s.mutex.Unlock()
+		return
+	}
+	s.pending = append(s.pending, msg)
+	s.mutex.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run hands the queued messages to the handler until the subscription stops
+func (s *memorySubscription) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ready:
+		}
+
+		for {
+			s.mutex.Lock()
+			batch := s.pending
+			s.pending = nil
+			s.slow = false
+			s.mutex.Unlock()
+			if len(batch) == 0 {
+				break
+			}
+
+			for i := range batch {
+				select {
+				case <-s.done:
+					return
+				default:
+				}
+				s.handler(&batch[i])
+			}
+		}
+	}
+}
+
+// memoryBrokers holds the in-process brokers by name
+var (
+	memoryBrokersMutex sync.Mutex
+	memoryBrokers      = make(map[string]*memoryBroker)
+)
+
+// memoryInboxes numbers the reply subjects of requests
+var memoryInboxes atomic.Uint64
+
+// memoryBrokerFor returns the broker named by a URL such as memory://local,
+// creating it on first use. An empty name is the default broker.
+func memoryBrokerFor(url string) (string, *memoryBroker) {
+	name := strings.TrimPrefix(strings.TrimSpace(url), "memory://")
+	if name == "" {
+		name = "default"
+	}
+
+	memoryBrokersMutex.Lock()
+	defer memoryBrokersMutex.Unlock()
+	broker, ok := memoryBrokers[name]
+	if !ok {
+		broker = &memoryBroker{}
+		memoryBrokers[name] = broker
+	}
+	return name, broker
+}
+
+// remove stops delivering to a subscription; its pending messages are dropped
+func (b *memoryBroker) remove(sub *memorySubscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, s := range b.subscribers {
+		if s == sub {
+			b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+			close(sub.done)
+			return
+		}
+	}
+}
+
+// deliver queues a copy of the message for each subscription matching its
+// subject
+func (b *memoryBroker) deliver(msg messaging.Message) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, sub := range b.subscribers {
+		if !messaging.MatchSubject(sub.pattern, msg.Subject) {
+			continue
+		}
+		delivered := msg
+		delivered.Data = append([]byte(nil), msg.Data...)
+		if msg.Headers != nil {
+			delivered.Headers = make(map[string]string, len(msg.Headers))
+			for key, value := range msg.Headers {
+				delivered.Headers[key] = value
+			}
+		}
+		sub.push(delivered)
+	}
+}
+
+// MemoryProvider implements MessagingProvider in-process, with NATS-style
+// subjects: "*" matches one token and a trailing ">" the rest. Providers
+// connected to the same URL share messages. Publish doesn't wait for the
+// handlers, so a handler may block or publish to subjects it matches.
+type MemoryProvider struct {
+	name          string
+	broker        *memoryBroker
+	subscriptions map[string]*memorySubscription
+	connected     bool
+	mutex         sync.RWMutex
+}
+
+// NewMemoryProvider creates a new memory provider
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		subscriptions: make(map[string]*memorySubscription),
+	}
+}
+
+// Connect attaches to the in-process broker named by the URL
+func (m *MemoryProvider) Connect(url string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.connected {
+		return nil
+	}
+
+	m.name, m.broker = memoryBrokerFor(url)
+	m.connected = true
+
+	log.Printf("Connected to memory broker %s", m.name)
+	return nil
+}
+
+// Publish sends a message to the specified subject
+func (m *MemoryProvider) Publish(subject string, data []byte) error {
+	return m.PublishWithHeaders(subject, data, nil)
+}
+
+// PublishWithHeaders sends a message with headers to the specified subject
+func (m *MemoryProvider) PublishWithHeaders(subject string, data []byte, headers map[string]string) error {
+	return m.publish(messaging.Message{Subject: subject, Data: data, Headers: headers})
+}
+
+// publish delivers a message to the subscribers of the broker
+func (m *MemoryProvider) publish(msg messaging.Message) error {
+	m.mutex.RLock()
+	broker := m.broker
+	connected := m.connected
+	m.mutex.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("not connected to memory broker")
+	}
+	if err := m.Capabilities().ValidateSubject(msg.Subject); err != nil {
+		return err
+	}
+
+	if len(msg.Headers) == 0 {
+		msg.Headers = nil
+	}
+	msg.Provider = messaging.ProviderMemory
+	msg.Timestamp = time.Now().UnixNano()
+	broker.deliver(msg)
+	return nil
+}
+
+// Request sends a message with a reply subject and waits for the first reply
+func (m *MemoryProvider) Request(subject string, data []byte, headers map[string]string, timeout time.Duration) (*messaging.Message, error) {
+	inbox := "_INBOX." + strconv.FormatUint(memoryInboxes.Add(1), 10)
+	replies := make(chan *messaging.Message, 1)
+	if err := m.Subscribe(inbox, func(msg *messaging.Message) {
+		select {
+		case replies <- msg:
+		default:
+		}
+	}); err != nil {
+		return nil, err
+	}
+	defer m.Unsubscribe(inbox)
+
+	if err := m.publish(messaging.Message{Subject: subject, Data: data, Headers: headers, Reply: inbox}); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("request to subject %s failed: no reply within %v", subject, timeout)
+	}
+}
+
+// Reply publishes data to the reply subject of a received request
+func (m *MemoryProvider) Reply(msg *messaging.Message, data []byte, headers map[string]string) error {
+	if msg.Reply == "" {
+		return fmt.Errorf("message on %s has no reply subject", msg.Subject)
+	}
+	return m.PublishWithHeaders(msg.Reply, data, headers)
+}
+
+// Subscribe subscribes to a subject pattern with a message handler
+func (m *MemoryProvider) Subscribe(subjectPattern string, handler messaging.MessageHandler) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.connected {
+		return fmt.Errorf("not connected to memory broker")
+	}
+	if err := m.Capabilities().ValidatePattern(subjectPattern); err != nil {
+		return err
+	}
+	if _, exists := m.subscriptions[subjectPattern]; exists {
+		return fmt.Errorf("already subscribed to subject pattern: %s", subjectPattern)
+	}
+
+	sub := newMemorySubscription(subjectPattern, handler)
+	m.broker.mutex.Lock()
+	m.broker.subscribers = append(m.broker.subscribers, sub)
+	m.broker.mutex.Unlock()
+	m.subscriptions[subjectPattern] = sub
+
+	log.Printf("Subscribed to memory subject pattern: %s", subjectPattern)
+	return nil
+}
+
+// Unsubscribe removes a subscription
+func (m *MemoryProvider) Unsubscribe(subjectPattern string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sub, exists := m.subscriptions[subjectPattern]
+	if !exists {
+		return fmt.Errorf("no subscription found for subject pattern: %s", subjectPattern)
+	}
+
+	m.broker.remove(sub)
+	delete(m.subscriptions, subjectPattern)
+
+	log.Printf("Unsubscribed from memory subject pattern: %s", subjectPattern)
+	return nil
+}
+
+// Close removes the subscriptions of the provider and detaches from the broker
+func (m *MemoryProvider) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.connected {
+		return nil
+	}
+
+	for _, sub := range m.subscriptions {
+		m.broker.remove(sub)
+	}
+
+	m.subscriptions = make(map[string]*memorySubscription)
+	m.connected = false
+
+	log.Printf("Disconnected from memory broker %s", m.name)
+	return nil
+}
+
+// IsConnected returns true if attached to a broker
+func (m *MemoryProvider) IsConnected() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.connected
+}
+
+// GetProviderType returns the provider type
+func (m *MemoryProvider) GetProviderType() messaging.ProviderType {
+	return messaging.ProviderMemory
+}
+
+// Explore lists the subscribed patterns of the broker, as the NATS monitoring
+// endpoint does; adminURL is not used
+func (m *MemoryProvider) Explore(ctx context.Context, adminURL string) ([]messaging.Entity, error) {
+	m.mutex.RLock()
+	broker := m.broker
+	connected := m.connected
+	m.mutex.RUnlock()
+	if !connected {
+		return nil, fmt.Errorf("not connected to memory broker")
+	}
+
+	broker.mutex.RLock()
+	counts := make(map[string]int)
+	var patterns []string
+	for _, sub := range broker.subscribers {
+		if strings.HasPrefix(sub.pattern, "_INBOX.") {
+			continue
+		}
+		if counts[sub.pattern] == 0 {
+			patterns = append(patterns, sub.pattern)
+		}
+		counts[sub.pattern]++
+	}
+	broker.mutex.RUnlock()
+
+	entities := make([]messaging.Entity, 0, len(patterns))
+	for _, pattern := range patterns {
+		entity := messaging.Entity{
+			Kind:      messaging.EntitySubject,
+			Name:      pattern,
+			Detail:    fmt.Sprintf("%d subscribers", counts[pattern]),
+			Subscribe: pattern,
+		}
+		if counts[pattern] == 1 {
+			entity.Detail = "1 subscriber"
+		}
+		if !strings.ContainsAny(pattern, "*>") {
+			entity.Publish = pattern
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// Capabilities returns what the memory broker supports, which is what core
+// NATS does, with the broker subscriptions as its admin view
+func (m *MemoryProvider) Capabilities() messaging.Capabilities {
+	return messaging.Capabilities{
+		Wildcards:   messaging.WildcardsNATS,
+		Names:       messaging.NamesTokens,
+		SubjectKind: "subject",
+		Headers:     true,
+		Request:     true,
+		Reply:       true,
+		Ack:         messaging.AckNone,
+		Ordered:     true,
+		Admin:       true,
+	}
+}
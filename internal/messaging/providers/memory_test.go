@@ -0,0 +1,290 @@
+package providers
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// connectMemory connects a memory provider to a broker private to the test
+func connectMemory(t *testing.T, url string) *MemoryProvider {
+	t.Helper()
+	m := NewMemoryProvider()
+	if err := m.Connect(url); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// collect subscribes to pattern and passes the received messages on
+func collect(t *testing.T, m *MemoryProvider, pattern string) <-chan *messaging.Message {
+	t.Helper()
+	received := make(chan *messaging.Message, 16)
+	if err := m.Subscribe(pattern, func(msg *messaging.Message) { received <- msg }); err != nil {
+		t.Fatal(err)
+	}
+	return received
+}
+
+// next waits for the next received message
+func next(t *testing.T, received <-chan *messaging.Message) *messaging.Message {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+// expectNone checks that no message arrives for a while
+func expectNone(t *testing.T, received <-chan *messaging.Message) {
+	t.Helper()
+	select {
+	case msg := <-received:
+		t.Errorf("unexpected message on %s", msg.Subject)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryWildcards(t *testing.T) {
+	url := "memory://" + t.Name()
+	publisher := connectMemory(t, url)
+	subscriber := connectMemory(t, url)
+
+	received := map[string]<-chan *messaging.Message{}
+	for _, pattern := range []string{"orders.*", "orders.>", "orders.eu.created"} {
+		received[pattern] = collect(t, subscriber, pattern)
+	}
+
+	for _, subject := range []string{"orders", "orders.new", "orders.eu.created", "invoices.new"} {
+		if err := publisher.Publish(subject, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string][]string{
+		"orders.*":          {"orders.new"},
+		"orders.>":          {"orders.new", "orders.eu.created"},
+		"orders.eu.created": {"orders.eu.created"},
+	}
+	for pattern, subjects := range want {
+		for _, subject := range subjects {
+			if msg := next(t, received[pattern]); msg.Subject != subject {
+				t.Errorf("%s received %s, want %s", pattern, msg.Subject, subject)
+			}
+		}
+		expectNone(t, received[pattern])
+	}
+}
+
+func TestMemoryMessages(t *testing.T) {
+	m := connectMemory(t, "memory://"+t.Name())
+
+	received := collect(t, m, "orders.created")
+	data := []byte(`{"id":1}`)
+	headers := map[string]string{"X-Tenant": "acme"}
+	if err := m.PublishWithHeaders("orders.created", data, headers); err != nil {
+		t.Fatal(err)
+	}
+	data[0], headers["X-Tenant"] = 'x', "changed"
+
+	got := next(t, received)
+	if string(got.Data) != `{"id":1}` || got.Headers["X-Tenant"] != "acme" ||
+		got.Provider != messaging.ProviderMemory || got.Timestamp == 0 {
+		t.Fatalf("received %+v", got)
+	}
+
+	if err := m.Subscribe("orders.created", func(*messaging.Message) {}); err == nil {
+		t.Error("a second subscription to the same pattern was accepted")
+	}
+	for _, subject := range []string{"", "orders.*", "orders..created", "orders.>"} {
+		if err := m.Publish(subject, data); err == nil {
+			t.Errorf("publishing to %q was accepted", subject)
+		}
+	}
+	if err := m.Subscribe("orders.>.created", func(*messaging.Message) {}); err == nil {
+		t.Error("a pattern with > in the middle was accepted")
+	}
+}
+
+func TestMemoryBrokersAreSeparate(t *testing.T) {
+	a := connectMemory(t, "memory://"+t.Name()+"-a")
+	b := connectMemory(t, "memory://"+t.Name()+"-b")
+
+	received := collect(t, b, ">")
+	if err := a.Publish("orders", nil); err != nil {
+		t.Fatal(err)
+	}
+	expectNone(t, received)
+}
+
+func TestMemoryDeliversInOrder(t *testing.T) {
+	m := connectMemory(t, "memory://"+t.Name())
+
+	var got []string
+	done := make(chan struct{})
+	if err := m.Subscribe("events", func(msg *messaging.Message) {
+		got = append(got, string(msg.Data))
+		if len(got) == 100 {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := range 100 {
+		want = append(want, strconv.Itoa(i))
+		if err := m.Publish("events", []byte(want[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("not every message was delivered")
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("received %v, want %v", got, want)
+	}
+}
+
+func TestMemoryHandlerPublishesToItself(t *testing.T) {
+	m := connectMemory(t, "memory://"+t.Name())
+
+	// A responder that answers on a subject its own pattern matches keeps
+	// publishing; each publish returns without running the handler
+	var handled atomic.Int64
+	if err := m.Subscribe("ping.>", func(msg *messaging.Message) {
+		handled.Add(1)
+		m.Publish("ping.again", msg.Data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Publish("ping.first", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for handled.Load() < 10000 {
+		if time.Now().After(deadline) {
+			t.Fatalf("handled %d messages, want the loop to keep going", handled.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := m.Unsubscribe("ping.>"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryBlockedHandlerDoesNotBlockPublish(t *testing.T) {
+	url := "memory://" + t.Name()
+	publisher := connectMemory(t, url)
+	subscriber := connectMemory(t, url)
+
+	release := make(chan struct{})
+	if err := subscriber.Subscribe("slow", func(*messaging.Message) { <-release }); err != nil {
+		t.Fatal(err)
+	}
+	fast := collect(t, subscriber, "fast")
+
+	published := make(chan error, 1)
+	go func() {
+		for range 3 {
+			if err := publisher.Publish("slow", nil); err != nil {
+				published <- err
+				return
+			}
+		}
+		published <- publisher.Publish("fast", nil)
+	}()
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish waited for a blocked handler")
+	}
+	next(t, fast)
+	close(release)
+}
+
+func TestMemoryRequestReply(t *testing.T) {
+	url := "memory://" + t.Name()
+	service := connectMemory(t, url)
+	client := connectMemory(t, url)
+
+	if err := service.Subscribe("time.now", func(msg *messaging.Message) {
+		service.Reply(msg, []byte("noon"), map[string]string{"X-Served-By": "test"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := client.Request("time.now", nil, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "noon" || reply.Headers["X-Served-By"] != "test" {
+		t.Errorf("reply = %+v", reply)
+	}
+
+	if _, err := client.Request("nobody.home", nil, nil, 10*time.Millisecond); err == nil {
+		t.Error("a request without responders did not time out")
+	}
+}
+
+func TestMemoryUnsubscribeAndClose(t *testing.T) {
+	url := "memory://" + t.Name()
+	publisher := connectMemory(t, url)
+	subscriber := connectMemory(t, url)
+
+	received := collect(t, subscriber, "events.>")
+	publisher.Publish("events.a", nil)
+	next(t, received)
+	if err := subscriber.Unsubscribe("events.>"); err != nil {
+		t.Fatal(err)
+	}
+	publisher.Publish("events.b", nil)
+	expectNone(t, received)
+	if err := subscriber.Unsubscribe("events.>"); err == nil {
+		t.Error("unsubscribing twice was accepted")
+	}
+
+	received = collect(t, subscriber, "events.>")
+	if err := subscriber.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := subscriber.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	publisher.Publish("events.c", nil)
+	expectNone(t, received)
+	if subscriber.IsConnected() {
+		t.Error("a closed provider is still connected")
+	}
+	if err := subscriber.Publish("events.d", nil); err == nil {
+		t.Error("a closed provider published")
+	}
+}
+
+func TestFactoryCreatesMemoryProvider(t *testing.T) {
+	f := NewFactory()
+	if f.DetectProviderFromURL("memory://local") != messaging.ProviderMemory {
+		t.Error("memory:// URLs are not detected")
+	}
+	provider, err := f.CreateProvider(messaging.ProviderMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := provider.(messaging.Requester); !ok {
+		t.Error("the memory provider has no request/reply")
+	}
+}
@@ -1,11 +1,15 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/topology"
 	"github.com/nats-io/nats.go"
 )
 
@@ -17,6 +21,7 @@ type NATSProvider struct {
 	handlers      map[string]messaging.MessageHandler
 	mutex         sync.RWMutex
 	connected     bool
+	onConnection  func(event messaging.ConnectionEvent, err error)
 }
 
 // NewNATSProvider creates a new NATS provider
@@ -42,7 +47,17 @@ func (n *NATSProvider) Connect(url string) error {
 		connectionURL = "nats://" + url
 	}
 
-	conn, err := nats.Connect(connectionURL)
+	conn, err := nats.Connect(connectionURL,
+		// A nil error means the connection was closed on purpose
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				n.notifyConnection(messaging.ConnectionLost, err)
+			}
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			n.notifyConnection(messaging.ConnectionRestored, nil)
+		}),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to connect to NATS server at %s: %w", connectionURL, err)
 	}
@@ -51,12 +66,34 @@ func (n *NATSProvider) Connect(url string) error {
 	n.url = connectionURL
 	n.connected = true
 
-	fmt.Printf("Connected to NATS server at %s\n", url)
+	log.Printf("Connected to NATS server at %s", url)
 	return nil
 }
 
+// OnConnectionEvent sets the handler of disconnects and automatic reconnects
+func (n *NATSProvider) OnConnectionEvent(handler func(event messaging.ConnectionEvent, err error)) {
+	n.mutex.Lock()
+	n.onConnection = handler
+	n.mutex.Unlock()
+}
+
+// notifyConnection reports a connection change to the handler, if any
+func (n *NATSProvider) notifyConnection(event messaging.ConnectionEvent, err error) {
+	n.mutex.RLock()
+	handler := n.onConnection
+	n.mutex.RUnlock()
+	if handler != nil {
+		handler(event, err)
+	}
+}
+
 // Publish sends a message to the specified subject
 func (n *NATSProvider) Publish(subject string, data []byte) error {
+	return n.PublishWithHeaders(subject, data, nil)
+}
+
+// PublishWithHeaders sends a message with NATS headers to the specified subject
+func (n *NATSProvider) PublishWithHeaders(subject string, data []byte, headers map[string]string) error {
 	n.mutex.RLock()
 	defer n.mutex.RUnlock()
 
@@ -64,15 +101,58 @@ func (n *NATSProvider) Publish(subject string, data []byte) error {
 		return fmt.Errorf("not connected to NATS server")
 	}
 
-	err := n.conn.Publish(subject, data)
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	for key, value := range headers {
+		msg.Header.Set(key, value)
+	}
+
+	err := n.conn.PublishMsg(msg)
 	if err != nil {
 		return fmt.Errorf("failed to publish message to subject %s: %w", subject, err)
 	}
-
-	fmt.Printf("Published message to subject: %s, data: %s\n", subject, string(data))
 	return nil
 }
 
+// Request sends a message and waits for the first reply
+func (n *NATSProvider) Request(subject string, data []byte, headers map[string]string, timeout time.Duration) (*messaging.Message, error) {
+	n.mutex.RLock()
+	conn := n.conn
+	connected := n.connected
+	n.mutex.RUnlock()
+
+	if !connected || conn == nil {
+		return nil, fmt.Errorf("not connected to NATS server")
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	for key, value := range headers {
+		msg.Header.Set(key, value)
+	}
+
+	reply, err := conn.RequestMsg(msg, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("request to subject %s failed: %w", subject, err)
+	}
+
+	return &messaging.Message{
+		Subject:   reply.Subject,
+		Data:      reply.Data,
+		Headers:   natsHeaders(reply.Header),
+		Provider:  messaging.ProviderNATS,
+		Timestamp: time.Now().UnixNano(),
+	}, nil
+}
+
+// Reply publishes data to the reply inbox of a received request
+func (n *NATSProvider) Reply(msg *messaging.Message, data []byte, headers map[string]string) error {
+	if msg.Reply == "" {
+		return fmt.Errorf("message on %s has no reply subject", msg.Subject)
+	}
+	return n.PublishWithHeaders(msg.Reply, data, headers)
+}
+
 // Subscribe subscribes to a subject pattern with a message handler
 func (n *NATSProvider) Subscribe(subjectPattern string, handler messaging.MessageHandler) error {
 	n.mutex.Lock()
@@ -89,7 +169,14 @@ func (n *NATSProvider) Subscribe(subjectPattern string, handler messaging.Messag
 
 	// Create NATS message handler wrapper
 	natsHandler := func(msg *nats.Msg) {
-		handler(msg.Subject, msg.Data)
+		handler(&messaging.Message{
+			Subject:   msg.Subject,
+			Data:      msg.Data,
+			Headers:   natsHeaders(msg.Header),
+			Reply:     msg.Reply,
+			Provider:  messaging.ProviderNATS,
+			Timestamp: time.Now().UnixNano(),
+		})
 	}
 
 	sub, err := n.conn.Subscribe(subjectPattern, natsHandler)
@@ -97,10 +184,17 @@ func (n *NATSProvider) Subscribe(subjectPattern string, handler messaging.Messag
 		return fmt.Errorf("failed to subscribe to subject pattern %s: %w", subjectPattern, err)
 	}
 
+	// Wait for the server to register the subscription, so that messages
+	// published from other connections after Subscribe returns are delivered
+	if err := n.conn.Flush(); err != nil {
+		sub.Unsubscribe()
+		return fmt.Errorf("failed to subscribe to subject pattern %s: %w", subjectPattern, err)
+	}
+
 	n.subscriptions[subjectPattern] = sub
 	n.handlers[subjectPattern] = handler
 
-	fmt.Printf("Subscribed to subject pattern: %s\n", subjectPattern)
+	log.Printf("Subscribed to subject pattern: %s", subjectPattern)
 	return nil
 }
 
@@ -122,7 +216,7 @@ func (n *NATSProvider) Unsubscribe(subjectPattern string) error {
 	delete(n.subscriptions, subjectPattern)
 	delete(n.handlers, subjectPattern)
 
-	fmt.Printf("Unsubscribed from subject pattern: %s\n", subjectPattern)
+	log.Printf("Unsubscribed from subject pattern: %s", subjectPattern)
 	return nil
 }
 
@@ -147,7 +241,7 @@ func (n *NATSProvider) Close() error {
 	n.subscriptions = make(map[string]*nats.Subscription)
 	n.handlers = make(map[string]messaging.MessageHandler)
 
-	fmt.Println("Disconnected from NATS server")
+	log.Println("Disconnected from NATS server")
 	return nil
 }
 
@@ -162,3 +256,48 @@ func (n *NATSProvider) IsConnected() bool {
 func (n *NATSProvider) GetProviderType() messaging.ProviderType {
 	return messaging.ProviderNATS
 }
+
+// Capabilities returns what core NATS supports: subject wildcards, headers
+// and request/reply, with at-most-once delivery to current subscribers. The
+// monitoring endpoint describes connections and subscriptions.
+func (n *NATSProvider) Capabilities() messaging.Capabilities {
+	return messaging.Capabilities{
+		Wildcards:   messaging.WildcardsNATS,
+		Names:       messaging.NamesTokens,
+		SubjectKind: "subject",
+		Headers:     true,
+		Request:     true,
+		Reply:       true,
+		Ack:         messaging.AckNone,
+		Ordered:     true,
+		Admin:       true,
+	}
+}
+
+// Explore lists the subjects, JetStream streams and connections reported by
+// the monitoring endpoint, by default on port 8222 of the server host
+func (n *NATSProvider) Explore(ctx context.Context, adminURL string) ([]messaging.Entity, error) {
+	if adminURL == "" {
+		n.mutex.RLock()
+		url := n.url
+		n.mutex.RUnlock()
+		if url == "" {
+			return nil, fmt.Errorf("not connected to NATS server")
+		}
+		adminURL = topology.NATSMonitorURL(url)
+	}
+	return topology.NATSMonitor(ctx, adminURL)
+}
+
+// natsHeaders flattens NATS headers, joining repeated values with a comma
+func natsHeaders(header nats.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(header))
+	for key, values := range header {
+		headers[key] = strings.Join(values, ", ")
+	}
+	return headers
+}
@@ -7,15 +7,19 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"github.com/devalexandre/broker-ui/internal/messaging"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // PubSubProvider implements the MessagingProvider interface for Google Cloud Pub/Sub
 type PubSubProvider struct {
 	client        *pubsub.Client
-	subscriptions map[string]*pubsub.Subscription
+	subscriptions map[string]*pubsubSubscription
 	topics        map[string]*pubsub.Topic
 	handlers      map[string]messaging.MessageHandler
 	ctx           context.Context
@@ -25,11 +29,19 @@ type PubSubProvider struct {
 	mu            sync.RWMutex
 }
 
+// pubsubSubscription is a running Receive on a subscription
+type pubsubSubscription struct {
+	subscription *pubsub.Subscription
+	cancel       context.CancelFunc
+	// done is closed when Receive has returned
+	done chan struct{}
+}
+
 // NewPubSubProvider creates a new Pub/Sub provider
 func NewPubSubProvider() *PubSubProvider {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &PubSubProvider{
-		subscriptions: make(map[string]*pubsub.Subscription),
+		subscriptions: make(map[string]*pubsubSubscription),
 		topics:        make(map[string]*pubsub.Topic),
 		handlers:      make(map[string]messaging.MessageHandler),
 		ctx:           ctx,
@@ -43,6 +55,10 @@ func (p *PubSubProvider) Connect(url string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.connected {
+		return nil
+	}
+
 	// Parse project ID from URL or use default
 	p.projectID = p.parseProjectID(url)
 
@@ -52,12 +68,17 @@ func (p *PubSubProvider) Connect(url string) error {
 		log.Printf("Connecting to Pub/Sub emulator at: %s", os.Getenv("PUBSUB_EMULATOR_HOST"))
 	}
 
-	// Create Pub/Sub client
-	client, err := pubsub.NewClient(p.ctx, p.projectID)
+	// Create Pub/Sub client, with a fresh context since Close cancels the previous one
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := pubsub.NewClient(ctx, p.projectID)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("failed to create pubsub client: %v", err)
 	}
 
+	p.ctx = ctx
+	p.cancel = cancel
+
 	p.client = client
 	p.connected = true
 
@@ -67,36 +88,40 @@ func (p *PubSubProvider) Connect(url string) error {
 
 // Publish sends a message to the specified topic
 func (p *PubSubProvider) Publish(subject string, data []byte) error {
-	p.mu.RLock()
-	if !p.connected {
-		p.mu.RUnlock()
-		return fmt.Errorf("not connected to Pub/Sub")
-	}
-	p.mu.RUnlock()
+	return p.PublishWithHeaders(subject, data, nil)
+}
 
-	// Get or create topic
-	topic, err := p.getOrCreateTopic(subject)
+// PublishWithHeaders publishes a message with headers as Pub/Sub attributes
+func (p *PubSubProvider) PublishWithHeaders(subject string, data []byte, headers map[string]string) error {
+	topic, err := p.topic(subject)
 	if err != nil {
-		return fmt.Errorf("failed to get/create topic %s: %v", subject, err)
+		return err
 	}
+	p.mu.RLock()
+	ctx := p.ctx
+	p.mu.RUnlock()
 
 	// Publish message
-	result := topic.Publish(p.ctx, &pubsub.Message{
-		Data: data,
+	result := topic.Publish(ctx, &pubsub.Message{
+		Data:       data,
+		Attributes: headers,
 	})
 
 	// Wait for the result
-	_, err = result.Get(p.ctx)
+	_, err = result.Get(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %v", err)
 	}
-
-	log.Printf("Published message to topic: %s", subject)
 	return nil
 }
 
 // Subscribe subscribes to a topic with a message handler
 func (p *PubSubProvider) Subscribe(subjectPattern string, handler messaging.MessageHandler) error {
+	topic, err := p.topic(subjectPattern)
+	if err != nil {
+		return err
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -104,34 +129,41 @@ func (p *PubSubProvider) Subscribe(subjectPattern string, handler messaging.Mess
 		return fmt.Errorf("not connected to Pub/Sub")
 	}
 
+	// Check if already subscribed
+	if _, exists := p.subscriptions[subjectPattern]; exists {
+		return fmt.Errorf("already subscribed to topic: %s", subjectPattern)
+	}
+
 	// Create subscription name (topic + "-subscription")
 	subscriptionName := subjectPattern + "-subscription"
 
-	// Get or create topic
-	topic, err := p.getOrCreateTopic(subjectPattern)
-	if err != nil {
-		return fmt.Errorf("failed to get/create topic %s: %v", subjectPattern, err)
-	}
-
 	// Get or create subscription
 	subscription, err := p.getOrCreateSubscription(subscriptionName, topic)
 	if err != nil {
 		return fmt.Errorf("failed to get/create subscription %s: %v", subscriptionName, err)
 	}
 
-	// Store handler and subscription
+	// Store handler and subscription, with a context of its own so that
+	// Unsubscribe can stop Receive
+	ctx, cancel := context.WithCancel(p.ctx)
+	sub := &pubsubSubscription{
+		subscription: subscription,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
 	p.handlers[subjectPattern] = handler
-	p.subscriptions[subjectPattern] = subscription
+	p.subscriptions[subjectPattern] = sub
 
 	// Start receiving messages in a goroutine
 	go func() {
-		err := subscription.Receive(p.ctx, func(ctx context.Context, msg *pubsub.Message) {
+		defer close(sub.done)
+		err := subscription.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
 			// Call the handler
-			handler(subjectPattern, msg.Data)
+			handler(pubsubMessage(subjectPattern, msg))
 			// Acknowledge the message
 			msg.Ack()
 		})
-		if err != nil && p.ctx.Err() == nil {
+		if err != nil && ctx.Err() == nil {
 			log.Printf("Error receiving messages for %s: %v", subjectPattern, err)
 		}
 	}()
@@ -140,54 +172,71 @@ func (p *PubSubProvider) Subscribe(subjectPattern string, handler messaging.Mess
 	return nil
 }
 
-// Unsubscribe removes a subscription
+// Unsubscribe removes a subscription and returns once no more messages are
+// delivered to its handler
 func (p *PubSubProvider) Unsubscribe(subjectPattern string) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	sub, exists := p.subscriptions[subjectPattern]
+	if !exists {
+		p.mu.Unlock()
+		return fmt.Errorf("no subscription found for topic: %s", subjectPattern)
+	}
 
-	if _, exists := p.subscriptions[subjectPattern]; exists {
-		// Note: We don't delete the subscription from Pub/Sub as it might be used by other clients
-		// We just stop receiving messages by canceling the context for this specific subscription
-		delete(p.subscriptions, subjectPattern)
-		delete(p.handlers, subjectPattern)
+	// Note: We don't delete the subscription from Pub/Sub as it might be used by other clients
+	// We just stop receiving messages by canceling the context for this specific subscription
+	delete(p.subscriptions, subjectPattern)
+	delete(p.handlers, subjectPattern)
+	p.mu.Unlock()
 
-		log.Printf("Unsubscribed from topic: %s", subjectPattern)
-		return nil
-	}
+	// Receive returns after the running handlers, which may use the provider
+	sub.cancel()
+	<-sub.done
 
-	return fmt.Errorf("no subscription found for topic: %s", subjectPattern)
+	log.Printf("Unsubscribed from topic: %s", subjectPattern)
+	return nil
 }
 
 // Close closes the connection to Pub/Sub
 func (p *PubSubProvider) Close() error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	if !p.connected {
+		p.mu.Unlock()
+		return nil
+	}
 
-	if p.connected {
-		// Cancel context to stop all receiving operations
-		p.cancel()
+	// Cancel context to stop all receiving operations
+	p.cancel()
 
-		// Close all topics
-		for _, topic := range p.topics {
-			topic.Stop()
-		}
+	subscriptions := p.subscriptions
+	topics := p.topics
+	client := p.client
 
-		// Close client
-		if p.client != nil {
-			err := p.client.Close()
-			if err != nil {
-				log.Printf("Error closing Pub/Sub client: %v", err)
-			}
-		}
+	p.connected = false
+	p.client = nil
+	p.subscriptions = make(map[string]*pubsubSubscription)
+	p.topics = make(map[string]*pubsub.Topic)
+	p.handlers = make(map[string]messaging.MessageHandler)
+	p.mu.Unlock()
 
-		p.connected = false
-		p.subscriptions = make(map[string]*pubsub.Subscription)
-		p.topics = make(map[string]*pubsub.Topic)
-		p.handlers = make(map[string]messaging.MessageHandler)
+	// Wait for Receive to return before closing the client
+	for _, sub := range subscriptions {
+		<-sub.done
+	}
 
-		log.Println("Disconnected from Google Cloud Pub/Sub")
+	// Close all topics
+	for _, topic := range topics {
+		topic.Stop()
+	}
+
+	// Close client
+	if client != nil {
+		err := client.Close()
+		if err != nil {
+			log.Printf("Error closing Pub/Sub client: %v", err)
+		}
 	}
 
+	log.Println("Disconnected from Google Cloud Pub/Sub")
 	return nil
 }
 
@@ -203,6 +252,87 @@ func (p *PubSubProvider) GetProviderType() messaging.ProviderType {
 	return messaging.ProviderPubSub
 }
 
+// Capabilities returns what the provider supports: subjects are topics with
+// attributes as headers, and each topic has one subscription of the app that
+// keeps messages while it is not receiving. Delivery is not ordered.
+func (p *PubSubProvider) Capabilities() messaging.Capabilities {
+	return messaging.Capabilities{
+		Wildcards:   messaging.WildcardsNone,
+		Names:       messaging.NamesResourceID,
+		SubjectKind: "topic",
+		Headers:     true,
+		Ack:         messaging.AckAfterHandler,
+		Replay:      true,
+		Admin:       true,
+	}
+}
+
+// Explore lists the topics and subscriptions of the project through the
+// admin API of the client; adminURL is not used
+func (p *PubSubProvider) Explore(ctx context.Context, adminURL string) ([]messaging.Entity, error) {
+	p.mu.RLock()
+	client := p.client
+	connected := p.connected
+	p.mu.RUnlock()
+	if !connected {
+		return nil, fmt.Errorf("not connected to Pub/Sub")
+	}
+
+	// Subscriptions come first to count them by topic
+	var subscriptions []messaging.Entity
+	counts := make(map[string]int64)
+	subs := client.Subscriptions(ctx)
+	for {
+		sub, err := subs.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+		config, err := sub.Config(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subscription %s: %w", sub.ID(), err)
+		}
+
+		detail := "detached"
+		if config.Topic != nil {
+			counts[config.Topic.ID()]++
+			detail = "topic " + config.Topic.ID()
+		}
+		subscriptions = append(subscriptions, messaging.Entity{
+			Kind:   messaging.EntitySubscription,
+			Name:   sub.ID(),
+			Detail: detail,
+		})
+	}
+
+	var entities []messaging.Entity
+	topics := client.Topics(ctx)
+	for {
+		topic, err := topics.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list topics: %w", err)
+		}
+		count := counts[topic.ID()]
+		detail := fmt.Sprintf("%d subscriptions", count)
+		if count == 1 {
+			detail = "1 subscription"
+		}
+		entities = append(entities, messaging.Entity{
+			Kind:      messaging.EntityTopic,
+			Name:      topic.ID(),
+			Detail:    detail,
+			Publish:   topic.ID(),
+			Subscribe: topic.ID(),
+		})
+	}
+	return append(entities, subscriptions...), nil
+}
+
 // Helper methods
 
 func (p *PubSubProvider) parseProjectID(url string) string {
@@ -253,27 +383,50 @@ func (p *PubSubProvider) parseEmulatorHost(url string) string {
 	return "localhost:8085" // Default
 }
 
-func (p *PubSubProvider) getOrCreateTopic(topicName string) (*pubsub.Topic, error) {
-	if topic, exists := p.topics[topicName]; exists {
+// topic returns the topic of a subject, creating it when it does not exist.
+// Topics are cached per connection. The lookup and creation run without
+// holding p.mu, so a slow call doesn't block other publishers.
+func (p *PubSubProvider) topic(topicName string) (*pubsub.Topic, error) {
+	p.mu.RLock()
+	if !p.connected {
+		p.mu.RUnlock()
+		return nil, fmt.Errorf("not connected to Pub/Sub")
+	}
+	topic, cached := p.topics[topicName]
+	client, ctx := p.client, p.ctx
+	p.mu.RUnlock()
+	if cached {
 		return topic, nil
 	}
 
-	topic := p.client.Topic(topicName)
-
-	// Check if topic exists, create if not
-	exists, err := topic.Exists(p.ctx)
+	topic = client.Topic(topicName)
+	exists, err := topic.Exists(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if topic exists: %v", err)
+		return nil, fmt.Errorf("failed to check if topic %s exists: %v", topicName, err)
 	}
-
 	if !exists {
-		topic, err = p.client.CreateTopic(p.ctx, topicName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create topic: %v", err)
+		created, err := client.CreateTopic(ctx, topicName)
+		switch {
+		case err == nil:
+			topic = created
+			log.Printf("Created topic: %s", topicName)
+		case status.Code(err) == codes.AlreadyExists:
+			// Another publisher created it since the check
+		default:
+			return nil, fmt.Errorf("failed to create topic %s: %v", topicName, err)
 		}
-		log.Printf("Created topic: %s", topicName)
 	}
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != client {
+		topic.Stop()
+		return nil, fmt.Errorf("not connected to Pub/Sub")
+	}
+	if existing, ok := p.topics[topicName]; ok {
+		topic.Stop()
+		return existing, nil
+	}
 	p.topics[topicName] = topic
 	return topic, nil
 }
@@ -299,3 +452,28 @@ func (p *PubSubProvider) getOrCreateSubscription(subscriptionName string, topic
 
 	return subscription, nil
 }
+
+// pubsubMessage converts a received Pub/Sub message, exposing its attributes as headers
+func pubsubMessage(topic string, msg *pubsub.Message) *messaging.Message {
+	headers := make(map[string]string, len(msg.Attributes)+2)
+	for key, value := range msg.Attributes {
+		headers[key] = value
+	}
+	headers["message-id"] = msg.ID
+	if msg.OrderingKey != "" {
+		headers["ordering-key"] = msg.OrderingKey
+	}
+
+	timestamp := msg.PublishTime
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return &messaging.Message{
+		Subject:   topic,
+		Data:      msg.Data,
+		Headers:   headers,
+		Provider:  messaging.ProviderPubSub,
+		Timestamp: timestamp.UnixNano(),
+	}
+}
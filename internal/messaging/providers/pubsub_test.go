@@ -0,0 +1,42 @@
+package providers
+
+import (
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/pubsub/pstest"
+)
+
+func TestPubSubConcurrentFirstPublish(t *testing.T) {
+	fake := pstest.NewServer()
+	t.Cleanup(func() { fake.Close() })
+	t.Setenv("PUBSUB_EMULATOR_HOST", fake.Addr)
+
+	p := NewPubSubProvider()
+	if err := p.Connect("concurrent"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	// Publishers that find the topic missing race to create it
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- p.Publish("orders", []byte("{}"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if got := len(fake.Messages()); got != 8 {
+		t.Errorf("published %d messages, want 8", got)
+	}
+}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -8,6 +9,8 @@ import (
 	"time"
 
 	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/topology"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -15,10 +18,21 @@ import (
 type RabbitMQProvider struct {
 	url           string
 	conn          *amqp.Connection
-	channel       *amqp.Channel
+	channel       amqpChannel
 	connected     bool
 	subscriptions map[string]*amqpSubscription
 	mutex         sync.RWMutex
+	onConnection  func(event messaging.ConnectionEvent, err error)
+}
+
+// amqpChannel is the part of *amqp.Channel the provider uses
+type amqpChannel interface {
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+	Close() error
 }
 
 type amqpSubscription struct {
@@ -82,26 +96,48 @@ func (r *RabbitMQProvider) handleConnectionErrors() {
 	connErrors := r.conn.NotifyClose(make(chan *amqp.Error))
 	channelErrors := r.channel.NotifyClose(make(chan *amqp.Error))
 
+	// A nil error means the connection was closed on purpose
+	var err *amqp.Error
 	select {
-	case err := <-connErrors:
+	case err = <-connErrors:
 		if err != nil {
 			log.Printf("RabbitMQ connection error: %s", err)
-			r.mutex.Lock()
-			r.connected = false
-			r.mutex.Unlock()
 		}
-	case err := <-channelErrors:
+	case err = <-channelErrors:
 		if err != nil {
 			log.Printf("RabbitMQ channel error: %s", err)
-			r.mutex.Lock()
-			r.connected = false
-			r.mutex.Unlock()
 		}
 	}
+	if err == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	r.connected = false
+	handler := r.onConnection
+	r.mutex.Unlock()
+
+	if handler != nil {
+		handler(messaging.ConnectionLost, err)
+	}
+}
+
+// OnConnectionEvent sets the handler of connection losses. RabbitMQ
+// connections are not restored automatically.
+func (r *RabbitMQProvider) OnConnectionEvent(handler func(event messaging.ConnectionEvent, err error)) {
+	r.mutex.Lock()
+	r.onConnection = handler
+	r.mutex.Unlock()
 }
 
 // Publish sends a message to the specified exchange/routing key
 func (r *RabbitMQProvider) Publish(subject string, data []byte) error {
+	return r.PublishWithHeaders(subject, data, nil)
+}
+
+// PublishWithHeaders sends a message with headers to the specified exchange/routing
+// key. Names of AMQP properties, such as content-type or reply-to, set the property.
+func (r *RabbitMQProvider) PublishWithHeaders(subject string, data []byte, headers map[string]string) error {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -138,19 +174,37 @@ func (r *RabbitMQProvider) Publish(subject string, data []byte) error {
 		routingKey, // routing key
 		false,      // mandatory
 		false,      // immediate
-		amqp.Publishing{
-			ContentType:  "text/plain",
-			Body:         data,
-			Timestamp:    time.Now(),
-			DeliveryMode: amqp.Persistent, // make message persistent
-		},
+		amqpPublishing(data, headers),
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
+	return nil
+}
 
-	log.Printf("Published message to RabbitMQ queue: %s", routingKey)
+// Reply publishes data to the reply-to queue of a received message through
+// the default exchange, keeping its correlation-id. The queue is not declared,
+// since reply queues are usually exclusive to the requester.
+func (r *RabbitMQProvider) Reply(msg *messaging.Message, data []byte, headers map[string]string) error {
+	if msg.Reply == "" {
+		return fmt.Errorf("message on %s has no reply-to", msg.Subject)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if !r.connected || r.channel == nil {
+		return fmt.Errorf("not connected to RabbitMQ server")
+	}
+
+	publishing := amqpPublishing(data, headers)
+	if publishing.CorrelationId == "" {
+		publishing.CorrelationId = msg.Headers["correlation-id"]
+	}
+	if err := r.channel.Publish("", msg.Reply, false, false, publishing); err != nil {
+		return fmt.Errorf("failed to publish reply to %s: %w", msg.Reply, err)
+	}
 	return nil
 }
 
@@ -181,15 +235,16 @@ func (r *RabbitMQProvider) Subscribe(subjectPattern string, handler messaging.Me
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
 
-	// Start consuming
+	// Start consuming with a tag of our own, which Unsubscribe cancels
+	consumerTag := "broker-ui-" + uuid.NewString()
 	msgs, err := r.channel.Consume(
-		queue.Name, // queue
-		"",         // consumer tag (auto-generated)
-		true,       // auto-ack
-		false,      // exclusive
-		false,      // no-local
-		false,      // no-wait
-		nil,        // args
+		queue.Name,  // queue
+		consumerTag, // consumer tag
+		true,        // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
 	)
 	if err != nil {
 		return fmt.Errorf("failed to register consumer: %w", err)
@@ -198,7 +253,7 @@ func (r *RabbitMQProvider) Subscribe(subjectPattern string, handler messaging.Me
 	// Create subscription
 	sub := &amqpSubscription{
 		queueName:    queue.Name,
-		consumerTag:  "", // auto-generated by RabbitMQ
+		consumerTag:  consumerTag,
 		handler:      handler,
 		deliveryChan: msgs,
 		done:         make(chan bool),
@@ -223,8 +278,15 @@ func (r *RabbitMQProvider) processMessages(sub *amqpSubscription, subjectPattern
 				return
 			}
 
-			// Call the handler with subject and data
-			sub.handler(msg.RoutingKey, msg.Body)
+			// Call the handler with the message and its properties
+			sub.handler(&messaging.Message{
+				Subject:   msg.RoutingKey,
+				Data:      msg.Body,
+				Headers:   amqpHeaders(msg),
+				Reply:     msg.ReplyTo,
+				Provider:  messaging.ProviderRabbitMQ,
+				Timestamp: time.Now().UnixNano(),
+			})
 
 		case <-sub.done:
 			log.Printf("Stopping message processing for queue: %s", subjectPattern)
@@ -243,8 +305,8 @@ func (r *RabbitMQProvider) Unsubscribe(subjectPattern string) error {
 		return fmt.Errorf("no subscription found for queue: %s", subjectPattern)
 	}
 
-	// Cancel the consumer
-	if r.channel != nil && sub.consumerTag != "" {
+	// Cancel the consumer, so the broker keeps further messages in the queue
+	if r.channel != nil {
 		err := r.channel.Cancel(sub.consumerTag, false)
 		if err != nil {
 			log.Printf("Warning: failed to cancel consumer for queue %s: %s", subjectPattern, err)
@@ -272,7 +334,7 @@ func (r *RabbitMQProvider) Close() error {
 
 	// Stop all subscriptions
 	for pattern, sub := range r.subscriptions {
-		if sub.consumerTag != "" && r.channel != nil {
+		if r.channel != nil {
 			r.channel.Cancel(sub.consumerTag, false)
 		}
 		close(sub.done)
@@ -308,3 +370,104 @@ func (r *RabbitMQProvider) IsConnected() bool {
 func (r *RabbitMQProvider) GetProviderType() messaging.ProviderType {
 	return messaging.ProviderRabbitMQ
 }
+
+// Capabilities returns what the provider supports: subjects are durable
+// queues, so messages wait for the next subscriber, and consumers auto-ack.
+// It answers reply-to but can't send requests.
+func (r *RabbitMQProvider) Capabilities() messaging.Capabilities {
+	return messaging.Capabilities{
+		Wildcards:   messaging.WildcardsNone,
+		Names:       messaging.NamesAny,
+		SubjectKind: "queue",
+		Headers:     true,
+		Reply:       true,
+		Ack:         messaging.AckOnDelivery,
+		Replay:      true,
+		Ordered:     true,
+		Admin:       true,
+	}
+}
+
+// Explore lists the exchanges and queues of the virtual host through the
+// management API, by default on port 15672 of the server host with the
+// credentials of the connection
+func (r *RabbitMQProvider) Explore(ctx context.Context, adminURL string) ([]messaging.Entity, error) {
+	r.mutex.RLock()
+	url := r.url
+	r.mutex.RUnlock()
+	if url == "" {
+		return nil, fmt.Errorf("not connected to RabbitMQ")
+	}
+
+	managementURL, user, password, vhost, err := topology.RabbitManagementURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if adminURL != "" {
+		managementURL = adminURL
+	}
+	return topology.RabbitManagement(ctx, managementURL, user, password, vhost)
+}
+
+// amqpHeaders collects the AMQP headers table and the common message properties
+func amqpHeaders(msg amqp.Delivery) map[string]string {
+	headers := make(map[string]string)
+	for key, value := range msg.Headers {
+		headers[key] = fmt.Sprint(value)
+	}
+
+	properties := map[string]string{
+		"content-type":     msg.ContentType,
+		"content-encoding": msg.ContentEncoding,
+		"correlation-id":   msg.CorrelationId,
+		"reply-to":         msg.ReplyTo,
+		"message-id":       msg.MessageId,
+		"type":             msg.Type,
+		"app-id":           msg.AppId,
+		"exchange":         msg.Exchange,
+	}
+	for key, value := range properties {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// amqpPublishing builds a persistent message, mapping property names to AMQP
+// properties and everything else to the headers table
+func amqpPublishing(data []byte, headers map[string]string) amqp.Publishing {
+	publishing := amqp.Publishing{
+		ContentType:  "text/plain",
+		Body:         data,
+		Timestamp:    time.Now(),
+		DeliveryMode: amqp.Persistent, // make message persistent
+	}
+
+	for key, value := range headers {
+		switch strings.ToLower(key) {
+		case "content-type":
+			publishing.ContentType = value
+		case "correlation-id":
+			publishing.CorrelationId = value
+		case "reply-to":
+			publishing.ReplyTo = value
+		case "message-id":
+			publishing.MessageId = value
+		case "type":
+			publishing.Type = value
+		case "app-id":
+			publishing.AppId = value
+		default:
+			if publishing.Headers == nil {
+				publishing.Headers = amqp.Table{}
+			}
+			publishing.Headers[key] = value
+		}
+	}
+	return publishing
+}
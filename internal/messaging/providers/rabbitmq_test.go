@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeChannel records the consumers of a channel without a broker
+type fakeChannel struct {
+	mutex     sync.Mutex
+	consumers map[string]chan amqp.Delivery
+	cancelled []string
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{consumers: make(map[string]chan amqp.Delivery)}
+}
+
+func (c *fakeChannel) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	return receiver
+}
+
+func (c *fakeChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	return amqp.Queue{Name: name}, nil
+}
+
+func (c *fakeChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	return nil
+}
+
+func (c *fakeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	deliveries := make(chan amqp.Delivery, 1)
+	c.consumers[consumer] = deliveries
+	return deliveries, nil
+}
+
+func (c *fakeChannel) Cancel(consumer string, noWait bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cancelled = append(c.cancelled, consumer)
+	if deliveries, ok := c.consumers[consumer]; ok {
+		close(deliveries)
+		delete(c.consumers, consumer)
+	}
+	return nil
+}
+
+func (c *fakeChannel) Close() error {
+	return nil
+}
+
+// deliver sends a message to the consumer with the given tag, reporting
+// whether it is still registered
+func (c *fakeChannel) deliver(consumer string, msg amqp.Delivery) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	deliveries, ok := c.consumers[consumer]
+	if ok {
+		deliveries <- msg
+	}
+	return ok
+}
+
+func TestRabbitMQUnsubscribeCancelsItsConsumer(t *testing.T) {
+	channel := newFakeChannel()
+	r := NewRabbitMQProvider()
+	r.channel = channel
+	r.connected = true
+
+	received := make(chan string, 1)
+	for _, queue := range []string{"orders", "invoices"} {
+		if err := r.Subscribe(queue, func(msg *messaging.Message) {
+			received <- string(msg.Data)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ordersTag := r.subscriptions["orders"].consumerTag
+	invoicesTag := r.subscriptions["invoices"].consumerTag
+	if ordersTag == "" || ordersTag == invoicesTag {
+		t.Fatalf("consumer tags = %q and %q, want distinct tags", ordersTag, invoicesTag)
+	}
+
+	if err := r.Unsubscribe("orders"); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.cancelled) != 1 || channel.cancelled[0] != ordersTag {
+		t.Fatalf("cancelled consumers = %v, want [%s]", channel.cancelled, ordersTag)
+	}
+
+	// The other queue keeps its consumer
+	if !channel.deliver(invoicesTag, amqp.Delivery{RoutingKey: "invoices", Body: []byte("paid")}) {
+		t.Fatal("invoices consumer was cancelled")
+	}
+	select {
+	case data := <-received:
+		if data != "paid" {
+			t.Errorf("received %q, want paid", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("invoices message was not delivered")
+	}
+
+	// Subscribing again registers a new consumer
+	if err := r.Subscribe("orders", func(*messaging.Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	if tag := r.subscriptions["orders"].consumerTag; tag == ordersTag {
+		t.Errorf("resubscribing reused consumer tag %s", tag)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.consumers) != 0 {
+		t.Errorf("consumers left after Close: %v", channel.consumers)
+	}
+}
@@ -0,0 +1,341 @@
+package messaging
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// BackpressurePolicy decides what happens to a message that arrives while a
+// subscription queue is full
+type BackpressurePolicy string
+
+const (
+	// PolicyDropOldest evicts the oldest queued message to make room
+	PolicyDropOldest BackpressurePolicy = "drop-oldest"
+	// PolicyDropNewest discards the incoming message
+	PolicyDropNewest BackpressurePolicy = "drop-newest"
+	// PolicyBlock waits for room up to the block timeout, then discards the incoming message
+	PolicyBlock BackpressurePolicy = "block"
+	// PolicySpillToDisk writes overflow to a temporary file and reads it back in order
+	PolicySpillToDisk BackpressurePolicy = "spill-to-disk"
+)
+
+// BackpressurePolicies lists all supported policies
+var BackpressurePolicies = []BackpressurePolicy{
+	PolicyDropOldest,
+	PolicyDropNewest,
+	PolicyBlock,
+	PolicySpillToDisk,
+}
+
+const (
+	// DefaultQueueCapacity is the in-memory capacity of a subscription queue
+	DefaultQueueCapacity = 1000
+	// DefaultBlockTimeout is how long PolicyBlock waits for room
+	DefaultBlockTimeout = time.Second
+)
+
+// QueueOptions configures a MessageQueue
+type QueueOptions struct {
+	Policy       BackpressurePolicy
+	Capacity     int
+	BlockTimeout time.Duration
+	// SpillDir is the directory for spill files; the system temp dir when empty
+	SpillDir string
+}
+
+// QueueStats holds the accounting of a MessageQueue. At any time
+// Received == Delivered + Dropped + Pending.
+type QueueStats struct {
+	Received  uint64
+	Delivered uint64
+	Dropped   uint64
+	Spilled   uint64
+	Pending   int
+}
+
+// MessageQueue is a bounded FIFO between a provider callback and a consumer
+// that applies a BackpressurePolicy when the consumer falls behind
+type MessageQueue struct {
+	options QueueOptions
+	items   []Message
+	closed  bool
+	stats   QueueStats
+	mutex   sync.Mutex
+
+	itemsReady chan struct{}
+	spaceReady chan struct{}
+
+	spillFile       *os.File
+	spillCount      int
+	spillReadOffset int64
+	spillSize       int64
+}
+
+// NewMessageQueue creates a queue with the given options, filling in defaults
+func NewMessageQueue(options QueueOptions) (*MessageQueue, error) {
+	if options.Policy == "" {
+		options.Policy = PolicyDropOldest
+	}
+	if !IsValidBackpressurePolicy(options.Policy) {
+		return nil, fmt.Errorf("unsupported backpressure policy: %s", options.Policy)
+	}
+	if options.Capacity < 1 {
+		options.Capacity = DefaultQueueCapacity
+	}
+	if options.BlockTimeout <= 0 {
+		options.BlockTimeout = DefaultBlockTimeout
+	}
+
+	return &MessageQueue{
+		options:    options,
+		itemsReady: make(chan struct{}, 1),
+		spaceReady: make(chan struct{}, 1),
+	}, nil
+}
+
+// IsValidBackpressurePolicy reports whether policy is a supported policy
+func IsValidBackpressurePolicy(policy BackpressurePolicy) bool {
+	for _, p := range BackpressurePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the backpressure policy of the queue
+func (q *MessageQueue) Policy() BackpressurePolicy {
+	return q.options.Policy
+}
+
+// Push enqueues a message and reports whether it was accepted. Depending on
+// the policy a full queue drops a message, blocks, or spills to disk.
+func (q *MessageQueue) Push(msg Message) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false
+	}
+	q.stats.Received++
+
+	switch q.options.Policy {
+	case PolicyDropOldest:
+		if len(q.items) >= q.options.Capacity {
+			q.items = q.items[1:]
+			q.stats.Dropped++
+		}
+
+	case PolicyDropNewest:
+		if len(q.items) >= q.options.Capacity {
+			q.stats.Dropped++
+			return false
+		}
+
+	case PolicyBlock:
+		if !q.waitForSpace() {
+			q.stats.Dropped++
+			return false
+		}
+
+	case PolicySpillToDisk:
+		// Once spilling starts, new messages go to disk until it drains to keep order
+		if len(q.items) >= q.options.Capacity || q.spillCount > 0 {
+			if err := q.spill(msg); err != nil {
+				q.stats.Dropped++
+				return false
+			}
+			q.stats.Spilled++
+			q.signal(q.itemsReady)
+			return true
+		}
+	}
+
+	q.items = append(q.items, msg)
+	q.signal(q.itemsReady)
+	return true
+}
+
+// waitForSpace waits until the queue has room or the block timeout expires.
+// It must be called with the mutex held and returns with it held.
+func (q *MessageQueue) waitForSpace() bool {
+	if len(q.items) < q.options.Capacity {
+		return true
+	}
+
+	timer := time.NewTimer(q.options.BlockTimeout)
+	defer timer.Stop()
+
+	for len(q.items) >= q.options.Capacity {
+		q.mutex.Unlock()
+		select {
+		case <-q.spaceReady:
+		case <-timer.C:
+			q.mutex.Lock()
+			return len(q.items) < q.options.Capacity && !q.closed
+		}
+		q.mutex.Lock()
+		if q.closed {
+			return false
+		}
+	}
+	return true
+}
+
+// Pop blocks until a message is available and returns it. It returns false
+// once the queue has been closed.
+func (q *MessageQueue) Pop() (Message, bool) {
+	for {
+		q.mutex.Lock()
+		if q.closed {
+			q.mutex.Unlock()
+			return Message{}, false
+		}
+
+		msg, ok := q.take()
+		if ok {
+			q.stats.Delivered++
+			if q.pendingLocked() > 0 {
+				q.signal(q.itemsReady)
+			}
+			q.signal(q.spaceReady)
+			q.mutex.Unlock()
+			return msg, true
+		}
+		q.mutex.Unlock()
+
+		<-q.itemsReady
+	}
+}
+
+// take removes the next message from memory or the spill file. It must be
+// called with the mutex held.
+func (q *MessageQueue) take() (Message, bool) {
+	if len(q.items) > 0 {
+		msg := q.items[0]
+		q.items = q.items[1:]
+		return msg, true
+	}
+
+	if q.spillCount > 0 {
+		msg, err := q.unspill()
+		if err == nil {
+			return msg, true
+		}
+		// The spill file is unreadable; account for what it held as lost
+		q.stats.Dropped += uint64(q.spillCount)
+		q.resetSpill()
+	}
+
+	return Message{}, false
+}
+
+// Stats returns a snapshot of the queue accounting
+func (q *MessageQueue) Stats() QueueStats {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stats := q.stats
+	stats.Pending = q.pendingLocked()
+	return stats
+}
+
+// pendingLocked returns the number of queued messages; the mutex must be held
+func (q *MessageQueue) pendingLocked() int {
+	return len(q.items) + q.spillCount
+}
+
+// Close releases the queue, unblocks waiting callers and removes any spill file
+func (q *MessageQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.itemsReady)
+	close(q.spaceReady)
+
+	if q.spillFile != nil {
+		name := q.spillFile.Name()
+		q.spillFile.Close()
+		q.spillFile = nil
+		return os.Remove(name)
+	}
+	return nil
+}
+
+// signal wakes up one waiter on ch without blocking
+func (q *MessageQueue) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// spill appends a length-prefixed JSON record to the spill file
+func (q *MessageQueue) spill(msg Message) error {
+	if q.spillFile == nil {
+		file, err := os.CreateTemp(q.options.SpillDir, "broker-ui-spill-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		q.spillFile = file
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode spilled message: %w", err)
+	}
+
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[4:], data)
+
+	if _, err := q.spillFile.WriteAt(record, q.spillSize); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	q.spillSize += int64(len(record))
+	q.spillCount++
+	return nil
+}
+
+// unspill reads the next record from the spill file
+func (q *MessageQueue) unspill() (Message, error) {
+	var msg Message
+
+	header := make([]byte, 4)
+	if _, err := q.spillFile.ReadAt(header, q.spillReadOffset); err != nil {
+		return msg, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := q.spillFile.ReadAt(data, q.spillReadOffset+4); err != nil {
+		return msg, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg, fmt.Errorf("failed to decode spilled message: %w", err)
+	}
+
+	q.spillReadOffset += int64(4 + len(data))
+	q.spillCount--
+	if q.spillCount == 0 {
+		q.resetSpill()
+	}
+	return msg, nil
+}
+
+// resetSpill truncates the spill file once everything in it has been read
+func (q *MessageQueue) resetSpill() {
+	q.spillCount = 0
+	q.spillReadOffset = 0
+	q.spillSize = 0
+	if q.spillFile != nil {
+		q.spillFile.Truncate(0)
+	}
+}
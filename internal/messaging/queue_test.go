@@ -0,0 +1,237 @@
+package messaging
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestQueue(t *testing.T, options QueueOptions) *MessageQueue {
+	t.Helper()
+	if options.SpillDir == "" {
+		options.SpillDir = t.TempDir()
+	}
+	q, err := NewMessageQueue(options)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func testMessage(i int) Message {
+	return Message{Subject: "load.test", Data: []byte(strconv.Itoa(i))}
+}
+
+func checkAccounting(t *testing.T, stats QueueStats, received uint64) {
+	t.Helper()
+	if stats.Received != received {
+		t.Errorf("Received = %d, want %d", stats.Received, received)
+	}
+	if got := stats.Delivered + stats.Dropped + uint64(stats.Pending); got != stats.Received {
+		t.Errorf("Delivered(%d) + Dropped(%d) + Pending(%d) = %d, want Received %d",
+			stats.Delivered, stats.Dropped, stats.Pending, got, stats.Received)
+	}
+}
+
+// pushConcurrently pushes producers*perProducer messages from several goroutines
+func pushConcurrently(q *MessageQueue, producers, perProducer int) {
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.Push(testMessage(p*perProducer + i))
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+func TestDropOldestKeepsNewestMessages(t *testing.T) {
+	q := newTestQueue(t, QueueOptions{Policy: PolicyDropOldest, Capacity: 10})
+
+	for i := 0; i < 100; i++ {
+		q.Push(testMessage(i))
+	}
+
+	stats := q.Stats()
+	checkAccounting(t, stats, 100)
+	if stats.Dropped != 90 || stats.Pending != 10 {
+		t.Fatalf("Dropped = %d, Pending = %d, want 90 and 10", stats.Dropped, stats.Pending)
+	}
+
+	msg, _ := q.Pop()
+	if string(msg.Data) != "90" {
+		t.Errorf("first message = %s, want 90", msg.Data)
+	}
+}
+
+func TestDropNewestKeepsOldestMessages(t *testing.T) {
+	q := newTestQueue(t, QueueOptions{Policy: PolicyDropNewest, Capacity: 10})
+
+	accepted := 0
+	for i := 0; i < 100; i++ {
+		if q.Push(testMessage(i)) {
+			accepted++
+		}
+	}
+
+	stats := q.Stats()
+	checkAccounting(t, stats, 100)
+	if accepted != 10 || stats.Dropped != 90 {
+		t.Fatalf("accepted = %d, Dropped = %d, want 10 and 90", accepted, stats.Dropped)
+	}
+
+	msg, _ := q.Pop()
+	if string(msg.Data) != "0" {
+		t.Errorf("first message = %s, want 0", msg.Data)
+	}
+}
+
+func TestBlockDropsAfterTimeout(t *testing.T) {
+	q := newTestQueue(t, QueueOptions{Policy: PolicyBlock, Capacity: 1, BlockTimeout: 10 * time.Millisecond})
+
+	if !q.Push(testMessage(0)) {
+		t.Fatal("first push should be accepted")
+	}
+
+	start := time.Now()
+	if q.Push(testMessage(1)) {
+		t.Fatal("push into a full queue without a consumer should time out")
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("push returned after %v, want it to block for the timeout", elapsed)
+	}
+
+	stats := q.Stats()
+	checkAccounting(t, stats, 2)
+	if stats.Dropped != 1 {
+		t.Errorf("Dropped = %d, want 1", stats.Dropped)
+	}
+}
+
+func TestPolicyAccountingUnderLoad(t *testing.T) {
+	const producers, perProducer = 8, 2000
+
+	for _, policy := range BackpressurePolicies {
+		t.Run(string(policy), func(t *testing.T) {
+			q := newTestQueue(t, QueueOptions{Policy: policy, Capacity: 50, BlockTimeout: time.Second})
+
+			var delivered uint64
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					if _, ok := q.Pop(); !ok {
+						return
+					}
+					delivered++
+					if delivered%100 == 0 {
+						time.Sleep(time.Millisecond)
+					}
+				}
+			}()
+
+			pushConcurrently(q, producers, perProducer)
+
+			// Let the consumer drain what was accepted
+			deadline := time.Now().Add(10 * time.Second)
+			for q.Stats().Pending > 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			stats := q.Stats()
+			q.Close()
+			<-done
+
+			checkAccounting(t, stats, producers*perProducer)
+			if stats.Pending != 0 {
+				t.Fatalf("Pending = %d after draining", stats.Pending)
+			}
+			if delivered != stats.Delivered {
+				t.Errorf("consumer saw %d messages, queue reports %d delivered", delivered, stats.Delivered)
+			}
+
+			switch policy {
+			case PolicySpillToDisk, PolicyBlock:
+				if stats.Dropped != 0 {
+					t.Errorf("Dropped = %d, want no loss for %s", stats.Dropped, policy)
+				}
+			}
+			if policy == PolicySpillToDisk && stats.Spilled == 0 {
+				t.Error("expected some messages to spill to disk")
+			}
+		})
+	}
+}
+
+func TestSpillToDiskPreservesOrder(t *testing.T) {
+	q := newTestQueue(t, QueueOptions{Policy: PolicySpillToDisk, Capacity: 5})
+
+	for i := 0; i < 100; i++ {
+		if !q.Push(testMessage(i)) {
+			t.Fatalf("push %d rejected", i)
+		}
+	}
+
+	stats := q.Stats()
+	checkAccounting(t, stats, 100)
+	if stats.Spilled != 95 {
+		t.Errorf("Spilled = %d, want 95", stats.Spilled)
+	}
+
+	for i := 0; i < 100; i++ {
+		msg, ok := q.Pop()
+		if !ok {
+			t.Fatalf("queue closed at message %d", i)
+		}
+		if string(msg.Data) != strconv.Itoa(i) {
+			t.Fatalf("message %d = %s, want in-order delivery", i, msg.Data)
+		}
+		// Interleave pushes while the spill file is draining
+		if i == 50 {
+			q.Push(testMessage(100))
+		}
+	}
+
+	msg, _ := q.Pop()
+	if string(msg.Data) != "100" {
+		t.Errorf("last message = %s, want 100", msg.Data)
+	}
+	checkAccounting(t, q.Stats(), 101)
+}
+
+func TestCloseUnblocksPop(t *testing.T) {
+	q := newTestQueue(t, QueueOptions{})
+
+	done := make(chan bool)
+	go func() {
+		_, ok := q.Pop()
+		done <- ok
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Pop returned a message from an empty closed queue")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pop did not return after Close")
+	}
+
+	if q.Push(testMessage(0)) {
+		t.Error("Push accepted a message after Close")
+	}
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	if _, err := NewMessageQueue(QueueOptions{Policy: "sometimes"}); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,29 @@
+package messaging
+
+import "strings"
+
+// MatchSubject reports whether subject matches a NATS-style pattern, where
+// tokens are separated by dots, "*" matches exactly one token and a trailing
+// ">" matches one or more tokens
+func MatchSubject(pattern, subject string) bool {
+	if pattern == subject {
+		return true
+	}
+
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i == len(patternTokens)-1 && len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,49 @@
+package messaging
+
+import "context"
+
+// EntityKind is what an entity found on a broker is
+type EntityKind string
+
+const (
+	EntitySubject      EntityKind = "subject"
+	EntityStream       EntityKind = "stream"
+	EntityTopic        EntityKind = "topic"
+	EntityExchange     EntityKind = "exchange"
+	EntityQueue        EntityKind = "queue"
+	EntitySubscription EntityKind = "subscription"
+	EntityConnection   EntityKind = "connection"
+)
+
+// EntityKinds lists the kinds in display order
+var EntityKinds = []EntityKind{
+	EntitySubject,
+	EntityStream,
+	EntityTopic,
+	EntityExchange,
+	EntityQueue,
+	EntitySubscription,
+	EntityConnection,
+}
+
+// Entity is a subject, stream, topic, queue or other part of a broker's topology
+type Entity struct {
+	Kind EntityKind
+	Name string
+	// Detail summarizes the entity, such as its message and consumer counts
+	Detail string
+	// Publish is the subject that publishes to the entity, empty when the
+	// provider can't publish to it
+	Publish string
+	// Subscribe is the pattern that receives from the entity, empty when the
+	// provider can't subscribe to it
+	Subscribe string
+}
+
+// Explorer is implemented by providers that can list the topology of their
+// broker, usually through an admin or monitoring API
+type Explorer interface {
+	// Explore lists the entities of the broker. adminURL is the address of
+	// the admin or monitoring API; empty derives it from the connection URL.
+	Explore(ctx context.Context, adminURL string) ([]Entity, error)
+}
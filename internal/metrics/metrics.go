@@ -0,0 +1,243 @@
+// Package metrics exposes captured traffic and connection state in the
+// Prometheus text format.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "broker_ui"
+
+// Metrics records traffic per server, subscription and subject. A nil
+// *Metrics is valid and records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	received       *prometheus.CounterVec
+	receivedBytes  *prometheus.CounterVec
+	messageSize    *prometheus.HistogramVec
+	published      *prometheus.CounterVec
+	publishedBytes *prometheus.CounterVec
+	publishErrors  *prometheus.CounterVec
+	publishLatency *prometheus.HistogramVec
+	disconnects    *prometheus.CounterVec
+	reconnects     *prometheus.CounterVec
+
+	connectedDesc *prometheus.Desc
+	droppedDesc   *prometheus.Desc
+	pendingDesc   *prometheus.Desc
+
+	mutex     sync.RWMutex
+	providers map[messaging.MessagingProvider]server
+	queues    map[string]queue
+}
+
+// server labels a connected provider
+type server struct {
+	name         string
+	providerType messaging.ProviderType
+}
+
+// queue is a subscription queue read at scrape time
+type queue struct {
+	server string
+	queue  *messaging.MessageQueue
+}
+
+// New creates the metrics and registers them with a new registry
+func New() *Metrics {
+	subscriptionLabels := []string{"server", "subscription", "subject"}
+	publishLabels := []string{"server", "subject"}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "messages_received_total",
+			Help: "Messages received by subscriptions.",
+		}, subscriptionLabels),
+		receivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "received_bytes_total",
+			Help: "Payload bytes received by subscriptions.",
+		}, subscriptionLabels),
+		messageSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "received_message_size_bytes",
+			Help:    "Payload size of received messages.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		}, subscriptionLabels),
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "messages_published_total",
+			Help: "Messages published successfully.",
+		}, publishLabels),
+		publishedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "published_bytes_total",
+			Help: "Payload bytes published successfully.",
+		}, publishLabels),
+		publishErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "publish_errors_total",
+			Help: "Publish attempts that failed.",
+		}, publishLabels),
+		publishLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "publish_duration_seconds",
+			Help:    "Time taken by the provider to publish a message.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 9),
+		}, publishLabels),
+		disconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "disconnects_total",
+			Help: "Connections lost unexpectedly.",
+		}, []string{"server"}),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "reconnects_total",
+			Help: "Connections restored by the provider after a loss.",
+		}, []string{"server"}),
+		connectedDesc: prometheus.NewDesc(namespace+"_connected",
+			"Whether the server connection is up (1) or down (0).", []string{"server", "provider"}, nil),
+		droppedDesc: prometheus.NewDesc(namespace+"_messages_dropped_total",
+			"Messages dropped by the subscription's backpressure policy.", []string{"server", "subscription"}, nil),
+		pendingDesc: prometheus.NewDesc(namespace+"_messages_pending",
+			"Messages waiting in the subscription queue.", []string{"server", "subscription"}, nil),
+		providers: make(map[messaging.MessagingProvider]server),
+		queues:    make(map[string]queue),
+	}
+
+	m.registry.MustRegister(
+		m.received, m.receivedBytes, m.messageSize,
+		m.published, m.publishedBytes, m.publishErrors, m.publishLatency,
+		m.disconnects, m.reconnects,
+		stateCollector{m},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterProvider labels the traffic of a connected provider with a server name
+func (m *Metrics) RegisterProvider(provider messaging.MessagingProvider, name string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	m.providers[provider] = server{name: name, providerType: provider.GetProviderType()}
+	m.mutex.Unlock()
+}
+
+// UnregisterProvider stops reporting a provider's connection state
+func (m *Metrics) UnregisterProvider(provider messaging.MessagingProvider) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	delete(m.providers, provider)
+	m.mutex.Unlock()
+}
+
+// RegisterQueue reports the drops and backlog of a subscription queue
+func (m *Metrics) RegisterQueue(provider messaging.MessagingProvider, subName string, q *messaging.MessageQueue) {
+	if m == nil || q == nil {
+		return
+	}
+	m.mutex.Lock()
+	m.queues[subName] = queue{server: m.providers[provider].name, queue: q}
+	m.mutex.Unlock()
+}
+
+// UnregisterQueue stops reporting a subscription queue
+func (m *Metrics) UnregisterQueue(subName string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	delete(m.queues, subName)
+	m.mutex.Unlock()
+}
+
+// MessageReceived counts a message received by a subscription
+func (m *Metrics) MessageReceived(provider messaging.MessagingProvider, subName, subject string, size int) {
+	if m == nil {
+		return
+	}
+	labels := prometheus.Labels{"server": m.serverName(provider), "subscription": subName, "subject": subject}
+	m.received.With(labels).Inc()
+	m.receivedBytes.With(labels).Add(float64(size))
+	m.messageSize.With(labels).Observe(float64(size))
+}
+
+// MessagePublished counts a publish attempt that took elapsed and failed if err is set
+func (m *Metrics) MessagePublished(provider messaging.MessagingProvider, subject string, size int, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	labels := prometheus.Labels{"server": m.serverName(provider), "subject": subject}
+	if err != nil {
+		m.publishErrors.With(labels).Inc()
+		return
+	}
+	m.published.With(labels).Inc()
+	m.publishedBytes.With(labels).Add(float64(size))
+	m.publishLatency.With(labels).Observe(elapsed.Seconds())
+}
+
+// ConnectionEvent counts connection losses and restorations of a provider
+func (m *Metrics) ConnectionEvent(provider messaging.MessagingProvider, event messaging.ConnectionEvent) {
+	if m == nil {
+		return
+	}
+	switch event {
+	case messaging.ConnectionLost:
+		m.disconnects.WithLabelValues(m.serverName(provider)).Inc()
+	case messaging.ConnectionRestored:
+		m.reconnects.WithLabelValues(m.serverName(provider)).Inc()
+	}
+}
+
+// serverName returns the server label of a provider, or its type when it
+// wasn't registered (e.g. a collection request connection)
+func (m *Metrics) serverName(provider messaging.MessagingProvider) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if s, ok := m.providers[provider]; ok {
+		return s.name
+	}
+	return string(provider.GetProviderType())
+}
+
+// stateCollector reports connection state and queue accounting at scrape time
+type stateCollector struct {
+	m *Metrics
+}
+
+func (c stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.m.connectedDesc
+	ch <- c.m.droppedDesc
+	ch <- c.m.pendingDesc
+}
+
+func (c stateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.m.mutex.RLock()
+	defer c.m.mutex.RUnlock()
+
+	for provider, s := range c.m.providers {
+		connected := 0.0
+		if provider.IsConnected() {
+			connected = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.m.connectedDesc, prometheus.GaugeValue, connected, s.name, string(s.providerType))
+	}
+
+	for subName, q := range c.m.queues {
+		stats := q.queue.Stats()
+		ch <- prometheus.MustNewConstMetric(c.m.droppedDesc, prometheus.CounterValue, float64(stats.Dropped), q.server, subName)
+		ch <- prometheus.MustNewConstMetric(c.m.pendingDesc, prometheus.GaugeValue, float64(stats.Pending), q.server, subName)
+	}
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// stubProvider is a connected provider that does nothing
+type stubProvider struct {
+	connected bool
+}
+
+func (p *stubProvider) Connect(string) error                             { return nil }
+func (p *stubProvider) Publish(string, []byte) error                     { return nil }
+func (p *stubProvider) Subscribe(string, messaging.MessageHandler) error { return nil }
+func (p *stubProvider) Unsubscribe(string) error                         { return nil }
+func (p *stubProvider) Close() error                                     { return nil }
+func (p *stubProvider) IsConnected() bool                                { return p.connected }
+func (p *stubProvider) GetProviderType() messaging.ProviderType          { return messaging.ProviderNATS }
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	return string(body)
+}
+
+func TestMetricsExposeTraffic(t *testing.T) {
+	m := New()
+	provider := &stubProvider{connected: true}
+	m.RegisterProvider(provider, "local")
+
+	m.MessageReceived(provider, "orders-sub", "orders.created", 100)
+	m.MessageReceived(provider, "orders-sub", "orders.created", 300)
+	m.MessagePublished(provider, "orders.created", 50, time.Millisecond, nil)
+	m.MessagePublished(provider, "orders.created", 50, time.Millisecond, errors.New("timeout"))
+	m.ConnectionEvent(provider, messaging.ConnectionLost)
+	m.ConnectionEvent(provider, messaging.ConnectionRestored)
+
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{Policy: messaging.PolicyDropNewest, Capacity: 1, SpillDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+	queue.Push(messaging.Message{Data: []byte("a")})
+	queue.Push(messaging.Message{Data: []byte("b")})
+	m.RegisterQueue(provider, "orders-sub", queue)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`broker_ui_messages_received_total{server="local",subject="orders.created",subscription="orders-sub"} 2`,
+		`broker_ui_received_bytes_total{server="local",subject="orders.created",subscription="orders-sub"} 400`,
+		`broker_ui_received_message_size_bytes_count{server="local",subject="orders.created",subscription="orders-sub"} 2`,
+		`broker_ui_messages_published_total{server="local",subject="orders.created"} 1`,
+		`broker_ui_publish_errors_total{server="local",subject="orders.created"} 1`,
+		`broker_ui_disconnects_total{server="local"} 1`,
+		`broker_ui_reconnects_total{server="local"} 1`,
+		`broker_ui_connected{provider="NATS",server="local"} 1`,
+		`broker_ui_messages_dropped_total{server="local",subscription="orders-sub"} 1`,
+		`broker_ui_messages_pending{server="local",subscription="orders-sub"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+
+	m.UnregisterProvider(provider)
+	m.UnregisterQueue("orders-sub")
+	if body := scrape(t, m); strings.Contains(body, "broker_ui_connected{") || strings.Contains(body, "broker_ui_messages_pending{") {
+		t.Error("unregistered provider and queue are still reported")
+	}
+}
+
+func TestNilMetricsRecordNothing(t *testing.T) {
+	var m *Metrics
+	provider := &stubProvider{}
+	m.RegisterProvider(provider, "local")
+	m.MessageReceived(provider, "sub", "subject", 1)
+	m.MessagePublished(provider, "subject", 1, time.Millisecond, nil)
+	m.ConnectionEvent(provider, messaging.ConnectionLost)
+}
+
+func TestServerStartStop(t *testing.T) {
+	s := NewServer(New())
+	if err := s.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Stop()
+
+	resp, err := http.Get("http://" + s.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+
+	s.Stop()
+	if s.Addr() != "" {
+		t.Error("Addr after Stop is not empty")
+	}
+}
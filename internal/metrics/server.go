@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultAddress is the default listen address of the metrics endpoint,
+// reachable from the local machine only
+const DefaultAddress = "127.0.0.1:9464"
+
+// Server serves /metrics over HTTP
+type Server struct {
+	metrics *Metrics
+	mutex   sync.Mutex
+	server  *http.Server
+	addr    string
+}
+
+// NewServer creates a stopped metrics server
+func NewServer(m *Metrics) *Server {
+	return &Server{metrics: m}
+}
+
+// Start listens on addr, replacing a running listener
+func (s *Server) Start(addr string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics endpoint stopped: %v", err)
+		}
+	}()
+
+	s.server = server
+	s.addr = listener.Addr().String()
+	log.Printf("Serving metrics on http://%s/metrics", s.addr)
+	return nil
+}
+
+// Stop closes the listener, if running
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stop()
+}
+
+func (s *Server) stop() {
+	if s.server == nil {
+		return
+	}
+	if err := s.server.Close(); err != nil {
+		log.Printf("Error stopping metrics endpoint: %v", err)
+	}
+	log.Printf("Stopped serving metrics on %s", s.addr)
+	s.server = nil
+	s.addr = ""
+}
+
+// Addr returns the address being served, or "" when stopped
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addr
+}
@@ -1,6 +1,13 @@
 package models
 
-import "github.com/devalexandre/broker-ui/internal/messaging"
+import (
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/alerts"
+	"github.com/devalexandre/broker-ui/internal/bridge"
+	"github.com/devalexandre/broker-ui/internal/filter"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
 
 // Server represents a messaging server configuration
 type Server struct {
@@ -8,6 +15,9 @@ type Server struct {
 	Name         string
 	URL          string
 	ProviderType messaging.ProviderType
+
+	// SchemaRegistryURL is the optional Confluent Schema Registry used for Avro payloads
+	SchemaRegistryURL string
 }
 
 // Topic represents a publisher topic
@@ -15,6 +25,10 @@ type Topic struct {
 	ID        int
 	ServerID  int
 	TopicName string
+	// JSONSchema is the optional contract payloads are validated against
+	JSONSchema string
+	// RejectInvalid refuses to publish invalid payloads instead of warning
+	RejectInvalid bool
 }
 
 // Subscription represents a subscriber configuration
@@ -23,6 +37,138 @@ type Subscription struct {
 	ServerID       int
 	SubName        string
 	SubjectPattern string
+	// BackpressurePolicy decides what happens when the UI falls behind
+	BackpressurePolicy messaging.BackpressurePolicy
+	// JSONSchema is the optional contract incoming messages are checked against
+	JSONSchema string
+	// Filters hide or highlight received messages client-side
+	Filters []filter.Rule
+	// Script is optional JavaScript run on each received message
+	Script        string
+	ScriptTimeout time.Duration
+}
+
+// ProtoSchema is a protobuf schema registered for a server, either .proto
+// source or a compiled FileDescriptorSet
+type ProtoSchema struct {
+	ID            int
+	ServerID      int
+	Name          string
+	Content       []byte
+	DescriptorSet bool
+}
+
+// ProtoMapping maps a subject/topic pattern to a protobuf message type
+type ProtoMapping struct {
+	ID             int
+	ServerID       int
+	SubjectPattern string
+	MessageType    string
+}
+
+// PayloadTemplate is a named, reusable payload for a topic, rendered with
+// text/template at send time
+type PayloadTemplate struct {
+	ID      int
+	TopicID int
+	Name    string
+	Body    string
+	// Variables are user-defined values available to the template
+	Variables map[string]string
+}
+
+// Collection groups saved publish requests into folders
+type Collection struct {
+	ID   int
+	Name string
+}
+
+// CollectionRequest is a saved publish request. ServerURL, Subject, header
+// values and Body are templates that may reference environment variables,
+// e.g. "nats://{{.user}}:{{.password}}@{{.host}}:4222".
+type CollectionRequest struct {
+	ID           int
+	CollectionID int
+	// Folder is a slash-separated folder path, empty for the collection root
+	Folder       string
+	Name         string
+	ProviderType messaging.ProviderType
+	ServerURL    string
+	Subject      string
+	Headers      map[string]string
+	Body         string
+}
+
+// Environment holds the variables requests are rendered with, such as the
+// hosts, subjects and credentials of dev, staging or prod
+type Environment struct {
+	ID        int
+	Name      string
+	Variables map[string]string
+}
+
+// AlertRule is an alert rule attached to a subscription
+type AlertRule struct {
+	ID             int
+	SubscriptionID int
+	Name           string
+	Kind           alerts.Kind
+	// Expression is the regular expression or JSONPath expression of message rules
+	Expression string
+	// Threshold is the silence in seconds or the rate in messages per second
+	Threshold float64
+	Cooldown  time.Duration
+	Enabled   bool
+}
+
+// Alert is an entry of the alert log
+type Alert struct {
+	ID       int
+	RuleID   int
+	RuleName string
+	SubName  string
+	Subject  string
+	Detail   string
+	// Payload summarizes the message that fired the rule, if any
+	Payload string
+	FiredAt time.Time
+}
+
+// Bridge forwards messages matching SourcePattern on the source server to
+// the target server
+type Bridge struct {
+	ID             int
+	Name           string
+	SourceServerID int
+	SourcePattern  string
+	TargetServerID int
+	// Mappings rewrite source subjects into target subjects
+	Mappings []bridge.Mapping
+	// Transform is an optional payload template
+	Transform string
+	// Script is optional JavaScript run on each message before it is mapped
+	Script        string
+	ScriptTimeout time.Duration
+}
+
+// Responder impersonates a service on a server, answering the messages
+// matching Pattern
+type Responder struct {
+	ID       int
+	Name     string
+	ServerID int
+	Pattern  string
+	// ReplyTemplate answers requests; EventSubject and EventTemplate publish
+	// a follow-up event
+	ReplyTemplate string
+	EventSubject  string
+	EventTemplate string
+	Headers       map[string]string
+	Delay         time.Duration
+	Jitter        time.Duration
+	// ErrorRate is the fraction of messages answered with ErrorTemplate
+	ErrorRate     float64
+	ErrorTemplate string
 }
 
 // Message represents a message sent or received
@@ -0,0 +1,94 @@
+// Package monitor keeps rolling time series of traffic rates for the dashboard.
+package monitor
+
+import "time"
+
+// Sample is a value observed at a point in time
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a fixed-capacity ring of samples that evicts the oldest sample
+// once it is full
+type Series struct {
+	samples []Sample
+	start   int
+	size    int
+}
+
+// NewSeries creates a series holding up to capacity samples
+func NewSeries(capacity int) *Series {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Series{samples: make([]Sample, capacity)}
+}
+
+// Add appends a sample
+func (s *Series) Add(t time.Time, value float64) {
+	if s.size < len(s.samples) {
+		s.samples[(s.start+s.size)%len(s.samples)] = Sample{Time: t, Value: value}
+		s.size++
+		return
+	}
+
+	s.samples[s.start] = Sample{Time: t, Value: value}
+	s.start = (s.start + 1) % len(s.samples)
+}
+
+// Len returns the number of samples
+func (s *Series) Len() int {
+	return s.size
+}
+
+// Window returns the values of the samples taken after now-window, oldest first
+func (s *Series) Window(now time.Time, window time.Duration) []float64 {
+	since := now.Add(-window)
+	values := make([]float64, 0, s.size)
+	for i := 0; i < s.size; i++ {
+		sample := s.samples[(s.start+i)%len(s.samples)]
+		if sample.Time.After(since) {
+			values = append(values, sample.Value)
+		}
+	}
+	return values
+}
+
+// Rate turns an increasing counter into a per-second rate series
+type Rate struct {
+	Series   *Series
+	last     float64
+	lastTime time.Time
+}
+
+// NewRate creates a rate tracker keeping up to capacity samples
+func NewRate(capacity int) *Rate {
+	return &Rate{Series: NewSeries(capacity)}
+}
+
+// Observe records the counter value at t and returns the rate since the
+// previous observation; the first observation only sets the baseline
+func (r *Rate) Observe(t time.Time, counter float64) float64 {
+	defer func() {
+		r.last = counter
+		r.lastTime = t
+	}()
+
+	if r.lastTime.IsZero() {
+		return 0
+	}
+	elapsed := t.Sub(r.lastTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	// A counter reset (e.g. after resubscribing) starts over from zero
+	delta := counter - r.last
+	if delta < 0 {
+		delta = counter
+	}
+	rate := delta / elapsed
+	r.Series.Add(t, rate)
+	return rate
+}
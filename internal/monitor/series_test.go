@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeriesWindowEvictsOldest(t *testing.T) {
+	s := NewSeries(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		s.Add(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	if s.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", s.Len())
+	}
+	got := s.Window(start.Add(4*time.Second), time.Hour)
+	if len(got) != 3 || got[0] != 2 || got[2] != 4 {
+		t.Errorf("Window = %v, want [2 3 4]", got)
+	}
+	if got := s.Window(start.Add(4*time.Second), 1500*time.Millisecond); len(got) != 2 {
+		t.Errorf("Window(1.5s) = %v, want the last two samples", got)
+	}
+}
+
+func TestRateFromCounter(t *testing.T) {
+	r := NewRate(10)
+	start := time.Now()
+
+	if rate := r.Observe(start, 100); rate != 0 || r.Series.Len() != 0 {
+		t.Fatalf("first observation = %v with %d samples, want a baseline only", rate, r.Series.Len())
+	}
+	if rate := r.Observe(start.Add(2*time.Second), 300); rate != 100 {
+		t.Errorf("rate = %v, want 100", rate)
+	}
+	// A reset counter counts from zero
+	if rate := r.Observe(start.Add(3*time.Second), 50); rate != 50 {
+		t.Errorf("rate after reset = %v, want 50", rate)
+	}
+}
@@ -0,0 +1,104 @@
+package payload
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InputEncoding is how a publisher's payload input should be interpreted
+type InputEncoding string
+
+const (
+	InputText   InputEncoding = "Text"
+	InputHex    InputEncoding = "Hex"
+	InputBase64 InputEncoding = "Base64"
+	InputFile   InputEncoding = "File"
+)
+
+// InputEncodings lists the encodings offered to publishers
+var InputEncodings = []InputEncoding{InputText, InputHex, InputBase64, InputFile}
+
+// DecodeInput converts publisher input into raw bytes. Hex input may contain
+// whitespace, colons and an optional 0x prefix; base64 accepts the standard
+// and URL alphabets with or without padding. File input is read by the caller.
+func DecodeInput(input string, encoding InputEncoding) ([]byte, error) {
+	switch encoding {
+	case InputText, "":
+		return []byte(input), nil
+
+	case InputHex:
+		cleaned := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) || r == ':' {
+				return -1
+			}
+			return r
+		}, input)
+		cleaned = strings.TrimPrefix(strings.TrimPrefix(cleaned, "0x"), "0X")
+		data, err := hex.DecodeString(cleaned)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex payload: %w", err)
+		}
+		return data, nil
+
+	case InputBase64:
+		cleaned := strings.Join(strings.Fields(input), "")
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if data, err := enc.DecodeString(cleaned); err == nil {
+				return data, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid base64 payload")
+
+	default:
+		return nil, fmt.Errorf("unsupported input encoding: %s", encoding)
+	}
+}
+
+// IsText reports whether data is valid UTF-8 without control characters
+// other than common whitespace
+func IsText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+		if r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// HexDump returns a canonical hex+ASCII dump of data
+func HexDump(data []byte) string {
+	return hex.Dump(data)
+}
+
+// Base64 returns the standard base64 encoding of data
+func Base64(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Summary renders data for single-line display: text as-is, binary as a short hex preview
+func Summary(data []byte) string {
+	if IsText(data) {
+		return string(data)
+	}
+
+	const previewBytes = 16
+	preview := data
+	if len(preview) > previewBytes {
+		preview = preview[:previewBytes]
+	}
+	summary := fmt.Sprintf("<binary %d bytes> %s", len(data), hex.EncodeToString(preview))
+	if len(data) > previewBytes {
+		summary += "..."
+	}
+	return summary
+}
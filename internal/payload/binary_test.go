@@ -0,0 +1,131 @@
+package payload
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		encoding InputEncoding
+		want     []byte
+	}{
+		{"text", "héllo", InputText, []byte("héllo")},
+		{"default is text", "abc", "", []byte("abc")},
+		{"hex", "deadBEEF", InputHex, []byte{0xde, 0xad, 0xbe, 0xef}},
+		{"hex with 0x prefix", "0x0102", InputHex, []byte{1, 2}},
+		{"hex with 0X prefix", "0X0a", InputHex, []byte{0x0a}},
+		{"hex with separators", " 01:02 03\n\t04 ", InputHex, []byte{1, 2, 3, 4}},
+		{"empty hex", "", InputHex, []byte{}},
+		{"base64", "AP8Q", InputBase64, []byte{0x00, 0xff, 0x10}},
+		{"base64 padded", "aGk=", InputBase64, []byte("hi")},
+		{"base64 unpadded", "aGk", InputBase64, []byte("hi")},
+		{"base64 URL alphabet", "-_8", InputBase64, []byte{0xfb, 0xff}},
+		{"base64 with line breaks", "aGVs\nbG8=\n", InputBase64, []byte("hello")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeInput(tt.input, tt.encoding)
+			if err != nil {
+				t.Fatalf("DecodeInput: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("DecodeInput(%q, %s) = %x, want %x", tt.input, tt.encoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeInputErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		encoding InputEncoding
+		// want is part of the expected error
+		want string
+	}{
+		{"odd hex length", "abc", InputHex, "invalid hex payload"},
+		{"not hex", "zz", InputHex, "invalid hex payload"},
+		{"prefix inside hex", "01 0x02", InputHex, "invalid hex payload"},
+		{"not base64", "a$b=", InputBase64, "invalid base64 payload"},
+		{"truncated base64", "a", InputBase64, "invalid base64 payload"},
+		{"file is read by the caller", "orders.bin", InputFile, "unsupported input encoding"},
+		{"unknown encoding", "x", "Octal", "unsupported input encoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeInput(tt.input, tt.encoding)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DecodeInput(%q, %s) error = %v, want it to contain %q", tt.input, tt.encoding, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"empty", "", true},
+		{"ASCII", "hello", true},
+		{"whitespace", "a\tb\r\nc", true},
+		{"multibyte", "日本語 ✓", true},
+		{"BOM", bom + "x", true},
+		{"NUL", "a\x00", false},
+		{"escape", "\x1b[31m", false},
+		{"DEL", "a\x7f", false},
+		{"invalid UTF-8", "caf\xe9", false},
+		{"truncated sequence", "\xe6\x97", false},
+		{"overlong encoding", "\xc0\xaf", false},
+		{"surrogate half", "\xed\xa0\x80", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsText([]byte(tt.data)); got != tt.want {
+				t.Errorf("IsText(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"text", []byte(`{"id":1}`), `{"id":1}`},
+		{"empty", nil, ""},
+		{"binary", []byte{0, 1, 2}, "<binary 3 bytes> 000102"},
+		{"invalid UTF-8", []byte("caf\xe9"), "<binary 4 bytes> 636166e9"},
+		{"long binary", bytes.Repeat([]byte{0xff}, 20), "<binary 20 bytes> " + strings.Repeat("ff", 16) + "..."},
+		{"16 binary bytes", bytes.Repeat([]byte{0}, 16), "<binary 16 bytes> " + strings.Repeat("00", 16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summary(tt.data); got != tt.want {
+				t.Errorf("Summary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHexAndBase64RoundTrip(t *testing.T) {
+	data := []byte{0x00, 0x7f, 0x80, 0xff, 'a'}
+
+	decoded, err := DecodeInput(Base64(data), InputBase64)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("base64 round trip = %x, %v", decoded, err)
+	}
+
+	// The dump lists the bytes between the offset and the ASCII column
+	dump := HexDump(data)
+	if !strings.HasPrefix(dump, "00000000  00 7f 80 ff 61") || !strings.Contains(dump, "|....a|") {
+		t.Errorf("HexDump = %q", dump)
+	}
+}
@@ -0,0 +1,238 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format represents the detected format of a message payload
+type Format string
+
+const (
+	FormatJSON   Format = "JSON"
+	FormatXML    Format = "XML"
+	FormatYAML   Format = "YAML"
+	FormatText   Format = "Text"
+	FormatBinary Format = "Binary"
+)
+
+// utf8BOM is the byte order mark some producers put before UTF-8 text
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// DetectFormat guesses the format of a payload. Plain scalars that happen to
+// be valid YAML are reported as text. A leading UTF-8 BOM is ignored.
+func DetectFormat(data []byte) Format {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	if len(trimmed) == 0 {
+		return FormatText
+	}
+
+	if !IsText(data) {
+		return FormatBinary
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return FormatJSON
+	}
+
+	if trimmed[0] == '<' && isXML(trimmed) {
+		return FormatXML
+	}
+
+	if isYAML(trimmed) {
+		return FormatYAML
+	}
+
+	return FormatText
+}
+
+// Pretty returns an indented rendering of the payload in the given format,
+// without a leading UTF-8 BOM
+func Pretty(data []byte, format Format) (string, error) {
+	if format != FormatBinary {
+		data = bytes.TrimPrefix(data, utf8BOM)
+	}
+
+	switch format {
+	case FormatJSON:
+		var out bytes.Buffer
+		if err := json.Indent(&out, bytes.TrimSpace(data), "", "  "); err != nil {
+			return "", err
+		}
+		return out.String(), nil
+
+	case FormatXML:
+		return prettyXML(data)
+
+	case FormatYAML:
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return "", err
+		}
+		encoder.Close()
+		return out.String(), nil
+
+	case FormatBinary:
+		return HexDump(data), nil
+
+	default:
+		return string(data), nil
+	}
+}
+
+// isXML reports whether data is a well-formed XML document with at least one element
+func isXML(data []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	elements := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
+
+// isYAML reports whether data parses as a YAML mapping or sequence
+func isYAML(data []byte) bool {
+	text := string(data)
+	if !strings.Contains(text, ":") && !strings.HasPrefix(text, "- ") {
+		return false
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil || len(node.Content) == 0 {
+		return false
+	}
+	kind := node.Content[0].Kind
+	return kind == yaml.MappingNode || kind == yaml.SequenceNode
+}
+
+// prettyXML re-encodes an XML document with indentation
+func prettyXML(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+	encoder.Indent("", "  ")
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		// Whitespace between elements is replaced by the encoder's indentation
+		if charData, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(charData)) == 0 {
+			continue
+		}
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// JSONTree is a flattened JSON document suitable for a tree widget. Node IDs
+// are JSON pointers prefixed with "#"; the empty ID is a virtual root whose
+// only child is the document.
+type JSONTree struct {
+	Children map[string][]string
+	Labels   map[string]string
+}
+
+// BuildJSONTree decodes a JSON payload into a JSONTree
+func BuildJSONTree(data []byte) (*JSONTree, error) {
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	tree := &JSONTree{
+		Children: make(map[string][]string),
+		Labels:   make(map[string]string),
+	}
+	tree.Children[""] = []string{"#"}
+	tree.add("#", "$", value)
+	return tree, nil
+}
+
+// IsBranch reports whether the node has children
+func (t *JSONTree) IsBranch(id string) bool {
+	_, ok := t.Children[id]
+	return ok
+}
+
+// add records a node and its descendants
+func (t *JSONTree) add(id, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		t.Labels[id] = key + " {" + plural(len(v), "key") + "}"
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		children := make([]string, 0, len(v))
+		for _, k := range keys {
+			childID := id + "/" + escapePointer(k)
+			children = append(children, childID)
+			t.add(childID, k, v[k])
+		}
+		t.Children[id] = children
+
+	case []interface{}:
+		t.Labels[id] = key + " [" + plural(len(v), "item") + "]"
+		children := make([]string, 0, len(v))
+		for i, item := range v {
+			childID := id + "/" + strconv.Itoa(i)
+			children = append(children, childID)
+			t.add(childID, strconv.Itoa(i), item)
+		}
+		t.Children[id] = children
+
+	default:
+		encoded, _ := json.Marshal(v)
+		t.Labels[id] = key + ": " + string(encoded)
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// plural formats a count with a noun, e.g. "1 key" or "3 keys"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
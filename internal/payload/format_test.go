@@ -0,0 +1,141 @@
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+const bom = "\xef\xbb\xbf"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Format
+	}{
+		{"empty", "", FormatText},
+		{"whitespace", " \n\t", FormatText},
+		{"JSON object", `{"id":1}`, FormatJSON},
+		{"JSON array with whitespace", "\n [1, 2]\n", FormatJSON},
+		{"JSON with BOM", bom + `{"id":1}`, FormatJSON},
+		{"truncated JSON", `{"id":1,"items":[`, FormatText},
+		{"truncated JSON with key", `{"id":`, FormatText},
+		{"JSON scalar", `42`, FormatText},
+		{"XML", `<?xml version="1.0"?><order id="1"><item/></order>`, FormatXML},
+		{"XML with BOM", bom + `<order/>`, FormatXML},
+		{"unclosed XML", `<order><item></order>`, FormatText},
+		{"angle bracket text", `<not xml`, FormatText},
+		{"YAML mapping", "id: 1\nname: apple", FormatYAML},
+		{"YAML sequence", "- a\n- b", FormatYAML},
+		{"text with colon", "note: see", FormatYAML},
+		{"text", "hello world", FormatText},
+		{"UTF-8 text", "olá, 世界", FormatText},
+		{"NUL byte", "a\x00b", FormatBinary},
+		{"invalid UTF-8", "\xff\xfe\xfd", FormatBinary},
+		{"BOM only", bom, FormatText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("DetectFormat(%q) = %s, want %s", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPretty(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format Format
+		want   string
+	}{
+		{"JSON", ` {"a":[1,2]} `, FormatJSON, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"JSON with BOM", bom + `{"a":1}`, FormatJSON, "{\n  \"a\": 1\n}"},
+		{"XML", `<a><b>1</b>  <c/></a>`, FormatXML, "<a>\n  <b>1</b>\n  <c></c>\n</a>"},
+		{"XML with BOM", bom + `<a><b/></a>`, FormatXML, "<a>\n  <b></b>\n</a>"},
+		{"YAML", "a:   1\nb: [x, y]", FormatYAML, "a: 1\nb: [x, y]\n"},
+		{"text", "hello\n", FormatText, "hello\n"},
+		{"empty text", "", FormatText, ""},
+		{"binary", "\x00\x01", FormatBinary, "00000000  00 01                                             |..|\n"},
+		{"binary keeps a BOM", bom, FormatBinary, "00000000  ef bb bf                                          |...|\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Pretty([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("Pretty: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Pretty = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrettyErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		format Format
+	}{
+		{`{"a":`, FormatJSON},
+		{``, FormatJSON},
+		{`<a><b></a>`, FormatXML},
+		{"a: [1", FormatYAML},
+	}
+	for _, tt := range tests {
+		if got, err := Pretty([]byte(tt.data), tt.format); err == nil {
+			t.Errorf("Pretty(%q, %s) = %q, want an error", tt.data, tt.format, got)
+		}
+	}
+}
+
+func TestBuildJSONTree(t *testing.T) {
+	tree, err := BuildJSONTree([]byte(bom + `{"b":[true,null],"a/~":{"n":1.50},"s":"x"}`))
+	if err != nil {
+		t.Fatalf("BuildJSONTree: %v", err)
+	}
+
+	wantChildren := map[string][]string{
+		"":        {"#"},
+		"#":       {"#/a~1~0", "#/b", "#/s"},
+		"#/a~1~0": {"#/a~1~0/n"},
+		"#/b":     {"#/b/0", "#/b/1"},
+	}
+	if !reflect.DeepEqual(tree.Children, wantChildren) {
+		t.Errorf("Children = %v, want %v", tree.Children, wantChildren)
+	}
+
+	wantLabels := map[string]string{
+		"#":         "$ {3 keys}",
+		"#/a~1~0":   "a/~ {1 key}",
+		"#/a~1~0/n": "n: 1.50",
+		"#/b":       "b [2 items]",
+		"#/b/0":     "0: true",
+		"#/b/1":     "1: null",
+		"#/s":       `s: "x"`,
+	}
+	if !reflect.DeepEqual(tree.Labels, wantLabels) {
+		t.Errorf("Labels = %v, want %v", tree.Labels, wantLabels)
+	}
+
+	if !tree.IsBranch("#/b") || tree.IsBranch("#/s") {
+		t.Error("IsBranch does not match the children")
+	}
+}
+
+func TestBuildJSONTreeScalarAndErrors(t *testing.T) {
+	tree, err := BuildJSONTree([]byte(`"plain"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Labels["#"] != `$: "plain"` || tree.IsBranch("#") {
+		t.Errorf("scalar tree = %+v", tree)
+	}
+
+	for _, data := range []string{``, `{"a":`, `[1,`, `not json`} {
+		if _, err := BuildJSONTree([]byte(data)); err == nil {
+			t.Errorf("BuildJSONTree(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+// Package responder impersonates services that are not running: it answers
+// requests and publishes follow-up events with templated payloads that can
+// refer to the incoming message, after a delay and with injected errors.
+package responder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/templating"
+)
+
+// OriginHeader marks what a responder with a templated event subject sends,
+// so that it skips its own events when they match its pattern. Static event
+// subjects are checked up front by CheckLoop instead.
+const OriginHeader = "X-Responder-Origin"
+
+// Config describes how a responder answers the messages it receives.
+// Templates see the incoming message as {{.subject}}, {{.payload}} and
+// {{.reply}}, its headers as {{var "header.Name"}} and its JSON fields as
+// {{var "json.customer.id"}}, with array items as {{var "json.items.0"}}.
+type Config struct {
+	// ReplyTemplate is the payload sent to the reply subject of requests;
+	// messages without a reply subject get no reply
+	ReplyTemplate string
+	// EventSubject and EventTemplate publish a follow-up event for every
+	// message; the subject is a template too
+	EventSubject  string
+	EventTemplate string
+	// Headers are added to replies and events; values are templates
+	Headers map[string]string
+	// Delay is waited before answering, plus a random part up to Jitter
+	Delay  time.Duration
+	Jitter time.Duration
+	// ErrorRate is the fraction of messages, from 0 to 1, that fail: they get
+	// ErrorTemplate as reply, or no reply at all when it is empty, and no event
+	ErrorRate     float64
+	ErrorTemplate string
+}
+
+// Validate checks that the templates parse and the numbers make sense
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.ReplyTemplate) == "" && strings.TrimSpace(c.EventSubject) == "" {
+		return fmt.Errorf("a responder needs a reply template or an event subject")
+	}
+	if strings.TrimSpace(c.EventTemplate) != "" && strings.TrimSpace(c.EventSubject) == "" {
+		return fmt.Errorf("the event template needs an event subject")
+	}
+	if c.Delay < 0 || c.Jitter < 0 {
+		return fmt.Errorf("delay and jitter can't be negative")
+	}
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return fmt.Errorf("error rate must be between 0 and 1")
+	}
+
+	templates := map[string]string{
+		"reply template": c.ReplyTemplate,
+		"event subject":  c.EventSubject,
+		"event template": c.EventTemplate,
+		"error template": c.ErrorTemplate,
+	}
+	for name, value := range c.Headers {
+		templates["header "+name] = value
+	}
+	for name, text := range templates {
+		if err := templating.Parse(text); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CheckLoop reports an error when a static event subject matches pattern,
+// since the responder would then answer its own events forever
+func (c Config) CheckLoop(pattern string) error {
+	subject := strings.TrimSpace(c.EventSubject)
+	if subject == "" || isTemplate(subject) {
+		return nil
+	}
+	if messaging.MatchSubject(pattern, subject) {
+		return fmt.Errorf("event subject %s matches the pattern %s, so the responder would answer its own events", subject, pattern)
+	}
+	return nil
+}
+
+// isTemplate reports whether text has template actions
+func isTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// Stats counts the messages handled by a responder
+type Stats struct {
+	Received      uint64
+	Replied       uint64
+	Published     uint64
+	Injected      uint64
+	Failed        uint64
+	LastResponded time.Time
+	LastError     string
+}
+
+// Sender sends the answers of a responder
+type Sender interface {
+	Publish(subject string, data []byte, headers map[string]string) error
+	Reply(msg *messaging.Message, data []byte, headers map[string]string) error
+}
+
+// ProviderSender sends through a provider connection. Replies use the
+// provider's own reply mechanism when it has one, or a publish to the reply
+// subject otherwise.
+func ProviderSender(provider messaging.MessagingProvider) Sender {
+	return providerSender{provider: provider}
+}
+
+// providerSender sends through a provider connection
+type providerSender struct {
+	provider messaging.MessagingProvider
+}
+
+func (s providerSender) Publish(subject string, data []byte, headers map[string]string) error {
+	if headerPublisher, ok := s.provider.(messaging.HeaderPublisher); ok && len(headers) > 0 {
+		return headerPublisher.PublishWithHeaders(subject, data, headers)
+	}
+	return s.provider.Publish(subject, data)
+}
+
+func (s providerSender) Reply(msg *messaging.Message, data []byte, headers map[string]string) error {
+	if replier, ok := s.provider.(messaging.Replier); ok {
+		return replier.Reply(msg, data, headers)
+	}
+	return s.Publish(msg.Reply, data, headers)
+}
+
+// Responder answers messages. It is safe for concurrent use.
+type Responder struct {
+	cfg    Config
+	sender Sender
+	// random returns a number in [0, 1); replaced by tests
+	random func() float64
+	// origin is the OriginHeader value of a templated event subject
+	origin string
+
+	pending sync.WaitGroup
+	stop    chan struct{}
+	once    sync.Once
+
+	mutex sync.Mutex
+	stats Stats
+}
+
+// New creates a responder sending through sender
+func New(cfg Config, sender Sender) (*Responder, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Responder{cfg: cfg, sender: sender, random: rand.Float64, stop: make(chan struct{})}
+	if isTemplate(cfg.EventSubject) {
+		r.origin = strconv.FormatUint(rand.Uint64(), 36)
+	}
+	return r, nil
+}
+
+// Handle answers a message after the configured delay without blocking the
+// caller, so a slow answer doesn't hold up the following messages. Messages
+// the responder sent itself are ignored.
+func (r *Responder) Handle(msg *messaging.Message) {
+	if r.origin != "" && msg.Headers[OriginHeader] == r.origin {
+		return
+	}
+
+	r.mutex.Lock()
+	r.stats.Received++
+	seq := int64(r.stats.Received)
+	r.mutex.Unlock()
+
+	delay := r.cfg.Delay
+	if r.cfg.Jitter > 0 {
+		delay += time.Duration(r.random() * float64(r.cfg.Jitter))
+	}
+
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-r.stop:
+				return
+			case <-timer.C:
+			}
+		}
+		r.respond(msg, seq)
+	}()
+}
+
+// Stop drops the answers still waiting for their delay and waits for the
+// ones being sent
+func (r *Responder) Stop() {
+	r.once.Do(func() { close(r.stop) })
+	r.pending.Wait()
+}
+
+// respond answers a message immediately; seq is the value of {{seq}}
+func (r *Responder) respond(msg *messaging.Message, seq int64) error {
+	ctx := templating.Context{Seq: seq, Vars: messageVars(msg)}
+
+	var err error
+	replied, published := false, false
+	injected := r.cfg.ErrorRate > 0 && r.random() < r.cfg.ErrorRate
+	switch {
+	case injected:
+		if msg.Reply != "" && strings.TrimSpace(r.cfg.ErrorTemplate) != "" {
+			err = r.reply(msg, r.cfg.ErrorTemplate, ctx)
+		}
+	default:
+		if msg.Reply != "" && strings.TrimSpace(r.cfg.ReplyTemplate) != "" {
+			err = r.reply(msg, r.cfg.ReplyTemplate, ctx)
+			replied = err == nil
+		}
+		if err == nil && strings.TrimSpace(r.cfg.EventSubject) != "" {
+			err = r.publishEvent(msg, ctx)
+			published = err == nil
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if injected {
+		r.stats.Injected++
+	}
+	if replied {
+		r.stats.Replied++
+	}
+	if published {
+		r.stats.Published++
+	}
+	if err != nil {
+		r.stats.Failed++
+		r.stats.LastError = err.Error()
+		return err
+	}
+	r.stats.LastResponded = time.Now()
+	return nil
+}
+
+// reply renders a template and sends it to the reply subject of msg
+func (r *Responder) reply(msg *messaging.Message, text string, ctx templating.Context) error {
+	data, headers, err := r.render(text, ctx)
+	if err != nil {
+		return fmt.Errorf("reply to %s: %w", msg.Subject, err)
+	}
+	if err := r.sender.Reply(msg, data, headers); err != nil {
+		return fmt.Errorf("reply to %s failed: %w", msg.Subject, err)
+	}
+	return nil
+}
+
+// publishEvent renders and publishes the follow-up event of msg
+func (r *Responder) publishEvent(msg *messaging.Message, ctx templating.Context) error {
+	subject, err := templating.Render(r.cfg.EventSubject, ctx)
+	if err != nil {
+		return fmt.Errorf("event subject for %s: %w", msg.Subject, err)
+	}
+	data, headers, err := r.render(r.cfg.EventTemplate, ctx)
+	if err != nil {
+		return fmt.Errorf("event for %s: %w", msg.Subject, err)
+	}
+	if err := r.sender.Publish(string(subject), data, headers); err != nil {
+		return fmt.Errorf("event %s for %s failed: %w", subject, msg.Subject, err)
+	}
+	return nil
+}
+
+// render renders a payload template and the configured headers, plus the
+// origin marker if any
+func (r *Responder) render(text string, ctx templating.Context) ([]byte, map[string]string, error) {
+	data, err := templating.Render(text, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var headers map[string]string
+	if r.origin != "" {
+		headers = map[string]string{OriginHeader: r.origin}
+	}
+	for name, value := range r.cfg.Headers {
+		rendered, err := templating.Render(value, ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(r.cfg.Headers))
+		}
+		headers[name] = string(rendered)
+	}
+	return data, headers, nil
+}
+
+// Stats returns the current counts
+func (r *Responder) Stats() Stats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.stats
+}
+
+// messageVars exposes a message to templates
+func messageVars(msg *messaging.Message) map[string]string {
+	vars := map[string]string{
+		"subject": msg.Subject,
+		"payload": string(msg.Data),
+		"reply":   msg.Reply,
+	}
+	for name, value := range msg.Headers {
+		vars["header."+name] = value
+	}
+
+	var doc any
+	if err := json.Unmarshal(msg.Data, &doc); err == nil {
+		flatten("json", doc, vars)
+	}
+	return vars
+}
+
+// flatten adds the fields of a JSON value as dotted names. Strings are added
+// as is and other values as JSON, so objects can be copied whole too.
+func flatten(prefix string, value any, vars map[string]string) {
+	switch v := value.(type) {
+	case string:
+		vars[prefix] = v
+		return
+	case map[string]any:
+		for key, child := range v {
+			flatten(prefix+"."+key, child, vars)
+		}
+	case []any:
+		for i, child := range v {
+			flatten(prefix+"."+strconv.Itoa(i), child, vars)
+		}
+	}
+	if prefix == "json" {
+		return
+	}
+	if encoded, err := json.Marshal(value); err == nil {
+		vars[prefix] = string(encoded)
+	}
+}
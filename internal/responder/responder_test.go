@@ -0,0 +1,202 @@
+package responder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// sent is a message sent by a responder
+type sent struct {
+	subject string
+	data    string
+	headers map[string]string
+}
+
+// recordingSender records replies and events instead of sending them
+type recordingSender struct {
+	replies []sent
+	events  []sent
+	fail    bool
+}
+
+func (s *recordingSender) Publish(subject string, data []byte, headers map[string]string) error {
+	if s.fail {
+		return errors.New("broker down")
+	}
+	s.events = append(s.events, sent{subject, string(data), headers})
+	return nil
+}
+
+func (s *recordingSender) Reply(msg *messaging.Message, data []byte, headers map[string]string) error {
+	if s.fail {
+		return errors.New("broker down")
+	}
+	s.replies = append(s.replies, sent{msg.Reply, string(data), headers})
+	return nil
+}
+
+var request = &messaging.Message{
+	Subject: "orders.create",
+	Data:    []byte(`{"id":7,"customer":{"name":"Ada"},"items":[{"sku":"a"}]}`),
+	Headers: map[string]string{"X-Tenant": "acme"},
+	Reply:   "_INBOX.1",
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []Config{
+		{},
+		{EventTemplate: "{}"},
+		{ReplyTemplate: "{}", ErrorRate: 1.5},
+		{ReplyTemplate: "{}", Delay: -time.Second},
+		{ReplyTemplate: "{{nope"},
+		{ReplyTemplate: "{}", Headers: map[string]string{"X": "{{"}},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v was accepted", cfg)
+		}
+	}
+}
+
+func TestCheckLoop(t *testing.T) {
+	tests := []struct {
+		subject string
+		loops   bool
+	}{
+		{"orders.created", true},
+		{"inventory.checked", false},
+		{"", false},
+		// Templated subjects are guarded by OriginHeader instead
+		{`orders.{{.subject}}`, false},
+	}
+	for _, tt := range tests {
+		cfg := Config{ReplyTemplate: "{}", EventSubject: tt.subject}
+		if err := cfg.CheckLoop("orders.*"); (err != nil) != tt.loops {
+			t.Errorf("CheckLoop with event subject %q = %v, want loop %v", tt.subject, err, tt.loops)
+		}
+	}
+}
+
+func TestHandleSkipsOwnEvents(t *testing.T) {
+	sender := &recordingSender{}
+	r, err := New(Config{EventSubject: `orders.{{var "header.X-Tenant"}}`}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Handle(request)
+	r.Stop()
+	if len(sender.events) != 1 || sender.events[0].headers[OriginHeader] == "" {
+		t.Fatalf("events = %+v, want one marked event", sender.events)
+	}
+
+	// The event comes back on the responder's own pattern
+	event := sender.events[0]
+	r.Handle(&messaging.Message{Subject: event.subject, Data: []byte(event.data), Headers: event.headers})
+	if stats := r.Stats(); stats.Received != 1 {
+		t.Errorf("stats = %+v, want the own event skipped", stats)
+	}
+}
+
+func TestRespondRepliesAndPublishes(t *testing.T) {
+	sender := &recordingSender{}
+	r, err := New(Config{
+		ReplyTemplate: `{"id":{{var "json.id"}},"customer":{{var "json.customer"}},"sku":"{{var "json.items.0.sku"}}","seq":{{seq}}}`,
+		EventSubject:  `orders.{{var "header.X-Tenant"}}.created`,
+		EventTemplate: `{{.payload}}`,
+		Headers:       map[string]string{"X-Source": "mock {{.subject}}"},
+	}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.respond(request, 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.replies) != 1 || sender.replies[0].subject != "_INBOX.1" ||
+		sender.replies[0].data != `{"id":7,"customer":{"name":"Ada"},"sku":"a","seq":3}` {
+		t.Errorf("replies = %+v", sender.replies)
+	}
+	if sender.replies[0].headers["X-Source"] != "mock orders.create" {
+		t.Errorf("reply headers = %v", sender.replies[0].headers)
+	}
+	if len(sender.events) != 1 || sender.events[0].subject != "orders.acme.created" || sender.events[0].data != string(request.Data) {
+		t.Errorf("events = %+v", sender.events)
+	}
+
+	// Messages without a reply subject only get the event
+	if err := r.respond(&messaging.Message{Subject: "orders.create", Data: []byte("{}"), Headers: map[string]string{"X-Tenant": "b"}}, 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.replies) != 1 || len(sender.events) != 2 {
+		t.Errorf("replies = %+v, events = %+v", sender.replies, sender.events)
+	}
+
+	if stats := r.Stats(); stats.Replied != 1 || stats.Published != 2 || stats.Failed != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRespondInjectsErrors(t *testing.T) {
+	sender := &recordingSender{}
+	r, err := New(Config{
+		ReplyTemplate: `{"ok":true}`,
+		EventSubject:  "orders.created",
+		ErrorRate:     0.5,
+		ErrorTemplate: `{"error":"unavailable"}`,
+	}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.random = func() float64 { return 0.2 }
+	if err := r.respond(request, 1); err != nil {
+		t.Fatal(err)
+	}
+	r.random = func() float64 { return 0.7 }
+	if err := r.respond(request, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.replies) != 2 || sender.replies[0].data != `{"error":"unavailable"}` || sender.replies[1].data != `{"ok":true}` {
+		t.Errorf("replies = %+v", sender.replies)
+	}
+	if len(sender.events) != 1 {
+		t.Errorf("a failed message published an event: %+v", sender.events)
+	}
+	if stats := r.Stats(); stats.Injected != 1 || stats.Replied != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	sender.fail = true
+	if err := r.respond(request, 3); err == nil {
+		t.Error("a send failure was not reported")
+	}
+	if stats := r.Stats(); stats.Failed != 1 || stats.LastError == "" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestHandleDelaysAndStop(t *testing.T) {
+	sender := &recordingSender{}
+	r, err := New(Config{EventSubject: "done", Delay: time.Hour}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	r.Handle(request)
+	r.Stop()
+	if time.Since(start) > time.Second {
+		t.Error("Stop waited for the delay")
+	}
+	if len(sender.events) != 0 {
+		t.Errorf("a stopped responder answered: %+v", sender.events)
+	}
+	if stats := r.Stats(); stats.Received != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
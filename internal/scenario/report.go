@@ -0,0 +1,122 @@
+package scenario
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format renders results for people, one line per step with the failures
+// indented below
+func Format(results []Result) string {
+	var b strings.Builder
+	passed := 0
+	for _, r := range results {
+		status := "PASS"
+		if r.Passed() {
+			passed++
+		} else {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s %s (%v)\n", status, r.Scenario, r.Duration.Round(time.Millisecond))
+		for _, step := range r.Steps {
+			fmt.Fprintf(&b, "  %-7s %s", step.Status, step.Name)
+			if step.Status != StatusSkipped {
+				fmt.Fprintf(&b, " (%v)", step.Duration.Round(time.Millisecond))
+			}
+			b.WriteString("\n")
+			for _, failure := range step.Failures {
+				fmt.Fprintf(&b, "          %s\n", failure)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d of %d scenarios passed\n", passed, len(results))
+	return b.String()
+}
+
+// junitTestSuites is the root of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a scenario
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	File     string          `xml:"file,attr,omitempty"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a step
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+// junitFailure explains a failed step
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders results as JUnit XML, with a test suite per scenario and a
+// test case per step
+func JUnit(results []Result) ([]byte, error) {
+	root := junitTestSuites{Name: "broker-ui scenarios"}
+	var total time.Duration
+	for _, r := range results {
+		suite := junitTestSuite{
+			Name:     r.Scenario,
+			File:     r.File,
+			Tests:    len(r.Steps),
+			Failures: r.Count(StatusFailed),
+			Skipped:  r.Count(StatusSkipped),
+			Time:     seconds(r.Duration),
+		}
+		for _, step := range r.Steps {
+			testCase := junitTestCase{Name: step.Name, Classname: r.Scenario, Time: seconds(step.Duration)}
+			switch step.Status {
+			case StatusFailed:
+				message := "step failed"
+				if len(step.Failures) > 0 {
+					message = step.Failures[0]
+				}
+				testCase.Failure = &junitFailure{Message: message, Text: strings.Join(step.Failures, "\n")}
+			case StatusSkipped:
+				testCase.Skipped = &struct{}{}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		root.Suites = append(root.Suites, suite)
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Skipped += suite.Skipped
+		total += r.Duration
+	}
+	root.Time = seconds(total)
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// seconds formats a duration in seconds as JUnit expects
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,394 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/jsonpath"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// expectBuffer is how many received messages a step holds before dropping
+const expectBuffer = 1024
+
+// Transport is what scenarios publish and subscribe through
+type Transport interface {
+	Publish(subject string, data []byte, headers map[string]string) error
+	// Subscribe delivers messages on a pattern until unsubscribe is called
+	Subscribe(pattern string, handler messaging.MessageHandler) (unsubscribe func(), err error)
+}
+
+// providerTransport runs scenarios on a provider connection
+type providerTransport struct {
+	provider messaging.MessagingProvider
+}
+
+// ProviderTransport returns a transport over a provider connection. The
+// connection should not be used by other subscriptions of the same patterns.
+func ProviderTransport(provider messaging.MessagingProvider) Transport {
+	return providerTransport{provider: provider}
+}
+
+func (t providerTransport) Publish(subject string, data []byte, headers map[string]string) error {
+	if len(headers) == 0 {
+		return t.provider.Publish(subject, data)
+	}
+	headerPublisher, ok := t.provider.(messaging.HeaderPublisher)
+	if !ok {
+		return fmt.Errorf("%s provider does not support message headers", t.provider.GetProviderType())
+	}
+	return headerPublisher.PublishWithHeaders(subject, data, headers)
+}
+
+func (t providerTransport) Subscribe(pattern string, handler messaging.MessageHandler) (func(), error) {
+	if err := t.provider.Subscribe(pattern, handler); err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := t.provider.Unsubscribe(pattern); err != nil {
+			log.Printf("Error unsubscribing scenario from %s: %v", pattern, err)
+		}
+	}, nil
+}
+
+// Status is the outcome of a step
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// StepResult is the outcome of one step
+type StepResult struct {
+	Name     string
+	Status   Status
+	Duration time.Duration
+	// Failures explains a failed step, with a diff of the closest message
+	Failures []string
+}
+
+// Result is the outcome of a scenario
+type Result struct {
+	Scenario string
+	File     string
+	Steps    []StepResult
+	Duration time.Duration
+}
+
+// Passed reports whether no step failed
+func (r Result) Passed() bool {
+	return r.Count(StatusFailed) == 0
+}
+
+// Count returns the number of steps with a status
+func (r Result) Count(status Status) int {
+	n := 0
+	for _, step := range r.Steps {
+		if step.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Run runs the steps of a scenario in order. Steps after a failure are
+// skipped, since they usually depend on it.
+func Run(ctx context.Context, transport Transport, sc *Scenario) Result {
+	start := time.Now()
+	result := Result{Scenario: sc.Name, File: sc.File}
+	failed := false
+	for i, step := range sc.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		if failed || ctx.Err() != nil {
+			result.Steps = append(result.Steps, StepResult{Name: name, Status: StatusSkipped})
+			continue
+		}
+
+		stepStart := time.Now()
+		failures := runStep(ctx, transport, sc, int64(i+1), step)
+		stepResult := StepResult{Name: name, Status: StatusPassed, Duration: time.Since(stepStart), Failures: failures}
+		if len(failures) > 0 {
+			stepResult.Status = StatusFailed
+			failed = true
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// runStep runs one step and returns why it failed, if it did
+func runStep(ctx context.Context, transport Transport, sc *Scenario, seq int64, step Step) []string {
+	if step.Wait > 0 {
+		select {
+		case <-ctx.Done():
+			return []string{"cancelled"}
+		case <-time.After(time.Duration(step.Wait)):
+		}
+	}
+
+	// Subscribe first so that fast replies are not missed
+	var received chan messaging.Message
+	var checker *checker
+	if step.Expect != nil {
+		var err error
+		if checker, err = newChecker(step.Expect); err != nil {
+			return []string{err.Error()}
+		}
+		received = make(chan messaging.Message, expectBuffer)
+		unsubscribe, err := transport.Subscribe(step.Expect.Subject, func(msg *messaging.Message) {
+			select {
+			case received <- *msg:
+			default:
+			}
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("subscribing to %s failed: %v", step.Expect.Subject, err)}
+		}
+		defer unsubscribe()
+	}
+
+	if p := step.Publish; p != nil {
+		data, err := p.payload(seq, sc.Vars)
+		if err != nil {
+			return []string{fmt.Sprintf("rendering the payload failed: %v", err)}
+		}
+		if err := transport.Publish(p.Subject, data, p.Headers); err != nil {
+			return []string{fmt.Sprintf("publishing to %s failed: %v", p.Subject, err)}
+		}
+	}
+
+	if step.Expect == nil {
+		return nil
+	}
+	return expectMessages(ctx, step.Expect, checker, received)
+}
+
+// expectMessages waits for the messages a step expects
+func expectMessages(ctx context.Context, e *Expect, c *checker, received <-chan messaging.Message) []string {
+	within := time.Duration(e.Within)
+	if within == 0 {
+		within = DefaultWithin
+	}
+	needed := e.Count
+	if needed == 0 {
+		needed = 1
+	}
+
+	timer := time.NewTimer(within)
+	defer timer.Stop()
+
+	matched, seen := 0, 0
+	var closest []string
+	var closestSubject string
+	for {
+		select {
+		case <-ctx.Done():
+			return []string{"cancelled"}
+		case msg := <-received:
+			seen++
+			failures := c.check(msg)
+			if len(failures) > 0 {
+				if closest == nil || len(failures) < len(closest) {
+					closest, closestSubject = failures, msg.Subject
+				}
+				continue
+			}
+			if e.None {
+				return []string{fmt.Sprintf("expected no matching message on %s within %v, got one on %s: %s",
+					e.Subject, within, msg.Subject, summarize(msg.Data))}
+			}
+			matched++
+			if matched >= needed {
+				return nil
+			}
+		case <-timer.C:
+			if e.None {
+				return nil
+			}
+			failures := []string{fmt.Sprintf("expected %d matching message(s) on %s within %v, got %d of %d received",
+				needed, e.Subject, within, matched, seen)}
+			if closest != nil {
+				failures = append(failures, fmt.Sprintf("closest message, on %s:", closestSubject))
+				for _, failure := range closest {
+					failures = append(failures, "  "+failure)
+				}
+			}
+			return failures
+		}
+	}
+}
+
+// checker holds the compiled checks of an expect block
+type checker struct {
+	expect   *Expect
+	asserts  []*jsonpath.Expression
+	payload  any
+	hasShape bool
+}
+
+// newChecker compiles the checks of an expect block
+func newChecker(e *Expect) (*checker, error) {
+	c := &checker{expect: e}
+	for _, expr := range e.Assert {
+		compiled, err := jsonpath.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("assert %q: %w", expr, err)
+		}
+		c.asserts = append(c.asserts, compiled)
+	}
+	if e.Payload != nil {
+		// A JSON round trip gives YAML values the types of decoded JSON
+		data, err := json.Marshal(e.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("expected payload: %w", err)
+		}
+		if err := json.Unmarshal(data, &c.payload); err != nil {
+			return nil, fmt.Errorf("expected payload: %w", err)
+		}
+		c.hasShape = true
+	}
+	return c, nil
+}
+
+// check returns what a message fails, or nothing when it matches
+func (c *checker) check(msg messaging.Message) []string {
+	var failures []string
+
+	for name, want := range c.expect.Headers {
+		if got, ok := msg.Headers[name]; !ok {
+			failures = append(failures, fmt.Sprintf("header %s: missing, expected %q", name, want))
+		} else if got != want {
+			failures = append(failures, fmt.Sprintf("header %s: expected %q, got %q", name, want, got))
+		}
+	}
+	if c.expect.Contains != "" && !strings.Contains(string(msg.Data), c.expect.Contains) {
+		failures = append(failures, fmt.Sprintf("payload does not contain %q: %s", c.expect.Contains, summarize(msg.Data)))
+	}
+
+	if len(c.asserts) == 0 && !c.hasShape {
+		return failures
+	}
+
+	var doc any
+	if err := json.Unmarshal(msg.Data, &doc); err != nil {
+		if text, ok := c.payload.(string); ok && len(c.asserts) == 0 {
+			if string(msg.Data) != text {
+				failures = append(failures, fmt.Sprintf("payload: expected %q, got %q", text, summarize(msg.Data)))
+			}
+			return failures
+		}
+		return append(failures, fmt.Sprintf("payload is not JSON: %s", summarize(msg.Data)))
+	}
+
+	for _, expr := range c.asserts {
+		if expr.Match(doc) {
+			continue
+		}
+		values := expr.Path.Select(doc)
+		if len(values) == 0 {
+			failures = append(failures, fmt.Sprintf("assert %s failed: %s is missing", expr, expr.Path))
+		} else {
+			failures = append(failures, fmt.Sprintf("assert %s failed: %s is %s", expr, expr.Path, formatValues(values)))
+		}
+	}
+	if c.hasShape {
+		failures = append(failures, Diff("$", c.payload, doc)...)
+	}
+	return failures
+}
+
+// Diff compares an expected JSON value with an actual one and describes the
+// differences by path. Objects may have fields that were not expected.
+func Diff(path string, expected, actual any) []string {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %s", path, formatValue(actual))}
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var diffs []string
+		for _, key := range keys {
+			childPath := childPath(path, key)
+			value, ok := got[key]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", childPath, formatValue(want[key])))
+				continue
+			}
+			diffs = append(diffs, Diff(childPath, want[key], value)...)
+		}
+		return diffs
+	case []any:
+		got, ok := actual.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array, got %s", path, formatValue(actual))}
+		}
+		if len(got) != len(want) {
+			return []string{fmt.Sprintf("%s: expected %d items, got %d: %s", path, len(want), len(got), formatValue(actual))}
+		}
+		var diffs []string
+		for i := range want {
+			diffs = append(diffs, Diff(fmt.Sprintf("%s[%d]", path, i), want[i], got[i])...)
+		}
+		return diffs
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, formatValue(expected), formatValue(actual))}
+	}
+	return nil
+}
+
+// identifier matches keys that can be written as .key in a path
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// childPath appends an object key to a path
+func childPath(path, key string) string {
+	if identifier.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s['%s']", path, key)
+}
+
+// formatValue renders a JSON value for a failure message
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return summarize(data)
+}
+
+// formatValues renders the values selected by a path
+func formatValues(values []any) string {
+	if len(values) == 1 {
+		return formatValue(values[0])
+	}
+	return formatValue(values)
+}
+
+// summarize shortens a payload for a failure message
+func summarize(data []byte) string {
+	const limit = 200
+	if len(data) > limit {
+		return string(data[:limit]) + "..."
+	}
+	return string(data)
+}
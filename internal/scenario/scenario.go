@@ -0,0 +1,184 @@
+// Package scenario runs publish-and-expect test scenarios written in YAML
+// against a messaging provider, for contract testing event-driven services.
+//
+// A scenario is a list of steps. Each step may publish a message and expect
+// messages on a subject within a time limit, checked with JSONPath
+// assertions, a payload pattern, text and headers:
+//
+//	name: order flow
+//	steps:
+//	  - name: create order
+//	    publish:
+//	      subject: orders.create
+//	      data: {id: 1, total: 20}
+//	    expect:
+//	      subject: orders.created
+//	      within: 2s
+//	      assert:
+//	        - $.status == 'ok'
+//	      payload: {id: 1}
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/jsonpath"
+	"github.com/devalexandre/broker-ui/internal/templating"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWithin is how long a step waits for expected messages by default
+const DefaultWithin = 2 * time.Second
+
+// Scenario is a named list of steps
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Server names the saved server to run against when none is chosen
+	Server string `yaml:"server,omitempty"`
+	// Vars are available to payloads as {{var "name"}}
+	Vars  map[string]string `yaml:"vars,omitempty"`
+	Steps []Step            `yaml:"steps"`
+	// File is the file the scenario was loaded from, if any
+	File string `yaml:"-"`
+}
+
+// Step publishes a message and checks what arrives. The expected messages
+// are subscribed to before publishing.
+type Step struct {
+	Name    string   `yaml:"name"`
+	Publish *Publish `yaml:"publish,omitempty"`
+	Expect  *Expect  `yaml:"expect,omitempty"`
+	// Wait pauses before the step, e.g. 500ms
+	Wait Duration `yaml:"wait,omitempty"`
+}
+
+// Publish describes a message to send. Data is a string sent as is, after
+// template rendering, or a YAML value sent as JSON.
+type Publish struct {
+	Subject string            `yaml:"subject"`
+	Data    any               `yaml:"data"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Expect describes the messages a step waits for
+type Expect struct {
+	Subject string   `yaml:"subject"`
+	Within  Duration `yaml:"within,omitempty"`
+	// Count is the number of matching messages required, 1 by default
+	Count int `yaml:"count,omitempty"`
+	// None expects no matching message within the time limit
+	None bool `yaml:"none,omitempty"`
+	// Assert lists JSONPath predicates such as $.status == 'ok'
+	Assert []string `yaml:"assert,omitempty"`
+	// Payload must be contained in the message: objects may have more
+	// fields, while arrays and values must be equal
+	Payload  any               `yaml:"payload,omitempty"`
+	Contains string            `yaml:"contains,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+}
+
+// Duration is a time.Duration written as 2s or 500ms in YAML
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Parse reads a scenario from YAML and validates it
+func Parse(data []byte) (*Scenario, error) {
+	var sc Scenario
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// Load reads a scenario file
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sc.File = path
+	if sc.Name == "" {
+		sc.Name = path
+	}
+	return sc, nil
+}
+
+// Validate checks that every step can run
+func (sc *Scenario) Validate() error {
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario %s has no steps", sc.Name)
+	}
+	var errs []error
+	for i, step := range sc.Steps {
+		if err := step.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("step %d (%s): %w", i+1, step.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks a step
+func (s Step) validate() error {
+	if s.Publish == nil && s.Expect == nil && s.Wait == 0 {
+		return fmt.Errorf("a step needs publish, expect or wait")
+	}
+	if p := s.Publish; p != nil {
+		if p.Subject == "" {
+			return fmt.Errorf("publish needs a subject")
+		}
+		if text, ok := p.Data.(string); ok {
+			if err := templating.Parse(text); err != nil {
+				return err
+			}
+		}
+	}
+	if e := s.Expect; e != nil {
+		if e.Subject == "" {
+			return fmt.Errorf("expect needs a subject")
+		}
+		if e.Count < 0 || e.Within < 0 {
+			return fmt.Errorf("expect count and within can't be negative")
+		}
+		for _, expr := range e.Assert {
+			if _, err := jsonpath.Compile(expr); err != nil {
+				return fmt.Errorf("assert %q: %w", expr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// payload renders the data of a publish step
+func (p *Publish) payload(seq int64, vars map[string]string) ([]byte, error) {
+	switch data := p.Data.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return templating.Render(data, templating.Context{Seq: seq, Vars: vars})
+	default:
+		return json.Marshal(data)
+	}
+}
@@ -0,0 +1,229 @@
+package scenario
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// fakeTransport delivers published messages to matching subscriptions and
+// lets a responder reply to them, like a service under test
+type fakeTransport struct {
+	mutex     sync.Mutex
+	handlers  map[string]messaging.MessageHandler
+	responder func(subject string, data []byte) (string, []byte, map[string]string)
+}
+
+func newFakeTransport(responder func(subject string, data []byte) (string, []byte, map[string]string)) *fakeTransport {
+	return &fakeTransport{handlers: make(map[string]messaging.MessageHandler), responder: responder}
+}
+
+func (f *fakeTransport) Publish(subject string, data []byte, headers map[string]string) error {
+	f.deliver(subject, data, headers)
+	if f.responder != nil {
+		if replySubject, reply, replyHeaders := f.responder(subject, data); replySubject != "" {
+			f.deliver(replySubject, reply, replyHeaders)
+		}
+	}
+	return nil
+}
+
+func (f *fakeTransport) deliver(subject string, data []byte, headers map[string]string) {
+	f.mutex.Lock()
+	var handlers []messaging.MessageHandler
+	for pattern, handler := range f.handlers {
+		if messaging.MatchSubject(pattern, subject) {
+			handlers = append(handlers, handler)
+		}
+	}
+	f.mutex.Unlock()
+	for _, handler := range handlers {
+		handler(&messaging.Message{Subject: subject, Data: data, Headers: headers, Timestamp: time.Now().UnixNano()})
+	}
+}
+
+func (f *fakeTransport) Subscribe(pattern string, handler messaging.MessageHandler) (func(), error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.handlers[pattern] = handler
+	return func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(f.handlers, pattern)
+	}, nil
+}
+
+const orderScenario = `
+name: order flow
+vars:
+  region: eu
+steps:
+  - name: create order
+    publish:
+      subject: orders.create
+      data: '{"id": {{seq}}, "region": "{{var "region"}}"}'
+      headers:
+        X-Trace: abc
+    expect:
+      subject: orders.created
+      within: 1s
+      assert:
+        - $.status == 'ok'
+      payload: {id: 1, region: eu}
+      headers:
+        X-Trace: abc
+  - name: nothing failed
+    expect:
+      subject: orders.failed
+      within: 50ms
+      none: true
+`
+
+func TestParse(t *testing.T) {
+	sc, err := Parse([]byte(orderScenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Name != "order flow" || len(sc.Steps) != 2 || sc.Vars["region"] != "eu" {
+		t.Fatalf("scenario = %+v", sc)
+	}
+	if time.Duration(sc.Steps[0].Expect.Within) != time.Second || !sc.Steps[1].Expect.None {
+		t.Errorf("steps = %+v, %+v", sc.Steps[0].Expect, sc.Steps[1].Expect)
+	}
+
+	invalid := map[string]string{
+		"no steps":       "name: x",
+		"unknown field":  "steps: [{publish: {subject: a}, retry: 3}]",
+		"empty step":     "steps: [{name: a}]",
+		"bad duration":   "steps: [{wait: soon}]",
+		"bad assert":     "steps: [{expect: {subject: a, assert: ['status == 1']}}]",
+		"bad template":   "steps: [{publish: {subject: a, data: '{{nope'}}]",
+		"missing target": "steps: [{expect: {within: 1s}}]",
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: scenario was accepted", name)
+		}
+	}
+}
+
+func TestRunPasses(t *testing.T) {
+	sc, err := Parse([]byte(orderScenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published string
+	transport := newFakeTransport(func(subject string, data []byte) (string, []byte, map[string]string) {
+		if subject != "orders.create" {
+			return "", nil, nil
+		}
+		published = string(data)
+		return "orders.created", []byte(`{"id":1,"region":"eu","status":"ok","total":20}`), map[string]string{"X-Trace": "abc"}
+	})
+
+	result := Run(context.Background(), transport, sc)
+	if !result.Passed() || result.Count(StatusPassed) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	if published != `{"id": 1, "region": "eu"}` {
+		t.Errorf("published %s", published)
+	}
+}
+
+func TestRunReportsDiffsAndSkips(t *testing.T) {
+	sc, err := Parse([]byte(orderScenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := newFakeTransport(func(subject string, data []byte) (string, []byte, map[string]string) {
+		return "orders.created", []byte(`{"id":2,"status":"failed"}`), nil
+	})
+	sc.Steps[0].Expect.Within = Duration(50 * time.Millisecond)
+
+	result := Run(context.Background(), transport, sc)
+	if result.Passed() || result.Steps[0].Status != StatusFailed || result.Steps[1].Status != StatusSkipped {
+		t.Fatalf("result = %+v", result)
+	}
+
+	failures := strings.Join(result.Steps[0].Failures, "\n")
+	for _, want := range []string{
+		"got 0 of 1 received",
+		`assert $.status == "ok" failed: $.status is "failed"`,
+		"$.id: expected 1, got 2",
+		`$.region: missing, expected "eu"`,
+		"header X-Trace: missing",
+	} {
+		if !strings.Contains(failures, want) {
+			t.Errorf("failures do not mention %q:\n%s", want, failures)
+		}
+	}
+}
+
+func TestRunNone(t *testing.T) {
+	sc, err := Parse([]byte(`
+steps:
+  - publish: {subject: orders.create, data: {id: 1}}
+    expect: {subject: orders.failed, within: 100ms, none: true, contains: '"id"'}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := newFakeTransport(func(subject string, data []byte) (string, []byte, map[string]string) {
+		return "orders.failed", data, nil
+	})
+	result := Run(context.Background(), transport, sc)
+	if result.Passed() || !strings.Contains(result.Steps[0].Failures[0], "expected no matching message") {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	expected := map[string]any{"items": []any{map[string]any{"sku": "a"}}, "tags": []any{"x"}, "my key": true}
+	actual := map[string]any{"items": []any{map[string]any{"sku": "b", "qty": 1.0}}, "tags": []any{"x", "y"}, "my key": true}
+	diffs := Diff("$", expected, actual)
+	want := []string{
+		`$.items[0].sku: expected "a", got "b"`,
+		`$.tags: expected 1 items, got 2: ["x","y"]`,
+	}
+	if strings.Join(diffs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Diff = %q, want %q", diffs, want)
+	}
+	if diffs := Diff("$", map[string]any{"a b": 1.0}, map[string]any{}); len(diffs) != 1 || !strings.HasPrefix(diffs[0], "$['a b']") {
+		t.Errorf("Diff = %q", diffs)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	results := []Result{{
+		Scenario: "order flow",
+		Steps: []StepResult{
+			{Name: "create", Status: StatusFailed, Duration: 1500 * time.Millisecond, Failures: []string{"timed out", "$.id: expected 1, got 2"}},
+			{Name: "check", Status: StatusSkipped},
+		},
+		Duration: 1500 * time.Millisecond,
+	}}
+	data, err := JUnit(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 2 || report.Failures != 1 || report.Skipped != 1 || report.Time != "1.500" {
+		t.Errorf("report = %+v", report)
+	}
+	failure := report.Suites[0].Cases[0].Failure
+	if failure == nil || failure.Message != "timed out" || !strings.Contains(failure.Text, "$.id") {
+		t.Errorf("failure = %+v", failure)
+	}
+	if report.Suites[0].Cases[1].Skipped == nil {
+		t.Error("the second step is not skipped")
+	}
+}
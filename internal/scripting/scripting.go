@@ -0,0 +1,195 @@
+// Package scripting runs small user JavaScript on messages. Scripts see the
+// message as msg (subject, data, headers), may change it, drop it by
+// returning false, and call publish() and log(). They run sandboxed, without
+// file or network access, and are interrupted after a timeout.
+package scripting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// DefaultTimeout bounds each run of a script when none is configured
+const DefaultTimeout = 250 * time.Millisecond
+
+// Message is the script's view of a message
+type Message struct {
+	Subject string
+	Data    []byte
+	Headers map[string]string
+}
+
+// Host provides the side effects available to scripts; nil functions make
+// the matching calls fail (publish) or go nowhere (log)
+type Host struct {
+	Publish func(subject string, data []byte, headers map[string]string) error
+	Log     func(line string)
+}
+
+// Result is the outcome of running a script on a message
+type Result struct {
+	Message Message
+	// Drop is set when the script returned false
+	Drop bool
+}
+
+// Stats counts the runs of a script
+type Stats struct {
+	Runs      uint64
+	Errors    uint64
+	Dropped   uint64
+	LastError string
+	LastLog   string
+}
+
+// Script is a compiled script. Runs are serialized, so a script may be
+// shared by concurrent message handlers.
+type Script struct {
+	program *goja.Program
+	timeout time.Duration
+
+	mutex sync.Mutex
+	stats Stats
+}
+
+// Compile compiles a script with a per-run timeout; zero uses DefaultTimeout
+func Compile(source string, timeout time.Duration) (*Script, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	// The wrapper keeps the user's line numbers and allows return
+	program, err := goja.Compile("script", "(function (msg) {"+source+"\n})", true)
+	if err != nil {
+		return nil, fmt.Errorf("script does not compile: %w", err)
+	}
+	return &Script{program: program, timeout: timeout}, nil
+}
+
+// Timeout returns the per-run timeout
+func (s *Script) Timeout() time.Duration {
+	return s.timeout
+}
+
+// Run executes the script on a message
+func (s *Script) Run(msg Message, host Host) (Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stats.Runs++
+	result, err := s.run(msg, host)
+	if err != nil {
+		s.stats.Errors++
+		s.stats.LastError = err.Error()
+		return Result{Message: msg}, err
+	}
+	if result.Drop {
+		s.stats.Dropped++
+	}
+	return result, nil
+}
+
+// Stats returns the run counts
+func (s *Script) Stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stats
+}
+
+// run executes the script in a fresh runtime; the caller holds the mutex
+func (s *Script) run(msg Message, host Host) (Result, error) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
+
+	if err := vm.Set("log", func(call goja.FunctionCall) goja.Value {
+		args := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.String()
+		}
+		line := strings.Join(args, " ")
+		s.stats.LastLog = line
+		if host.Log != nil {
+			host.Log(line)
+		}
+		return goja.Undefined()
+	}); err != nil {
+		return Result{}, err
+	}
+
+	if err := vm.Set("publish", func(subject string, data goja.Value, headers map[string]string) error {
+		if host.Publish == nil {
+			return errors.New("publish is not available here")
+		}
+		payload, err := toBytes(data)
+		if err != nil {
+			return err
+		}
+		return host.Publish(subject, payload, headers)
+	}); err != nil {
+		return Result{}, err
+	}
+
+	headers := make(map[string]any, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	jsMsg := vm.NewObject()
+	jsMsg.Set("subject", msg.Subject)
+	jsMsg.Set("data", string(msg.Data))
+	jsMsg.Set("headers", headers)
+
+	fn, err := vm.RunProgram(s.program)
+	if err != nil {
+		return Result{}, err
+	}
+	handler, ok := goja.AssertFunction(fn)
+	if !ok {
+		return Result{}, errors.New("script did not compile to a function")
+	}
+
+	timer := time.AfterFunc(s.timeout, func() {
+		vm.Interrupt(fmt.Sprintf("script timed out after %v", s.timeout))
+	})
+	returned, err := handler(goja.Undefined(), jsMsg)
+	timer.Stop()
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			return Result{}, fmt.Errorf("%v", interrupted.Value())
+		}
+		return Result{}, err
+	}
+
+	data, err := toBytes(jsMsg.Get("data"))
+	if err != nil {
+		return Result{}, fmt.Errorf("msg.data: %w", err)
+	}
+	result := Result{Message: Message{Subject: jsMsg.Get("subject").String(), Data: data}}
+	if exported, ok := jsMsg.Get("headers").Export().(map[string]any); ok && len(exported) > 0 {
+		result.Message.Headers = make(map[string]string, len(exported))
+		for k, v := range exported {
+			result.Message.Headers[k] = fmt.Sprint(v)
+		}
+	}
+	if keep, ok := returned.Export().(bool); ok && !keep {
+		result.Drop = true
+	}
+	return result, nil
+}
+
+// toBytes converts a payload given by a script: strings are used as is and
+// other values are encoded as JSON
+func toBytes(value goja.Value) ([]byte, error) {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, nil
+	}
+	if s, ok := value.Export().(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(value.Export())
+}
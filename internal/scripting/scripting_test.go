@@ -0,0 +1,111 @@
+package scripting
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunModifiesMessage(t *testing.T) {
+	script, err := Compile(`
+		var body = JSON.parse(msg.data);
+		body.total = body.price * body.qty;
+		msg.data = body;
+		msg.subject = "enriched." + msg.subject;
+		msg.headers["X-Script"] = "yes";
+		log("total", body.total);
+	`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logged []string
+	result, err := script.Run(Message{Subject: "orders", Data: []byte(`{"price":2,"qty":3}`)}, Host{
+		Log: func(line string) { logged = append(logged, line) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Drop {
+		t.Error("message was dropped")
+	}
+	if result.Message.Subject != "enriched.orders" {
+		t.Errorf("subject = %s", result.Message.Subject)
+	}
+	if !strings.Contains(string(result.Message.Data), `"total":6`) {
+		t.Errorf("data = %s, want the total", result.Message.Data)
+	}
+	if result.Message.Headers["X-Script"] != "yes" {
+		t.Errorf("headers = %v", result.Message.Headers)
+	}
+	if len(logged) != 1 || logged[0] != "total 6" {
+		t.Errorf("logged = %q", logged)
+	}
+}
+
+func TestRunDropAndPublish(t *testing.T) {
+	script, err := Compile(`
+		if (msg.subject === "noise") { return false; }
+		publish("replies." + msg.subject, {ok: true});
+		publish("raw", "text", {"X-Id": "1"});
+	`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type published struct {
+		subject, data string
+		headers       map[string]string
+	}
+	var sent []published
+	host := Host{Publish: func(subject string, data []byte, headers map[string]string) error {
+		sent = append(sent, published{subject, string(data), headers})
+		return nil
+	}}
+
+	result, err := script.Run(Message{Subject: "noise"}, host)
+	if err != nil || !result.Drop {
+		t.Fatalf("noise: drop = %v, err = %v, want dropped", result.Drop, err)
+	}
+
+	if _, err := script.Run(Message{Subject: "ping"}, host); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[0].subject != "replies.ping" || sent[0].data != `{"ok":true}` || sent[1].headers["X-Id"] != "1" {
+		t.Errorf("published = %+v", sent)
+	}
+
+	stats := script.Stats()
+	if stats.Runs != 2 || stats.Dropped != 1 || stats.Errors != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := Compile("function (", 0); err == nil {
+		t.Error("syntax error was accepted")
+	}
+
+	script, err := Compile(`while (true) {}`, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := script.Run(Message{}, Host{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timeout took %v", elapsed)
+	}
+
+	script, err = Compile(`publish("x", "y")`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := script.Run(Message{}, Host{}); err == nil {
+		t.Error("publish without a host succeeded")
+	}
+	if stats := script.Stats(); stats.Errors != 1 || stats.LastError == "" {
+		t.Errorf("stats = %+v, want the error recorded", stats)
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/alerts"
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/payload"
+)
+
+// alertTickInterval is how often silence and rate rules are evaluated
+const alertTickInterval = time.Second
+
+// watchedSubscription is an active subscription whose rules are evaluated
+type watchedSubscription struct {
+	subscription models.Subscription
+	monitor      *alerts.Monitor
+}
+
+type AlertService struct {
+	alertRepo *database.AlertRepository
+	watched   map[string]*watchedSubscription
+	onAlert   func(models.Alert)
+	mutex     sync.RWMutex
+}
+
+// NewAlertService creates a new alert service
+func NewAlertService(alertRepo *database.AlertRepository) *AlertService {
+	return &AlertService{
+		alertRepo: alertRepo,
+		watched:   make(map[string]*watchedSubscription),
+	}
+}
+
+// SetAlertHandler sets the function called, from any goroutine, after an
+// alert has been logged
+func (s *AlertService) SetAlertHandler(handler func(models.Alert)) {
+	s.mutex.Lock()
+	s.onAlert = handler
+	s.mutex.Unlock()
+}
+
+// Run evaluates the silence and rate rules of watched subscriptions until
+// ctx is cancelled
+func (s *AlertService) Run(ctx context.Context) {
+	ticker := time.NewTicker(alertTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mutex.RLock()
+			watched := make([]*watchedSubscription, 0, len(s.watched))
+			for _, w := range s.watched {
+				watched = append(watched, w)
+			}
+			s.mutex.RUnlock()
+
+			for _, w := range watched {
+				s.record(w.subscription.SubName, w.monitor.Tick(now), "")
+			}
+		}
+	}
+}
+
+// Watch starts evaluating the enabled rules of an active subscription
+func (s *AlertService) Watch(subscription models.Subscription) error {
+	monitor, err := s.newMonitor(subscription.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.watched[subscription.SubName] = &watchedSubscription{subscription: subscription, monitor: monitor}
+	s.mutex.Unlock()
+	return nil
+}
+
+// Unwatch stops evaluating the rules of a subscription
+func (s *AlertService) Unwatch(subName string) {
+	s.mutex.Lock()
+	delete(s.watched, subName)
+	s.mutex.Unlock()
+}
+
+// Observe evaluates the message rules of a subscription against a received
+// message. It is meant to be registered as a MessageService observer.
+func (s *AlertService) Observe(subName string, msg *messaging.Message) {
+	s.mutex.RLock()
+	w, ok := s.watched[subName]
+	s.mutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	firings := w.monitor.Observe(msg.Subject, msg.Data, time.Now())
+	if len(firings) > 0 {
+		s.record(subName, firings, payload.Summary(msg.Data))
+	}
+}
+
+// GetRules returns the alert rules of a subscription
+func (s *AlertService) GetRules(subID int) ([]models.AlertRule, error) {
+	return s.alertRepo.GetRules(subID)
+}
+
+// SaveRule validates and saves a rule, then reloads the rules of its
+// subscription if it is being watched
+func (s *AlertService) SaveRule(rule models.AlertRule) error {
+	if err := toAlertsRule(rule).Validate(); err != nil {
+		return err
+	}
+	if _, err := s.alertRepo.SaveRule(rule); err != nil {
+		return fmt.Errorf("error saving alert rule: %w", err)
+	}
+	s.reload(rule.SubscriptionID)
+	return nil
+}
+
+// SetRuleEnabled enables or disables a rule
+func (s *AlertService) SetRuleEnabled(rule models.AlertRule, enabled bool) error {
+	if err := s.alertRepo.SetRuleEnabled(rule.ID, enabled); err != nil {
+		return fmt.Errorf("error updating alert rule: %w", err)
+	}
+	s.reload(rule.SubscriptionID)
+	return nil
+}
+
+// DeleteRule deletes a rule
+func (s *AlertService) DeleteRule(rule models.AlertRule) error {
+	if err := s.alertRepo.DeleteRule(rule.ID); err != nil {
+		return fmt.Errorf("error deleting alert rule: %w", err)
+	}
+	s.reload(rule.SubscriptionID)
+	return nil
+}
+
+// DeleteRules deletes all rules of a subscription
+func (s *AlertService) DeleteRules(subID int) error {
+	return s.alertRepo.DeleteRules(subID)
+}
+
+// GetAlerts returns the latest entries of the alert log, newest first
+func (s *AlertService) GetAlerts(limit int) ([]models.Alert, error) {
+	return s.alertRepo.GetAlerts(limit)
+}
+
+// ClearAlerts empties the alert log
+func (s *AlertService) ClearAlerts() error {
+	return s.alertRepo.ClearAlerts()
+}
+
+// newMonitor compiles the enabled rules of a subscription. Invalid rules are
+// logged and left out.
+func (s *AlertService) newMonitor(subID int) (*alerts.Monitor, error) {
+	rules, err := s.alertRepo.GetRules(subID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading alert rules: %w", err)
+	}
+
+	specs := make([]alerts.Rule, len(rules))
+	for i, rule := range rules {
+		specs[i] = toAlertsRule(rule)
+	}
+	monitor, err := alerts.NewMonitor(specs, time.Now())
+	if err != nil {
+		log.Printf("Ignoring invalid alert rules of sub %d: %v", subID, err)
+	}
+	return monitor, nil
+}
+
+// reload replaces the monitor of a watched subscription after its rules changed
+func (s *AlertService) reload(subID int) {
+	s.mutex.RLock()
+	var watched *watchedSubscription
+	for _, w := range s.watched {
+		if w.subscription.ID == subID {
+			watched = w
+			break
+		}
+	}
+	s.mutex.RUnlock()
+	if watched == nil {
+		return
+	}
+
+	if err := s.Watch(watched.subscription); err != nil {
+		log.Printf("Error reloading alert rules of sub %s: %v", watched.subscription.SubName, err)
+	}
+}
+
+// record logs firings and hands them to the alert handler
+func (s *AlertService) record(subName string, firings []alerts.Firing, summary string) {
+	s.mutex.RLock()
+	onAlert := s.onAlert
+	s.mutex.RUnlock()
+
+	for _, firing := range firings {
+		alert := models.Alert{
+			RuleID:   firing.Rule.ID,
+			RuleName: firing.Rule.Name,
+			SubName:  subName,
+			Subject:  firing.Subject,
+			Detail:   firing.Detail,
+			Payload:  summary,
+			FiredAt:  firing.At,
+		}
+		log.Printf("Alert %s fired on sub %s: %s", alert.RuleName, subName, alert.Detail)
+
+		if err := s.alertRepo.AppendAlert(alert); err != nil {
+			log.Printf("Error logging alert %s: %v", alert.RuleName, err)
+		}
+		if onAlert != nil {
+			onAlert(alert)
+		}
+	}
+}
+
+// toAlertsRule converts a stored rule for evaluation
+func toAlertsRule(rule models.AlertRule) alerts.Rule {
+	return alerts.Rule{
+		ID:         rule.ID,
+		Name:       rule.Name,
+		Kind:       rule.Kind,
+		Expression: rule.Expression,
+		Threshold:  rule.Threshold,
+		Cooldown:   rule.Cooldown,
+		Enabled:    rule.Enabled,
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/bridge"
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+// runningBridge is a started bridge and the connections it owns
+type runningBridge struct {
+	bridge    models.Bridge
+	source    messaging.MessagingProvider
+	target    messaging.MessagingProvider
+	forwarder *bridge.Forwarder
+}
+
+type BridgeService struct {
+	bridgeRepo    *database.BridgeRepository
+	serverService *ServerService
+	running       map[int]*runningBridge
+	starting      map[int]bool
+	mutex         sync.RWMutex
+}
+
+// NewBridgeService creates a new bridge service
+func NewBridgeService(bridgeRepo *database.BridgeRepository, serverService *ServerService) *BridgeService {
+	return &BridgeService{
+		bridgeRepo:    bridgeRepo,
+		serverService: serverService,
+		running:       make(map[int]*runningBridge),
+		starting:      make(map[int]bool),
+	}
+}
+
+// GetBridges returns all saved bridges
+func (s *BridgeService) GetBridges() ([]models.Bridge, error) {
+	return s.bridgeRepo.GetAll()
+}
+
+// SaveBridge validates and saves a bridge. A running bridge keeps its old
+// settings until it is restarted.
+func (s *BridgeService) SaveBridge(b models.Bridge) (int, error) {
+	if strings.TrimSpace(b.Name) == "" {
+		return 0, fmt.Errorf("bridge name is required")
+	}
+	if strings.TrimSpace(b.SourcePattern) == "" {
+		return 0, fmt.Errorf("source pattern is required")
+	}
+	if err := bridgeConfig(b).Validate(); err != nil {
+		return 0, err
+	}
+	if b.SourceServerID == b.TargetServerID {
+		if err := bridge.CheckLoop(b.SourcePattern, b.Mappings); err != nil {
+			return 0, err
+		}
+	}
+	source, err := s.findServer(b.SourceServerID)
+	if err != nil {
+		return 0, err
+	}
+	caps, err := s.serverService.GetCapabilities(source.ProviderType)
+	if err != nil {
+		return 0, err
+	}
+	if err := caps.ValidatePattern(b.SourcePattern); err != nil {
+		return 0, err
+	}
+
+	id, err := s.bridgeRepo.Save(b)
+	if err != nil {
+		return 0, fmt.Errorf("error saving bridge: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteBridge stops and deletes a bridge
+func (s *BridgeService) DeleteBridge(bridgeID int) error {
+	s.Stop(bridgeID)
+	return s.bridgeRepo.Delete(bridgeID)
+}
+
+// Start subscribes on the source server and republishes to the target server.
+// Both sides use their own connections, so the bridge runs independently of
+// the selected server and its subscription tabs.
+func (s *BridgeService) Start(b models.Bridge) error {
+	if b.SourceServerID == b.TargetServerID {
+		if err := bridge.CheckLoop(b.SourcePattern, b.Mappings); err != nil {
+			return err
+		}
+	}
+
+	// Reserve the ID so a second Start doesn't open connections too
+	s.mutex.Lock()
+	if _, ok := s.running[b.ID]; ok || s.starting[b.ID] {
+		s.mutex.Unlock()
+		return fmt.Errorf("bridge %s is already running", b.Name)
+	}
+	s.starting[b.ID] = true
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.starting, b.ID)
+		s.mutex.Unlock()
+	}()
+
+	sourceServer, err := s.findServer(b.SourceServerID)
+	if err != nil {
+		return err
+	}
+	targetServer, err := s.findServer(b.TargetServerID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.serverService.OpenProvider(targetServer.ProviderType, targetServer.URL)
+	if err != nil {
+		return fmt.Errorf("target %s: %w", targetServer.Name, err)
+	}
+	headerPublisher, supportsHeaders := target.(messaging.HeaderPublisher)
+
+	forwarder, err := bridge.NewForwarder(bridgeConfig(b),
+		func(subject string, data []byte, headers map[string]string) error {
+			if len(headers) > 0 && supportsHeaders {
+				return headerPublisher.PublishWithHeaders(subject, data, headers)
+			}
+			return target.Publish(subject, data)
+		})
+	if err != nil {
+		target.Close()
+		return err
+	}
+
+	source, err := s.serverService.OpenProvider(sourceServer.ProviderType, sourceServer.URL)
+	if err != nil {
+		target.Close()
+		return fmt.Errorf("source %s: %w", sourceServer.Name, err)
+	}
+
+	// Failures are counted by the forwarder; only log when the error changes
+	var lastError string
+	var lastErrorMutex sync.Mutex
+	err = source.Subscribe(b.SourcePattern, func(msg *messaging.Message) {
+		err := forwarder.Forward(msg.Subject, msg.Data, msg.Headers)
+		if err == nil {
+			return
+		}
+		lastErrorMutex.Lock()
+		defer lastErrorMutex.Unlock()
+		if err.Error() != lastError {
+			lastError = err.Error()
+			log.Printf("Bridge %s: %v", b.Name, err)
+		}
+	})
+	if err != nil {
+		source.Close()
+		target.Close()
+		return fmt.Errorf("error subscribing to %s: %w", b.SourcePattern, err)
+	}
+
+	s.mutex.Lock()
+	s.running[b.ID] = &runningBridge{bridge: b, source: source, target: target, forwarder: forwarder}
+	s.mutex.Unlock()
+
+	log.Printf("Bridge %s started: %s %s -> %s", b.Name, sourceServer.Name, b.SourcePattern, targetServer.Name)
+	return nil
+}
+
+// Stop stops a running bridge and closes its connections
+func (s *BridgeService) Stop(bridgeID int) {
+	s.mutex.Lock()
+	running, ok := s.running[bridgeID]
+	delete(s.running, bridgeID)
+	s.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := running.source.Unsubscribe(running.bridge.SourcePattern); err != nil {
+		log.Printf("Error unsubscribing bridge %s: %v", running.bridge.Name, err)
+	}
+	if err := running.source.Close(); err != nil {
+		log.Printf("Error closing source of bridge %s: %v", running.bridge.Name, err)
+	}
+	if err := running.target.Close(); err != nil {
+		log.Printf("Error closing target of bridge %s: %v", running.bridge.Name, err)
+	}
+
+	stats := running.forwarder.Stats()
+	log.Printf("Bridge %s stopped: %d forwarded, %d failed", running.bridge.Name, stats.Forwarded, stats.Failed)
+}
+
+// StopAll stops every running bridge
+func (s *BridgeService) StopAll() {
+	s.mutex.RLock()
+	ids := make([]int, 0, len(s.running))
+	for id := range s.running {
+		ids = append(ids, id)
+	}
+	s.mutex.RUnlock()
+
+	for _, id := range ids {
+		s.Stop(id)
+	}
+}
+
+// IsRunning reports whether a bridge is running
+func (s *BridgeService) IsRunning(bridgeID int) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.running[bridgeID]
+	return ok
+}
+
+// GetStats returns the counts of the running bridges by ID
+func (s *BridgeService) GetStats() map[int]bridge.Stats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stats := make(map[int]bridge.Stats, len(s.running))
+	for id, running := range s.running {
+		stats[id] = running.forwarder.Stats()
+	}
+	return stats
+}
+
+// bridgeConfig returns the forwarding settings of a bridge
+func bridgeConfig(b models.Bridge) bridge.Config {
+	return bridge.Config{
+		Mappings:      b.Mappings,
+		Transform:     b.Transform,
+		Script:        b.Script,
+		ScriptTimeout: b.ScriptTimeout,
+		DropUnmapped:  b.SourceServerID == b.TargetServerID,
+	}
+}
+
+// findServer returns the saved server with an ID
+func (s *BridgeService) findServer(serverID int) (models.Server, error) {
+	servers, err := s.serverService.GetAllServers()
+	if err != nil {
+		return models.Server{}, err
+	}
+	for _, server := range servers {
+		if server.ID == serverID {
+			return server, nil
+		}
+	}
+	return models.Server{}, fmt.Errorf("server %d not found", serverID)
+}
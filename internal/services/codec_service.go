@@ -0,0 +1,245 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/codec"
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+type CodecService struct {
+	serverRepo       *database.ServerRepository
+	protoSchemaRepo  *database.ProtoSchemaRepository
+	protoMappingRepo *database.ProtoMappingRepository
+	protoTypes       map[int]*codec.ProtoTypes
+	registries       map[int]*codec.Registry
+	mutex            sync.Mutex
+}
+
+// NewCodecService creates a new codec service
+func NewCodecService(serverRepo *database.ServerRepository, protoSchemaRepo *database.ProtoSchemaRepository, protoMappingRepo *database.ProtoMappingRepository) *CodecService {
+	return &CodecService{
+		serverRepo:       serverRepo,
+		protoSchemaRepo:  protoSchemaRepo,
+		protoMappingRepo: protoMappingRepo,
+		protoTypes:       make(map[int]*codec.ProtoTypes),
+		registries:       make(map[int]*codec.Registry),
+	}
+}
+
+// GetProtoSchemas returns the protobuf schemas registered for a server
+func (s *CodecService) GetProtoSchemas(serverID int) ([]models.ProtoSchema, error) {
+	return s.protoSchemaRepo.GetByServerID(serverID)
+}
+
+// SaveProtoSchema validates and saves a protobuf schema. The schema must
+// compile together with the schemas already registered for the server.
+func (s *CodecService) SaveProtoSchema(serverID int, name string, content []byte, descriptorSet bool) error {
+	schemas, err := s.protoSchemaRepo.GetByServerID(serverID)
+	if err != nil {
+		return err
+	}
+	schemas = append(schemas, models.ProtoSchema{Name: name, Content: content, DescriptorSet: descriptorSet})
+
+	if _, err := codec.LoadProtoTypes(protoSources(schemas)); err != nil {
+		return err
+	}
+
+	if err := s.protoSchemaRepo.Save(serverID, name, content, descriptorSet); err != nil {
+		return err
+	}
+	s.invalidate(serverID)
+	return nil
+}
+
+// DeleteProtoSchema deletes a protobuf schema
+func (s *CodecService) DeleteProtoSchema(serverID, schemaID int) error {
+	if err := s.protoSchemaRepo.Delete(schemaID); err != nil {
+		return err
+	}
+	s.invalidate(serverID)
+	return nil
+}
+
+// GetProtoMappings returns the subject to message type mappings for a server
+func (s *CodecService) GetProtoMappings(serverID int) ([]models.ProtoMapping, error) {
+	return s.protoMappingRepo.GetByServerID(serverID)
+}
+
+// SaveProtoMapping maps a subject pattern to a known protobuf message type
+func (s *CodecService) SaveProtoMapping(serverID int, subjectPattern, messageType string) error {
+	types, err := s.loadProtoTypes(serverID)
+	if err != nil {
+		return err
+	}
+	if _, err := types.Codec(messageType); err != nil {
+		return err
+	}
+
+	if err := s.protoMappingRepo.Save(serverID, subjectPattern, messageType); err != nil {
+		return err
+	}
+	s.invalidate(serverID)
+	return nil
+}
+
+// DeleteProtoMapping deletes a protobuf mapping
+func (s *CodecService) DeleteProtoMapping(serverID, mappingID int) error {
+	if err := s.protoMappingRepo.Delete(mappingID); err != nil {
+		return err
+	}
+	s.invalidate(serverID)
+	return nil
+}
+
+// GetMessageTypes returns the protobuf message types known for a server
+func (s *CodecService) GetMessageTypes(serverID int) ([]string, error) {
+	types, err := s.loadProtoTypes(serverID)
+	if err != nil {
+		return nil, err
+	}
+	return types.MessageTypes(), nil
+}
+
+// GetSchemaRegistryURL returns the Schema Registry URL configured for a server
+func (s *CodecService) GetSchemaRegistryURL(serverID int) (string, error) {
+	return s.serverRepo.GetSchemaRegistryURL(serverID)
+}
+
+// SetSchemaRegistryURL configures the Schema Registry used to decode and encode
+// Avro payloads. An empty URL disables Avro support for the server.
+func (s *CodecService) SetSchemaRegistryURL(serverID int, registryURL string) error {
+	registryURL = strings.TrimSpace(registryURL)
+	if registryURL != "" {
+		parsed, err := url.Parse(registryURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid schema registry URL %q: expected http(s)://host:port", registryURL)
+		}
+	}
+
+	if err := s.serverRepo.SetSchemaRegistryURL(serverID, registryURL); err != nil {
+		return err
+	}
+	s.invalidate(serverID)
+	return nil
+}
+
+// Decode fills msg.Decoded and msg.Schema when a codec is mapped to the
+// message subject. Messages without a mapping are left untouched.
+func (s *CodecService) Decode(serverID int, msg *messaging.Message) error {
+	registry, err := s.registry(serverID)
+	if err != nil {
+		return err
+	}
+
+	decoded, name, err := registry.Decode(msg.Subject, msg.Data)
+	if err != nil {
+		return err
+	}
+	msg.Decoded = decoded
+	msg.Schema = name
+	return nil
+}
+
+// Encode converts a JSON payload to the wire format mapped to subject. It
+// returns the payload unchanged, and an empty codec name, without a mapping.
+func (s *CodecService) Encode(serverID int, subject string, jsonData []byte) ([]byte, string, error) {
+	registry, err := s.registry(serverID)
+	if err != nil {
+		return nil, "", err
+	}
+	return registry.Encode(subject, jsonData)
+}
+
+// registry returns the cached codec registry for a server, building it on first use
+func (s *CodecService) registry(serverID int) (*codec.Registry, error) {
+	s.mutex.Lock()
+	registry, ok := s.registries[serverID]
+	s.mutex.Unlock()
+	if ok {
+		return registry, nil
+	}
+
+	types, err := s.loadProtoTypes(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := s.protoMappingRepo.GetByServerID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	registry = codec.NewRegistry()
+	for _, mapping := range mappings {
+		c, err := types.Codec(mapping.MessageType)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", mapping.SubjectPattern, err)
+		}
+		registry.Add(mapping.SubjectPattern, c)
+	}
+
+	registryURL, err := s.serverRepo.GetSchemaRegistryURL(serverID)
+	if err != nil {
+		return nil, err
+	}
+	if registryURL != "" {
+		registry.SetSchemaRegistry(codec.NewSchemaRegistryClient(registryURL))
+	}
+
+	s.mutex.Lock()
+	s.registries[serverID] = registry
+	s.mutex.Unlock()
+	return registry, nil
+}
+
+// loadProtoTypes returns the cached protobuf types for a server, compiling them on first use
+func (s *CodecService) loadProtoTypes(serverID int) (*codec.ProtoTypes, error) {
+	s.mutex.Lock()
+	types, ok := s.protoTypes[serverID]
+	s.mutex.Unlock()
+	if ok {
+		return types, nil
+	}
+
+	schemas, err := s.protoSchemaRepo.GetByServerID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err = codec.LoadProtoTypes(protoSources(schemas))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.protoTypes[serverID] = types
+	s.mutex.Unlock()
+	return types, nil
+}
+
+// invalidate drops cached codecs for a server after its schemas or mappings change
+func (s *CodecService) invalidate(serverID int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.protoTypes, serverID)
+	delete(s.registries, serverID)
+}
+
+// protoSources converts stored schemas to codec sources
+func protoSources(schemas []models.ProtoSchema) []codec.ProtoSource {
+	sources := make([]codec.ProtoSource, len(schemas))
+	for i, schema := range schemas {
+		sources[i] = codec.ProtoSource{
+			Name:          schema.Name,
+			Content:       schema.Content,
+			DescriptorSet: schema.DescriptorSet,
+		}
+	}
+	return sources
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/templating"
+)
+
+// collectionFormat identifies exported collection files
+const collectionFormat = "broker-ui-collection"
+
+// collectionFormatVersion is the version of the exported collection file
+const collectionFormatVersion = 1
+
+// CollectionFile is the shareable JSON form of a collection. Environments are
+// not included, so credentials stay local.
+type CollectionFile struct {
+	Format   string                  `json:"format"`
+	Version  int                     `json:"version"`
+	Name     string                  `json:"name"`
+	Requests []CollectionFileRequest `json:"requests"`
+}
+
+// CollectionFileRequest is a request in a collection file
+type CollectionFileRequest struct {
+	Folder   string                 `json:"folder,omitempty"`
+	Name     string                 `json:"name"`
+	Provider messaging.ProviderType `json:"provider"`
+	Server   string                 `json:"server"`
+	Subject  string                 `json:"subject"`
+	Headers  map[string]string      `json:"headers,omitempty"`
+	Body     string                 `json:"body"`
+}
+
+// ResolvedRequest is a collection request rendered with an environment
+type ResolvedRequest struct {
+	ProviderType messaging.ProviderType
+	ServerURL    string
+	Subject      string
+	Headers      map[string]string
+	Body         []byte
+	Seq          int64
+}
+
+type CollectionService struct {
+	collectionRepo  *database.CollectionRepository
+	environmentRepo *database.EnvironmentRepository
+	serverService   *ServerService
+	messageService  *MessageService
+	sequences       map[int]int64
+	mutex           sync.Mutex
+}
+
+// NewCollectionService creates a new collection service
+func NewCollectionService(collectionRepo *database.CollectionRepository, environmentRepo *database.EnvironmentRepository, serverService *ServerService, messageService *MessageService) *CollectionService {
+	return &CollectionService{
+		collectionRepo:  collectionRepo,
+		environmentRepo: environmentRepo,
+		serverService:   serverService,
+		messageService:  messageService,
+		sequences:       make(map[int]int64),
+	}
+}
+
+// GetCollections returns all collections
+func (s *CollectionService) GetCollections() ([]models.Collection, error) {
+	return s.collectionRepo.GetAll()
+}
+
+// CreateCollection creates an empty collection
+func (s *CollectionService) CreateCollection(name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("collection name is required")
+	}
+	return s.collectionRepo.Create(name)
+}
+
+// DeleteCollection deletes a collection and its requests
+func (s *CollectionService) DeleteCollection(collectionID int) error {
+	return s.collectionRepo.Delete(collectionID)
+}
+
+// GetRequests returns the requests of a collection
+func (s *CollectionService) GetRequests(collectionID int) ([]models.CollectionRequest, error) {
+	return s.collectionRepo.GetRequests(collectionID)
+}
+
+// SaveRequest checks the request templates and saves the request, returning its ID
+func (s *CollectionService) SaveRequest(request models.CollectionRequest) (int, error) {
+	request.Name = strings.TrimSpace(request.Name)
+	request.Folder = cleanFolder(request.Folder)
+	if request.Name == "" {
+		return 0, fmt.Errorf("request name is required")
+	}
+	if request.ProviderType == "" {
+		return 0, fmt.Errorf("request provider is required")
+	}
+
+	templates := map[string]string{
+		"server URL": request.ServerURL,
+		"subject":    request.Subject,
+		"body":       request.Body,
+	}
+	for name, value := range request.Headers {
+		templates["header "+name] = value
+	}
+	for field, text := range templates {
+		if err := templating.Parse(text); err != nil {
+			return 0, fmt.Errorf("%s: %w", field, err)
+		}
+	}
+
+	return s.collectionRepo.SaveRequest(request)
+}
+
+// DeleteRequest deletes a request
+func (s *CollectionService) DeleteRequest(requestID int) error {
+	return s.collectionRepo.DeleteRequest(requestID)
+}
+
+// GetEnvironments returns all environments
+func (s *CollectionService) GetEnvironments() ([]models.Environment, error) {
+	return s.environmentRepo.GetAll()
+}
+
+// SaveEnvironment saves an environment, replacing one with the same name
+func (s *CollectionService) SaveEnvironment(name string, variables map[string]string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("environment name is required")
+	}
+	return s.environmentRepo.Save(name, variables)
+}
+
+// DeleteEnvironment deletes an environment
+func (s *CollectionService) DeleteEnvironment(environmentID int) error {
+	return s.environmentRepo.Delete(environmentID)
+}
+
+// Resolve renders a request with the variables of an environment (nil for
+// none) and the next sequence number of the request
+func (s *CollectionService) Resolve(request models.CollectionRequest, environment *models.Environment) (ResolvedRequest, error) {
+	s.mutex.Lock()
+	seq := s.sequences[request.ID] + 1
+	s.mutex.Unlock()
+
+	ctx := templating.Context{Seq: seq, Vars: map[string]string{}}
+	if environment != nil && environment.Variables != nil {
+		ctx.Vars = environment.Variables
+	}
+
+	render := func(field, text string) (string, error) {
+		rendered, err := templating.Render(text, ctx)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", field, err)
+		}
+		return string(rendered), nil
+	}
+
+	resolved := ResolvedRequest{ProviderType: request.ProviderType, Seq: seq}
+	var err error
+	if resolved.ServerURL, err = render("server URL", request.ServerURL); err != nil {
+		return resolved, err
+	}
+	if resolved.Subject, err = render("subject", request.Subject); err != nil {
+		return resolved, err
+	}
+
+	if len(request.Headers) > 0 {
+		resolved.Headers = make(map[string]string, len(request.Headers))
+		for name, value := range request.Headers {
+			if resolved.Headers[name], err = render("header "+name, value); err != nil {
+				return resolved, err
+			}
+		}
+	}
+
+	body, err := render("body", request.Body)
+	if err != nil {
+		return resolved, err
+	}
+	resolved.Body = []byte(body)
+
+	if resolved.ServerURL == "" || resolved.Subject == "" {
+		return resolved, fmt.Errorf("request %s needs a server URL and a subject", request.Name)
+	}
+	return resolved, nil
+}
+
+// Send publishes a resolved request, reusing a matching server connection when
+// one is open
+func (s *CollectionService) Send(requestID int, resolved ResolvedRequest) error {
+	provider, release, err := s.serverService.AcquireProvider(resolved.ProviderType, resolved.ServerURL)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	err = s.messageService.PublishMessageWithHeaders(provider, resolved.Subject, resolved.Body, resolved.Headers)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if resolved.Seq > s.sequences[requestID] {
+		s.sequences[requestID] = resolved.Seq
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+// ExportCollection renders a collection as a shareable JSON file
+func (s *CollectionService) ExportCollection(collectionID int) ([]byte, error) {
+	collections, err := s.collectionRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	file := CollectionFile{Format: collectionFormat, Version: collectionFormatVersion, Requests: []CollectionFileRequest{}}
+	for _, c := range collections {
+		if c.ID == collectionID {
+			file.Name = c.Name
+		}
+	}
+	if file.Name == "" {
+		return nil, fmt.Errorf("collection %d not found", collectionID)
+	}
+
+	requests, err := s.collectionRepo.GetRequests(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		file.Requests = append(file.Requests, CollectionFileRequest{
+			Folder:   req.Folder,
+			Name:     req.Name,
+			Provider: req.ProviderType,
+			Server:   req.ServerURL,
+			Subject:  req.Subject,
+			Headers:  req.Headers,
+			Body:     req.Body,
+		})
+	}
+
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// ImportCollection creates a collection from an exported JSON file. A name
+// already in use gets a numeric suffix. It returns the new collection ID.
+func (s *CollectionService) ImportCollection(data []byte) (int, error) {
+	var file CollectionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("invalid collection file: %w", err)
+	}
+	if file.Format != collectionFormat {
+		return 0, fmt.Errorf("invalid collection file: unknown format %q", file.Format)
+	}
+	if file.Version > collectionFormatVersion {
+		return 0, fmt.Errorf("collection file version %d is newer than supported version %d", file.Version, collectionFormatVersion)
+	}
+
+	collections, err := s.collectionRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	name := uniqueCollectionName(file.Name, collections)
+
+	collectionID, err := s.CreateCollection(name)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, req := range file.Requests {
+		_, err := s.SaveRequest(models.CollectionRequest{
+			CollectionID: collectionID,
+			Folder:       req.Folder,
+			Name:         req.Name,
+			ProviderType: req.Provider,
+			ServerURL:    req.Server,
+			Subject:      req.Subject,
+			Headers:      req.Headers,
+			Body:         req.Body,
+		})
+		if err != nil {
+			// Don't leave a half-imported collection behind
+			s.collectionRepo.Delete(collectionID)
+			return 0, fmt.Errorf("request %s: %w", req.Name, err)
+		}
+	}
+
+	return collectionID, nil
+}
+
+// Folders returns every folder path used by requests, including parents, sorted
+func Folders(requests []models.CollectionRequest) []string {
+	seen := map[string]bool{}
+	for _, req := range requests {
+		parts := strings.Split(req.Folder, "/")
+		for i := range parts {
+			if folder := strings.Join(parts[:i+1], "/"); folder != "" {
+				seen[folder] = true
+			}
+		}
+	}
+
+	folders := make([]string, 0, len(seen))
+	for folder := range seen {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// cleanFolder normalizes a folder path such as " /orders//created/ " to "orders/created"
+func cleanFolder(folder string) string {
+	var parts []string
+	for _, part := range strings.Split(folder, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// uniqueCollectionName appends " (2)", " (3)", ... to name while it is taken
+func uniqueCollectionName(name string, collections []models.Collection) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Imported"
+	}
+
+	taken := map[string]bool{}
+	for _, c := range collections {
+		taken[c.Name] = true
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return candidate
+}
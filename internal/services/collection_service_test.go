@@ -0,0 +1,217 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+)
+
+// testCollectionService creates a collection service over a fresh database
+func testCollectionService(t *testing.T) (*CollectionService, models.Server) {
+	t.Helper()
+	db, serverService, messageService, server := testServices(t)
+	collectionService := NewCollectionService(
+		database.NewCollectionRepository(db.GetDB()),
+		database.NewEnvironmentRepository(db.GetDB()),
+		serverService,
+		messageService,
+	)
+	return collectionService, server
+}
+
+// collectionFile decodes an exported collection
+func collectionFile(t *testing.T, data []byte) CollectionFile {
+	t.Helper()
+	var file CollectionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("exported collection is not JSON: %v\n%s", err, data)
+	}
+	return file
+}
+
+func TestCollectionExportImportRoundTrip(t *testing.T) {
+	collectionService, server := testCollectionService(t)
+
+	collectionID, err := collectionService.CreateCollection("Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := []models.CollectionRequest{
+		{
+			CollectionID: collectionID,
+			Folder:       " created/ eu/ ",
+			Name:         "EU order",
+			ProviderType: messaging.ProviderMemory,
+			ServerURL:    "{{.server}}",
+			Subject:      "orders.eu.created",
+			Headers:      map[string]string{"X-Tenant": "{{.tenant}}"},
+			Body:         `{"id":"{{uuid}}","n":{{seq}}}`,
+		},
+		{
+			CollectionID: collectionID,
+			Name:         "ping",
+			ProviderType: messaging.ProviderMemory,
+			ServerURL:    server.URL,
+			Subject:      "ping",
+		},
+	}
+	for _, request := range requests {
+		if _, err := collectionService.SaveRequest(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exported, err := collectionService.ExportCollection(collectionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := collectionFile(t, exported)
+	if original.Format != collectionFormat || original.Version != collectionFormatVersion || original.Name != "Orders" {
+		t.Errorf("exported header = %s %d %q", original.Format, original.Version, original.Name)
+	}
+	// Requests are ordered by folder, with the normalized folder path
+	if len(original.Requests) != 2 || original.Requests[0].Name != "ping" || original.Requests[1].Folder != "created/eu" {
+		t.Fatalf("exported requests = %+v", original.Requests)
+	}
+
+	// Importing next to the original renames the copy, twice
+	for _, wantName := range []string{"Orders (2)", "Orders (3)"} {
+		importedID, err := collectionService.ImportCollection(exported)
+		if err != nil {
+			t.Fatalf("ImportCollection: %v", err)
+		}
+		if importedID == collectionID {
+			t.Fatal("the import replaced the original collection")
+		}
+
+		reexported, err := collectionService.ExportCollection(importedID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported := collectionFile(t, reexported)
+		if imported.Name != wantName {
+			t.Errorf("imported name = %q, want %q", imported.Name, wantName)
+		}
+		if !reflect.DeepEqual(imported.Requests, original.Requests) {
+			t.Errorf("imported requests = %+v, want %+v", imported.Requests, original.Requests)
+		}
+	}
+
+	collections, err := collectionService.GetCollections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 3 {
+		t.Errorf("collections = %+v, want the original and two copies", collections)
+	}
+	if got, _ := collectionService.GetRequests(collectionID); len(got) != 2 {
+		t.Errorf("the original has %d requests after importing, want 2", len(got))
+	}
+}
+
+func TestCollectionImportErrors(t *testing.T) {
+	collectionService, _ := testCollectionService(t)
+
+	tests := []struct {
+		name string
+		data string
+		// want is part of the expected error
+		want string
+	}{
+		{"not JSON", `{"format":`, "invalid collection file"},
+		{"unknown format", `{"format":"postman","version":1,"name":"x"}`, `unknown format "postman"`},
+		{"newer version", `{"format":"broker-ui-collection","version":2,"name":"x"}`, "version 2 is newer"},
+		{
+			"invalid request template",
+			`{"format":"broker-ui-collection","version":1,"name":"x","requests":[` +
+				`{"name":"ok","provider":"memory","server":"memory://x","subject":"a","body":"{}"},` +
+				`{"name":"broken","provider":"memory","server":"memory://x","subject":"a","body":"{{if}}"}]}`,
+			"request broken: body",
+		},
+		{
+			"request without provider",
+			`{"format":"broker-ui-collection","version":1,"name":"x","requests":[{"name":"a","subject":"a"}]}`,
+			"request a: request provider is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := collectionService.ImportCollection([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ImportCollection error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	// Failed imports leave no collection behind
+	if collections, _ := collectionService.GetCollections(); len(collections) != 0 {
+		t.Errorf("collections after failed imports = %+v", collections)
+	}
+}
+
+func TestCollectionResolve(t *testing.T) {
+	collectionService, server := testCollectionService(t)
+
+	request := models.CollectionRequest{
+		ID:           1,
+		Name:         "order",
+		ProviderType: messaging.ProviderMemory,
+		ServerURL:    "{{.server}}",
+		Subject:      "orders.{{.region}}",
+		Headers:      map[string]string{"X-Tenant": "{{var \"tenant\"}}"},
+		Body:         `{"n":{{seq}}}`,
+	}
+	environment := &models.Environment{Name: "dev", Variables: map[string]string{
+		"server": server.URL,
+		"region": "eu",
+		"tenant": "acme",
+	}}
+
+	resolved, err := collectionService.Resolve(request, environment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ResolvedRequest{
+		ProviderType: messaging.ProviderMemory,
+		ServerURL:    server.URL,
+		Subject:      "orders.eu",
+		Headers:      map[string]string{"X-Tenant": "acme"},
+		Body:         []byte(`{"n":1}`),
+		Seq:          1,
+	}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("Resolve = %+v, want %+v", resolved, want)
+	}
+
+	// The sequence advances once the request is sent
+	if err := collectionService.Send(request.ID, resolved); err != nil {
+		t.Fatal(err)
+	}
+	if resolved, _ := collectionService.Resolve(request, environment); resolved.Seq != 2 {
+		t.Errorf("seq after Send = %d, want 2", resolved.Seq)
+	}
+
+	// Missing variables name the field that uses them
+	tests := []struct {
+		name        string
+		environment *models.Environment
+		want        string
+	}{
+		{"no environment", nil, "server URL:"},
+		{"missing header variable", &models.Environment{Variables: map[string]string{"server": server.URL, "region": "eu"}}, `header X-Tenant: `},
+		{"empty server URL", &models.Environment{Variables: map[string]string{"server": "", "region": "eu", "tenant": "acme"}}, "needs a server URL and a subject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := collectionService.Resolve(request, tt.environment)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Resolve error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
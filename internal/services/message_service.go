@@ -1,20 +1,45 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/devalexandre/broker-ui/internal/bench"
 	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/filter"
+	"github.com/devalexandre/broker-ui/internal/jsonschema"
 	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/metrics"
+	"github.com/devalexandre/broker-ui/internal/payload"
+	"github.com/devalexandre/broker-ui/internal/scripting"
 )
 
+// TrafficStats accounts for the messages received by a subscription
+type TrafficStats struct {
+	Messages    uint64
+	Bytes       uint64
+	LastMessage time.Time
+}
+
+// MessageObserver is notified of each message received by a subscription
+type MessageObserver func(subName string, msg *messaging.Message)
+
 type MessageService struct {
 	topicRepo        *database.TopicRepository
 	subscriptionRepo *database.SubscriptionRepository
 	sentMessages     map[string][]string
-	receivedMessages map[string][]string
-	dashboardCounts  map[string]int
+	traffic          map[string]TrafficStats
+	violationCounts  map[string]int
+	queues           map[string]*messaging.MessageQueue
+	active           map[string]bool
+	handoffs         map[string]func()
+	probes           map[string]bench.ProbeStats
+	metrics          *metrics.Metrics
+	observers        []MessageObserver
 	mutex            sync.RWMutex
 }
 
@@ -24,24 +49,90 @@ func NewMessageService(topicRepo *database.TopicRepository, subscriptionRepo *da
 		topicRepo:        topicRepo,
 		subscriptionRepo: subscriptionRepo,
 		sentMessages:     make(map[string][]string),
-		receivedMessages: make(map[string][]string),
-		dashboardCounts:  make(map[string]int),
+		traffic:          make(map[string]TrafficStats),
+		violationCounts:  make(map[string]int),
+		queues:           make(map[string]*messaging.MessageQueue),
+		active:           make(map[string]bool),
+		handoffs:         make(map[string]func()),
+		probes:           make(map[string]bench.ProbeStats),
 	}
 }
 
+// SetMetrics records traffic in m; nil disables recording
+func (s *MessageService) SetMetrics(m *metrics.Metrics) {
+	s.mutex.Lock()
+	s.metrics = m
+	s.mutex.Unlock()
+}
+
+// getMetrics returns the metrics in use, possibly nil
+func (s *MessageService) getMetrics() *metrics.Metrics {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.metrics
+}
+
+// AddObserver registers an observer for messages received by subscriptions
+// started afterwards
+func (s *MessageService) AddObserver(observer MessageObserver) {
+	s.mutex.Lock()
+	s.observers = append(s.observers, observer)
+	s.mutex.Unlock()
+}
+
 // SaveTopic saves a new topic
 func (s *MessageService) SaveTopic(serverID int, topicName string) error {
 	return s.topicRepo.Save(serverID, topicName)
 }
 
+// SetTopicSchema attaches a JSON Schema to a topic after checking that it compiles
+func (s *MessageService) SetTopicSchema(topicID int, schema string, rejectInvalid bool) error {
+	if err := checkJSONSchema(schema); err != nil {
+		return err
+	}
+	return s.topicRepo.SetJSONSchema(topicID, schema, rejectInvalid)
+}
+
 // DeleteTopic deletes a topic
 func (s *MessageService) DeleteTopic(topicName string, serverID int) error {
 	return s.topicRepo.Delete(topicName, serverID)
 }
 
 // SaveSubscription saves a new subscription
-func (s *MessageService) SaveSubscription(serverID int, subName, subjectPattern string) error {
-	return s.subscriptionRepo.Save(serverID, subName, subjectPattern)
+func (s *MessageService) SaveSubscription(serverID int, subName, subjectPattern string, policy messaging.BackpressurePolicy) error {
+	return s.subscriptionRepo.Save(serverID, subName, subjectPattern, policy)
+}
+
+// SetSubscriptionSchema attaches a JSON Schema to a subscription after checking that it compiles
+func (s *MessageService) SetSubscriptionSchema(subID int, schema string) error {
+	if err := checkJSONSchema(schema); err != nil {
+		return err
+	}
+	return s.subscriptionRepo.SetJSONSchema(subID, schema)
+}
+
+// SetSubscriptionFilters saves the filter rules of a subscription after
+// checking that they compile
+func (s *MessageService) SetSubscriptionFilters(subID int, rules []filter.Rule) error {
+	// Disabled rules are checked too, so they still work once enabled
+	for _, rule := range rules {
+		rule.Enabled = true
+		if _, err := filter.Compile([]filter.Rule{rule}); err != nil {
+			return err
+		}
+	}
+	return s.subscriptionRepo.SetFilters(subID, rules)
+}
+
+// SetSubscriptionScript attaches a script to a subscription after checking
+// that it compiles
+func (s *MessageService) SetSubscriptionScript(subID int, script string, timeout time.Duration) error {
+	if strings.TrimSpace(script) != "" {
+		if _, err := scripting.Compile(script, timeout); err != nil {
+			return err
+		}
+	}
+	return s.subscriptionRepo.SetScript(subID, script, timeout)
 }
 
 // DeleteSubscription deletes a subscription
@@ -49,53 +140,240 @@ func (s *MessageService) DeleteSubscription(subName string, serverID int) error
 	return s.subscriptionRepo.Delete(subName, serverID)
 }
 
-// PublishMessage publishes a message to a topic
-func (s *MessageService) PublishMessage(provider messaging.MessagingProvider, subject, payload string) error {
-	if payload == "" {
+// PublishMessage publishes a raw payload to a topic
+func (s *MessageService) PublishMessage(provider messaging.MessagingProvider, subject string, data []byte) error {
+	return s.PublishMessageWithHeaders(provider, subject, data, nil)
+}
+
+// PublishMessageWithHeaders publishes a raw payload with headers to a topic.
+// It fails when headers are given and the provider cannot send them.
+func (s *MessageService) PublishMessageWithHeaders(provider messaging.MessagingProvider, subject string, data []byte, headers map[string]string) error {
+	if len(data) == 0 {
 		return nil
 	}
+	if err := messaging.CapabilitiesOf(provider).ValidateSubject(subject); err != nil {
+		return err
+	}
 
-	err := provider.Publish(subject, []byte(payload))
+	var err error
+	start := time.Now()
+	if len(headers) > 0 {
+		headerPublisher, ok := provider.(messaging.HeaderPublisher)
+		if !ok {
+			return fmt.Errorf("%s provider does not support message headers", provider.GetProviderType())
+		}
+		err = headerPublisher.PublishWithHeaders(subject, data, headers)
+	} else {
+		err = provider.Publish(subject, data)
+	}
+	s.getMetrics().MessagePublished(provider, subject, len(data), time.Since(start), err)
 	if err != nil {
 		return fmt.Errorf("error publishing message: %w", err)
 	}
 
-	log.Printf("Sending message to topic %s: %s", subject, payload)
+	summary := payload.Summary(data)
+	log.Printf("Sending message to topic %s: %s", subject, summary)
 
 	// Store sent message
 	s.mutex.Lock()
-	s.sentMessages[subject] = append(s.sentMessages[subject], payload)
+	s.sentMessages[subject] = append(s.sentMessages[subject], summary)
 	s.mutex.Unlock()
 
 	return nil
 }
 
-// Subscribe subscribes to a subject pattern
-func (s *MessageService) Subscribe(provider messaging.MessagingProvider, subName, subjectPattern string, messageChan chan<- string) error {
+// Request sends a request through a provider with request/reply and waits
+// up to timeout for the reply
+func (s *MessageService) Request(provider messaging.MessagingProvider, subject string, data []byte, headers map[string]string, timeout time.Duration) (*messaging.Message, error) {
+	requester, ok := provider.(messaging.Requester)
+	if !ok {
+		return nil, fmt.Errorf("%s provider does not support request/reply", provider.GetProviderType())
+	}
+
+	start := time.Now()
+	reply, err := requester.Request(subject, data, headers, timeout)
+	if err != nil {
+		log.Printf("Request to %s failed: %v", subject, err)
+		return nil, err
+	}
+
+	log.Printf("Request to %s answered in %v: %s", subject, time.Since(start), payload.Summary(reply.Data))
+	return reply, nil
+}
+
+// RunBenchmark publishes load through a provider as described by cfg. Unlike
+// PublishMessage it doesn't log or record each message.
+func (s *MessageService) RunBenchmark(ctx context.Context, provider messaging.MessagingProvider, cfg bench.Config, interval time.Duration, progress func(bench.Progress)) (bench.Result, error) {
+	log.Printf("Starting benchmark on %s: %d messages, %v, %.0f msgs/s, %d publishers",
+		cfg.Subject, cfg.Count, cfg.Duration, cfg.Rate, cfg.Publishers)
+
+	result, err := bench.Run(ctx, provider.Publish, cfg, interval, progress)
+	if err != nil {
+		return result, err
+	}
+
+	log.Printf("Benchmark on %s finished: %d sent, %d errors, %.1f msgs/s, p99 %v",
+		cfg.Subject, result.Sent, result.Errors, result.Throughput, result.Latency.P99)
+	return result, nil
+}
+
+// RunLatencyProbe measures end-to-end latency by publishing stamped messages
+// through publisher and receiving them with subscriber, which should be a
+// separate connection. The latest stats are kept under name for the dashboard.
+func (s *MessageService) RunLatencyProbe(ctx context.Context, name string, publisher, subscriber messaging.MessagingProvider, cfg bench.ProbeConfig, interval time.Duration, progress func(bench.ProbeStats)) (bench.ProbeStats, error) {
+	headerPublisher, ok := publisher.(messaging.HeaderPublisher)
+	if cfg.Stamp == bench.StampHeader && !ok {
+		return bench.ProbeStats{}, fmt.Errorf("%s provider does not support message headers, use an envelope stamp", publisher.GetProviderType())
+	}
+
+	transport := bench.ProbeTransport{
+		Publish: func(subject string, data []byte, headers map[string]string) error {
+			if headers != nil {
+				return headerPublisher.PublishWithHeaders(subject, data, headers)
+			}
+			return publisher.Publish(subject, data)
+		},
+		Subscribe: func(subject string, handler func(data []byte, headers map[string]string)) (func(), error) {
+			err := subscriber.Subscribe(subject, func(msg *messaging.Message) {
+				handler(msg.Data, msg.Headers)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				if err := subscriber.Unsubscribe(subject); err != nil {
+					log.Printf("Error unsubscribing latency probe from %s: %v", subject, err)
+				}
+			}, nil
+		},
+	}
+
+	log.Printf("Starting latency probe %s: %s -> %s", name, cfg.PublishSubject, cfg.ReceiveSubject)
+	stats, err := bench.RunProbe(ctx, transport, cfg, interval, func(stats bench.ProbeStats) {
+		s.mutex.Lock()
+		s.probes[name] = stats
+		s.mutex.Unlock()
+		if progress != nil {
+			progress(stats)
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	log.Printf("Latency probe %s finished: %d sent, %d received, %d lost, %d reordered, p50 %v, p99 %v",
+		name, stats.Sent, stats.Received, stats.Lost, stats.Reordered, stats.Latency.P50, stats.Latency.P99)
+	return stats, nil
+}
+
+// GetLatencyProbes returns the latest stats of the latency probes by name
+func (s *MessageService) GetLatencyProbes() map[string]bench.ProbeStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	probes := make(map[string]bench.ProbeStats, len(s.probes))
+	for name, stats := range s.probes {
+		probes[name] = stats
+	}
+	return probes
+}
+
+// Subscribe subscribes to a subject pattern and feeds received messages into
+// queue, which applies the subscription's backpressure policy. Names are
+// unique among running subscriptions, except that a subscription with a
+// queue takes over one registered with HandOff.
+func (s *MessageService) Subscribe(provider messaging.MessagingProvider, subName, subjectPattern string, queue *messaging.MessageQueue) error {
+	if err := messaging.CapabilitiesOf(provider).ValidatePattern(subjectPattern); err != nil {
+		return err
+	}
+
 	s.mutex.Lock()
-	s.receivedMessages[subName] = []string{}
+	if release, ok := s.handoffs[subName]; ok && queue != nil && s.active[subName] {
+		s.mutex.Unlock()
+		release()
+		s.mutex.Lock()
+	}
+	if s.active[subName] {
+		s.mutex.Unlock()
+		return fmt.Errorf("subscription %s is already active", subName)
+	}
+	s.active[subName] = true
+	if queue != nil {
+		s.queues[subName] = queue
+	}
+	m := s.metrics
+	observers := s.observers
 	s.mutex.Unlock()
+	m.RegisterQueue(provider, subName, queue)
 
-	return provider.Subscribe(subjectPattern, func(subject string, data []byte) {
-		payload := string(data)
-		log.Printf("Received message from sub %s (subject: %s): %s", subName, subject, payload)
-
-		message := fmt.Sprintf("[%s] %s", subject, payload)
+	providerType := provider.GetProviderType()
+	err := provider.Subscribe(subjectPattern, func(msg *messaging.Message) {
+		m.MessageReceived(provider, subName, msg.Subject, len(msg.Data))
 
 		s.mutex.Lock()
-		s.receivedMessages[subName] = append(s.receivedMessages[subName], message)
-		s.dashboardCounts[subName]++
+		traffic := s.traffic[subName]
+		traffic.Messages++
+		traffic.Bytes += uint64(len(msg.Data))
+		traffic.LastMessage = time.Now()
+		s.traffic[subName] = traffic
 		s.mutex.Unlock()
 
-		// Send to channel if provided
-		if messageChan != nil {
-			select {
-			case messageChan <- message:
-			default:
-				// Channel is full, skip this message
+		for _, observe := range observers {
+			observe(subName, msg)
+		}
+
+		// Hand over to the queue; drops are recorded in its stats
+		if queue != nil {
+			if msg.Provider == "" {
+				msg.Provider = providerType
 			}
+			if msg.Timestamp == 0 {
+				msg.Timestamp = time.Now().UnixNano()
+			}
+			queue.Push(*msg)
 		}
 	})
+	if err != nil {
+		s.mutex.Lock()
+		delete(s.active, subName)
+		delete(s.queues, subName)
+		s.mutex.Unlock()
+		m.UnregisterQueue(subName)
+	}
+	return err
+}
+
+// HandOff lets a subscription with a queue, such as a UI tab, take over the
+// running subscription subName. release is called first and must stop it.
+func (s *MessageService) HandOff(subName string, release func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.active[subName] {
+		s.handoffs[subName] = release
+	}
+}
+
+// Unsubscribe stops a subscription and closes its queue
+func (s *MessageService) Unsubscribe(provider messaging.MessagingProvider, subName, subjectPattern string) error {
+	s.mutex.Lock()
+	queue, ok := s.queues[subName]
+	delete(s.queues, subName)
+	delete(s.active, subName)
+	delete(s.handoffs, subName)
+	m := s.metrics
+	s.mutex.Unlock()
+	m.UnregisterQueue(subName)
+
+	if ok {
+		if err := queue.Close(); err != nil {
+			log.Printf("Error closing queue for sub %s: %v", subName, err)
+		}
+	}
+
+	if provider == nil || !provider.IsConnected() {
+		return nil
+	}
+	return provider.Unsubscribe(subjectPattern)
 }
 
 // GetSentMessages returns sent messages for a topic
@@ -105,22 +383,67 @@ func (s *MessageService) GetSentMessages(topicName string) []string {
 	return s.sentMessages[topicName]
 }
 
-// GetReceivedMessages returns received messages for a subscription
-func (s *MessageService) GetReceivedMessages(subName string) []string {
+// GetDashboardCounts returns message counts for dashboard
+func (s *MessageService) GetDashboardCounts() map[string]int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	return s.receivedMessages[subName]
+
+	// Create a copy to avoid data races
+	result := make(map[string]int)
+	for k, v := range s.traffic {
+		result[k] = int(v.Messages)
+	}
+	return result
 }
 
-// GetDashboardCounts returns message counts for dashboard
-func (s *MessageService) GetDashboardCounts() map[string]int {
+// GetTrafficStats returns the received traffic of each subscription
+func (s *MessageService) GetTrafficStats() map[string]TrafficStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make(map[string]TrafficStats, len(s.traffic))
+	for k, v := range s.traffic {
+		result[k] = v
+	}
+	return result
+}
+
+// RecordViolation counts a message that violated its subscription's JSON Schema
+func (s *MessageService) RecordViolation(subName string) {
+	s.mutex.Lock()
+	s.violationCounts[subName]++
+	s.mutex.Unlock()
+}
+
+// GetViolationCounts returns JSON Schema violation counts per subscription
+func (s *MessageService) GetViolationCounts() map[string]int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	// Create a copy to avoid data races
 	result := make(map[string]int)
-	for k, v := range s.dashboardCounts {
+	for k, v := range s.violationCounts {
 		result[k] = v
 	}
 	return result
 }
+
+// GetSubscriptionStats returns queue accounting for each active subscription
+func (s *MessageService) GetSubscriptionStats() map[string]messaging.QueueStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make(map[string]messaging.QueueStats)
+	for subName, queue := range s.queues {
+		result[subName] = queue.Stats()
+	}
+	return result
+}
+
+// checkJSONSchema returns an error when a non-empty schema does not compile
+func checkJSONSchema(schema string) error {
+	if strings.TrimSpace(schema) == "" {
+		return nil
+	}
+	_, err := jsonschema.Compile([]byte(schema))
+	return err
+}
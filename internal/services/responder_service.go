@@ -0,0 +1,217 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/responder"
+)
+
+// runningResponder is a started responder and the connection it owns
+type runningResponder struct {
+	responder models.Responder
+	provider  messaging.MessagingProvider
+	handler   *responder.Responder
+}
+
+type ResponderService struct {
+	responderRepo *database.ResponderRepository
+	serverService *ServerService
+	running       map[int]*runningResponder
+	starting      map[int]bool
+	mutex         sync.RWMutex
+}
+
+// NewResponderService creates a new responder service
+func NewResponderService(responderRepo *database.ResponderRepository, serverService *ServerService) *ResponderService {
+	return &ResponderService{
+		responderRepo: responderRepo,
+		serverService: serverService,
+		running:       make(map[int]*runningResponder),
+		starting:      make(map[int]bool),
+	}
+}
+
+// GetResponders returns all saved responders
+func (s *ResponderService) GetResponders() ([]models.Responder, error) {
+	return s.responderRepo.GetAll()
+}
+
+// SaveResponder validates and saves a responder. A running responder keeps
+// its old settings until it is restarted.
+func (s *ResponderService) SaveResponder(m models.Responder) (int, error) {
+	if strings.TrimSpace(m.Name) == "" {
+		return 0, fmt.Errorf("responder name is required")
+	}
+	if strings.TrimSpace(m.Pattern) == "" {
+		return 0, fmt.Errorf("subject pattern is required")
+	}
+	if err := responderConfig(m).Validate(); err != nil {
+		return 0, err
+	}
+	if err := responderConfig(m).CheckLoop(m.Pattern); err != nil {
+		return 0, err
+	}
+	if err := s.checkCapabilities(m); err != nil {
+		return 0, err
+	}
+
+	id, err := s.responderRepo.Save(m)
+	if err != nil {
+		return 0, fmt.Errorf("error saving responder: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteResponder stops and deletes a responder
+func (s *ResponderService) DeleteResponder(responderID int) error {
+	s.Stop(responderID)
+	return s.responderRepo.Delete(responderID)
+}
+
+// Start subscribes to the responder's pattern on its own connection to the
+// server, so it runs independently of the selected server and its tabs
+func (s *ResponderService) Start(m models.Responder) error {
+	if err := responderConfig(m).CheckLoop(m.Pattern); err != nil {
+		return err
+	}
+
+	// Reserve the ID so a second Start doesn't open a connection too
+	s.mutex.Lock()
+	if _, ok := s.running[m.ID]; ok || s.starting[m.ID] {
+		s.mutex.Unlock()
+		return fmt.Errorf("responder %s is already running", m.Name)
+	}
+	s.starting[m.ID] = true
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.starting, m.ID)
+		s.mutex.Unlock()
+	}()
+
+	server, err := s.findServer(m.ServerID)
+	if err != nil {
+		return err
+	}
+	provider, err := s.serverService.OpenProvider(server.ProviderType, server.URL)
+	if err != nil {
+		return fmt.Errorf("server %s: %w", server.Name, err)
+	}
+
+	handler, err := responder.New(responderConfig(m), responder.ProviderSender(provider))
+	if err != nil {
+		provider.Close()
+		return err
+	}
+	if err := provider.Subscribe(m.Pattern, handler.Handle); err != nil {
+		provider.Close()
+		return fmt.Errorf("error subscribing to %s: %w", m.Pattern, err)
+	}
+
+	s.mutex.Lock()
+	s.running[m.ID] = &runningResponder{responder: m, provider: provider, handler: handler}
+	s.mutex.Unlock()
+
+	log.Printf("Responder %s started on %s %s", m.Name, server.Name, m.Pattern)
+	return nil
+}
+
+// Stop stops a running responder, dropping delayed answers, and closes its connection
+func (s *ResponderService) Stop(responderID int) {
+	s.mutex.Lock()
+	running, ok := s.running[responderID]
+	delete(s.running, responderID)
+	s.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := running.provider.Unsubscribe(running.responder.Pattern); err != nil {
+		log.Printf("Error unsubscribing responder %s: %v", running.responder.Name, err)
+	}
+	running.handler.Stop()
+	if err := running.provider.Close(); err != nil {
+		log.Printf("Error closing connection of responder %s: %v", running.responder.Name, err)
+	}
+
+	stats := running.handler.Stats()
+	log.Printf("Responder %s stopped: %d received, %d replied, %d failed", running.responder.Name, stats.Received, stats.Replied, stats.Failed)
+}
+
+// IsRunning reports whether a responder is running
+func (s *ResponderService) IsRunning(responderID int) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.running[responderID]
+	return ok
+}
+
+// GetStats returns the counts of the running responders by ID
+func (s *ResponderService) GetStats() map[int]responder.Stats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stats := make(map[int]responder.Stats, len(s.running))
+	for id, running := range s.running {
+		stats[id] = running.handler.Stats()
+	}
+	return stats
+}
+
+// responderConfig returns the answering settings of a responder
+func responderConfig(m models.Responder) responder.Config {
+	return responder.Config{
+		ReplyTemplate: m.ReplyTemplate,
+		EventSubject:  m.EventSubject,
+		EventTemplate: m.EventTemplate,
+		Headers:       m.Headers,
+		Delay:         m.Delay,
+		Jitter:        m.Jitter,
+		ErrorRate:     m.ErrorRate,
+		ErrorTemplate: m.ErrorTemplate,
+	}
+}
+
+// findServer returns the saved server with an ID
+// checkCapabilities checks the server of a responder supports its pattern,
+// replies and headers
+func (s *ResponderService) checkCapabilities(m models.Responder) error {
+	server, err := s.findServer(m.ServerID)
+	if err != nil {
+		return err
+	}
+	caps, err := s.serverService.GetCapabilities(server.ProviderType)
+	if err != nil {
+		return err
+	}
+
+	if err := caps.ValidatePattern(m.Pattern); err != nil {
+		return err
+	}
+	if strings.TrimSpace(m.ReplyTemplate) != "" && !caps.Reply {
+		return fmt.Errorf("%s servers can't answer requests; leave the reply empty and publish an event instead", server.ProviderType)
+	}
+	if len(m.Headers) > 0 && !caps.Headers {
+		return fmt.Errorf("%s servers don't support message headers", server.ProviderType)
+	}
+	return nil
+}
+
+func (s *ResponderService) findServer(serverID int) (models.Server, error) {
+	servers, err := s.serverService.GetAllServers()
+	if err != nil {
+		return models.Server{}, err
+	}
+	for _, server := range servers {
+		if server.ID == serverID {
+			return server, nil
+		}
+	}
+	return models.Server{}, fmt.Errorf("server %d not found", serverID)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/scenario"
+)
+
+type ScenarioService struct {
+	serverService  *ServerService
+	messageService *MessageService
+}
+
+// NewScenarioService creates a new scenario service
+func NewScenarioService(serverService *ServerService, messageService *MessageService) *ScenarioService {
+	return &ScenarioService{
+		serverService:  serverService,
+		messageService: messageService,
+	}
+}
+
+// Run runs a scenario against a server. It opens a connection of its own so
+// that its subscriptions don't interfere with the subscription tabs.
+func (s *ScenarioService) Run(ctx context.Context, server models.Server, sc *scenario.Scenario) (scenario.Result, error) {
+	provider, err := s.serverService.OpenProvider(server.ProviderType, server.URL)
+	if err != nil {
+		return scenario.Result{}, fmt.Errorf("server %s: %w", server.Name, err)
+	}
+	defer func() {
+		if err := provider.Close(); err != nil {
+			log.Printf("Error closing scenario connection to %s: %v", server.Name, err)
+		}
+	}()
+
+	transport := scenarioTransport{
+		Transport:      scenario.ProviderTransport(provider),
+		provider:       provider,
+		messageService: s.messageService,
+	}
+	return scenario.Run(ctx, transport, sc), nil
+}
+
+// scenarioTransport publishes through the message service, so scenario
+// messages are counted like any other published message
+type scenarioTransport struct {
+	scenario.Transport
+	provider       messaging.MessagingProvider
+	messageService *MessageService
+}
+
+func (t scenarioTransport) Publish(subject string, data []byte, headers map[string]string) error {
+	return t.messageService.PublishMessageWithHeaders(t.provider, subject, data, headers)
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"fmt"
+	"log"
+	"sync"
 
 	"github.com/devalexandre/broker-ui/internal/database"
 	"github.com/devalexandre/broker-ui/internal/messaging"
 	"github.com/devalexandre/broker-ui/internal/messaging/providers"
+	"github.com/devalexandre/broker-ui/internal/metrics"
 	"github.com/devalexandre/broker-ui/internal/models"
 )
 
@@ -15,6 +18,10 @@ type ServerService struct {
 	subscriptionRepo   *database.SubscriptionRepository
 	messagingProviders map[int]messaging.MessagingProvider
 	providerFactory    messaging.ProviderFactory
+	metrics            *metrics.Metrics
+	// providersMutex guards messagingProviders, which the local API reads
+	// from its own goroutines
+	providersMutex sync.RWMutex
 }
 
 // NewServerService creates a new server service
@@ -28,6 +35,11 @@ func NewServerService(serverRepo *database.ServerRepository, topicRepo *database
 	}
 }
 
+// SetMetrics reports connection state and events in m; nil disables reporting
+func (s *ServerService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
 // GetAllServers returns all servers
 func (s *ServerService) GetAllServers() ([]models.Server, error) {
 	return s.serverRepo.GetAll()
@@ -46,10 +58,7 @@ func (s *ServerService) UpdateServer(serverID int, name, url string, providerTyp
 // DeleteServer deletes a server and disconnects from it
 func (s *ServerService) DeleteServer(serverID int) error {
 	// Close connection if exists
-	if provider, exists := s.messagingProviders[serverID]; exists {
-		provider.Close()
-		delete(s.messagingProviders, serverID)
-	}
+	s.DisconnectFromServer(serverID)
 
 	// Delete from database
 	return s.serverRepo.Delete(serverID)
@@ -62,29 +71,104 @@ func (s *ServerService) ConnectToServer(serverID int, url string, providerType m
 		return fmt.Errorf("failed to create provider: %w", err)
 	}
 
+	name := s.serverName(serverID)
+	if notifier, ok := provider.(messaging.ConnectionNotifier); ok {
+		m := s.metrics
+		notifier.OnConnectionEvent(func(event messaging.ConnectionEvent, connErr error) {
+			log.Printf("Connection to server %s %s: %v", name, event, connErr)
+			m.ConnectionEvent(provider, event)
+		})
+	}
+
 	err = provider.Connect(url)
 	if err != nil {
 		return fmt.Errorf("failed to connect to server: %w", err)
 	}
 
+	s.providersMutex.Lock()
 	s.messagingProviders[serverID] = provider
+	s.providersMutex.Unlock()
+	s.metrics.RegisterProvider(provider, name)
 	return nil
 }
 
+// serverName returns the name of a server, or its ID if it can't be found
+func (s *ServerService) serverName(serverID int) string {
+	servers, err := s.serverRepo.GetAll()
+	if err == nil {
+		for _, server := range servers {
+			if server.ID == serverID {
+				return server.Name
+			}
+		}
+	}
+	return fmt.Sprintf("server-%d", serverID)
+}
+
 // DisconnectFromServer closes the connection to a messaging server
 func (s *ServerService) DisconnectFromServer(serverID int) {
-	if provider, ok := s.messagingProviders[serverID]; ok {
+	s.providersMutex.Lock()
+	provider, ok := s.messagingProviders[serverID]
+	delete(s.messagingProviders, serverID)
+	s.providersMutex.Unlock()
+
+	if ok {
+		s.metrics.UnregisterProvider(provider)
 		provider.Close()
-		delete(s.messagingProviders, serverID)
 	}
 }
 
 // GetMessagingProvider returns the messaging provider for a server
 func (s *ServerService) GetMessagingProvider(serverID int) (messaging.MessagingProvider, bool) {
+	s.providersMutex.RLock()
+	defer s.providersMutex.RUnlock()
 	provider, ok := s.messagingProviders[serverID]
 	return provider, ok
 }
 
+// AcquireProvider returns a provider connected to url. It reuses the connection
+// of a connected server with the same provider type and URL, or opens a new one;
+// release must be called once the provider is no longer needed.
+func (s *ServerService) AcquireProvider(providerType messaging.ProviderType, url string) (messaging.MessagingProvider, func(), error) {
+	servers, err := s.serverRepo.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, server := range servers {
+		if server.ProviderType != providerType || server.URL != url {
+			continue
+		}
+		if provider, ok := s.GetMessagingProvider(server.ID); ok && provider.IsConnected() {
+			return provider, func() {}, nil
+		}
+	}
+
+	provider, err := s.OpenProvider(providerType, url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release := func() {
+		if err := provider.Close(); err != nil {
+			log.Printf("Error closing %s connection: %v", providerType, err)
+		}
+	}
+	return provider, release, nil
+}
+
+// OpenProvider opens a new connection, separate from the server connections,
+// that the caller must close
+func (s *ServerService) OpenProvider(providerType messaging.ProviderType, url string) (messaging.MessagingProvider, error) {
+	provider, err := s.providerFactory.CreateProvider(providerType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	if err := provider.Connect(url); err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+	return provider, nil
+}
+
 // GetTopicsForServer returns all topics for a server
 func (s *ServerService) GetTopicsForServer(serverID int) ([]models.Topic, error) {
 	return s.topicRepo.GetByServerID(serverID)
@@ -95,6 +179,15 @@ func (s *ServerService) GetSubscriptionsForServer(serverID int) ([]models.Subscr
 	return s.subscriptionRepo.GetByServerID(serverID)
 }
 
+// GetCapabilities returns what a provider type supports, without connecting
+func (s *ServerService) GetCapabilities(providerType messaging.ProviderType) (messaging.Capabilities, error) {
+	provider, err := s.providerFactory.CreateProvider(providerType)
+	if err != nil {
+		return messaging.Capabilities{}, fmt.Errorf("failed to create provider: %w", err)
+	}
+	return messaging.CapabilitiesOf(provider), nil
+}
+
 // GetSupportedProviders returns the list of supported messaging providers
 func (s *ServerService) GetSupportedProviders() []string {
 	providers := s.providerFactory.(*providers.Factory).GetSupportedProviders()
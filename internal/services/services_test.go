@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/bridge"
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/scenario"
+)
+
+// testServices creates the services over a fresh database with one memory server
+func testServices(t *testing.T) (*database.Database, *ServerService, *MessageService, models.Server) {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	serverRepo := database.NewServerRepository(db.GetDB())
+	topicRepo := database.NewTopicRepository(db.GetDB())
+	subscriptionRepo := database.NewSubscriptionRepository(db.GetDB())
+	if err := serverRepo.Save("mem", "memory://"+t.Name(), messaging.ProviderMemory); err != nil {
+		t.Fatal(err)
+	}
+
+	serverService := NewServerService(serverRepo, topicRepo, subscriptionRepo)
+	servers, err := serverService.GetAllServers()
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("servers = %v, %v", servers, err)
+	}
+	return db, serverService, NewMessageService(topicRepo, subscriptionRepo), servers[0]
+}
+
+func TestResponderAnswersScenario(t *testing.T) {
+	db, serverService, messageService, server := testServices(t)
+
+	responderService := NewResponderService(database.NewResponderRepository(db.GetDB()), serverService)
+	id, err := responderService.SaveResponder(models.Responder{
+		Name:          "inventory",
+		ServerID:      server.ID,
+		Pattern:       "inventory.check",
+		EventSubject:  "inventory.checked",
+		EventTemplate: `{"sku":"{{var "json.sku"}}","available":3}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responders, err := responderService.GetResponders()
+	if err != nil || len(responders) != 1 || responders[0].ID != id {
+		t.Fatalf("responders = %+v, %v", responders, err)
+	}
+	if err := responderService.Start(responders[0]); err != nil {
+		t.Fatal(err)
+	}
+	defer responderService.Stop(id)
+
+	sc, err := scenario.Parse([]byte(`
+name: inventory
+steps:
+  - publish: {subject: inventory.check, data: {sku: a1}}
+    expect:
+      subject: inventory.checked
+      assert: ["$.available > 0"]
+      payload: {sku: a1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewScenarioService(serverService, messageService).Run(context.Background(), server, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed() {
+		t.Fatalf("scenario failed: %s", scenario.Format([]scenario.Result{result}))
+	}
+	if stats := responderService.GetStats()[id]; stats.Received != 1 || stats.Published != 1 {
+		t.Errorf("responder stats = %+v", stats)
+	}
+}
+
+func TestRequestReplyOverMemory(t *testing.T) {
+	_, serverService, messageService, server := testServices(t)
+
+	if err := serverService.ConnectToServer(server.ID, server.URL, server.ProviderType); err != nil {
+		t.Fatal(err)
+	}
+	defer serverService.DisconnectFromServer(server.ID)
+	provider, ok := serverService.GetMessagingProvider(server.ID)
+	if !ok {
+		t.Fatal("the server is not connected")
+	}
+
+	replier := provider.(messaging.Replier)
+	if err := provider.Subscribe("echo", func(msg *messaging.Message) {
+		replier.Reply(msg, msg.Data, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := messageService.Request(provider, "echo", []byte("ping"), nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "ping" {
+		t.Errorf("reply = %q", reply.Data)
+	}
+}
+
+func TestSaveResponderChecksCapabilities(t *testing.T) {
+	db, serverService, _, server := testServices(t)
+	responderService := NewResponderService(database.NewResponderRepository(db.GetDB()), serverService)
+
+	caps, err := serverService.GetCapabilities(server.ProviderType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Wildcards != messaging.WildcardsNATS || !caps.Reply {
+		t.Fatalf("memory capabilities = %+v", caps)
+	}
+
+	m := models.Responder{Name: "inventory", ServerID: server.ID, Pattern: "inventory.>.check", ReplyTemplate: "{}"}
+	if _, err := responderService.SaveResponder(m); err == nil {
+		t.Error("a pattern with > in the middle was saved")
+	}
+	m.Pattern = "inventory.*"
+	if _, err := responderService.SaveResponder(m); err != nil {
+		t.Errorf("SaveResponder: %v", err)
+	}
+
+	m.Name, m.EventSubject = "looping", "inventory.checked"
+	if _, err := responderService.SaveResponder(m); err == nil {
+		t.Error("a responder publishing events into its own pattern was saved")
+	}
+}
+
+func TestBridgeWithinServer(t *testing.T) {
+	db, serverService, _, server := testServices(t)
+	bridgeService := NewBridgeService(database.NewBridgeRepository(db.GetDB()), serverService)
+
+	b := models.Bridge{
+		Name:           "mirror",
+		SourceServerID: server.ID,
+		SourcePattern:  "orders.>",
+		TargetServerID: server.ID,
+		Mappings:       []bridge.Mapping{{From: "orders.eu.>", To: "orders.copy.$1"}},
+	}
+	if _, err := bridgeService.SaveBridge(b); err == nil {
+		t.Error("a bridge republishing into its own pattern was saved")
+	}
+	b.Mappings[0].To = "audit.$1"
+	id, err := bridgeService.SaveBridge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.ID = id
+	if err := bridgeService.Start(b); err != nil {
+		t.Fatal(err)
+	}
+	defer bridgeService.Stop(id)
+	if err := bridgeService.Start(b); err == nil {
+		t.Error("a running bridge was started again")
+	}
+
+	if err := serverService.ConnectToServer(server.ID, server.URL, server.ProviderType); err != nil {
+		t.Fatal(err)
+	}
+	defer serverService.DisconnectFromServer(server.ID)
+	provider, _ := serverService.GetMessagingProvider(server.ID)
+
+	// Unmapped subjects are dropped rather than republished as they are
+	provider.Publish("orders.eu.created", []byte("{}"))
+	provider.Publish("orders.us.created", []byte("{}"))
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := bridgeService.GetStats()[id]
+		if stats.Forwarded == 1 && stats.Dropped == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("bridge stats = %+v, want 1 forwarded and 1 dropped", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSubscribeRejectsInvalidPattern(t *testing.T) {
+	_, serverService, messageService, server := testServices(t)
+
+	if err := serverService.ConnectToServer(server.ID, server.URL, server.ProviderType); err != nil {
+		t.Fatal(err)
+	}
+	defer serverService.DisconnectFromServer(server.ID)
+	provider, _ := serverService.GetMessagingProvider(server.ID)
+
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+	if err := messageService.Subscribe(provider, "orders", "orders.>.created", queue); err == nil {
+		t.Fatal("a pattern with > in the middle was subscribed")
+	}
+
+	// The rejected subscription doesn't hold on to its name
+	if err := messageService.Subscribe(provider, "orders", "orders.>", queue); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := messageService.Unsubscribe(provider, "orders", "orders.>"); err != nil {
+		t.Errorf("Unsubscribe: %v", err)
+	}
+}
+
+func TestExploreOverMemory(t *testing.T) {
+	_, serverService, _, server := testServices(t)
+
+	if err := serverService.ConnectToServer(server.ID, server.URL, server.ProviderType); err != nil {
+		t.Fatal(err)
+	}
+	defer serverService.DisconnectFromServer(server.ID)
+	provider, _ := serverService.GetMessagingProvider(server.ID)
+	if err := provider.Subscribe("orders.>", func(*messaging.Message) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages keep flowing while the explorer samples
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				provider.Publish("billing.paid", []byte("{}"))
+			}
+		}
+	}()
+
+	entities, err := NewTopologyService(serverService).Explore(context.Background(), server, "", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]messaging.Entity)
+	for _, entity := range entities {
+		found[entity.Name] = entity
+	}
+	if orders := found["orders.>"]; orders.Subscribe != "orders.>" || orders.Publish != "" {
+		t.Errorf("orders.> = %+v, want a subscribe-only subject", orders)
+	}
+	if billing := found["billing.paid"]; billing.Publish != "billing.paid" {
+		t.Errorf("billing.paid = %+v, want a sampled subject", billing)
+	}
+	if _, ok := found[">"]; ok {
+		t.Error("the sampling subscription was listed")
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/templating"
+)
+
+type TemplateService struct {
+	templateRepo *database.PayloadTemplateRepository
+	sequences    map[int]int64
+	mutex        sync.Mutex
+}
+
+// NewTemplateService creates a new payload template service
+func NewTemplateService(templateRepo *database.PayloadTemplateRepository) *TemplateService {
+	return &TemplateService{
+		templateRepo: templateRepo,
+		sequences:    make(map[int]int64),
+	}
+}
+
+// GetTemplates returns the payload templates saved for a topic
+func (s *TemplateService) GetTemplates(topicID int) ([]models.PayloadTemplate, error) {
+	return s.templateRepo.GetByTopicID(topicID)
+}
+
+// SaveTemplate checks and saves a template; an existing template with the same name is replaced
+func (s *TemplateService) SaveTemplate(topicID int, name, body string, variables map[string]string) error {
+	if name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if err := templating.Parse(body); err != nil {
+		return err
+	}
+	return s.templateRepo.Save(topicID, name, body, variables)
+}
+
+// DeleteTemplate deletes a template
+func (s *TemplateService) DeleteTemplate(templateID int) error {
+	return s.templateRepo.Delete(templateID)
+}
+
+// Render renders a template with the next sequence number of the topic. The
+// sequence only advances once MarkSent confirms the payload was published.
+func (s *TemplateService) Render(topicID int, body string, variables map[string]string) ([]byte, int64, error) {
+	s.mutex.Lock()
+	seq := s.sequences[topicID] + 1
+	s.mutex.Unlock()
+
+	data, err := templating.Render(body, templating.Context{Seq: seq, Vars: variables})
+	return data, seq, err
+}
+
+// MarkSent records that the payload rendered with seq was published
+func (s *TemplateService) MarkSent(topicID int, seq int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if seq > s.sequences[topicID] {
+		s.sequences[topicID] = seq
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devalexandre/broker-ui/internal/database"
+)
+
+func TestTemplateServiceSaveTemplate(t *testing.T) {
+	db, _, _, _ := testServices(t)
+	templateService := NewTemplateService(database.NewPayloadTemplateRepository(db.GetDB()))
+
+	if err := templateService.SaveTemplate(1, "", `{}`, nil); err == nil {
+		t.Error("a template without a name was saved")
+	}
+	if err := templateService.SaveTemplate(1, "broken", `{{if}}`, nil); err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Errorf("SaveTemplate of a broken template = %v", err)
+	}
+
+	vars := map[string]string{"tenant": "acme"}
+	if err := templateService.SaveTemplate(1, "order", `{"tenant":"{{.tenant}}"}`, vars); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same name replaces the template
+	if err := templateService.SaveTemplate(1, "order", `{"tenant":"{{.tenant}}","n":{{seq}}}`, vars); err != nil {
+		t.Fatal(err)
+	}
+	if err := templateService.SaveTemplate(2, "order", `{}`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := templateService.GetTemplates(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || !strings.Contains(templates[0].Body, "{{seq}}") || templates[0].Variables["tenant"] != "acme" {
+		t.Fatalf("templates = %+v, want the replaced order template", templates)
+	}
+
+	if err := templateService.DeleteTemplate(templates[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if templates, _ := templateService.GetTemplates(1); len(templates) != 0 {
+		t.Errorf("templates after delete = %+v", templates)
+	}
+}
+
+func TestTemplateServiceRender(t *testing.T) {
+	db, _, _, _ := testServices(t)
+	templateService := NewTemplateService(database.NewPayloadTemplateRepository(db.GetDB()))
+	body := `{"tenant":"{{.tenant}}","n":{{seq}}}`
+	vars := map[string]string{"tenant": "acme"}
+
+	data, seq, err := templateService.Render(1, body, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"tenant":"acme","n":1}` || seq != 1 {
+		t.Errorf("Render = %s, %d", data, seq)
+	}
+
+	// The sequence advances only once the payload is sent, per topic
+	if _, seq, _ := templateService.Render(1, body, vars); seq != 1 {
+		t.Errorf("seq before MarkSent = %d, want 1", seq)
+	}
+	templateService.MarkSent(1, 1)
+	if _, seq, _ := templateService.Render(1, body, vars); seq != 2 {
+		t.Errorf("seq after MarkSent = %d, want 2", seq)
+	}
+	if _, seq, _ := templateService.Render(2, body, vars); seq != 1 {
+		t.Errorf("seq of another topic = %d, want 1", seq)
+	}
+	// A late confirmation doesn't move the sequence back
+	templateService.MarkSent(1, 5)
+	templateService.MarkSent(1, 3)
+	if _, seq, _ := templateService.Render(1, body, vars); seq != 6 {
+		t.Errorf("seq after late MarkSent = %d, want 6", seq)
+	}
+
+	if _, _, err := templateService.Render(1, body, nil); err == nil || !strings.Contains(err.Error(), `"tenant"`) {
+		t.Errorf("Render without variables error = %v, want the missing variable", err)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/topology"
+)
+
+// TopologyService discovers the entities of a server for the explorer
+type TopologyService struct {
+	serverService *ServerService
+}
+
+// NewTopologyService creates a new topology service
+func NewTopologyService(serverService *ServerService) *TopologyService {
+	return &TopologyService{serverService: serverService}
+}
+
+// Explore lists the entities of a server from its admin API and, for
+// providers with NATS wildcards, from the subjects of the messages received
+// on ">" during sample. It uses a connection of its own. What was found is
+// returned with the error when one of the sources fails.
+func (s *TopologyService) Explore(ctx context.Context, server models.Server, adminURL string, sample time.Duration) ([]messaging.Entity, error) {
+	provider, err := s.serverService.OpenProvider(server.ProviderType, server.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := provider.Close(); err != nil {
+			log.Printf("Error closing explorer connection to %s: %v", server.Name, err)
+		}
+	}()
+
+	var lists [][]messaging.Entity
+	var exploreErr error
+	if explorer, ok := provider.(messaging.Explorer); ok {
+		entities, err := explorer.Explore(ctx, adminURL)
+		if err != nil {
+			exploreErr = fmt.Errorf("failed to explore %s: %w", server.Name, err)
+		}
+		lists = append(lists, entities)
+	}
+
+	caps := messaging.CapabilitiesOf(provider)
+	if caps.Wildcards == messaging.WildcardsNATS && sample > 0 {
+		sampler := topology.NewSampler()
+		if err := provider.Subscribe(">", sampler.Handle); err != nil {
+			return topology.Merge(lists...), fmt.Errorf("failed to sample subjects: %w", err)
+		}
+		select {
+		case <-time.After(sample):
+		case <-ctx.Done():
+		}
+		if err := provider.Unsubscribe(">"); err != nil {
+			log.Printf("Error stopping subject sampling on %s: %v", server.Name, err)
+		}
+		lists = append(lists, sampler.Entities())
+	}
+
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("%s servers can't be explored", server.ProviderType)
+	}
+	return topology.Merge(lists...), exploreErr
+}
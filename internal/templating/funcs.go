@@ -0,0 +1,80 @@
+package templating
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const randStringAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var (
+	firstNames = []string{"Ana", "Bruno", "Carla", "Diego", "Elena", "Felipe", "Grace", "Hiro", "Isabel", "João", "Kenji", "Lucia", "Marta", "Nadia", "Omar", "Priya"}
+	lastNames  = []string{"Almeida", "Brown", "Costa", "Dubois", "Evans", "Fischer", "Garcia", "Hansen", "Ito", "Jensen", "Kowalski", "Lopez", "Moreau", "Novak", "Okafor", "Silva"}
+	words      = []string{"alpha", "bravo", "cobalt", "delta", "ember", "falcon", "glacier", "harbor", "indigo", "juniper", "kepler", "lumen", "meadow", "nimbus", "orbit", "prism"}
+	domains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// funcs returns the template functions; seq and var read from ctx
+func funcs(ctx Context) template.FuncMap {
+	return template.FuncMap{
+		"uuid": func() string { return uuid.NewString() },
+		"now": func(layout ...string) string {
+			if len(layout) > 0 {
+				return time.Now().Format(layout[0])
+			}
+			return time.Now().UTC().Format(time.RFC3339Nano)
+		},
+		"nowUnix":      func() int64 { return time.Now().Unix() },
+		"nowUnixMilli": func() int64 { return time.Now().UnixMilli() },
+		"seq":          func() int64 { return ctx.Seq },
+		"env":          os.Getenv,
+		"var": func(name string) (string, error) {
+			value, ok := ctx.Vars[name]
+			if !ok {
+				return "", fmt.Errorf("variable %q is not defined", name)
+			}
+			return value, nil
+		},
+		"randInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("randInt: max %d is less than min %d", max, min)
+			}
+			return min + rand.IntN(max-min+1), nil
+		},
+		"randFloat": func(min, max float64) float64 {
+			return min + rand.Float64()*(max-min)
+		},
+		"randBool": func() bool { return rand.IntN(2) == 1 },
+		"randString": func(n int) string {
+			var b strings.Builder
+			for i := 0; i < n; i++ {
+				b.WriteByte(randStringAlphabet[rand.IntN(len(randStringAlphabet))])
+			}
+			return b.String()
+		},
+		"randChoice": func(choices ...string) (string, error) {
+			if len(choices) == 0 {
+				return "", fmt.Errorf("randChoice needs at least one choice")
+			}
+			return pick(choices), nil
+		},
+		"firstName": func() string { return pick(firstNames) },
+		"lastName":  func() string { return pick(lastNames) },
+		"name":      func() string { return pick(firstNames) + " " + pick(lastNames) },
+		"word":      func() string { return pick(words) },
+		"email": func() string {
+			return fmt.Sprintf("%s.%s@%s", strings.ToLower(pick(firstNames)), strings.ToLower(pick(lastNames)), pick(domains))
+		},
+	}
+}
+
+// pick returns a random element of values
+func pick(values []string) string {
+	return values[rand.IntN(len(values))]
+}
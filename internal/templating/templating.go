@@ -0,0 +1,93 @@
+// Package templating renders payload templates with Go text/template,
+// providing generators for IDs, timestamps, sequence numbers and fake data.
+package templating
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Context is the per-render state of a template
+type Context struct {
+	// Seq is the value of {{seq}}, typically the number of the send
+	Seq int64
+	// Vars are user variables, available as {{.name}} or {{var "name"}}
+	Vars map[string]string
+}
+
+// Parse checks a template for syntax errors and unknown functions
+func Parse(text string) error {
+	_, err := parse(text, Context{})
+	return err
+}
+
+// Render executes a template
+func Render(text string, ctx Context) ([]byte, error) {
+	tmpl, err := parse(text, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := ctx.Vars
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parse compiles text with the generator functions bound to ctx
+func parse(text string, ctx Context) (*template.Template, error) {
+	tmpl, err := template.New("payload").
+		Option("missingkey=error").
+		Funcs(funcs(ctx)).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ParseVariables parses user variables written one "name=value" per line.
+// Blank lines and lines starting with # are ignored.
+func ParseVariables(text string) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected name=value, got %q", line, entry)
+		}
+		vars[name] = strings.TrimSpace(value)
+	}
+	return vars, scanner.Err()
+}
+
+// FormatVariables renders variables in the format read by ParseVariables
+func FormatVariables(vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%s\n", name, vars[name])
+	}
+	return b.String()
+}
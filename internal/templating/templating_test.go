@@ -0,0 +1,142 @@
+package templating
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRender(t *testing.T) {
+	ctx := Context{Seq: 7, Vars: map[string]string{"tenant": "acme", "region": "eu"}}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"plain text", `{"id":1}`, `{"id":1}`},
+		{"field variable", `{"tenant":"{{.tenant}}"}`, `{"tenant":"acme"}`},
+		{"var function", `{{var "region"}}-{{var "tenant"}}`, `eu-acme`},
+		{"sequence", `order-{{seq}}`, `order-7`},
+		{"pipeline", `{{.tenant | printf "%q"}}`, `"acme"`},
+		{"conditional", `{{if eq .region "eu"}}EUR{{else}}USD{{end}}`, `EUR`},
+		{"randInt of one value", `{{randInt 3 3}}`, `3`},
+		{"randChoice of one value", `{{randChoice "only"}}`, `only`},
+		{"randString length", `{{len (randString 12)}}`, `12`},
+		{"now with layout", `{{now "2006"}}`, time.Now().Format("2006")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.template, ctx)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render(%s) = %s, want %s", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderGenerators(t *testing.T) {
+	got, err := Render(`{{uuid}} {{now}} {{email}}`, Context{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(got))
+	if len(fields) != 3 {
+		t.Fatalf("Render = %q", got)
+	}
+	if _, err := uuid.Parse(fields[0]); err != nil {
+		t.Errorf("uuid = %q: %v", fields[0], err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+		t.Errorf("now = %q: %v", fields[1], err)
+	}
+	if !strings.Contains(fields[2], "@example.") {
+		t.Errorf("email = %q", fields[2])
+	}
+
+	// Each render draws new values
+	again, _ := Render(`{{uuid}}`, Context{})
+	if string(again) == fields[0] {
+		t.Error("uuid repeated across renders")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		vars     map[string]string
+		// want is part of the expected error
+		want string
+	}{
+		{"missing field variable", `{{.tenant}}`, nil, `map has no entry for key "tenant"`},
+		{"missing var", `{{var "tenant"}}`, map[string]string{"region": "eu"}, `variable "tenant" is not defined`},
+		{"variable names are case-sensitive", `{{.Tenant}}`, map[string]string{"tenant": "acme"}, `no entry for key "Tenant"`},
+		{"unknown function", `{{price}}`, nil, `invalid template`},
+		{"syntax error", `{{if .a}}`, nil, `invalid template`},
+		{"randInt range", `{{randInt 5 1}}`, nil, `max 1 is less than min 5`},
+		{"randChoice without choices", `{{randChoice}}`, nil, `at least one choice`},
+		{"wrong argument type", `{{randInt "a" 2}}`, nil, `failed to render template`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.template, Context{Vars: tt.vars})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Render error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	// Variables are only checked when rendering
+	if err := Parse(`{"tenant":"{{.tenant}}","n":{{seq}}}`); err != nil {
+		t.Errorf("Parse: %v", err)
+	}
+	for _, text := range []string{`{{price}}`, `{{end}}`, `{{.a`} {
+		if err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded", text)
+		}
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	vars, err := ParseVariables("# shared\ntenant = acme\n\n  region=eu  \nurl=http://x?a=b\nempty=\n")
+	if err != nil {
+		t.Fatalf("ParseVariables: %v", err)
+	}
+	want := map[string]string{"tenant": "acme", "region": "eu", "url": "http://x?a=b", "empty": ""}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("ParseVariables = %v, want %v", vars, want)
+	}
+
+	// FormatVariables writes what ParseVariables reads
+	again, err := ParseVariables(FormatVariables(vars))
+	if err != nil || !reflect.DeepEqual(again, vars) {
+		t.Errorf("round trip = %v, %v", again, err)
+	}
+	if got := FormatVariables(map[string]string{"b": "2", "a": "1"}); got != "a=1\nb=2\n" {
+		t.Errorf("FormatVariables = %q", got)
+	}
+}
+
+func TestParseVariablesErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"tenant", `line 1: expected name=value, got "tenant"`},
+		{"a=1\n\n=2", `line 3: expected name=value, got "=2"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseVariables(tt.text)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseVariables(%q) error = %v, want %s", tt.text, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package topology
+
+import (
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// Sampler counts the subjects of the messages it handles, to discover the
+// subjects in use through a ">" subscription
+type Sampler struct {
+	mutex  sync.Mutex
+	counts map[string]int64
+}
+
+// NewSampler creates an empty sampler
+func NewSampler() *Sampler {
+	return &Sampler{counts: make(map[string]int64)}
+}
+
+// Handle is the message handler of the sampling subscription
+func (s *Sampler) Handle(msg *messaging.Message) {
+	if isInbox(msg.Subject) {
+		return
+	}
+	s.mutex.Lock()
+	s.counts[msg.Subject]++
+	s.mutex.Unlock()
+}
+
+// Entities returns the sampled subjects with their message counts
+func (s *Sampler) Entities() []messaging.Entity {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entities := make([]messaging.Entity, 0, len(s.counts))
+	for subject, count := range s.counts {
+		entities = append(entities, messaging.Entity{
+			Kind:      messaging.EntitySubject,
+			Name:      subject,
+			Detail:    plural(count, "message") + " sampled",
+			Publish:   subject,
+			Subscribe: subject,
+		})
+	}
+	return entities
+}
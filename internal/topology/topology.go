@@ -0,0 +1,117 @@
+// Package topology discovers the subjects, streams, topics, queues and
+// connections of brokers from their monitoring and management APIs and from
+// sampled traffic
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// httpClient calls the admin APIs, each call bounded by a timeout
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// getJSON decodes the JSON response of a GET, with basic auth when user is set
+func getJSON(ctx context.Context, url, user, password string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid admin API address %s: %w", url, err)
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// Merge combines lists of entities sorted by kind, in the order of
+// messaging.EntityKinds, then name. The details of an entity found by more
+// than one source are joined.
+func Merge(lists ...[]messaging.Entity) []messaging.Entity {
+	type key struct {
+		kind messaging.EntityKind
+		name string
+	}
+	index := make(map[key]int)
+	var merged []messaging.Entity
+	for _, list := range lists {
+		for _, entity := range list {
+			k := key{entity.Kind, entity.Name}
+			i, seen := index[k]
+			if !seen {
+				index[k] = len(merged)
+				merged = append(merged, entity)
+				continue
+			}
+
+			existing := &merged[i]
+			switch {
+			case existing.Detail == "":
+				existing.Detail = entity.Detail
+			case entity.Detail != "":
+				existing.Detail += "; " + entity.Detail
+			}
+			if existing.Publish == "" {
+				existing.Publish = entity.Publish
+			}
+			if existing.Subscribe == "" {
+				existing.Subscribe = entity.Subscribe
+			}
+		}
+	}
+
+	order := make(map[messaging.EntityKind]int, len(messaging.EntityKinds))
+	for i, kind := range messaging.EntityKinds {
+		order[kind] = i
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Kind != merged[j].Kind {
+			return order[merged[i].Kind] < order[merged[j].Kind]
+		}
+		return merged[i].Name < merged[j].Name
+	})
+	return merged
+}
+
+// plural formats a count with a noun, such as "1 message" or "3 messages"
+func plural(n int64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// isWildcard reports whether a subject has NATS wildcard tokens
+func isWildcard(subject string) bool {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// isInbox reports whether a subject is a request/reply inbox, which is
+// noise when exploring
+func isInbox(subject string) bool {
+	return strings.HasPrefix(subject, "_INBOX.")
+}
@@ -0,0 +1,50 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+func TestMerge(t *testing.T) {
+	monitor := []messaging.Entity{
+		{Kind: messaging.EntityConnection, Name: "cid 1"},
+		{Kind: messaging.EntitySubject, Name: "orders.>", Detail: "1 subscriber", Subscribe: "orders.>"},
+		{Kind: messaging.EntitySubject, Name: "orders.created", Detail: "2 subscribers", Subscribe: "orders.created"},
+	}
+	sampled := []messaging.Entity{
+		{Kind: messaging.EntitySubject, Name: "orders.created", Detail: "3 messages sampled", Publish: "orders.created", Subscribe: "orders.created"},
+		{Kind: messaging.EntitySubject, Name: "billing.paid", Detail: "1 message sampled", Publish: "billing.paid", Subscribe: "billing.paid"},
+	}
+
+	merged := Merge(monitor, sampled)
+	want := []messaging.Entity{
+		{Kind: messaging.EntitySubject, Name: "billing.paid", Detail: "1 message sampled", Publish: "billing.paid", Subscribe: "billing.paid"},
+		{Kind: messaging.EntitySubject, Name: "orders.>", Detail: "1 subscriber", Subscribe: "orders.>"},
+		{Kind: messaging.EntitySubject, Name: "orders.created", Detail: "2 subscribers; 3 messages sampled", Publish: "orders.created", Subscribe: "orders.created"},
+		{Kind: messaging.EntityConnection, Name: "cid 1"},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("Merge() = %+v, want %+v", merged, want)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("entity %d = %+v, want %+v", i, merged[i], want[i])
+		}
+	}
+}
+
+func TestSampler(t *testing.T) {
+	sampler := NewSampler()
+	for _, subject := range []string{"orders.created", "orders.created", "_INBOX.abc", "billing.paid"} {
+		sampler.Handle(&messaging.Message{Subject: subject})
+	}
+
+	entities := Merge(sampler.Entities())
+	if len(entities) != 2 {
+		t.Fatalf("Entities() = %+v, want billing.paid and orders.created", entities)
+	}
+	if entities[1].Name != "orders.created" || entities[1].Detail != "2 messages sampled" {
+		t.Errorf("entity = %+v, want orders.created with 2 messages sampled", entities[1])
+	}
+}
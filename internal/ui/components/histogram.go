@@ -0,0 +1,114 @@
+package components
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Histogram is a bar chart of counts per labelled bucket
+type Histogram struct {
+	widget.BaseWidget
+	labels []string
+	counts []uint64
+}
+
+// NewHistogram creates a histogram with one bar per label
+func NewHistogram(labels []string) *Histogram {
+	h := &Histogram{labels: labels, counts: make([]uint64, len(labels))}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+// SetCounts updates the bar counts; extra counts are ignored
+func (h *Histogram) SetCounts(counts []uint64) {
+	h.counts = make([]uint64, len(h.labels))
+	copy(h.counts, counts)
+	h.Refresh()
+}
+
+// CreateRenderer implements fyne.Widget
+func (h *Histogram) CreateRenderer() fyne.WidgetRenderer {
+	r := &histogramRenderer{histogram: h}
+	for _, label := range h.labels {
+		bar := canvas.NewRectangle(theme.Color(theme.ColorNamePrimary))
+		name := canvas.NewText(label, theme.Color(theme.ColorNameForeground))
+		name.TextSize = theme.Size(theme.SizeNameCaptionText)
+		name.Alignment = fyne.TextAlignCenter
+		value := canvas.NewText("0", theme.Color(theme.ColorNameForeground))
+		value.TextSize = theme.Size(theme.SizeNameCaptionText)
+		value.Alignment = fyne.TextAlignCenter
+
+		r.bars = append(r.bars, bar)
+		r.names = append(r.names, name)
+		r.values = append(r.values, value)
+		r.objects = append(r.objects, bar, name, value)
+	}
+	return r
+}
+
+type histogramRenderer struct {
+	histogram *Histogram
+	bars      []*canvas.Rectangle
+	names     []*canvas.Text
+	values    []*canvas.Text
+	objects   []fyne.CanvasObject
+}
+
+func (r *histogramRenderer) Layout(size fyne.Size) {
+	if len(r.bars) == 0 {
+		return
+	}
+
+	var highest uint64
+	for _, c := range r.histogram.counts {
+		highest = max(highest, c)
+	}
+
+	textHeight := theme.Size(theme.SizeNameCaptionText) * 1.5
+	slot := size.Width / float32(len(r.bars))
+	chartHeight := size.Height - 2*textHeight
+	padding := theme.Padding()
+
+	for i, bar := range r.bars {
+		count := r.histogram.counts[i]
+		height := float32(0)
+		if highest > 0 {
+			height = chartHeight * float32(count) / float32(highest)
+		}
+
+		x := slot * float32(i)
+		bar.Move(fyne.NewPos(x+padding/2, textHeight+chartHeight-height))
+		bar.Resize(fyne.NewSize(slot-padding, height))
+
+		r.values[i].Text = strconv.FormatUint(count, 10)
+		r.values[i].Move(fyne.NewPos(x, chartHeight-height))
+		r.values[i].Resize(fyne.NewSize(slot, textHeight))
+
+		r.names[i].Move(fyne.NewPos(x, size.Height-textHeight))
+		r.names[i].Resize(fyne.NewSize(slot, textHeight))
+	}
+}
+
+func (r *histogramRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(float32(len(r.bars))*48, 160)
+}
+
+func (r *histogramRenderer) Refresh() {
+	for i := range r.bars {
+		r.bars[i].FillColor = theme.Color(theme.ColorNamePrimary)
+		r.names[i].Color = theme.Color(theme.ColorNameForeground)
+		r.values[i].Color = theme.Color(theme.ColorNameForeground)
+	}
+	r.Layout(r.histogram.Size())
+	canvas.Refresh(r.histogram)
+}
+
+func (r *histogramRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *histogramRenderer) Destroy() {}
@@ -0,0 +1,124 @@
+package components
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// LineChart plots a series of values, oldest on the left, scaled to the
+// largest value
+type LineChart struct {
+	widget.BaseWidget
+	title  string
+	format func(float64) string
+	values []float64
+}
+
+// NewLineChart creates a chart; format renders values in the caption
+func NewLineChart(title string, format func(float64) string) *LineChart {
+	if format == nil {
+		format = func(v float64) string { return fmt.Sprintf("%.1f", v) }
+	}
+	c := &LineChart{title: title, format: format}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetValues replaces the plotted values
+func (c *LineChart) SetValues(values []float64) {
+	c.values = values
+	c.Refresh()
+}
+
+// CreateRenderer implements fyne.Widget
+func (c *LineChart) CreateRenderer() fyne.WidgetRenderer {
+	caption := canvas.NewText(c.title, theme.Color(theme.ColorNameForeground))
+	caption.TextSize = theme.Size(theme.SizeNameCaptionText)
+
+	frame := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	frame.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	frame.StrokeWidth = 1
+
+	return &lineChartRenderer{chart: c, caption: caption, frame: frame}
+}
+
+type lineChartRenderer struct {
+	chart   *LineChart
+	caption *canvas.Text
+	frame   *canvas.Rectangle
+	lines   []*canvas.Line
+}
+
+func (r *lineChartRenderer) Layout(size fyne.Size) {
+	values := r.chart.values
+
+	var current, highest float64
+	for _, v := range values {
+		highest = max(highest, v)
+	}
+	if len(values) > 0 {
+		current = values[len(values)-1]
+	}
+	r.caption.Text = fmt.Sprintf("%s - now %s, max %s", r.chart.title, r.chart.format(current), r.chart.format(highest))
+
+	captionHeight := theme.Size(theme.SizeNameCaptionText) * 1.5
+	r.caption.Move(fyne.NewPos(0, 0))
+	r.caption.Resize(fyne.NewSize(size.Width, captionHeight))
+
+	plot := fyne.NewSize(size.Width, size.Height-captionHeight)
+	r.frame.Move(fyne.NewPos(0, captionHeight))
+	r.frame.Resize(plot)
+
+	// One segment between each pair of values
+	segments := max(len(values)-1, 0)
+	for len(r.lines) < segments {
+		line := canvas.NewLine(theme.Color(theme.ColorNamePrimary))
+		line.StrokeWidth = 2
+		r.lines = append(r.lines, line)
+	}
+	r.lines = r.lines[:segments]
+
+	padding := theme.Padding()
+	height := plot.Height - 2*padding
+	point := func(i int) fyne.Position {
+		x := plot.Width * float32(i) / float32(max(segments, 1))
+		y := captionHeight + padding + height
+		if highest > 0 {
+			y -= height * float32(values[i]/highest)
+		}
+		return fyne.NewPos(x, y)
+	}
+	for i, line := range r.lines {
+		line.Position1 = point(i)
+		line.Position2 = point(i + 1)
+	}
+}
+
+func (r *lineChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(200, 120)
+}
+
+func (r *lineChartRenderer) Refresh() {
+	r.caption.Color = theme.Color(theme.ColorNameForeground)
+	r.frame.FillColor = theme.Color(theme.ColorNameInputBackground)
+	r.frame.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	r.Layout(r.chart.Size())
+	for _, line := range r.lines {
+		line.StrokeColor = theme.Color(theme.ColorNamePrimary)
+	}
+	canvas.Refresh(r.chart)
+}
+
+func (r *lineChartRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.frame, r.caption}
+	for _, line := range r.lines {
+		objects = append(objects, line)
+	}
+	return objects
+}
+
+func (r *lineChartRenderer) Destroy() {}
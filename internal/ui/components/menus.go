@@ -3,18 +3,26 @@ package components
 import (
 "fyne.io/fyne/v2"
 "fyne.io/fyne/v2/container"
+"fyne.io/fyne/v2/theme"
 "fyne.io/fyne/v2/widget"
 "github.com/devalexandre/broker-ui/icons"
 )
 
-func MainMenu(onAddServer, onToggleTheme, onExit func(), isDarkTheme bool) *fyne.Container {
+func MainMenu(onAddServer, onCollections, onAlerts, onBridges, onResponders, onScenarios, onMetrics, onAPI, onToggleTheme, onExit func(), isDarkTheme bool) *fyne.Container {
 	addServerButton := widget.NewButtonWithIcon("Add Server", icons.AddServerIcon(), onAddServer)
+	collectionsButton := widget.NewButtonWithIcon("Collections", theme.FolderIcon(), onCollections)
+	alertsButton := widget.NewButtonWithIcon("Alerts", theme.WarningIcon(), onAlerts)
+	bridgesButton := widget.NewButtonWithIcon("Bridges", theme.MailForwardIcon(), onBridges)
+	respondersButton := widget.NewButtonWithIcon("Responders", theme.MailReplyIcon(), onResponders)
+	scenariosButton := widget.NewButtonWithIcon("Scenarios", theme.ConfirmIcon(), onScenarios)
+	metricsButton := widget.NewButtonWithIcon("Metrics", theme.ComputerIcon(), onMetrics)
+	apiButton := widget.NewButtonWithIcon("API", theme.StorageIcon(), onAPI)
 	themeButton := widget.NewButtonWithIcon("Theme", icons.ThemeToggleIcon(isDarkTheme), onToggleTheme)
 	exitButton := widget.NewButtonWithIcon("Exit", icons.ExitIcon(), onExit)
 
 	return container.NewBorder(
 nil, nil,
-container.NewHBox(addServerButton, themeButton),
+container.NewHBox(addServerButton, collectionsButton, alertsButton, bridgesButton, respondersButton, scenariosButton, metricsButton, apiButton, themeButton),
 exitButton,
 )
 }
@@ -0,0 +1,309 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/payload"
+)
+
+// MessageDetail shows the envelope and pretty-printed payload of a single message
+type MessageDetail struct {
+	window  fyne.Window
+	message *messaging.Message
+
+	subjectLabel   *widget.Label
+	timestampLabel *widget.Label
+	sizeLabel      *widget.Label
+	formatLabel    *widget.Label
+	headersBox     *fyne.Container
+	violationsBox  *fyne.Container
+	payloadText    *widget.Entry
+	hexText        *widget.Entry
+	base64Text     *widget.Entry
+	treeContainer  *fyne.Container
+	views          *container.AppTabs
+	hexTab         *container.TabItem
+	violationsTab  *container.TabItem
+	content        fyne.CanvasObject
+}
+
+// NewMessageDetail creates an empty detail pane; window is used for the clipboard
+func NewMessageDetail(window fyne.Window) *MessageDetail {
+	md := &MessageDetail{
+		window:         window,
+		subjectLabel:   widget.NewLabel(""),
+		timestampLabel: widget.NewLabel(""),
+		sizeLabel:      widget.NewLabel(""),
+		formatLabel:    widget.NewLabel(""),
+		headersBox:     container.NewVBox(),
+		violationsBox:  container.NewVBox(),
+		payloadText:    newPayloadEntry(fyne.TextWrapOff),
+		hexText:        newPayloadEntry(fyne.TextWrapOff),
+		base64Text:     newPayloadEntry(fyne.TextWrapBreak),
+		treeContainer:  container.NewStack(),
+	}
+
+	copyPayloadButton := widget.NewButtonWithIcon("Copy Payload", theme.ContentCopyIcon(), md.copyPayload)
+	copyEnvelopeButton := widget.NewButtonWithIcon("Copy Envelope", theme.ContentCopyIcon(), md.copyEnvelope)
+	saveButton := widget.NewButtonWithIcon("Save to File", theme.DocumentSaveIcon(), md.savePayload)
+
+	envelope := widget.NewForm(
+		widget.NewFormItem("Subject", md.subjectLabel),
+		widget.NewFormItem("Timestamp", md.timestampLabel),
+		widget.NewFormItem("Size", md.sizeLabel),
+		widget.NewFormItem("Format", md.formatLabel),
+	)
+
+	md.hexTab = container.NewTabItem("Hex", md.hexText)
+	md.violationsTab = container.NewTabItem("Violations", container.NewVScroll(md.violationsBox))
+	md.views = container.NewAppTabs(
+		container.NewTabItem("Payload", md.payloadText),
+		container.NewTabItem("Tree", md.treeContainer),
+		md.hexTab,
+		container.NewTabItem("Base64", md.base64Text),
+		container.NewTabItem("Headers", container.NewVScroll(md.headersBox)),
+		md.violationsTab,
+	)
+
+	header := container.NewVBox(
+		envelope,
+		container.NewHBox(copyPayloadButton, copyEnvelopeButton, saveButton),
+	)
+
+	md.content = container.NewBorder(header, nil, nil, nil, md.views)
+	md.Clear()
+	return md
+}
+
+// Content returns the canvas object to place in a layout
+func (md *MessageDetail) Content() fyne.CanvasObject {
+	return md.content
+}
+
+// Clear resets the pane to its empty state
+func (md *MessageDetail) Clear() {
+	md.message = nil
+	md.subjectLabel.SetText("Select a message to see its details")
+	md.timestampLabel.SetText("")
+	md.sizeLabel.SetText("")
+	md.formatLabel.SetText("")
+	md.headersBox.Objects = nil
+	md.headersBox.Refresh()
+	md.violationsBox.Objects = nil
+	md.violationsBox.Refresh()
+	md.payloadText.SetText("")
+	md.hexText.SetText("")
+	md.base64Text.SetText("")
+	md.treeContainer.Objects = nil
+	md.treeContainer.Refresh()
+}
+
+// Show displays a message
+func (md *MessageDetail) Show(msg messaging.Message) {
+	md.message = &msg
+
+	md.subjectLabel.SetText(msg.Subject)
+	md.timestampLabel.SetText(formatTimestamp(msg.Timestamp))
+	md.sizeLabel.SetText(fmt.Sprintf("%d bytes", len(msg.Data)))
+
+	// Schema-decoded payloads are shown as their JSON rendering
+	body := msg.Data
+	if msg.Decoded != nil {
+		body = msg.Decoded
+	}
+
+	format := payload.DetectFormat(body)
+	if msg.Schema != "" {
+		md.formatLabel.SetText(fmt.Sprintf("%s (decoded from %s)", format, msg.Schema))
+	} else {
+		md.formatLabel.SetText(string(format))
+	}
+
+	pretty, err := payload.Pretty(body, format)
+	if err != nil || format == payload.FormatBinary {
+		// The payload tab is the UTF-8 view; binary data is shown with replacement characters
+		pretty = strings.ToValidUTF8(string(body), "\uFFFD")
+	}
+	md.payloadText.SetText(pretty)
+	md.hexText.SetText(payload.HexDump(msg.Data))
+	md.base64Text.SetText(payload.Base64(msg.Data))
+
+	md.showHeaders(msg.Headers)
+	md.showViolations(msg.Violations)
+	md.showTree(body, format)
+
+	// Contract violations matter most, then binary payloads are most useful as a hex dump
+	if len(msg.Violations) > 0 {
+		md.views.Select(md.violationsTab)
+	} else if format == payload.FormatBinary {
+		md.views.Select(md.hexTab)
+	}
+}
+
+// showViolations lists the JSON Schema violations of the message
+func (md *MessageDetail) showViolations(violations []string) {
+	md.violationsBox.Objects = nil
+	if len(violations) == 0 {
+		md.violationsBox.Add(widget.NewLabel("No schema violations"))
+	}
+	for _, violation := range violations {
+		label := widget.NewLabel(violation)
+		label.Wrapping = fyne.TextWrapWord
+		md.violationsBox.Add(label)
+	}
+	md.violationsBox.Refresh()
+}
+
+// showHeaders lists the message headers sorted by name
+func (md *MessageDetail) showHeaders(headers map[string]string) {
+	md.headersBox.Objects = nil
+	if len(headers) == 0 {
+		md.headersBox.Add(widget.NewLabel("No headers"))
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		label := widget.NewLabel(fmt.Sprintf("%s: %s", key, headers[key]))
+		label.Wrapping = fyne.TextWrapWord
+		md.headersBox.Add(label)
+	}
+	md.headersBox.Refresh()
+}
+
+// showTree renders JSON payloads as a collapsible tree
+func (md *MessageDetail) showTree(data []byte, format payload.Format) {
+	md.treeContainer.Objects = nil
+	defer md.treeContainer.Refresh()
+
+	if format != payload.FormatJSON {
+		md.treeContainer.Add(widget.NewLabel("Tree view is only available for JSON payloads"))
+		return
+	}
+
+	jsonTree, err := payload.BuildJSONTree(data)
+	if err != nil {
+		md.treeContainer.Add(widget.NewLabel(err.Error()))
+		return
+	}
+
+	tree := widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return jsonTree.Children[id]
+		},
+		func(id widget.TreeNodeID) bool {
+			return jsonTree.IsBranch(id)
+		},
+		func(branch bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(jsonTree.Labels[id])
+		},
+	)
+	tree.OpenBranch("#")
+	md.treeContainer.Add(tree)
+}
+
+// copyPayload copies the payload to the clipboard, as base64 when it is binary
+func (md *MessageDetail) copyPayload() {
+	if md.message == nil {
+		return
+	}
+	if !payload.IsText(md.message.Data) {
+		md.window.Clipboard().SetContent(payload.Base64(md.message.Data))
+		return
+	}
+	md.window.Clipboard().SetContent(string(md.message.Data))
+}
+
+// copyEnvelope copies the message and its metadata as JSON to the clipboard
+func (md *MessageDetail) copyEnvelope() {
+	if md.message == nil {
+		return
+	}
+
+	envelope := map[string]interface{}{
+		"subject":   md.message.Subject,
+		"timestamp": formatTimestamp(md.message.Timestamp),
+		"size":      len(md.message.Data),
+		"provider":  md.message.Provider,
+		"headers":   md.message.Headers,
+	}
+	if md.message.Schema != "" {
+		envelope["schema"] = md.message.Schema
+		envelope["decoded"] = json.RawMessage(md.message.Decoded)
+	}
+	if len(md.message.Violations) > 0 {
+		envelope["violations"] = md.message.Violations
+	}
+
+	// Embed JSON payloads as objects rather than escaped strings, and binary ones as base64
+	switch {
+	case json.Valid(md.message.Data):
+		envelope["payload"] = json.RawMessage(md.message.Data)
+	case payload.IsText(md.message.Data):
+		envelope["payload"] = string(md.message.Data)
+	default:
+		envelope["payload_base64"] = payload.Base64(md.message.Data)
+	}
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		ErrorDialog(err, md.window)
+		return
+	}
+	md.window.Clipboard().SetContent(string(data))
+}
+
+// savePayload writes the raw payload bytes to a file chosen by the user
+func (md *MessageDetail) savePayload() {
+	if md.message == nil {
+		return
+	}
+	data := md.message.Data
+
+	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			ErrorDialog(err, md.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(data); err != nil {
+			ErrorDialog(fmt.Errorf("failed to save payload: %w", err), md.window)
+		}
+	}, md.window)
+}
+
+// newPayloadEntry creates a read-friendly monospace entry for payload views
+func newPayloadEntry(wrapping fyne.TextWrap) *widget.Entry {
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = wrapping
+	entry.TextStyle = fyne.TextStyle{Monospace: true}
+	return entry
+}
+
+// formatTimestamp renders a Unix nanosecond timestamp in local time
+func formatTimestamp(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(0, timestamp).Format("2006-01-02 15:04:05.000 MST")
+}
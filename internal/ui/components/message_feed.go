@@ -0,0 +1,273 @@
+package components
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// MessageBuffer is a fixed-capacity ring buffer that evicts the oldest
+// message once it is full
+type MessageBuffer struct {
+	items []messaging.Message
+	start int
+	size  int
+}
+
+// NewMessageBuffer creates a ring buffer holding up to capacity messages
+func NewMessageBuffer(capacity int) *MessageBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MessageBuffer{items: make([]messaging.Message, capacity)}
+}
+
+// Push appends a message and reports whether an older message was evicted
+func (b *MessageBuffer) Push(message messaging.Message) bool {
+	if b.size < len(b.items) {
+		b.items[(b.start+b.size)%len(b.items)] = message
+		b.size++
+		return false
+	}
+
+	b.items[b.start] = message
+	b.start = (b.start + 1) % len(b.items)
+	return true
+}
+
+// Get returns the message at index i, where 0 is the oldest message
+func (b *MessageBuffer) Get(i int) messaging.Message {
+	if i < 0 || i >= b.size {
+		return messaging.Message{}
+	}
+	return b.items[(b.start+i)%len(b.items)]
+}
+
+// Len returns the number of messages in the buffer
+func (b *MessageBuffer) Len() int {
+	return b.size
+}
+
+// Cap returns the maximum number of messages the buffer holds
+func (b *MessageBuffer) Cap() int {
+	return len(b.items)
+}
+
+// Resize changes the capacity, keeping the newest messages, and returns how
+// many messages were evicted
+func (b *MessageBuffer) Resize(capacity int) int {
+	resized := NewMessageBuffer(capacity)
+	evicted := 0
+	for i := 0; i < b.size; i++ {
+		if resized.Push(b.Get(i)) {
+			evicted++
+		}
+	}
+	*b = *resized
+	return evicted
+}
+
+// Clear removes all messages
+func (b *MessageBuffer) Clear() {
+	b.items = make([]messaging.Message, len(b.items))
+	b.start = 0
+	b.size = 0
+}
+
+// MessageFilter decides whether a message is shown and whether it is highlighted
+type MessageFilter func(msg messaging.Message) (visible, highlighted bool)
+
+// viewEntry is a shown message, identified by its push sequence number
+type viewEntry struct {
+	seq         uint64
+	highlighted bool
+}
+
+// MessageFeed batches incoming messages into a MessageBuffer, holding them
+// back while paused, and keeps the filtered view and the dropped count. It
+// is safe for concurrent use.
+type MessageFeed struct {
+	buffer  *MessageBuffer
+	pending []messaging.Message
+	paused  bool
+	dropped int
+	dirty   bool
+
+	// pushed counts the messages ever pushed into buffer
+	pushed uint64
+	// view lists the buffered messages that pass filter, oldest first
+	view   []viewEntry
+	filter MessageFilter
+
+	mutex sync.Mutex
+}
+
+// NewMessageFeed creates a feed that keeps at most capacity messages
+func NewMessageFeed(capacity int) *MessageFeed {
+	return &MessageFeed{buffer: NewMessageBuffer(capacity)}
+}
+
+// Add queues a message until the next Flush
+func (f *MessageFeed) Add(message messaging.Message) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// While paused, hold at most one buffer worth of messages back
+	if f.paused && len(f.pending) >= f.buffer.Cap() {
+		f.pending = f.pending[1:]
+		f.dropped++
+	}
+	f.pending = append(f.pending, message)
+	f.dirty = true
+}
+
+// Flush moves pending messages into the buffer unless paused, and reports
+// whether anything changed since the last Flush
+func (f *MessageFeed) Flush() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.paused {
+		for _, message := range f.pending {
+			if f.buffer.Push(message) {
+				f.dropped++
+			}
+			f.addToView(message, f.pushed)
+			f.pushed++
+		}
+		f.pending = nil
+		f.pruneView()
+	}
+
+	dirty := f.dirty
+	f.dirty = false
+	return dirty
+}
+
+// SetPaused holds new messages back until resumed
+func (f *MessageFeed) SetPaused(paused bool) {
+	f.mutex.Lock()
+	f.paused = paused
+	f.dirty = true
+	f.mutex.Unlock()
+}
+
+// Paused reports whether new messages are held back
+func (f *MessageFeed) Paused() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.paused
+}
+
+// SetFilter hides or highlights messages, including those already buffered;
+// nil shows every message
+func (f *MessageFeed) SetFilter(filter MessageFilter) {
+	f.mutex.Lock()
+	f.filter = filter
+	f.view = f.view[:0]
+	first := f.firstSeq()
+	for i := 0; i < f.buffer.Len(); i++ {
+		f.addToView(f.buffer.Get(i), first+uint64(i))
+	}
+	f.dirty = true
+	f.mutex.Unlock()
+}
+
+// SetCapacity changes the maximum number of messages kept
+func (f *MessageFeed) SetCapacity(capacity int) {
+	f.mutex.Lock()
+	if capacity != f.buffer.Cap() {
+		f.dropped += f.buffer.Resize(capacity)
+		f.pruneView()
+		f.dirty = true
+	}
+	f.mutex.Unlock()
+}
+
+// Clear removes all buffered and pending messages and resets the dropped counter
+func (f *MessageFeed) Clear() {
+	f.mutex.Lock()
+	f.buffer.Clear()
+	f.view = nil
+	f.pending = nil
+	f.dropped = 0
+	f.dirty = true
+	f.mutex.Unlock()
+}
+
+// Cap returns the maximum number of messages kept
+func (f *MessageFeed) Cap() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.buffer.Cap()
+}
+
+// Dropped returns the number of messages evicted or discarded so far
+func (f *MessageFeed) Dropped() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.dropped
+}
+
+// Len returns the number of shown messages
+func (f *MessageFeed) Len() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.view)
+}
+
+// Get returns the i-th shown message, oldest first, and whether it is highlighted
+func (f *MessageFeed) Get(i int) (messaging.Message, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if i < 0 || i >= len(f.view) {
+		return messaging.Message{}, false
+	}
+	entry := f.view[i]
+	return f.buffer.Get(int(entry.seq - f.firstSeq())), entry.highlighted
+}
+
+// Status describes the counters for the status line
+func (f *MessageFeed) Status() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	status := fmt.Sprintf("%d/%d messages - Dropped: %d", f.buffer.Len(), f.buffer.Cap(), f.dropped)
+	if f.filter != nil {
+		status = fmt.Sprintf("%d shown - %s", len(f.view), status)
+	}
+	if f.paused {
+		status += fmt.Sprintf(" - Paused (%d pending)", len(f.pending))
+	}
+	return status
+}
+
+// firstSeq returns the sequence number of the oldest buffered message; the
+// caller must hold the mutex
+func (f *MessageFeed) firstSeq() uint64 {
+	return f.pushed - uint64(f.buffer.Len())
+}
+
+// addToView lists a buffered message if it passes the filter; the caller
+// must hold the mutex
+func (f *MessageFeed) addToView(message messaging.Message, seq uint64) {
+	visible, highlighted := true, false
+	if f.filter != nil {
+		visible, highlighted = f.filter(message)
+	}
+	if visible {
+		f.view = append(f.view, viewEntry{seq: seq, highlighted: highlighted})
+	}
+}
+
+// pruneView drops evicted messages from the view; the caller must hold the mutex
+func (f *MessageFeed) pruneView() {
+	first := f.firstSeq()
+	n := 0
+	for n < len(f.view) && f.view[n].seq < first {
+		n++
+	}
+	f.view = f.view[n:]
+}
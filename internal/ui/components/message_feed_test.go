@@ -0,0 +1,128 @@
+package components
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// subjects returns the subjects of the shown messages, oldest first
+func subjects(f *MessageFeed) []string {
+	var got []string
+	for i := 0; i < f.Len(); i++ {
+		message, _ := f.Get(i)
+		got = append(got, message.Subject)
+	}
+	return got
+}
+
+// addSubjects adds a message per subject
+func addSubjects(f *MessageFeed, subjects ...string) {
+	for _, subject := range subjects {
+		f.Add(messaging.Message{Subject: subject})
+	}
+}
+
+func TestMessageBufferWrapsAround(t *testing.T) {
+	b := NewMessageBuffer(3)
+	evicted := 0
+	for i := 0; i < 7; i++ {
+		if b.Push(messaging.Message{Subject: fmt.Sprint(i)}) {
+			evicted++
+		}
+	}
+	if evicted != 4 || b.Len() != 3 {
+		t.Fatalf("evicted %d, len %d; want 4 and 3", evicted, b.Len())
+	}
+	for i, want := range []string{"4", "5", "6"} {
+		if got := b.Get(i).Subject; got != want {
+			t.Errorf("Get(%d) = %s, want %s", i, got, want)
+		}
+	}
+	if b.Get(3).Subject != "" || b.Get(-1).Subject != "" {
+		t.Error("Get out of range returned a message")
+	}
+
+	// Shrinking keeps the newest messages
+	if evicted := b.Resize(2); evicted != 1 || b.Get(0).Subject != "5" || b.Get(1).Subject != "6" {
+		t.Errorf("Resize(2) evicted %d, kept %s %s", evicted, b.Get(0).Subject, b.Get(1).Subject)
+	}
+	if evicted := b.Resize(4); evicted != 0 || b.Len() != 2 || b.Cap() != 4 {
+		t.Errorf("Resize(4) evicted %d, len %d, cap %d", evicted, b.Len(), b.Cap())
+	}
+}
+
+func TestMessageFeedCountsDropped(t *testing.T) {
+	f := NewMessageFeed(3)
+	addSubjects(f, "a", "b")
+	if got := subjects(f); got != nil {
+		t.Errorf("shown before Flush = %v", got)
+	}
+	if !f.Flush() || f.Flush() {
+		t.Error("Flush didn't report the change exactly once")
+	}
+
+	// Evicted messages count as dropped
+	addSubjects(f, "c", "d", "e")
+	f.Flush()
+	if got := fmt.Sprint(subjects(f)); got != "[c d e]" || f.Dropped() != 2 {
+		t.Errorf("shown %s, dropped %d; want [c d e] and 2", got, f.Dropped())
+	}
+
+	// Paused, one buffer worth is held back and the oldest pending are dropped
+	f.SetPaused(true)
+	addSubjects(f, "f", "g", "h", "i")
+	f.Flush()
+	if got := fmt.Sprint(subjects(f)); got != "[c d e]" || f.Dropped() != 3 {
+		t.Errorf("paused: shown %s, dropped %d; want [c d e] and 3", got, f.Dropped())
+	}
+	if got, want := f.Status(), "3/3 messages - Dropped: 3 - Paused (3 pending)"; got != want {
+		t.Errorf("Status = %q, want %q", got, want)
+	}
+	f.SetPaused(false)
+	f.Flush()
+	if got := fmt.Sprint(subjects(f)); got != "[g h i]" || f.Dropped() != 6 {
+		t.Errorf("resumed: shown %s, dropped %d; want [g h i] and 6", got, f.Dropped())
+	}
+
+	f.SetCapacity(1)
+	if got := fmt.Sprint(subjects(f)); got != "[i]" || f.Dropped() != 8 {
+		t.Errorf("SetCapacity(1): shown %s, dropped %d; want [i] and 8", got, f.Dropped())
+	}
+	f.Clear()
+	if f.Len() != 0 || f.Dropped() != 0 {
+		t.Errorf("after Clear: %d shown, %d dropped", f.Len(), f.Dropped())
+	}
+}
+
+func TestMessageFeedFilterFollowsWraparound(t *testing.T) {
+	f := NewMessageFeed(4)
+	addSubjects(f, "a1", "b1", "a2", "b2")
+	f.Flush()
+
+	f.SetFilter(func(msg messaging.Message) (bool, bool) {
+		return msg.Subject[0] == 'a', msg.Subject == "a3"
+	})
+	if got := fmt.Sprint(subjects(f)); got != "[a1 a2]" {
+		t.Errorf("filtered = %s, want [a1 a2]", got)
+	}
+
+	// The view drops evicted messages and maps the rest past the wraparound
+	addSubjects(f, "a3", "b3", "b4")
+	f.Flush()
+	if got := fmt.Sprint(subjects(f)); got != "[a3]" {
+		t.Errorf("after wraparound = %s, want [a3]", got)
+	}
+	if _, highlighted := f.Get(0); !highlighted {
+		t.Error("a3 is not highlighted")
+	}
+	if got, want := f.Status(), "1 shown - 4/4 messages - Dropped: 3"; got != want {
+		t.Errorf("Status = %q, want %q", got, want)
+	}
+
+	f.SetFilter(nil)
+	if got := fmt.Sprint(subjects(f)); got != "[b2 a3 b3 b4]" {
+		t.Errorf("unfiltered = %s, want [b2 a3 b3 b4]", got)
+	}
+}
@@ -0,0 +1,232 @@
+package components
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/payload"
+)
+
+// DefaultMessageListCapacity is the number of messages kept by a message list
+// when no capacity has been configured
+const DefaultMessageListCapacity = 1000
+
+// messageListFlushInterval controls how often batched messages are pushed to the UI
+const messageListFlushInterval = 100 * time.Millisecond
+
+// MessageListCapacities are the capacity choices offered in the toolbar
+var MessageListCapacities = []int{100, 500, 1000, 5000, 10000}
+
+// MessageList is a virtualized, bounded list of messages with pause/resume,
+// auto-scroll and a dropped-message counter. Messages may be added from any
+// goroutine; a MessageFeed batches them and they are handed to the UI thread
+// periodically.
+type MessageList struct {
+	// OnCapacityChanged is called when the user picks a new capacity
+	OnCapacityChanged func(capacity int)
+	// OnSelected is called on the UI thread when the user clicks a message
+	OnSelected func(msg messaging.Message)
+
+	feed       *MessageFeed
+	autoScroll bool
+	mutex      sync.Mutex
+
+	list        *widget.List
+	statusLabel *widget.Label
+	pauseButton *widget.Button
+	content     fyne.CanvasObject
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewMessageList creates a message list that keeps at most capacity messages
+func NewMessageList(capacity int) *MessageList {
+	ml := &MessageList{
+		feed:       NewMessageFeed(capacity),
+		autoScroll: true,
+		stop:       make(chan struct{}),
+	}
+
+	ml.list = widget.NewList(
+		ml.feed.Len,
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			message, highlighted := ml.feed.Get(i)
+
+			label := o.(*widget.Label)
+			label.Importance = widget.MediumImportance
+			if highlighted {
+				label.Importance = widget.WarningImportance
+			}
+			label.SetText(summarizeMessage(message))
+		},
+	)
+	ml.list.OnSelected = func(i widget.ListItemID) {
+		message, _ := ml.feed.Get(i)
+		// Indexes shift as the buffer evicts, so don't keep a stale highlight
+		ml.list.Unselect(i)
+		if ml.OnSelected != nil {
+			ml.OnSelected(message)
+		}
+	}
+
+	ml.statusLabel = widget.NewLabel("")
+	ml.pauseButton = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), ml.togglePause)
+
+	autoScrollCheck := widget.NewCheck("Auto-scroll", func(checked bool) {
+		ml.mutex.Lock()
+		ml.autoScroll = checked
+		ml.mutex.Unlock()
+	})
+	autoScrollCheck.SetChecked(true)
+
+	clearButton := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), ml.Clear)
+
+	capacityOptions := make([]string, len(MessageListCapacities))
+	for i, c := range MessageListCapacities {
+		capacityOptions[i] = strconv.Itoa(c)
+	}
+	capacitySelect := widget.NewSelect(capacityOptions, func(value string) {
+		c, err := strconv.Atoi(value)
+		if err != nil || c == ml.Cap() {
+			return
+		}
+		ml.SetCapacity(c)
+		if ml.OnCapacityChanged != nil {
+			ml.OnCapacityChanged(c)
+		}
+	})
+	capacitySelect.SetSelected(strconv.Itoa(ml.feed.Cap()))
+
+	toolbar := container.NewHBox(
+		ml.pauseButton,
+		autoScrollCheck,
+		clearButton,
+		widget.NewLabel("Keep:"),
+		capacitySelect,
+		ml.statusLabel,
+	)
+
+	ml.content = container.NewBorder(toolbar, nil, nil, nil, ml.list)
+	ml.updateStatus()
+
+	go ml.flushLoop()
+
+	return ml
+}
+
+// Content returns the canvas object to place in a layout
+func (ml *MessageList) Content() fyne.CanvasObject {
+	return ml.content
+}
+
+// Add queues a message for display. It is safe to call from any goroutine.
+func (ml *MessageList) Add(message messaging.Message) {
+	ml.feed.Add(message)
+}
+
+// SetFilter hides or highlights messages, including those already listed;
+// nil shows every message
+func (ml *MessageList) SetFilter(filter MessageFilter) {
+	ml.feed.SetFilter(filter)
+}
+
+// SetCapacity changes the maximum number of messages kept
+func (ml *MessageList) SetCapacity(capacity int) {
+	ml.feed.SetCapacity(capacity)
+}
+
+// Clear removes all displayed and pending messages and resets the dropped counter
+func (ml *MessageList) Clear() {
+	ml.feed.Clear()
+}
+
+// Cap returns the maximum number of messages kept
+func (ml *MessageList) Cap() int {
+	return ml.feed.Cap()
+}
+
+// Dropped returns the number of messages evicted or discarded so far
+func (ml *MessageList) Dropped() int {
+	return ml.feed.Dropped()
+}
+
+// Stop ends the background flush loop
+func (ml *MessageList) Stop() {
+	ml.stopOnce.Do(func() {
+		close(ml.stop)
+	})
+}
+
+// togglePause pauses or resumes the display of new messages
+func (ml *MessageList) togglePause() {
+	paused := !ml.feed.Paused()
+	ml.feed.SetPaused(paused)
+
+	if paused {
+		ml.pauseButton.SetText("Resume")
+		ml.pauseButton.SetIcon(theme.MediaPlayIcon())
+	} else {
+		ml.pauseButton.SetText("Pause")
+		ml.pauseButton.SetIcon(theme.MediaPauseIcon())
+	}
+}
+
+// flushLoop periodically moves pending messages into the buffer and refreshes the list
+func (ml *MessageList) flushLoop() {
+	ticker := time.NewTicker(messageListFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if ml.feed.Flush() {
+				fyne.Do(ml.refresh)
+			}
+		case <-ml.stop:
+			return
+		}
+	}
+}
+
+// refresh redraws the list and status; it must run on the UI thread
+func (ml *MessageList) refresh() {
+	ml.list.Refresh()
+	ml.updateStatus()
+
+	ml.mutex.Lock()
+	autoScroll := ml.autoScroll && !ml.feed.Paused()
+	ml.mutex.Unlock()
+
+	if autoScroll {
+		ml.list.ScrollToBottom()
+	}
+}
+
+// updateStatus updates the status label with the current counters
+func (ml *MessageList) updateStatus() {
+	ml.statusLabel.SetText(ml.feed.Status())
+}
+
+// summarizeMessage renders a message as a single list line
+func summarizeMessage(msg messaging.Message) string {
+	prefix := time.Unix(0, msg.Timestamp).Format("15:04:05.000")
+	if len(msg.Violations) > 0 {
+		prefix += " [INVALID]"
+	}
+	if msg.Decoded != nil {
+		return fmt.Sprintf("%s [%s] %s", prefix, msg.Subject, string(msg.Decoded))
+	}
+	return fmt.Sprintf("%s [%s] %s", prefix, msg.Subject, payload.Summary(msg.Data))
+}
@@ -0,0 +1,373 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/alerts"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const alertsTabName = "Alerts"
+
+// alertLogLimit is how many alert log entries the Alerts tab shows
+const alertLogLimit = 500
+
+// alertKindLabels describes each rule kind in the rule editor
+var alertKindLabels = map[alerts.Kind]string{
+	alerts.KindRegex:    "Payload matches regex",
+	alerts.KindJSONPath: "JSONPath expression matches",
+	alerts.KindSilence:  "No message for N seconds",
+	alerts.KindRate:     "Rate above N msgs/s",
+}
+
+// alertColumns are the columns of the alert log table
+var alertColumns = []string{"Time", "Subscription", "Rule", "Subject", "Detail", "Payload"}
+
+// NotifyAlert raises a desktop notification for a fired alert, highlights the
+// subscription tab and refreshes the alert log. It may be called from any
+// goroutine.
+func (tm *TabManager) NotifyAlert(alert models.Alert) {
+	fyne.CurrentApp().SendNotification(fyne.NewNotification(
+		fmt.Sprintf("Alert: %s", alert.RuleName),
+		fmt.Sprintf("%s: %s", alert.SubName, alert.Detail),
+	))
+
+	fyne.Do(func() {
+		tm.highlightTab(fmt.Sprintf("sub-%v", alert.SubName))
+		if tm.refreshAlertLog != nil {
+			tm.refreshAlertLog()
+		}
+	})
+}
+
+// highlightTab marks a tab with a warning icon until it is selected
+func (tm *TabManager) highlightTab(tabName string) {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text != tabName || tab == tm.tabContainer.Selected() {
+			continue
+		}
+		if _, ok := tm.highlighted[tab]; !ok {
+			tm.highlighted[tab] = tab.Icon
+		}
+		tab.Icon = theme.WarningIcon()
+		tm.tabContainer.Refresh()
+		return
+	}
+}
+
+// clearHighlight restores the icon of a highlighted tab
+func (tm *TabManager) clearHighlight(tab *container.TabItem) {
+	icon, ok := tm.highlighted[tab]
+	if !ok {
+		return
+	}
+	delete(tm.highlighted, tab)
+	tab.Icon = icon
+	tm.tabContainer.Refresh()
+}
+
+// AddAlertsTab adds (or selects) a tab showing the alert log
+func (tm *TabManager) AddAlertsTab() {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == alertsTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	var entries []models.Alert
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(entries) + 1, len(alertColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("2006-01-02 15:04:05")
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(alertColumns[id.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText(alertCell(entries[id.Row-1], id.Col))
+		},
+	)
+	for col, width := range []float32{160, 120, 120, 140, 260, 300} {
+		table.SetColumnWidth(col, width)
+	}
+
+	countLabel := widget.NewLabel("")
+	reload := func() {
+		loaded, err := tm.alertService.GetAlerts(alertLogLimit)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		entries = loaded
+		countLabel.SetText(fmt.Sprintf("%d alerts", len(entries)))
+		table.Refresh()
+	}
+
+	refreshButton := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), reload)
+	clearButton := widget.NewButtonWithIcon("Clear", theme.DeleteIcon(), func() {
+		components.ConfirmDialog("Clear Alerts", "Delete all entries of the alert log?", func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := tm.alertService.ClearAlerts(); err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			reload()
+		}, tm.window).Show()
+	})
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(alertsTabName)
+	})
+
+	content := container.NewBorder(
+		container.NewHBox(widget.NewLabel("Alert Log"), countLabel, layout.NewSpacer(), refreshButton, clearButton, closeButton),
+		nil, nil, nil,
+		table,
+	)
+	reload()
+
+	tab := container.NewTabItemWithIcon(alertsTabName, theme.WarningIcon(), content)
+	tm.refreshAlertLog = reload
+	tm.cleanups[tab] = func() {
+		tm.refreshAlertLog = nil
+	}
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// alertCell returns the text of an alert log cell
+func alertCell(a models.Alert, col int) string {
+	switch col {
+	case 0:
+		return a.FiredAt.Format("2006-01-02 15:04:05")
+	case 1:
+		return a.SubName
+	case 2:
+		return a.RuleName
+	case 3:
+		return a.Subject
+	case 4:
+		return a.Detail
+	case 5:
+		return a.Payload
+	}
+	return ""
+}
+
+// showAlertRulesDialog lists the alert rules of a subscription
+func (tm *TabManager) showAlertRulesDialog(subscription models.Subscription) {
+	var rules []models.AlertRule
+	var list *widget.List
+
+	reload := func() {
+		loaded, err := tm.alertService.GetRules(subscription.ID)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		rules = loaded
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int {
+			return len(rules)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil,
+				widget.NewCheck("", nil),
+				container.NewHBox(
+					widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil),
+					widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
+				),
+				widget.NewLabel("Rule"),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			rule := rules[id]
+			row := o.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			enabled := row.Objects[1].(*widget.Check)
+			buttons := row.Objects[2].(*fyne.Container)
+
+			label.SetText(fmt.Sprintf("%s - %s", rule.Name, describeAlertRule(rule)))
+			enabled.OnChanged = nil
+			enabled.SetChecked(rule.Enabled)
+			enabled.OnChanged = func(checked bool) {
+				if err := tm.alertService.SetRuleEnabled(rule, checked); err != nil {
+					components.ErrorDialog(err, tm.window)
+				}
+				reload()
+			}
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				tm.showAlertRuleDialog(rule, reload)
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				components.ConfirmDialog("Delete Alert Rule", fmt.Sprintf("Delete the alert rule %s?", rule.Name), func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					if err := tm.alertService.DeleteRule(rule); err != nil {
+						components.ErrorDialog(err, tm.window)
+					}
+					reload()
+				}, tm.window).Show()
+			}
+		},
+	)
+
+	addButton := widget.NewButtonWithIcon("Add Rule", theme.ContentAddIcon(), func() {
+		tm.showAlertRuleDialog(models.AlertRule{
+			SubscriptionID: subscription.ID,
+			Kind:           alerts.KindRegex,
+			Cooldown:       time.Minute,
+			Enabled:        true,
+		}, reload)
+	})
+	logButton := widget.NewButtonWithIcon("Alert Log", theme.ListIcon(), tm.AddAlertsTab)
+
+	content := container.NewBorder(
+		widget.NewLabel("Checked rules raise a notification when they fire"),
+		container.NewHBox(addButton, logButton),
+		nil, nil,
+		list,
+	)
+	reload()
+
+	rulesDialog := dialog.NewCustom(fmt.Sprintf("Alert Rules: %s", subscription.SubName), "Close", content, tm.window)
+	rulesDialog.Resize(fyne.NewSize(600, 400))
+	rulesDialog.Show()
+}
+
+// showAlertRuleDialog edits a new or existing alert rule
+func (tm *TabManager) showAlertRuleDialog(rule models.AlertRule, onSaved func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(rule.Name)
+	nameEntry.SetPlaceHolder("e.g. errors")
+
+	expressionEntry := widget.NewEntry()
+	expressionEntry.SetText(rule.Expression)
+	thresholdEntry := widget.NewEntry()
+	if rule.Threshold > 0 {
+		thresholdEntry.SetText(strconv.FormatFloat(rule.Threshold, 'f', -1, 64))
+	}
+	cooldownEntry := widget.NewEntry()
+	cooldownEntry.SetText(rule.Cooldown.String())
+	enabledCheck := widget.NewCheck("Enabled", nil)
+	enabledCheck.SetChecked(rule.Enabled)
+
+	labels := make([]string, len(alerts.Kinds))
+	kinds := make(map[string]alerts.Kind, len(alerts.Kinds))
+	for i, kind := range alerts.Kinds {
+		labels[i] = alertKindLabels[kind]
+		kinds[labels[i]] = kind
+	}
+	kindSelect := widget.NewSelect(labels, func(label string) {
+		switch kinds[label] {
+		case alerts.KindRegex:
+			expressionEntry.Enable()
+			expressionEntry.SetPlaceHolder(`e.g. (?i)timeout|refused`)
+			thresholdEntry.Disable()
+		case alerts.KindJSONPath:
+			expressionEntry.Enable()
+			expressionEntry.SetPlaceHolder(`e.g. $.level == 'error'`)
+			thresholdEntry.Disable()
+		case alerts.KindSilence:
+			expressionEntry.Disable()
+			thresholdEntry.Enable()
+			thresholdEntry.SetPlaceHolder("Seconds, e.g. 60")
+		case alerts.KindRate:
+			expressionEntry.Disable()
+			thresholdEntry.Enable()
+			thresholdEntry.SetPlaceHolder("Messages per second, e.g. 1000")
+		}
+	})
+	kindSelect.SetSelected(alertKindLabels[rule.Kind])
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Condition", kindSelect),
+		widget.NewFormItem("Expression", expressionEntry),
+		widget.NewFormItem("Threshold", thresholdEntry),
+		widget.NewFormItem("Cooldown", cooldownEntry),
+		widget.NewFormItem("", enabledCheck),
+	}
+
+	title := "Add Alert Rule"
+	if rule.ID > 0 {
+		title = "Edit Alert Rule"
+	}
+	ruleDialog := components.FormDialog(title, "Save", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		rule.Name = strings.TrimSpace(nameEntry.Text)
+		rule.Kind = kinds[kindSelect.Selected]
+		rule.Enabled = enabledCheck.Checked
+		rule.Expression = ""
+		rule.Threshold = 0
+
+		var err error
+		switch rule.Kind {
+		case alerts.KindRegex, alerts.KindJSONPath:
+			rule.Expression = strings.TrimSpace(expressionEntry.Text)
+		default:
+			if rule.Threshold, err = strconv.ParseFloat(strings.TrimSpace(thresholdEntry.Text), 64); err != nil {
+				components.ErrorDialog(fmt.Errorf("invalid threshold: %w", err), tm.window)
+				return
+			}
+		}
+		if rule.Cooldown, err = time.ParseDuration(strings.TrimSpace(cooldownEntry.Text)); err != nil {
+			components.ErrorDialog(fmt.Errorf("invalid cooldown: %w", err), tm.window)
+			return
+		}
+
+		if err := tm.alertService.SaveRule(rule); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		onSaved()
+	}, tm.window)
+	ruleDialog.Resize(fyne.NewSize(480, 360))
+	ruleDialog.Show()
+}
+
+// describeAlertRule summarizes the condition and cooldown of a rule
+func describeAlertRule(rule models.AlertRule) string {
+	var condition string
+	switch rule.Kind {
+	case alerts.KindRegex:
+		condition = fmt.Sprintf("payload matches %s", rule.Expression)
+	case alerts.KindJSONPath:
+		condition = rule.Expression
+	case alerts.KindSilence:
+		condition = fmt.Sprintf("no message for %gs", rule.Threshold)
+	case alerts.KindRate:
+		condition = fmt.Sprintf("rate above %g msgs/s", rule.Threshold)
+	default:
+		condition = string(rule.Kind)
+	}
+	if rule.Cooldown > 0 {
+		condition += fmt.Sprintf(" (cooldown %v)", rule.Cooldown)
+	}
+	return condition
+}
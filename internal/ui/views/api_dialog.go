@@ -0,0 +1,132 @@
+package views
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/api"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// Preferences of the local API
+const (
+	apiEnabledKey = "apiEnabled"
+	apiAddressKey = "apiAddress"
+	apiTokenKey   = "apiToken"
+)
+
+// apiToken returns the saved API token, generating one the first time
+func (mw *MainWindow) apiToken() (string, error) {
+	prefs := mw.app.Preferences()
+	if token := prefs.String(apiTokenKey); token != "" {
+		return token, nil
+	}
+	token, err := api.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	prefs.SetString(apiTokenKey, token)
+	return token, nil
+}
+
+// startAPIEndpoint serves the local API at startup if it was enabled
+func (mw *MainWindow) startAPIEndpoint() {
+	prefs := mw.app.Preferences()
+	if !prefs.Bool(apiEnabledKey) {
+		return
+	}
+	token, err := mw.apiToken()
+	if err != nil {
+		log.Printf("Error creating API token: %v", err)
+		return
+	}
+	if err := mw.apiServer.Start(prefs.StringWithFallback(apiAddressKey, api.DefaultAddress), token); err != nil {
+		log.Printf("Error starting API: %v", err)
+	}
+}
+
+// showAPIDialog enables, disables or moves the local API and shows its token
+func (mw *MainWindow) showAPIDialog() {
+	prefs := mw.app.Preferences()
+	token, err := mw.apiToken()
+	if err != nil {
+		components.ErrorDialog(err, mw.window)
+		return
+	}
+
+	enabledCheck := widget.NewCheck("Serve the local API", nil)
+	enabledCheck.SetChecked(prefs.Bool(apiEnabledKey))
+
+	addressEntry := widget.NewEntry()
+	addressEntry.SetText(prefs.StringWithFallback(apiAddressKey, api.DefaultAddress))
+	addressEntry.SetPlaceHolder(api.DefaultAddress)
+
+	tokenEntry := widget.NewEntry()
+	tokenEntry.SetText(token)
+	tokenEntry.Disable()
+	copyButton := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		mw.window.Clipboard().SetContent(tokenEntry.Text)
+	})
+	regenerateButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		newToken, err := api.GenerateToken()
+		if err != nil {
+			components.ErrorDialog(err, mw.window)
+			return
+		}
+		tokenEntry.SetText(newToken)
+	})
+
+	status := "Not running"
+	if addr := mw.apiServer.Addr(); addr != "" {
+		status = fmt.Sprintf("Serving http://%s/api", addr)
+	}
+	statusLabel := widget.NewLabel(status)
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("", enabledCheck),
+		widget.NewFormItem("Listen Address", addressEntry),
+		widget.NewFormItem("Token", container.NewBorder(nil, nil, nil, container.NewHBox(copyButton, regenerateButton), tokenEntry)),
+		widget.NewFormItem("Status", statusLabel),
+	}
+	items[1].HintText = "Loopback addresses only"
+	items[2].HintText = "Send as Authorization: Bearer <token>, or ?token= for streams"
+
+	apiDialog := components.FormDialog(
+		"Local API",
+		"Apply",
+		"Cancel",
+		items,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			address := strings.TrimSpace(addressEntry.Text)
+			if address == "" {
+				address = api.DefaultAddress
+			}
+
+			if enabledCheck.Checked {
+				if err := mw.apiServer.Start(address, tokenEntry.Text); err != nil {
+					components.ErrorDialog(err, mw.window)
+					return
+				}
+			} else {
+				mw.apiServer.Stop()
+			}
+
+			prefs.SetBool(apiEnabledKey, enabledCheck.Checked)
+			prefs.SetString(apiAddressKey, address)
+			prefs.SetString(apiTokenKey, tokenEntry.Text)
+		},
+		mw.window,
+	)
+	apiDialog.Resize(fyne.NewSize(560, 300))
+	apiDialog.Show()
+}
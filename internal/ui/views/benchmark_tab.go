@@ -0,0 +1,311 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/bench"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/templating"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const (
+	benchModeCount    = "Message count"
+	benchModeDuration = "Duration"
+	benchPayloadSize  = "Fixed size"
+	benchPayloadTmpl  = "Template"
+)
+
+// benchColumns are the columns of the benchmark results table
+var benchColumns = []string{"Started", "Sent", "Errors", "Msgs/s", "KB/s", "p50", "p95", "p99", "Max"}
+
+// AddBenchmarkTab adds (or selects) a tab that generates publish load on a topic
+func (tm *TabManager) AddBenchmarkTab(topic models.Topic) {
+	tabName := fmt.Sprintf("bench-%v", topic.TopicName)
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == tabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	subjectEntry := widget.NewEntry()
+	subjectEntry.SetText(topic.TopicName)
+
+	countEntry := widget.NewEntry()
+	countEntry.SetText("10000")
+	durationEntry := widget.NewEntry()
+	durationEntry.SetText("10s")
+	durationEntry.SetPlaceHolder("e.g. 30s, 5m")
+	modeRadio := widget.NewRadioGroup([]string{benchModeCount, benchModeDuration}, func(mode string) {
+		if mode == benchModeDuration {
+			countEntry.Disable()
+			durationEntry.Enable()
+		} else {
+			durationEntry.Disable()
+			countEntry.Enable()
+		}
+	})
+	modeRadio.Horizontal = true
+	modeRadio.Required = true
+	modeRadio.SetSelected(benchModeCount)
+
+	rateEntry := widget.NewEntry()
+	rateEntry.SetText("0")
+	rateEntry.SetPlaceHolder("Messages per second, 0 for unlimited")
+	publishersEntry := widget.NewEntry()
+	publishersEntry.SetText("1")
+
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetText("256")
+	templateEntry := widget.NewMultiLineEntry()
+	templateEntry.SetPlaceHolder(`{"id": "{{uuid}}", "seq": {{seq}}, "at": "{{now}}"}`)
+	templateEntry.SetMinRowsVisible(4)
+	variablesEntry := widget.NewMultiLineEntry()
+	variablesEntry.SetPlaceHolder("Variables, one name=value per line")
+	variablesEntry.SetMinRowsVisible(2)
+	templateOptions := container.NewVBox(templateEntry, variablesEntry)
+	payloadRadio := widget.NewRadioGroup([]string{benchPayloadSize, benchPayloadTmpl}, func(mode string) {
+		if mode == benchPayloadTmpl {
+			sizeEntry.Disable()
+			templateOptions.Show()
+		} else {
+			sizeEntry.Enable()
+			templateOptions.Hide()
+		}
+	})
+	payloadRadio.Horizontal = true
+	payloadRadio.Required = true
+	payloadRadio.SetSelected(benchPayloadSize)
+
+	// readConfig builds the benchmark configuration from the form
+	readConfig := func() (bench.Config, error) {
+		cfg := bench.Config{Subject: strings.TrimSpace(subjectEntry.Text)}
+
+		var err error
+		if modeRadio.Selected == benchModeDuration {
+			if cfg.Duration, err = time.ParseDuration(strings.TrimSpace(durationEntry.Text)); err != nil {
+				return cfg, fmt.Errorf("invalid duration: %w", err)
+			}
+		} else if cfg.Count, err = strconv.Atoi(strings.TrimSpace(countEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid message count: %w", err)
+		}
+		if cfg.Rate, err = strconv.ParseFloat(strings.TrimSpace(rateEntry.Text), 64); err != nil {
+			return cfg, fmt.Errorf("invalid rate: %w", err)
+		}
+		if cfg.Publishers, err = strconv.Atoi(strings.TrimSpace(publishersEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid number of publishers: %w", err)
+		}
+
+		if payloadRadio.Selected == benchPayloadTmpl {
+			cfg.Template = templateEntry.Text
+			if cfg.Vars, err = templating.ParseVariables(variablesEntry.Text); err != nil {
+				return cfg, fmt.Errorf("invalid variables: %w", err)
+			}
+		} else if cfg.PayloadSize, err = strconv.Atoi(strings.TrimSpace(sizeEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid payload size: %w", err)
+		}
+
+		return cfg, cfg.Validate()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Subject", subjectEntry),
+		widget.NewFormItem("Run for", modeRadio),
+		widget.NewFormItem("Messages", countEntry),
+		widget.NewFormItem("Duration", durationEntry),
+		widget.NewFormItem("Rate (msgs/s)", rateEntry),
+		widget.NewFormItem("Publishers", publishersEntry),
+		widget.NewFormItem("Payload", payloadRadio),
+		widget.NewFormItem("Size (bytes)", sizeEntry),
+		widget.NewFormItem("Template", templateOptions),
+	)
+
+	var results []bench.Result
+	resultsTable := widget.NewTable(
+		func() (int, int) {
+			return len(results) + 1, len(benchColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("00:00:00.000")
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(benchColumns[id.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText(benchCell(results[id.Row-1], id.Col))
+		},
+	)
+	for col := range benchColumns {
+		resultsTable.SetColumnWidth(col, 110)
+	}
+
+	progressBar := widget.NewProgressBar()
+	statusLabel := widget.NewLabel("Ready")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	var cancel context.CancelFunc
+	var startButton, stopButton *widget.Button
+
+	startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), func() {
+		cfg, err := readConfig()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+
+		provider, ok := tm.serverService.GetMessagingProvider(topic.ServerID)
+		if !ok {
+			components.ErrorDialog(fmt.Errorf("no messaging provider connection for server"), tm.window)
+			return
+		}
+
+		ctx, runCancel := context.WithCancel(context.Background())
+		cancel = runCancel
+		startButton.Disable()
+		stopButton.Enable()
+		progressBar.SetValue(0)
+		statusLabel.SetText("Running...")
+
+		go func() {
+			defer runCancel()
+			result, err := tm.messageService.RunBenchmark(ctx, provider, cfg, 250*time.Millisecond, func(p bench.Progress) {
+				var fraction float64
+				if cfg.Count > 0 {
+					fraction = float64(p.Sent+p.Errors) / float64(cfg.Count)
+				} else {
+					fraction = p.Elapsed.Seconds() / cfg.Duration.Seconds()
+				}
+				rate := 0.0
+				if seconds := p.Elapsed.Seconds(); seconds > 0 {
+					rate = float64(p.Sent) / seconds
+				}
+				text := fmt.Sprintf("Sent %d, errors %d, %.1f msgs/s, %v elapsed", p.Sent, p.Errors, rate, p.Elapsed.Round(time.Millisecond))
+				fyne.Do(func() {
+					progressBar.SetValue(min(fraction, 1))
+					statusLabel.SetText(text)
+				})
+			})
+
+			fyne.Do(func() {
+				startButton.Enable()
+				stopButton.Disable()
+				if err != nil {
+					statusLabel.SetText(fmt.Sprintf("Failed: %v", err))
+					return
+				}
+
+				results = append(results, result)
+				resultsTable.Refresh()
+
+				text := fmt.Sprintf("Done: %d sent, %d errors in %v - %.1f msgs/s, p50 %v, p95 %v, p99 %v",
+					result.Sent, result.Errors, result.Elapsed.Round(time.Millisecond), result.Throughput,
+					result.Latency.P50, result.Latency.P95, result.Latency.P99)
+				if result.FirstError != "" {
+					text += "\nFirst error: " + result.FirstError
+				}
+				statusLabel.SetText(text)
+			})
+		}()
+	})
+	startButton.Importance = widget.HighImportance
+
+	stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	stopButton.Disable()
+
+	export := func(extension string, encode func([]bench.Result) ([]byte, error)) {
+		if len(results) == 0 {
+			components.ErrorDialog(fmt.Errorf("no benchmark results to export"), tm.window)
+			return
+		}
+		data, err := encode(results)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if _, err := writer.Write(data); err != nil {
+				components.ErrorDialog(fmt.Errorf("failed to export results: %w", err), tm.window)
+			}
+		}, tm.window)
+		saveDialog.SetFileName(fmt.Sprintf("benchmark-%s.%s", topic.TopicName, extension))
+		saveDialog.Show()
+	}
+	csvButton := widget.NewButtonWithIcon("Export CSV", theme.DocumentSaveIcon(), func() { export("csv", bench.CSV) })
+	jsonButton := widget.NewButtonWithIcon("Export JSON", theme.DocumentSaveIcon(), func() { export("json", bench.JSON) })
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(tabName)
+	})
+
+	top := container.NewVBox(
+		container.NewHBox(widget.NewLabel(fmt.Sprintf("Benchmark: %s", topic.TopicName)), closeButton),
+		form,
+		container.NewHBox(startButton, stopButton, csvButton, jsonButton),
+		progressBar,
+		statusLabel,
+		widget.NewLabel("Results:"),
+	)
+	content := container.NewBorder(top, nil, nil, nil, resultsTable)
+
+	tab := container.NewTabItemWithIcon(tabName, theme.MediaFastForwardIcon(), content)
+	tm.cleanups[tab] = func() {
+		if cancel != nil {
+			cancel()
+		}
+	}
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// benchCell formats a column of a benchmark result
+func benchCell(r bench.Result, col int) string {
+	switch col {
+	case 0:
+		return r.StartedAt.Format("15:04:05.000")
+	case 1:
+		return strconv.FormatUint(r.Sent, 10)
+	case 2:
+		return strconv.FormatUint(r.Errors, 10)
+	case 3:
+		return fmt.Sprintf("%.1f", r.Throughput)
+	case 4:
+		return fmt.Sprintf("%.1f", r.ByteRate/1024)
+	case 5:
+		return r.Latency.P50.String()
+	case 6:
+		return r.Latency.P95.String()
+	case 7:
+		return r.Latency.P99.String()
+	case 8:
+		return r.Latency.Max.String()
+	}
+	return ""
+}
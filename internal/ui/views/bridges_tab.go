@@ -0,0 +1,300 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/bridge"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const bridgesTabName = "Bridges"
+
+// bridgeStatsInterval is how often the Bridges tab refreshes the counts
+const bridgeStatsInterval = time.Second
+
+// bridgeRow shows one bridge and its live counts
+type bridgeRow struct {
+	bridge     models.Bridge
+	statsLabel *widget.Label
+}
+
+// AddBridgesTab adds (or selects) a tab that manages the bridges forwarding
+// messages between servers
+func (tm *TabManager) AddBridgesTab() {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == bridgesTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	rowsBox := container.NewVBox()
+	// rows is replaced on the UI thread and read by fyne.Do callbacks
+	var rows []*bridgeRow
+
+	var reload func()
+	reload = func() {
+		bridges, err := tm.bridgeService.GetBridges()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		servers, err := tm.serverService.GetAllServers()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		serverNames := make(map[int]string, len(servers))
+		for _, server := range servers {
+			serverNames[server.ID] = server.Name
+		}
+
+		rows = nil
+		rowsBox.RemoveAll()
+		if len(bridges) == 0 {
+			rowsBox.Add(widget.NewLabel("No bridges yet. A bridge subscribes on one server and republishes to another."))
+		}
+		for _, b := range bridges {
+			row := &bridgeRow{bridge: b, statsLabel: widget.NewLabel("Stopped")}
+			row.statsLabel.Wrapping = fyne.TextWrapWord
+			rows = append(rows, row)
+
+			title := widget.NewLabel(fmt.Sprintf("%s: %s %s -> %s", b.Name,
+				serverNames[b.SourceServerID], b.SourcePattern, serverNames[b.TargetServerID]))
+			title.TextStyle = fyne.TextStyle{Bold: true}
+
+			var toggleButton *widget.Button
+			toggleButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), func() {
+				if tm.bridgeService.IsRunning(b.ID) {
+					tm.bridgeService.Stop(b.ID)
+					reload()
+					return
+				}
+				toggleButton.Disable()
+				go func() {
+					err := tm.bridgeService.Start(b)
+					fyne.Do(func() {
+						if err != nil {
+							components.ErrorDialog(err, tm.window)
+						}
+						reload()
+					})
+				}()
+			})
+			if tm.bridgeService.IsRunning(b.ID) {
+				toggleButton.SetText("Stop")
+				toggleButton.SetIcon(theme.MediaStopIcon())
+			}
+
+			editButton := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				tm.showBridgeDialog(b, servers, reload)
+			})
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				components.ConfirmDialog("Delete Bridge", fmt.Sprintf("Delete the bridge %s?", b.Name), func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					if err := tm.bridgeService.DeleteBridge(b.ID); err != nil {
+						components.ErrorDialog(err, tm.window)
+					}
+					reload()
+				}, tm.window).Show()
+			})
+
+			rowsBox.Add(widget.NewSeparator())
+			rowsBox.Add(container.NewBorder(nil, nil, nil, container.NewHBox(toggleButton, editButton, deleteButton), title))
+			rowsBox.Add(row.statsLabel)
+		}
+		rowsBox.Refresh()
+	}
+
+	addButton := widget.NewButtonWithIcon("Add Bridge", theme.ContentAddIcon(), func() {
+		servers, err := tm.serverService.GetAllServers()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		tm.showBridgeDialog(models.Bridge{}, servers, reload)
+	})
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(bridgesTabName)
+	})
+
+	content := container.NewBorder(
+		container.NewHBox(widget.NewLabel("Bridges"), layout.NewSpacer(), addButton, closeButton),
+		nil, nil, nil,
+		container.NewVScroll(rowsBox),
+	)
+	reload()
+
+	// Bridges keep running when the tab closes; only the counts stop
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(bridgeStatsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stats := tm.bridgeService.GetStats()
+				fyne.Do(func() {
+					for _, row := range rows {
+						s, running := stats[row.bridge.ID]
+						row.statsLabel.SetText(formatBridgeStats(s, running))
+					}
+				})
+			}
+		}
+	}()
+
+	tab := container.NewTabItemWithIcon(bridgesTabName, theme.MailForwardIcon(), content)
+	tm.cleanups[tab] = cancel
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// formatBridgeStats describes the counts of a bridge
+func formatBridgeStats(stats bridge.Stats, running bool) string {
+	if !running {
+		return "Stopped"
+	}
+	text := fmt.Sprintf("Running - forwarded %d, failed %d", stats.Forwarded, stats.Failed)
+	if stats.Dropped > 0 {
+		text += fmt.Sprintf(", dropped by script %d", stats.Dropped)
+	}
+	if !stats.LastForwarded.IsZero() {
+		text += fmt.Sprintf(", last %v ago", time.Since(stats.LastForwarded).Round(time.Second))
+	}
+	if stats.LastError != "" {
+		text += fmt.Sprintf("\nLast error: %s", stats.LastError)
+	}
+	return text
+}
+
+// showBridgeDialog edits a new or existing bridge
+func (tm *TabManager) showBridgeDialog(b models.Bridge, servers []models.Server, onSaved func()) {
+	if len(servers) == 0 {
+		components.ErrorDialog(fmt.Errorf("add a server first"), tm.window)
+		return
+	}
+
+	serverNames := make([]string, len(servers))
+	serverIDs := make(map[string]int, len(servers))
+	providerTypes := make(map[string]messaging.ProviderType, len(servers))
+	for i, server := range servers {
+		serverNames[i] = fmt.Sprintf("%s (%s)", server.Name, server.ProviderType)
+		serverIDs[serverNames[i]] = server.ID
+		providerTypes[serverNames[i]] = server.ProviderType
+	}
+	selectServer := func(s *widget.Select, serverID int) {
+		for name, id := range serverIDs {
+			if id == serverID {
+				s.SetSelected(name)
+			}
+		}
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(b.Name)
+	nameEntry.SetPlaceHolder("e.g. prod orders to local")
+	patternEntry := widget.NewEntry()
+	patternEntry.SetText(b.SourcePattern)
+	patternEntry.SetPlaceHolder("orders.>")
+	mappingsEntry := widget.NewMultiLineEntry()
+	mappingsEntry.SetText(bridge.FormatMappings(b.Mappings))
+	mappingsEntry.SetPlaceHolder("orders.*.created -> debug.created.$1\nevents.> -> mirror.$1")
+	mappingsEntry.SetMinRowsVisible(3)
+
+	// The examples follow the pattern syntax of the source server
+	sourceSelect := widget.NewSelect(serverNames, func(name string) {
+		caps := tm.providerCapabilities(providerTypes[name])
+		patternEntry.SetPlaceHolder(caps.PatternHint())
+		if caps.Wildcards == messaging.WildcardsNATS {
+			mappingsEntry.SetPlaceHolder("orders.*.created -> debug.created.$1\nevents.> -> mirror.$1")
+		} else {
+			mappingsEntry.SetPlaceHolder("orders -> orders-mirror")
+		}
+	})
+	selectServer(sourceSelect, b.SourceServerID)
+	targetSelect := widget.NewSelect(serverNames, nil)
+	selectServer(targetSelect, b.TargetServerID)
+	transformEntry := widget.NewMultiLineEntry()
+	transformEntry.SetText(b.Transform)
+	transformEntry.SetPlaceHolder(`Optional, e.g. {"source":"{{.subject}}","data":{{.payload}}}`)
+	transformEntry.SetMinRowsVisible(3)
+	scriptEntry := widget.NewMultiLineEntry()
+	scriptEntry.SetText(b.Script)
+	scriptEntry.SetPlaceHolder("Optional JavaScript, e.g.\nif (msg.headers[\"X-Debug\"]) return false;\nmsg.data = msg.data.trim();")
+	scriptEntry.SetMinRowsVisible(4)
+	scriptTimeoutEntry := newScriptTimeoutEntry(b.ScriptTimeout)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Source server", sourceSelect),
+		widget.NewFormItem("Source pattern", patternEntry),
+		widget.NewFormItem("Target server", targetSelect),
+		widget.NewFormItem("Subject mappings", mappingsEntry),
+		widget.NewFormItem("Transform", transformEntry),
+		widget.NewFormItem("Script", scriptEntry),
+		widget.NewFormItem("Script timeout (ms)", scriptTimeoutEntry),
+	}
+	items[4].HintText = "One from -> to per line; unmatched subjects are kept"
+	items[5].HintText = "Payload template with {{.subject}}, {{.payload}} and {{var \"header.Name\"}}"
+	items[6].HintText = "Runs before the mappings; see msg, publish() and log()"
+
+	title := "Add Bridge"
+	if b.ID > 0 {
+		title = "Edit Bridge"
+	}
+	bridgeDialog := components.FormDialog(title, "Save", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if sourceSelect.Selected == "" || targetSelect.Selected == "" {
+			components.ErrorDialog(fmt.Errorf("select a source and a target server"), tm.window)
+			return
+		}
+		mappings, err := bridge.ParseMappings(mappingsEntry.Text)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("invalid subject mappings: %w", err), tm.window)
+			return
+		}
+		scriptTimeout, err := parseScriptTimeout(scriptTimeoutEntry.Text)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+
+		b.Name = strings.TrimSpace(nameEntry.Text)
+		b.SourceServerID = serverIDs[sourceSelect.Selected]
+		b.SourcePattern = strings.TrimSpace(patternEntry.Text)
+		b.TargetServerID = serverIDs[targetSelect.Selected]
+		b.Mappings = mappings
+		b.Transform = transformEntry.Text
+		b.Script = scriptEntry.Text
+		b.ScriptTimeout = scriptTimeout
+
+		if _, err := tm.bridgeService.SaveBridge(b); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		if tm.bridgeService.IsRunning(b.ID) {
+			dialog.ShowInformation("Bridge Saved", "Restart the bridge to apply the changes.", tm.window)
+		}
+		onSaved()
+	}, tm.window)
+	bridgeDialog.Resize(fyne.NewSize(560, 640))
+	bridgeDialog.Show()
+}
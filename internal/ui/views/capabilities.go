@@ -0,0 +1,62 @@
+package views
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/devalexandre/broker-ui/internal/messaging"
+)
+
+// providerCapabilities returns what a provider type supports. Unknown
+// providers get no capabilities, which skips the checks that rely on them.
+func (tm *TabManager) providerCapabilities(providerType messaging.ProviderType) messaging.Capabilities {
+	caps, err := tm.serverService.GetCapabilities(providerType)
+	if err != nil {
+		log.Printf("Error getting capabilities of %s: %v", providerType, err)
+		return messaging.Capabilities{SubjectKind: "subject"}
+	}
+	return caps
+}
+
+// kindTitle capitalizes a subject kind for labels, such as "Queue"
+func kindTitle(kind string) string {
+	if kind == "" {
+		return "Subject"
+	}
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
+
+// formatCapabilities describes what a provider supports for the config tab
+func formatCapabilities(caps messaging.Capabilities) string {
+	names := fmt.Sprintf("exact %s names", caps.SubjectKind)
+	if caps.Wildcards == messaging.WildcardsNATS {
+		names = fmt.Sprintf(`%s patterns with "*" and ">" wildcards`, caps.SubjectKind)
+	}
+	text := "Subscriptions: " + names
+
+	if caps.Ack != "" {
+		text += fmt.Sprintf("\nAcknowledgement: %s", caps.Ack)
+	}
+
+	var features []string
+	for _, feature := range []struct {
+		supported bool
+		name      string
+	}{
+		{caps.Headers, "headers"},
+		{caps.Request, "requests"},
+		{caps.Reply, "replies"},
+		{caps.Replay, "messages kept while unsubscribed"},
+		{caps.Ordered, "ordered delivery"},
+		{caps.Admin, "admin API"},
+	} {
+		if feature.supported {
+			features = append(features, feature.name)
+		}
+	}
+	if len(features) > 0 {
+		text += "\nSupports: " + strings.Join(features, ", ")
+	}
+	return text
+}
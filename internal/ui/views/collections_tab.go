@@ -0,0 +1,682 @@
+package views
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/services"
+	"github.com/devalexandre/broker-ui/internal/templating"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const collectionsTabName = "Collections"
+
+// activeEnvironmentKey is the preference holding the selected environment name
+const activeEnvironmentKey = "activeEnvironment"
+
+// noEnvironment is the environment option that renders requests without variables
+const noEnvironment = "No Environment"
+
+const (
+	folderNodePrefix  = "folder:"
+	requestNodePrefix = "request:"
+)
+
+// collectionsView is the state of the Collections tab
+type collectionsView struct {
+	tm *TabManager
+
+	collections  []models.Collection
+	environments []models.Environment
+	requests     []models.CollectionRequest
+	collectionID int
+	// requestID is the request in the editor, 0 for a new one
+	requestID int
+
+	children map[string][]string
+	labels   map[string]string
+
+	collectionSelect  *widget.Select
+	environmentSelect *widget.Select
+	tree              *widget.Tree
+	nameEntry         *widget.Entry
+	folderEntry       *widget.Entry
+	providerSelect    *widget.Select
+	serverEntry       *widget.Entry
+	subjectEntry      *widget.Entry
+	headersEntry      *widget.Entry
+	bodyEntry         *widget.Entry
+	statusLabel       *widget.Label
+}
+
+// AddCollectionsTab adds (or selects) the tab for saved publish requests
+func (tm *TabManager) AddCollectionsTab() {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == collectionsTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	v := &collectionsView{tm: tm}
+	content := v.build()
+
+	tab := container.NewTabItemWithIcon(collectionsTabName, theme.FolderIcon(), content)
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+
+	v.reloadEnvironments()
+	v.reloadCollections("")
+}
+
+// build creates the widgets of the tab
+func (v *collectionsView) build() fyne.CanvasObject {
+	v.collectionSelect = widget.NewSelect(nil, v.selectCollection)
+	v.collectionSelect.PlaceHolder = "Select a collection"
+	v.environmentSelect = widget.NewSelect(nil, func(name string) {
+		fyne.CurrentApp().Preferences().SetString(activeEnvironmentKey, name)
+	})
+
+	newCollectionButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), v.showNewCollectionDialog)
+	importButton := widget.NewButtonWithIcon("Import", theme.DownloadIcon(), v.importCollection)
+	exportButton := widget.NewButtonWithIcon("Export", theme.UploadIcon(), v.exportCollection)
+	deleteCollectionButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), v.deleteCollection)
+	environmentsButton := widget.NewButtonWithIcon("Environments...", theme.SettingsIcon(), v.showEnvironmentsDialog)
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		v.tm.removeTabByName(collectionsTabName)
+	})
+
+	toolbar := container.NewBorder(nil, nil,
+		widget.NewLabel("Collection:"),
+		container.NewHBox(newCollectionButton, importButton, exportButton, deleteCollectionButton,
+			widget.NewLabel("Environment:"), v.environmentSelect, environmentsButton, closeButton),
+		v.collectionSelect,
+	)
+
+	v.tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return v.children[id]
+		},
+		func(id widget.TreeNodeID) bool {
+			return id == "" || strings.HasPrefix(id, folderNodePrefix)
+		},
+		func(branch bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(v.labels[id])
+		},
+	)
+	v.tree.OnSelected = v.selectNode
+
+	v.nameEntry = widget.NewEntry()
+	v.nameEntry.SetPlaceHolder("Request name")
+	v.folderEntry = widget.NewEntry()
+	v.folderEntry.SetPlaceHolder("Folder, e.g. orders/created (optional)")
+	v.providerSelect = widget.NewSelect(v.tm.serverService.GetSupportedProviders(), nil)
+	v.serverEntry = widget.NewEntry()
+	v.serverEntry.SetPlaceHolder("nats://{{.user}}:{{.password}}@{{.host}}:4222")
+	v.subjectEntry = widget.NewEntry()
+	v.subjectEntry.SetPlaceHolder("{{.prefix}}.orders.created")
+	v.headersEntry = widget.NewMultiLineEntry()
+	v.headersEntry.SetPlaceHolder("Headers, one name=value per line")
+	v.headersEntry.SetMinRowsVisible(3)
+	v.providerSelect.OnChanged = v.adaptToProvider
+	v.bodyEntry = widget.NewMultiLineEntry()
+	v.bodyEntry.SetPlaceHolder(`{"id": "{{uuid}}", "seq": {{seq}}}`)
+	v.bodyEntry.SetMinRowsVisible(8)
+	v.statusLabel = widget.NewLabel("")
+	v.statusLabel.Wrapping = fyne.TextWrapWord
+
+	form := widget.NewForm(
+		widget.NewFormItem("Name", v.nameEntry),
+		widget.NewFormItem("Folder", v.folderEntry),
+		widget.NewFormItem("Provider", v.providerSelect),
+		widget.NewFormItem("Server URL", v.serverEntry),
+		widget.NewFormItem("Subject", v.subjectEntry),
+		widget.NewFormItem("Headers", v.headersEntry),
+		widget.NewFormItem("Payload", v.bodyEntry),
+	)
+
+	newRequestButton := widget.NewButtonWithIcon("New Request", theme.ContentAddIcon(), v.newRequest)
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() { v.saveRequest() })
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), v.deleteRequest)
+	previewButton := widget.NewButtonWithIcon("Preview", theme.VisibilityIcon(), v.previewRequest)
+	sendButton := widget.NewButtonWithIcon("Send", theme.MailSendIcon(), v.sendRequest)
+	sendButton.Importance = widget.HighImportance
+
+	editor := container.NewBorder(nil,
+		container.NewVBox(
+			container.NewHBox(newRequestButton, saveButton, deleteButton, previewButton, sendButton),
+			v.statusLabel,
+		),
+		nil, nil,
+		container.NewVScroll(form),
+	)
+
+	split := container.NewHSplit(v.tree, editor)
+	split.Offset = 0.3
+
+	return container.NewBorder(toolbar, nil, nil, nil, split)
+}
+
+// reloadCollections refreshes the collection list, selecting name when given
+func (v *collectionsView) reloadCollections(name string) {
+	collections, err := v.tm.collectionService.GetCollections()
+	if err != nil {
+		components.ErrorDialog(fmt.Errorf("failed to load collections: %w", err), v.tm.window)
+		return
+	}
+	v.collections = collections
+
+	names := make([]string, len(collections))
+	for i, c := range collections {
+		names[i] = c.Name
+	}
+	v.collectionSelect.SetOptions(names)
+
+	switch {
+	case name != "":
+		v.collectionSelect.SetSelected(name)
+	case len(names) > 0:
+		v.collectionSelect.SetSelected(names[0])
+	default:
+		v.collectionSelect.ClearSelected()
+		v.collectionID = 0
+		v.reloadRequests()
+	}
+}
+
+// reloadEnvironments refreshes the environment list, keeping the active one
+func (v *collectionsView) reloadEnvironments() {
+	environments, err := v.tm.collectionService.GetEnvironments()
+	if err != nil {
+		components.ErrorDialog(fmt.Errorf("failed to load environments: %w", err), v.tm.window)
+		return
+	}
+	v.environments = environments
+
+	options := []string{noEnvironment}
+	active := fyne.CurrentApp().Preferences().StringWithFallback(activeEnvironmentKey, noEnvironment)
+	selected := noEnvironment
+	for _, e := range environments {
+		options = append(options, e.Name)
+		if e.Name == active {
+			selected = active
+		}
+	}
+	v.environmentSelect.SetOptions(options)
+	v.environmentSelect.SetSelected(selected)
+}
+
+// activeEnvironment returns the selected environment, or nil
+func (v *collectionsView) activeEnvironment() *models.Environment {
+	for i := range v.environments {
+		if v.environments[i].Name == v.environmentSelect.Selected {
+			return &v.environments[i]
+		}
+	}
+	return nil
+}
+
+// selectCollection shows the requests of the named collection
+func (v *collectionsView) selectCollection(name string) {
+	v.collectionID = 0
+	for _, c := range v.collections {
+		if c.Name == name {
+			v.collectionID = c.ID
+		}
+	}
+	v.reloadRequests()
+	v.newRequest()
+}
+
+// reloadRequests rebuilds the request tree of the current collection
+func (v *collectionsView) reloadRequests() {
+	v.requests = nil
+	if v.collectionID != 0 {
+		requests, err := v.tm.collectionService.GetRequests(v.collectionID)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("failed to load requests: %w", err), v.tm.window)
+		}
+		v.requests = requests
+	}
+
+	v.children = map[string][]string{}
+	v.labels = map[string]string{}
+
+	for _, folder := range services.Folders(v.requests) {
+		parent := ""
+		name := folder
+		if i := strings.LastIndex(folder, "/"); i >= 0 {
+			parent = folderNodePrefix + folder[:i]
+			name = folder[i+1:]
+		}
+		id := folderNodePrefix + folder
+		v.children[parent] = append(v.children[parent], id)
+		v.labels[id] = name
+	}
+
+	for _, req := range v.requests {
+		parent := ""
+		if req.Folder != "" {
+			parent = folderNodePrefix + req.Folder
+		}
+		id := requestNodePrefix + strconv.Itoa(req.ID)
+		v.children[parent] = append(v.children[parent], id)
+		v.labels[id] = fmt.Sprintf("%s (%s)", req.Name, req.ProviderType)
+	}
+
+	// Folders first, then requests, each alphabetically
+	for _, ids := range v.children {
+		sort.SliceStable(ids, func(i, j int) bool {
+			iFolder := strings.HasPrefix(ids[i], folderNodePrefix)
+			jFolder := strings.HasPrefix(ids[j], folderNodePrefix)
+			if iFolder != jFolder {
+				return iFolder
+			}
+			return v.labels[ids[i]] < v.labels[ids[j]]
+		})
+	}
+
+	v.tree.Refresh()
+}
+
+// selectNode loads a request into the editor, or presets the folder of new requests
+func (v *collectionsView) selectNode(id widget.TreeNodeID) {
+	if folder, ok := strings.CutPrefix(id, folderNodePrefix); ok {
+		v.newRequest()
+		v.folderEntry.SetText(folder)
+		return
+	}
+
+	requestID, _ := strconv.Atoi(strings.TrimPrefix(id, requestNodePrefix))
+	for _, req := range v.requests {
+		if req.ID == requestID {
+			v.showRequest(req)
+			return
+		}
+	}
+}
+
+// adaptToProvider offers headers only for providers that send them
+func (v *collectionsView) adaptToProvider(providerType string) {
+	if v.tm.providerCapabilities(messaging.ProviderType(providerType)).Headers {
+		v.headersEntry.Enable()
+	} else {
+		v.headersEntry.Disable()
+	}
+}
+
+// showRequest fills the editor with a request
+func (v *collectionsView) showRequest(req models.CollectionRequest) {
+	v.requestID = req.ID
+	v.nameEntry.SetText(req.Name)
+	v.folderEntry.SetText(req.Folder)
+	v.providerSelect.SetSelected(string(req.ProviderType))
+	v.serverEntry.SetText(req.ServerURL)
+	v.subjectEntry.SetText(req.Subject)
+	v.headersEntry.SetText(templating.FormatVariables(req.Headers))
+	v.bodyEntry.SetText(req.Body)
+	v.statusLabel.SetText("")
+}
+
+// newRequest clears the editor for a new request
+func (v *collectionsView) newRequest() {
+	provider := v.providerSelect.Selected
+	if provider == "" && len(v.providerSelect.Options) > 0 {
+		provider = v.providerSelect.Options[0]
+	}
+	v.showRequest(models.CollectionRequest{
+		ProviderType: messaging.ProviderType(provider),
+		Folder:       v.folderEntry.Text,
+	})
+	v.tree.UnselectAll()
+}
+
+// editedRequest returns the request described by the editor
+func (v *collectionsView) editedRequest() (models.CollectionRequest, error) {
+	headers, err := templating.ParseVariables(v.headersEntry.Text)
+	if err != nil {
+		return models.CollectionRequest{}, fmt.Errorf("invalid headers: %w", err)
+	}
+
+	return models.CollectionRequest{
+		ID:           v.requestID,
+		CollectionID: v.collectionID,
+		Folder:       v.folderEntry.Text,
+		Name:         v.nameEntry.Text,
+		ProviderType: messaging.ProviderType(v.providerSelect.Selected),
+		ServerURL:    v.serverEntry.Text,
+		Subject:      v.subjectEntry.Text,
+		Headers:      headers,
+		Body:         v.bodyEntry.Text,
+	}, nil
+}
+
+// saveRequest saves the editor content and reports whether it succeeded
+func (v *collectionsView) saveRequest() bool {
+	if v.collectionID == 0 {
+		components.ErrorDialog(fmt.Errorf("create or select a collection first"), v.tm.window)
+		return false
+	}
+
+	req, err := v.editedRequest()
+	if err != nil {
+		components.ErrorDialog(err, v.tm.window)
+		return false
+	}
+
+	id, err := v.tm.collectionService.SaveRequest(req)
+	if err != nil {
+		components.ErrorDialog(err, v.tm.window)
+		return false
+	}
+
+	v.requestID = id
+	v.reloadRequests()
+	v.tree.Select(requestNodePrefix + strconv.Itoa(id))
+	v.statusLabel.SetText(fmt.Sprintf("Saved %s", req.Name))
+	return true
+}
+
+// deleteRequest deletes the request in the editor after confirmation
+func (v *collectionsView) deleteRequest() {
+	if v.requestID == 0 {
+		v.newRequest()
+		return
+	}
+
+	requestID := v.requestID
+	components.ConfirmDialog(
+		"Delete Request",
+		fmt.Sprintf("Are you sure you want to delete the request %q?", v.nameEntry.Text),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := v.tm.collectionService.DeleteRequest(requestID); err != nil {
+				components.ErrorDialog(err, v.tm.window)
+				return
+			}
+			v.reloadRequests()
+			v.newRequest()
+		},
+		v.tm.window,
+	).Show()
+}
+
+// resolve renders the editor content with the active environment
+func (v *collectionsView) resolve() (services.ResolvedRequest, bool) {
+	req, err := v.editedRequest()
+	if err == nil {
+		var resolved services.ResolvedRequest
+		resolved, err = v.tm.collectionService.Resolve(req, v.activeEnvironment())
+		if err == nil {
+			return resolved, true
+		}
+	}
+	components.ErrorDialog(err, v.tm.window)
+	return services.ResolvedRequest{}, false
+}
+
+// previewRequest shows the rendered request and offers to send it
+func (v *collectionsView) previewRequest() {
+	resolved, ok := v.resolve()
+	if !ok {
+		return
+	}
+
+	target := fmt.Sprintf("%s on %s (%s)", resolved.Subject, redactURL(resolved.ServerURL), resolved.ProviderType)
+	if len(resolved.Headers) > 0 {
+		target += fmt.Sprintf(", %d headers", len(resolved.Headers))
+	}
+
+	v.tm.showPayloadPreview(target, resolved.Body, func() {
+		v.send(resolved)
+	})
+}
+
+// sendRequest renders and sends the editor content
+func (v *collectionsView) sendRequest() {
+	if resolved, ok := v.resolve(); ok {
+		v.send(resolved)
+	}
+}
+
+// send publishes a resolved request in the background, reporting the result
+func (v *collectionsView) send(resolved services.ResolvedRequest) {
+	requestID := v.requestID
+	server := redactURL(resolved.ServerURL)
+	v.statusLabel.SetText(fmt.Sprintf("Sending to %s on %s...", resolved.Subject, server))
+
+	go func() {
+		err := v.tm.collectionService.Send(requestID, resolved)
+		fyne.Do(func() {
+			if err != nil {
+				v.statusLabel.SetText(fmt.Sprintf("Failed: %v", err))
+				return
+			}
+			v.statusLabel.SetText(fmt.Sprintf("Sent %d bytes to %s on %s (seq %d)", len(resolved.Body), resolved.Subject, server, resolved.Seq))
+		})
+	}()
+}
+
+// showNewCollectionDialog asks for the name of a new collection
+func (v *collectionsView) showNewCollectionDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Enter collection name...")
+
+	components.FormDialog(
+		"New Collection",
+		"Create",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Collection Name", nameEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if _, err := v.tm.collectionService.CreateCollection(nameEntry.Text); err != nil {
+				components.ErrorDialog(err, v.tm.window)
+				return
+			}
+			v.reloadCollections(strings.TrimSpace(nameEntry.Text))
+		},
+		v.tm.window,
+	).Show()
+}
+
+// deleteCollection deletes the selected collection after confirmation
+func (v *collectionsView) deleteCollection() {
+	if v.collectionID == 0 {
+		return
+	}
+
+	collectionID := v.collectionID
+	components.ConfirmDialog(
+		"Delete Collection",
+		fmt.Sprintf("Are you sure you want to delete the collection %q and all its requests?", v.collectionSelect.Selected),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := v.tm.collectionService.DeleteCollection(collectionID); err != nil {
+				components.ErrorDialog(err, v.tm.window)
+				return
+			}
+			v.reloadCollections("")
+		},
+		v.tm.window,
+	).Show()
+}
+
+// exportCollection writes the selected collection to a JSON file
+func (v *collectionsView) exportCollection() {
+	if v.collectionID == 0 {
+		return
+	}
+
+	data, err := v.tm.collectionService.ExportCollection(v.collectionID)
+	if err != nil {
+		components.ErrorDialog(err, v.tm.window)
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			components.ErrorDialog(err, v.tm.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(data); err != nil {
+			components.ErrorDialog(fmt.Errorf("failed to export collection: %w", err), v.tm.window)
+			return
+		}
+		v.statusLabel.SetText(fmt.Sprintf("Exported to %s", writer.URI().Name()))
+	}, v.tm.window)
+	saveDialog.SetFileName(strings.ReplaceAll(strings.ToLower(v.collectionSelect.Selected), " ", "-") + ".collection.json")
+	saveDialog.Show()
+}
+
+// importCollection creates a collection from a JSON file
+func (v *collectionsView) importCollection() {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			components.ErrorDialog(err, v.tm.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("failed to read collection: %w", err), v.tm.window)
+			return
+		}
+
+		collectionID, err := v.tm.collectionService.ImportCollection(data)
+		if err != nil {
+			components.ErrorDialog(err, v.tm.window)
+			return
+		}
+
+		collections, err := v.tm.collectionService.GetCollections()
+		if err != nil {
+			components.ErrorDialog(err, v.tm.window)
+			return
+		}
+		for _, c := range collections {
+			if c.ID == collectionID {
+				v.reloadCollections(c.Name)
+			}
+		}
+	}, v.tm.window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	openDialog.Show()
+}
+
+// showEnvironmentsDialog edits environments and their variables
+func (v *collectionsView) showEnvironmentsDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Environment name, e.g. staging")
+	variablesEntry := widget.NewMultiLineEntry()
+	variablesEntry.SetPlaceHolder("host=nats.staging.internal\nuser=app\npassword=secret")
+	variablesEntry.SetMinRowsVisible(10)
+
+	environmentSelect := widget.NewSelect(nil, nil)
+	environmentSelect.PlaceHolder = "New environment"
+	refresh := func(selected string) {
+		v.reloadEnvironments()
+		names := make([]string, len(v.environments))
+		for i, e := range v.environments {
+			names[i] = e.Name
+		}
+		environmentSelect.SetOptions(names)
+		if selected != "" {
+			environmentSelect.SetSelected(selected)
+		} else {
+			environmentSelect.ClearSelected()
+			nameEntry.SetText("")
+			variablesEntry.SetText("")
+		}
+	}
+	environmentSelect.OnChanged = func(name string) {
+		for _, e := range v.environments {
+			if e.Name == name {
+				nameEntry.SetText(e.Name)
+				variablesEntry.SetText(templating.FormatVariables(e.Variables))
+			}
+		}
+	}
+
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		vars, err := templating.ParseVariables(variablesEntry.Text)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("invalid variables: %w", err), v.tm.window)
+			return
+		}
+		if err := v.tm.collectionService.SaveEnvironment(nameEntry.Text, vars); err != nil {
+			components.ErrorDialog(err, v.tm.window)
+			return
+		}
+		refresh(strings.TrimSpace(nameEntry.Text))
+	})
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		for _, e := range v.environments {
+			if e.Name == environmentSelect.Selected {
+				if err := v.tm.collectionService.DeleteEnvironment(e.ID); err != nil {
+					components.ErrorDialog(err, v.tm.window)
+					return
+				}
+				refresh("")
+				return
+			}
+		}
+	})
+	newButton := widget.NewButtonWithIcon("New", theme.ContentAddIcon(), func() { refresh("") })
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, newButton, environmentSelect),
+			widget.NewForm(widget.NewFormItem("Name", nameEntry)),
+			widget.NewLabel("Variables, one name=value per line; use them as {{.name}} in requests"),
+		),
+		container.NewHBox(saveButton, deleteButton),
+		nil, nil,
+		variablesEntry,
+	)
+	refresh("")
+
+	environmentsDialog := dialog.NewCustom("Environments", "Close", content, v.tm.window)
+	environmentsDialog.Resize(fyne.NewSize(560, 480))
+	environmentsDialog.Show()
+}
+
+// redactURL hides the password of a URL for display
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,200 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/monitor"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const dashboardTabName = "Dashboard"
+
+// dashboardInterval is how often the dashboard samples traffic
+const dashboardInterval = time.Second
+
+// dashboardWindows are the rolling windows offered for the rate charts
+var dashboardWindows = map[string]time.Duration{
+	"1 minute":   time.Minute,
+	"5 minutes":  5 * time.Minute,
+	"15 minutes": 15 * time.Minute,
+}
+
+// dashboardWindowOptions orders dashboardWindows for the select
+var dashboardWindowOptions = []string{"1 minute", "5 minutes", "15 minutes"}
+
+// subscriptionPanel shows the traffic of one subscription
+type subscriptionPanel struct {
+	label     *widget.Label
+	msgChart  *components.LineChart
+	byteChart *components.LineChart
+	// Rates are only touched by the monitor goroutine
+	msgRate  *monitor.Rate
+	byteRate *monitor.Rate
+}
+
+// probeView shows the latest stats of a latency probe
+type probeView struct {
+	label     *widget.Label
+	histogram *components.Histogram
+}
+
+// AddDashboardTab adds a dashboard monitoring tab. Its monitor stops when the
+// tab is closed or the tabs are cleared.
+func (tm *TabManager) AddDashboardTab(subscriptions []models.Subscription) {
+	windowSelect := widget.NewSelect(dashboardWindowOptions, nil)
+	windowSelect.SetSelected(dashboardWindowOptions[0])
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(dashboardTabName)
+	})
+
+	dashboardContainer := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Message Monitoring Dashboard"), layout.NewSpacer(), widget.NewLabel("Window:"), windowSelect, closeButton),
+	)
+
+	capacity := int(dashboardWindows[dashboardWindowOptions[len(dashboardWindowOptions)-1]] / dashboardInterval)
+	panels := make(map[string]*subscriptionPanel)
+	for _, sub := range subscriptions {
+		panel := &subscriptionPanel{
+			label:     widget.NewLabel(fmt.Sprintf("Sub: %s - Messages received: 0", sub.SubName)),
+			msgChart:  components.NewLineChart("msgs/s", nil),
+			byteChart: components.NewLineChart("bytes/s", formatBytes),
+			msgRate:   monitor.NewRate(capacity),
+			byteRate:  monitor.NewRate(capacity),
+		}
+		panel.label.Wrapping = fyne.TextWrapWord
+		panels[sub.SubName] = panel
+
+		dashboardContainer.Add(widget.NewSeparator())
+		dashboardContainer.Add(panel.label)
+		dashboardContainer.Add(container.NewGridWithColumns(2, panel.msgChart, panel.byteChart))
+	}
+
+	// Latency probes appear as they are started
+	probesContainer := container.NewVBox()
+	dashboardContainer.Add(probesContainer)
+
+	dashboardTab := container.NewTabItem(dashboardTabName, container.NewVScroll(dashboardContainer))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.cleanups[dashboardTab] = cancel
+	tm.tabContainer.Append(dashboardTab)
+
+	// The window is read on the UI thread and handed to the monitor
+	window := make(chan time.Duration, 1)
+	window <- dashboardWindows[windowSelect.Selected]
+	windowSelect.OnChanged = func(value string) {
+		select {
+		case <-window:
+		default:
+		}
+		window <- dashboardWindows[value]
+	}
+
+	go tm.monitorMessages(ctx, panels, probesContainer, window)
+}
+
+// monitorMessages samples subscription traffic and probe stats into the
+// dashboard until ctx is cancelled
+func (tm *TabManager) monitorMessages(ctx context.Context, panels map[string]*subscriptionPanel, probesContainer *fyne.Container, windowUpdates <-chan time.Duration) {
+	// Probe views are only touched from fyne.Do callbacks
+	probeViews := make(map[string]*probeView)
+	window := <-windowUpdates
+
+	ticker := time.NewTicker(dashboardInterval)
+	defer ticker.Stop()
+
+	observe := func(now time.Time) {
+		traffic := tm.messageService.GetTrafficStats()
+		for subName, panel := range panels {
+			panel.msgRate.Observe(now, float64(traffic[subName].Messages))
+			panel.byteRate.Observe(now, float64(traffic[subName].Bytes))
+		}
+	}
+
+	now := time.Now()
+	observe(now)
+	for {
+		tm.renderDashboard(now, window, panels, probesContainer, probeViews)
+
+		select {
+		case <-ctx.Done():
+			return
+		case window = <-windowUpdates:
+			// Redraw with the new window right away
+		case now = <-ticker.C:
+			observe(now)
+		}
+	}
+}
+
+// renderDashboard pushes the current traffic, rates and probe stats to the UI
+func (tm *TabManager) renderDashboard(now time.Time, window time.Duration, panels map[string]*subscriptionPanel, probesContainer *fyne.Container, probeViews map[string]*probeView) {
+	traffic := tm.messageService.GetTrafficStats()
+	stats := tm.messageService.GetSubscriptionStats()
+	violations := tm.messageService.GetViolationCounts()
+
+	for subName, panel := range panels {
+		t := traffic[subName]
+		msgValues := panel.msgRate.Series.Window(now, window)
+		byteValues := panel.byteRate.Series.Window(now, window)
+
+		lastMessage := "never"
+		if !t.LastMessage.IsZero() {
+			lastMessage = fmt.Sprintf("%v ago", time.Since(t.LastMessage).Round(time.Second))
+		}
+		text := fmt.Sprintf("Sub: %s - Messages received: %d - Bytes: %s - Last message: %s",
+			subName, t.Messages, formatBytes(float64(t.Bytes)), lastMessage)
+		if s, ok := stats[subName]; ok {
+			text += fmt.Sprintf(" - Delivered: %d - Dropped: %d - Spilled: %d - Pending: %d",
+				s.Delivered, s.Dropped, s.Spilled, s.Pending)
+		}
+		text += fmt.Sprintf(" - Schema violations: %d", violations[subName])
+
+		fyne.Do(func() {
+			panel.label.SetText(text)
+			panel.msgChart.SetValues(msgValues)
+			panel.byteChart.SetValues(byteValues)
+		})
+	}
+
+	for name, stats := range tm.messageService.GetLatencyProbes() {
+		text := fmt.Sprintf("Latency probe %s - %s", name, formatProbeStats(stats))
+		fyne.Do(func() {
+			view, ok := probeViews[name]
+			if !ok {
+				view = &probeView{label: widget.NewLabel(""), histogram: components.NewHistogram(probeHistogramLabels())}
+				view.label.Wrapping = fyne.TextWrapWord
+				probeViews[name] = view
+				probesContainer.Add(widget.NewSeparator())
+				probesContainer.Add(view.label)
+				probesContainer.Add(view.histogram)
+			}
+			view.label.SetText(text)
+			view.histogram.SetCounts(stats.Histogram)
+		})
+	}
+}
+
+// formatBytes renders a byte count or rate with a binary unit
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for n >= unit && i < len(suffixes)-1 {
+		n /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", n, suffixes[i])
+}
@@ -0,0 +1,297 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/icons"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/topology"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// explorerSampleOff disables sampling subjects on ">"
+const explorerSampleOff = "Off"
+
+// AddExplorerTab adds (or selects) a tab that lists the subjects, streams,
+// topics, queues and connections of a server, with actions that open a
+// publisher or subscription tab for them
+func (tm *TabManager) AddExplorerTab(server models.Server) {
+	tabName := fmt.Sprintf("explorer-%v", server.Name)
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == tabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	caps := tm.providerCapabilities(server.ProviderType)
+
+	adminEntry := widget.NewEntry()
+	adminEntry.SetPlaceHolder(explorerAdminHint(server))
+	if server.ProviderType != messaging.ProviderNATS && server.ProviderType != messaging.ProviderRabbitMQ {
+		adminEntry.Disable()
+	}
+
+	// Sampling only finds subjects where ">" subscribes to everything
+	sampleSelect := widget.NewSelect([]string{explorerSampleOff, "2s", "5s", "15s"}, nil)
+	sampleSelect.SetSelected("2s")
+	if caps.Wildcards != messaging.WildcardsNATS {
+		sampleSelect.SetSelected(explorerSampleOff)
+		sampleSelect.Disable()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Admin API", adminEntry),
+		widget.NewFormItem("Sample traffic", sampleSelect),
+	)
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	// entities and children are replaced on the UI thread only. Branches
+	// are entity kinds and leaves are indexes into entities.
+	var entities []messaging.Entity
+	children := make(map[widget.TreeNodeID][]widget.TreeNodeID)
+
+	entityAt := func(uid widget.TreeNodeID) (messaging.Entity, bool) {
+		i := strings.LastIndex(uid, ":")
+		if i < 0 {
+			return messaging.Entity{}, false
+		}
+		index, err := strconv.Atoi(uid[i+1:])
+		if err != nil || index >= len(entities) {
+			return messaging.Entity{}, false
+		}
+		return entities[index], true
+	}
+
+	tree := widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			return children[uid]
+		},
+		func(uid widget.TreeNodeID) bool {
+			_, ok := children[uid]
+			return ok
+		},
+		func(branch bool) fyne.CanvasObject {
+			name := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			detail := widget.NewLabel("")
+			detail.Truncation = fyne.TextTruncateEllipsis
+			publish := widget.NewButtonWithIcon("", icons.PublisherIcon(), nil)
+			subscribe := widget.NewButtonWithIcon("", icons.SubscriberIcon(), nil)
+			return container.NewBorder(nil, nil, name, container.NewHBox(publish, subscribe), detail)
+		},
+		func(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			detail := row.Objects[0].(*widget.Label)
+			name := row.Objects[1].(*widget.Label)
+			actions := row.Objects[2].(*fyne.Container)
+			publish := actions.Objects[0].(*widget.Button)
+			subscribe := actions.Objects[1].(*widget.Button)
+
+			if branch {
+				name.SetText(fmt.Sprintf("%ss (%d)", kindTitle(uid), len(children[uid])))
+				detail.SetText("")
+				actions.Hide()
+				return
+			}
+
+			entity, ok := entityAt(uid)
+			if !ok {
+				return
+			}
+			name.SetText(entity.Name)
+			detail.SetText(entity.Detail)
+			actions.Show()
+
+			publish.OnTapped = func() { tm.openExplorerPublisher(server, entity.Publish) }
+			if entity.Publish == "" {
+				publish.Disable()
+			} else {
+				publish.Enable()
+			}
+			subscribe.OnTapped = func() { tm.openExplorerSubscription(server, entity.Subscribe) }
+			if entity.Subscribe == "" {
+				subscribe.Disable()
+			} else {
+				subscribe.Enable()
+			}
+		},
+	)
+
+	// show replaces the listed entities, grouped by kind
+	show := func(found []messaging.Entity) {
+		entities = found
+		children = map[widget.TreeNodeID][]widget.TreeNodeID{}
+		for _, kind := range messaging.EntityKinds {
+			var leaves []widget.TreeNodeID
+			for i, entity := range entities {
+				if entity.Kind == kind {
+					leaves = append(leaves, fmt.Sprintf("%s:%d", kind, i))
+				}
+			}
+			if len(leaves) > 0 {
+				children[""] = append(children[""], string(kind))
+				children[string(kind)] = leaves
+			}
+		}
+		tree.UnselectAll()
+		tree.Refresh()
+		tree.OpenAllBranches()
+	}
+
+	var cancel context.CancelFunc
+	var refreshButton *widget.Button
+	refreshButton = widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		var sample time.Duration
+		if sampleSelect.Selected != explorerSampleOff {
+			sample, _ = time.ParseDuration(sampleSelect.Selected)
+		}
+		adminURL := strings.TrimSpace(adminEntry.Text)
+
+		ctx, exploreCancel := context.WithTimeout(context.Background(), sample+30*time.Second)
+		cancel = exploreCancel
+		refreshButton.Disable()
+		statusLabel.SetText("Exploring...")
+
+		go func() {
+			defer exploreCancel()
+			found, err := tm.topologyService.Explore(ctx, server, adminURL, sample)
+			if err != nil {
+				log.Printf("Error exploring %s: %v", server.Name, err)
+			}
+
+			fyne.Do(func() {
+				refreshButton.Enable()
+				show(found)
+				status := fmt.Sprintf("Found %d entities at %s", len(found), time.Now().Format("15:04:05"))
+				if err != nil {
+					status += fmt.Sprintf("\nFailed: %v", err)
+				}
+				statusLabel.SetText(status)
+			})
+		}()
+	})
+	refreshButton.Importance = widget.HighImportance
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(tabName)
+	})
+
+	top := container.NewVBox(
+		container.NewHBox(widget.NewLabel(fmt.Sprintf("Explorer: %s", server.Name)), closeButton),
+		form,
+		container.NewHBox(refreshButton),
+		statusLabel,
+	)
+	content := container.NewBorder(top, nil, nil, nil, tree)
+
+	tab := container.NewTabItemWithIcon(tabName, theme.SearchIcon(), content)
+	tm.cleanups[tab] = func() {
+		if cancel != nil {
+			cancel()
+		}
+	}
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+	refreshButton.OnTapped()
+}
+
+// explorerAdminHint describes the default admin API address of a server
+func explorerAdminHint(server models.Server) string {
+	switch server.ProviderType {
+	case messaging.ProviderNATS:
+		return "Monitoring endpoint, default " + topology.NATSMonitorURL(server.URL)
+	case messaging.ProviderRabbitMQ:
+		if managementURL, _, _, _, err := topology.RabbitManagementURL(server.URL); err == nil {
+			return "Management API, default " + managementURL
+		}
+		return "Management API address"
+	default:
+		return "Listed through the client connection"
+	}
+}
+
+// openExplorerPublisher opens the publisher tab of a subject, asking to save
+// it first when the server has none
+func (tm *TabManager) openExplorerPublisher(server models.Server, subject string) {
+	if tm.selectTab(fmt.Sprintf("topic-%v", subject)) {
+		return
+	}
+	topics, err := tm.serverService.GetTopicsForServer(server.ID)
+	if err != nil {
+		components.ErrorDialog(err, tm.window)
+		return
+	}
+	for _, topic := range topics {
+		if topic.TopicName == subject {
+			tm.AddTopicTab(topic)
+			tm.selectTab(fmt.Sprintf("topic-%v", subject))
+			return
+		}
+	}
+
+	tm.showAddTopicDialog(server, subject, func(name string) {
+		tm.openExplorerPublisher(server, name)
+	})
+}
+
+// openExplorerSubscription opens a subscription tab of a pattern, asking to
+// save one first when the server has none
+func (tm *TabManager) openExplorerSubscription(server models.Server, pattern string) {
+	opened := tm.openSubscriptionTab(server, func(sub models.Subscription) bool {
+		return sub.SubjectPattern == pattern
+	})
+	if opened {
+		return
+	}
+
+	tm.showAddSubscriptionDialog(server, pattern, func(subName string) {
+		tm.openSubscriptionTab(server, func(sub models.Subscription) bool {
+			return sub.SubName == subName
+		})
+	})
+}
+
+// openSubscriptionTab opens (or selects) the tab of the first saved
+// subscription of the server that matches, reporting whether one did
+func (tm *TabManager) openSubscriptionTab(server models.Server, match func(models.Subscription) bool) bool {
+	subscriptions, err := tm.serverService.GetSubscriptionsForServer(server.ID)
+	if err != nil {
+		components.ErrorDialog(err, tm.window)
+		return true
+	}
+	for _, sub := range subscriptions {
+		if !match(sub) {
+			continue
+		}
+		tabName := fmt.Sprintf("sub-%v", sub.SubName)
+		if !tm.selectTab(tabName) {
+			tm.AddSubscriptionTab(sub)
+			tm.selectTab(tabName)
+		}
+		return true
+	}
+	return false
+}
+
+// selectTab selects the tab with the given name, reporting whether it is open
+func (tm *TabManager) selectTab(tabName string) bool {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == tabName {
+			tm.tabContainer.Select(tab)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/filter"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// filterPlaceholders hints at the expression syntax of each filter kind
+var filterPlaceholders = map[filter.Kind]string{
+	filter.KindSubject:  "orders.*.created or orders.>",
+	filter.KindContains: "text in the payload",
+	filter.KindRegex:    `(?i)timeout|refused`,
+	filter.KindJSONPath: `$.level == 'error'`,
+	filter.KindHeader:   "X-Tenant=acme",
+}
+
+// filterRow edits one filter rule
+type filterRow struct {
+	enabled    *widget.Check
+	action     *widget.Select
+	kind       *widget.Select
+	expression *widget.Entry
+}
+
+// rule returns the rule being edited
+func (r *filterRow) rule() filter.Rule {
+	return filter.Rule{
+		Kind:       filter.Kind(r.kind.Selected),
+		Expression: strings.TrimSpace(r.expression.Text),
+		Action:     filter.Action(r.action.Selected),
+		Enabled:    r.enabled.Checked,
+	}
+}
+
+// showFiltersDialog edits the client-side filters of a subscription. onApply
+// is called with the rules to apply, and save set when they should be kept
+// with the subscription.
+func (tm *TabManager) showFiltersDialog(subName string, rules []filter.Rule, onApply func(rules []filter.Rule, save bool) error) {
+	actions := make([]string, len(filter.Actions))
+	for i, action := range filter.Actions {
+		actions[i] = string(action)
+	}
+	kinds := make([]string, len(filter.Kinds))
+	for i, kind := range filter.Kinds {
+		kinds[i] = string(kind)
+	}
+
+	var rows []*filterRow
+	rowsBox := container.NewVBox()
+
+	var render func()
+	addRow := func(rule filter.Rule) {
+		row := &filterRow{
+			enabled:    widget.NewCheck("", nil),
+			action:     widget.NewSelect(actions, nil),
+			expression: widget.NewEntry(),
+		}
+		row.kind = widget.NewSelect(kinds, func(kind string) {
+			row.expression.SetPlaceHolder(filterPlaceholders[filter.Kind(kind)])
+		})
+		row.enabled.SetChecked(rule.Enabled)
+		row.action.SetSelected(string(rule.Action))
+		row.kind.SetSelected(string(rule.Kind))
+		row.expression.SetText(rule.Expression)
+		rows = append(rows, row)
+		render()
+	}
+
+	render = func() {
+		rowsBox.RemoveAll()
+		if len(rows) == 0 {
+			rowsBox.Add(widget.NewLabel("No filters: every message is shown"))
+		}
+		for i, row := range rows {
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				rows = append(rows[:i], rows[i+1:]...)
+				render()
+			})
+			rowsBox.Add(container.NewBorder(nil, nil,
+				container.NewHBox(row.enabled, row.action, row.kind),
+				deleteButton,
+				row.expression,
+			))
+		}
+	}
+
+	for _, rule := range rules {
+		addRow(rule)
+	}
+	render()
+
+	collect := func() []filter.Rule {
+		collected := make([]filter.Rule, len(rows))
+		for i, row := range rows {
+			collected[i] = row.rule()
+		}
+		return collected
+	}
+
+	addButton := widget.NewButtonWithIcon("Add Filter", theme.ContentAddIcon(), func() {
+		addRow(filter.Rule{Kind: filter.KindContains, Action: filter.ActionHighlight, Enabled: true})
+	})
+	applyButton := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		if err := onApply(collect(), false); err != nil {
+			components.ErrorDialog(err, tm.window)
+		}
+	})
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		if err := onApply(collect(), true); err != nil {
+			components.ErrorDialog(err, tm.window)
+		}
+	})
+	saveButton.Importance = widget.HighImportance
+
+	help := widget.NewLabel("Show keeps only messages matching every show filter, hide drops messages matching any hide filter and highlight marks matches. Filters apply to listed and new messages without resubscribing.")
+	help.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		help,
+		container.NewHBox(addButton, applyButton, saveButton),
+		nil, nil,
+		container.NewVScroll(rowsBox),
+	)
+
+	filtersDialog := dialog.NewCustom(fmt.Sprintf("Filters: %s", subName), "Close", content, tm.window)
+	filtersDialog.Resize(fyne.NewSize(720, 420))
+	filtersDialog.Show()
+}
@@ -0,0 +1,120 @@
+package views
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/jsonschema"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// maxListedViolations bounds the violations listed in publish dialogs
+const maxListedViolations = 10
+
+// compileJSONSchema compiles a stored schema, returning nil when there is none
+// or it no longer compiles
+func compileJSONSchema(schema string) *jsonschema.Schema {
+	if strings.TrimSpace(schema) == "" {
+		return nil
+	}
+	compiled, err := jsonschema.Compile([]byte(schema))
+	if err != nil {
+		log.Printf("Ignoring invalid JSON schema: %v", err)
+		return nil
+	}
+	return compiled
+}
+
+// schemaViolations validates data against schema, reporting non-JSON payloads
+// as a violation of the whole document
+func schemaViolations(schema *jsonschema.Schema, data []byte) []string {
+	errs, err := schema.Validate(data)
+	if err != nil {
+		return []string{"#: " + err.Error()}
+	}
+
+	violations := make([]string, len(errs))
+	for i, e := range errs {
+		violations[i] = e.Error()
+	}
+	return violations
+}
+
+// formatViolations renders violations for a dialog, truncating long lists
+func formatViolations(violations []string) string {
+	if len(violations) <= maxListedViolations {
+		return strings.Join(violations, "\n")
+	}
+	return fmt.Sprintf("%s\n... and %d more", strings.Join(violations[:maxListedViolations], "\n"), len(violations)-maxListedViolations)
+}
+
+// showJSONSchemaDialog edits a JSON Schema. The reject option is offered when
+// rejectInvalid is not nil. onSave may return an error to keep the dialog open.
+func (tm *TabManager) showJSONSchemaDialog(title, schema string, rejectInvalid *bool, onSave func(schema string, rejectInvalid bool) error) {
+	schemaEntry := widget.NewMultiLineEntry()
+	schemaEntry.SetPlaceHolder(`{"type": "object", "required": ["id"]}`)
+	schemaEntry.SetText(schema)
+	schemaEntry.SetMinRowsVisible(16)
+
+	loadButton := widget.NewButtonWithIcon("Load File...", theme.FolderOpenIcon(), func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				components.ErrorDialog(fmt.Errorf("failed to read schema: %w", err), tm.window)
+				return
+			}
+			schemaEntry.SetText(string(data))
+		}, tm.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fileDialog.Show()
+	})
+
+	rejectCheck := widget.NewCheck("Refuse to publish invalid payloads (otherwise warn)", nil)
+	if rejectInvalid != nil {
+		rejectCheck.SetChecked(*rejectInvalid)
+	} else {
+		rejectCheck.Hide()
+	}
+
+	var schemaDialog dialog.Dialog
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		if err := onSave(schemaEntry.Text, rejectCheck.Checked); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		schemaDialog.Hide()
+	})
+	saveButton.Importance = widget.HighImportance
+	cancelButton := widget.NewButton("Cancel", func() { schemaDialog.Hide() })
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("Paste a JSON Schema (draft-07 or 2020-12); leave empty to remove it"),
+			loadButton,
+		),
+		container.NewVBox(rejectCheck, container.NewHBox(cancelButton, saveButton)),
+		nil, nil,
+		schemaEntry,
+	)
+
+	schemaDialog = dialog.NewCustomWithoutButtons(title, content, tm.window)
+	schemaDialog.Resize(fyne.NewSize(640, 520))
+	schemaDialog.Show()
+}
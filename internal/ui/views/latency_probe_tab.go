@@ -0,0 +1,236 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/bench"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const (
+	probeStampHeader   = "Header"
+	probeStampEnvelope = "JSON envelope"
+)
+
+// AddLatencyProbeTab adds (or selects) a tab that measures publish to receive
+// latency through a server
+func (tm *TabManager) AddLatencyProbeTab(server models.Server) {
+	tabName := fmt.Sprintf("probe-%v", server.Name)
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == tabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	publishEntry := widget.NewEntry()
+	publishEntry.SetText("broker-ui.probe")
+	receiveEntry := widget.NewEntry()
+	receiveEntry.SetPlaceHolder("Same as publish subject, or a mirrored subject/queue")
+
+	countEntry := widget.NewEntry()
+	countEntry.SetText("1000")
+	durationEntry := widget.NewEntry()
+	durationEntry.SetText("1m")
+	modeRadio := widget.NewRadioGroup([]string{benchModeCount, benchModeDuration}, func(mode string) {
+		if mode == benchModeDuration {
+			countEntry.Disable()
+			durationEntry.Enable()
+		} else {
+			durationEntry.Disable()
+			countEntry.Enable()
+		}
+	})
+	modeRadio.Horizontal = true
+	modeRadio.Required = true
+	modeRadio.SetSelected(benchModeCount)
+
+	rateEntry := widget.NewEntry()
+	rateEntry.SetText("100")
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetText("128")
+	drainEntry := widget.NewEntry()
+	drainEntry.SetText("2s")
+
+	stampRadio := widget.NewRadioGroup([]string{probeStampHeader, probeStampEnvelope}, nil)
+	stampRadio.Horizontal = true
+	stampRadio.Required = true
+	stampRadio.SetSelected(probeStampEnvelope)
+	if provider, ok := tm.serverService.GetMessagingProvider(server.ID); ok {
+		if _, ok := provider.(messaging.HeaderPublisher); ok {
+			stampRadio.SetSelected(probeStampHeader)
+		}
+	}
+
+	// readConfig builds the probe configuration from the form
+	readConfig := func() (bench.ProbeConfig, error) {
+		cfg := bench.ProbeConfig{
+			PublishSubject: strings.TrimSpace(publishEntry.Text),
+			ReceiveSubject: strings.TrimSpace(receiveEntry.Text),
+			Stamp:          bench.StampEnvelope,
+		}
+		if cfg.ReceiveSubject == "" {
+			cfg.ReceiveSubject = cfg.PublishSubject
+		}
+		if stampRadio.Selected == probeStampHeader {
+			cfg.Stamp = bench.StampHeader
+		}
+
+		var err error
+		if modeRadio.Selected == benchModeDuration {
+			if cfg.Duration, err = time.ParseDuration(strings.TrimSpace(durationEntry.Text)); err != nil {
+				return cfg, fmt.Errorf("invalid duration: %w", err)
+			}
+		} else if cfg.Count, err = strconv.Atoi(strings.TrimSpace(countEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid message count: %w", err)
+		}
+		if cfg.Rate, err = strconv.ParseFloat(strings.TrimSpace(rateEntry.Text), 64); err != nil {
+			return cfg, fmt.Errorf("invalid rate: %w", err)
+		}
+		if cfg.PayloadSize, err = strconv.Atoi(strings.TrimSpace(sizeEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid payload size: %w", err)
+		}
+		if cfg.Drain, err = time.ParseDuration(strings.TrimSpace(drainEntry.Text)); err != nil {
+			return cfg, fmt.Errorf("invalid drain time: %w", err)
+		}
+
+		return cfg, cfg.Validate()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Publish subject", publishEntry),
+		widget.NewFormItem("Receive subject", receiveEntry),
+		widget.NewFormItem("Run for", modeRadio),
+		widget.NewFormItem("Messages", countEntry),
+		widget.NewFormItem("Duration", durationEntry),
+		widget.NewFormItem("Rate (msgs/s)", rateEntry),
+		widget.NewFormItem("Stamp", stampRadio),
+		widget.NewFormItem("Size (bytes)", sizeEntry),
+		widget.NewFormItem("Wait for late", drainEntry),
+	)
+
+	histogram := components.NewHistogram(probeHistogramLabels())
+	statsLabel := widget.NewLabel("Ready")
+	statsLabel.Wrapping = fyne.TextWrapWord
+
+	var cancel context.CancelFunc
+	var startButton, stopButton *widget.Button
+
+	startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), func() {
+		cfg, err := readConfig()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+
+		publisher, ok := tm.serverService.GetMessagingProvider(server.ID)
+		if !ok {
+			components.ErrorDialog(fmt.Errorf("no messaging provider connection for server"), tm.window)
+			return
+		}
+
+		ctx, runCancel := context.WithCancel(context.Background())
+		cancel = runCancel
+		startButton.Disable()
+		stopButton.Enable()
+		statsLabel.SetText("Connecting...")
+
+		name := fmt.Sprintf("%s: %s", server.Name, cfg.PublishSubject)
+		go func() {
+			defer runCancel()
+
+			// The probe receives on its own connection, like a real consumer
+			subscriber, err := tm.serverService.OpenProvider(server.ProviderType, server.URL)
+			var stats bench.ProbeStats
+			if err == nil {
+				stats, err = tm.messageService.RunLatencyProbe(ctx, name, publisher, subscriber, cfg, 250*time.Millisecond, func(stats bench.ProbeStats) {
+					text := formatProbeStats(stats)
+					fyne.Do(func() {
+						histogram.SetCounts(stats.Histogram)
+						statsLabel.SetText(text)
+					})
+				})
+				subscriber.Close()
+			}
+
+			fyne.Do(func() {
+				startButton.Enable()
+				stopButton.Disable()
+				if err != nil {
+					statsLabel.SetText(fmt.Sprintf("Failed: %v", err))
+					return
+				}
+				histogram.SetCounts(stats.Histogram)
+				statsLabel.SetText(formatProbeStats(stats))
+			})
+		}()
+	})
+	startButton.Importance = widget.HighImportance
+
+	stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	stopButton.Disable()
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(tabName)
+	})
+
+	top := container.NewVBox(
+		container.NewHBox(widget.NewLabel(fmt.Sprintf("Latency Probe: %s", server.Name)), closeButton),
+		form,
+		container.NewHBox(startButton, stopButton),
+		statsLabel,
+	)
+	content := container.NewBorder(top, nil, nil, nil, histogram)
+
+	tab := container.NewTabItemWithIcon(tabName, theme.HistoryIcon(), content)
+	tm.cleanups[tab] = func() {
+		if cancel != nil {
+			cancel()
+		}
+	}
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// probeHistogramLabels returns the labels of the latency histogram buckets
+func probeHistogramLabels() []string {
+	labels := make([]string, len(bench.HistogramBounds)+1)
+	for i := range labels {
+		labels[i] = bench.HistogramLabel(i)
+	}
+	return labels
+}
+
+// formatProbeStats describes probe stats in one or two lines
+func formatProbeStats(stats bench.ProbeStats) string {
+	state := "Running"
+	lost := "missing"
+	if stats.Done {
+		state = "Done"
+		lost = "lost"
+	}
+
+	lossPercent := 0.0
+	if stats.Sent > 0 {
+		lossPercent = float64(stats.Lost) / float64(stats.Sent) * 100
+	}
+
+	return fmt.Sprintf("%s (%v): sent %d, received %d, %s %d (%.2f%%), reordered %d, duplicates %d, errors %d\np50 %v - p95 %v - p99 %v - max %v",
+		state, stats.Elapsed.Round(time.Millisecond), stats.Sent, stats.Received, lost, stats.Lost, lossPercent,
+		stats.Reordered, stats.Duplicates, stats.Errors,
+		stats.Latency.P50, stats.Latency.P95, stats.Latency.P99, stats.Latency.Max)
+}
@@ -1,6 +1,7 @@
 package views
 
 import (
+	"context"
 	"log"
 
 	"fyne.io/fyne/v2"
@@ -8,8 +9,10 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 	"github.com/devalexandre/broker-ui/icons"
+	"github.com/devalexandre/broker-ui/internal/api"
 	"github.com/devalexandre/broker-ui/internal/database"
 	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/metrics"
 	"github.com/devalexandre/broker-ui/internal/models"
 	"github.com/devalexandre/broker-ui/internal/services"
 	"github.com/devalexandre/broker-ui/internal/ui/components"
@@ -27,6 +30,8 @@ type MainWindow struct {
 	isDarkTheme    bool
 	themeButton    *widget.Button
 	servers        []models.Server
+	metricsServer  *metrics.Server
+	apiServer      *api.Server
 }
 
 // NewMainWindow creates a new main window
@@ -35,10 +40,32 @@ func NewMainWindow(db *database.Database) *MainWindow {
 	serverRepo := database.NewServerRepository(db.GetDB())
 	topicRepo := database.NewTopicRepository(db.GetDB())
 	subscriptionRepo := database.NewSubscriptionRepository(db.GetDB())
+	protoSchemaRepo := database.NewProtoSchemaRepository(db.GetDB())
+	protoMappingRepo := database.NewProtoMappingRepository(db.GetDB())
+	templateRepo := database.NewPayloadTemplateRepository(db.GetDB())
+	collectionRepo := database.NewCollectionRepository(db.GetDB())
+	environmentRepo := database.NewEnvironmentRepository(db.GetDB())
+	alertRepo := database.NewAlertRepository(db.GetDB())
+	bridgeRepo := database.NewBridgeRepository(db.GetDB())
+	responderRepo := database.NewResponderRepository(db.GetDB())
 
 	// Initialize services
 	serverService := services.NewServerService(serverRepo, topicRepo, subscriptionRepo)
 	messageService := services.NewMessageService(topicRepo, subscriptionRepo)
+	codecService := services.NewCodecService(serverRepo, protoSchemaRepo, protoMappingRepo)
+	templateService := services.NewTemplateService(templateRepo)
+	collectionService := services.NewCollectionService(collectionRepo, environmentRepo, serverService, messageService)
+	alertService := services.NewAlertService(alertRepo)
+	messageService.AddObserver(alertService.Observe)
+	bridgeService := services.NewBridgeService(bridgeRepo, serverService)
+	responderService := services.NewResponderService(responderRepo, serverService)
+	scenarioService := services.NewScenarioService(serverService, messageService)
+	topologyService := services.NewTopologyService(serverService)
+
+	// Traffic metrics are recorded always and served only when enabled
+	trafficMetrics := metrics.New()
+	serverService.SetMetrics(trafficMetrics)
+	messageService.SetMetrics(trafficMetrics)
 
 	// Create Fyne app
 	myApp := app.New()
@@ -51,14 +78,22 @@ func NewMainWindow(db *database.Database) *MainWindow {
 		serverService:  serverService,
 		messageService: messageService,
 		isDarkTheme:    true,
+		metricsServer:  metrics.NewServer(trafficMetrics),
+		apiServer:      api.NewServer(serverService, messageService),
 	}
 
 	// Initialize tab manager
-	mw.tabManager = NewTabManager(messageService, serverService, myWindow)
+	mw.tabManager = NewTabManager(messageService, serverService, codecService, templateService, collectionService, alertService, bridgeService, responderService, scenarioService, topologyService, myWindow)
+
+	// Alert rules are evaluated for as long as the app runs
+	alertService.SetAlertHandler(mw.tabManager.NotifyAlert)
+	go alertService.Run(context.Background())
 
 	// Setup UI
 	mw.setupUI()
 	mw.loadServers()
+	mw.startMetricsEndpoint()
+	mw.startAPIEndpoint()
 
 	return mw
 }
@@ -71,6 +106,13 @@ func (mw *MainWindow) setupUI() {
 	// Create main menu
 	menu := components.MainMenu(
 		mw.showAddServerDialog,
+		mw.tabManager.AddCollectionsTab,
+		mw.tabManager.AddAlertsTab,
+		mw.tabManager.AddBridgesTab,
+		mw.tabManager.AddRespondersTab,
+		mw.tabManager.AddScenariosTab,
+		mw.showMetricsDialog,
+		mw.showAPIDialog,
 		mw.toggleTheme,
 		mw.app.Quit,
 		mw.isDarkTheme,
@@ -0,0 +1,82 @@
+package views
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/metrics"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// Preferences of the Prometheus metrics endpoint
+const (
+	metricsEnabledKey = "metricsEnabled"
+	metricsAddressKey = "metricsAddress"
+)
+
+// startMetricsEndpoint serves /metrics at startup if it was enabled
+func (mw *MainWindow) startMetricsEndpoint() {
+	prefs := mw.app.Preferences()
+	if !prefs.Bool(metricsEnabledKey) {
+		return
+	}
+	if err := mw.metricsServer.Start(prefs.StringWithFallback(metricsAddressKey, metrics.DefaultAddress)); err != nil {
+		log.Printf("Error starting metrics endpoint: %v", err)
+	}
+}
+
+// showMetricsDialog enables, disables or moves the Prometheus metrics endpoint
+func (mw *MainWindow) showMetricsDialog() {
+	prefs := mw.app.Preferences()
+
+	enabledCheck := widget.NewCheck("Serve Prometheus metrics", nil)
+	enabledCheck.SetChecked(prefs.Bool(metricsEnabledKey))
+
+	addressEntry := widget.NewEntry()
+	addressEntry.SetText(prefs.StringWithFallback(metricsAddressKey, metrics.DefaultAddress))
+	addressEntry.SetPlaceHolder(metrics.DefaultAddress)
+
+	status := "Not running"
+	if addr := mw.metricsServer.Addr(); addr != "" {
+		status = fmt.Sprintf("Serving http://%s/metrics", addr)
+	}
+	statusLabel := widget.NewLabel(status)
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	components.FormDialog(
+		"Metrics Endpoint",
+		"Apply",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("", enabledCheck),
+			widget.NewFormItem("Listen Address", addressEntry),
+			widget.NewFormItem("Status", statusLabel),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			address := strings.TrimSpace(addressEntry.Text)
+			if address == "" {
+				address = metrics.DefaultAddress
+			}
+
+			if enabledCheck.Checked {
+				if err := mw.metricsServer.Start(address); err != nil {
+					components.ErrorDialog(err, mw.window)
+					return
+				}
+			} else {
+				mw.metricsServer.Stop()
+			}
+
+			prefs.SetBool(metricsEnabledKey, enabledCheck.Checked)
+			prefs.SetString(metricsAddressKey, address)
+		},
+		mw.window,
+	).Show()
+}
@@ -0,0 +1,369 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/messaging"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/responder"
+	"github.com/devalexandre/broker-ui/internal/templating"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const respondersTabName = "Responders"
+
+// responderStatsInterval is how often the Responders tab refreshes the counts
+const responderStatsInterval = time.Second
+
+// responderRow shows one responder and its live counts
+type responderRow struct {
+	responder  models.Responder
+	statsLabel *widget.Label
+}
+
+// AddRespondersTab adds (or selects) a tab that manages the mock responders
+// standing in for services that are not running
+func (tm *TabManager) AddRespondersTab() {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == respondersTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	rowsBox := container.NewVBox()
+	// rows is replaced on the UI thread and read by fyne.Do callbacks
+	var rows []*responderRow
+
+	var reload func()
+	reload = func() {
+		responders, err := tm.responderService.GetResponders()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		servers, err := tm.serverService.GetAllServers()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		serverNames := make(map[int]string, len(servers))
+		for _, server := range servers {
+			serverNames[server.ID] = server.Name
+		}
+
+		rows = nil
+		rowsBox.RemoveAll()
+		if len(responders) == 0 {
+			rowsBox.Add(widget.NewLabel("No responders yet. A responder answers requests and publishes events in place of a service."))
+		}
+		for _, m := range responders {
+			row := &responderRow{responder: m, statsLabel: widget.NewLabel("Stopped")}
+			row.statsLabel.Wrapping = fyne.TextWrapWord
+			rows = append(rows, row)
+
+			title := widget.NewLabel(fmt.Sprintf("%s: %s %s", m.Name, serverNames[m.ServerID], m.Pattern))
+			title.TextStyle = fyne.TextStyle{Bold: true}
+
+			var toggleButton *widget.Button
+			toggleButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), func() {
+				if tm.responderService.IsRunning(m.ID) {
+					tm.responderService.Stop(m.ID)
+					reload()
+					return
+				}
+				toggleButton.Disable()
+				go func() {
+					err := tm.responderService.Start(m)
+					fyne.Do(func() {
+						if err != nil {
+							components.ErrorDialog(err, tm.window)
+						}
+						reload()
+					})
+				}()
+			})
+			if tm.responderService.IsRunning(m.ID) {
+				toggleButton.SetText("Stop")
+				toggleButton.SetIcon(theme.MediaStopIcon())
+			}
+
+			editButton := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				tm.showResponderDialog(m, servers, reload)
+			})
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				components.ConfirmDialog("Delete Responder", fmt.Sprintf("Delete the responder %s?", m.Name), func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					if err := tm.responderService.DeleteResponder(m.ID); err != nil {
+						components.ErrorDialog(err, tm.window)
+					}
+					reload()
+				}, tm.window).Show()
+			})
+
+			rowsBox.Add(widget.NewSeparator())
+			rowsBox.Add(container.NewBorder(nil, nil, nil, container.NewHBox(toggleButton, editButton, deleteButton), title))
+			rowsBox.Add(row.statsLabel)
+		}
+		rowsBox.Refresh()
+	}
+
+	addButton := widget.NewButtonWithIcon("Add Responder", theme.ContentAddIcon(), func() {
+		servers, err := tm.serverService.GetAllServers()
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		tm.showResponderDialog(models.Responder{}, servers, reload)
+	})
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(respondersTabName)
+	})
+
+	content := container.NewBorder(
+		container.NewHBox(widget.NewLabel("Responders"), layout.NewSpacer(), addButton, closeButton),
+		nil, nil, nil,
+		container.NewVScroll(rowsBox),
+	)
+	reload()
+
+	// Responders keep running when the tab closes; only the counts stop
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(responderStatsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stats := tm.responderService.GetStats()
+				fyne.Do(func() {
+					for _, row := range rows {
+						s, running := stats[row.responder.ID]
+						row.statsLabel.SetText(formatResponderStats(s, running))
+					}
+				})
+			}
+		}
+	}()
+
+	tab := container.NewTabItemWithIcon(respondersTabName, theme.MailReplyIcon(), content)
+	tm.cleanups[tab] = cancel
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// formatResponderStats describes the counts of a responder
+func formatResponderStats(stats responder.Stats, running bool) string {
+	if !running {
+		return "Stopped"
+	}
+	text := fmt.Sprintf("Running - received %d, replied %d, events %d, injected errors %d, failed %d",
+		stats.Received, stats.Replied, stats.Published, stats.Injected, stats.Failed)
+	if !stats.LastResponded.IsZero() {
+		text += fmt.Sprintf(", last %v ago", time.Since(stats.LastResponded).Round(time.Second))
+	}
+	if stats.LastError != "" {
+		text += fmt.Sprintf("\nLast error: %s", stats.LastError)
+	}
+	return text
+}
+
+// newMillisecondsEntry returns an entry for a duration in milliseconds
+func newMillisecondsEntry(d time.Duration) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("0")
+	if d > 0 {
+		entry.SetText(strconv.FormatInt(d.Milliseconds(), 10))
+	}
+	return entry
+}
+
+// parseMilliseconds reads a duration in milliseconds; empty means zero
+func parseMilliseconds(name, text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	ms, err := strconv.Atoi(text)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("%s must be a number of milliseconds", name)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// showResponderDialog edits a new or existing responder
+func (tm *TabManager) showResponderDialog(m models.Responder, servers []models.Server, onSaved func()) {
+	if len(servers) == 0 {
+		components.ErrorDialog(fmt.Errorf("add a server first"), tm.window)
+		return
+	}
+
+	serverNames := make([]string, len(servers))
+	serverIDs := make(map[string]int, len(servers))
+	providerTypes := make(map[string]messaging.ProviderType, len(servers))
+	for i, server := range servers {
+		serverNames[i] = fmt.Sprintf("%s (%s)", server.Name, server.ProviderType)
+		serverIDs[serverNames[i]] = server.ID
+		providerTypes[serverNames[i]] = server.ProviderType
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(m.Name)
+	nameEntry.SetPlaceHolder("e.g. fake inventory service")
+	serverSelect := widget.NewSelect(serverNames, nil)
+	patternEntry := widget.NewEntry()
+	patternEntry.SetText(m.Pattern)
+	patternEntry.SetPlaceHolder("inventory.check")
+
+	replyEntry := widget.NewMultiLineEntry()
+	replyEntry.SetText(m.ReplyTemplate)
+	replyEntry.SetPlaceHolder(`{"sku":"{{var "json.sku"}}","available":{{randInt 0 10}}}`)
+	replyEntry.SetMinRowsVisible(3)
+	eventSubjectEntry := widget.NewEntry()
+	eventSubjectEntry.SetText(m.EventSubject)
+	eventSubjectEntry.SetPlaceHolder("Optional, e.g. inventory.checked")
+	eventEntry := widget.NewMultiLineEntry()
+	eventEntry.SetText(m.EventTemplate)
+	eventEntry.SetPlaceHolder(`{"request":{{.payload}},"at":"{{now}}"}`)
+	eventEntry.SetMinRowsVisible(3)
+	headersEntry := widget.NewMultiLineEntry()
+	headersEntry.SetText(templating.FormatVariables(m.Headers))
+	headersEntry.SetPlaceHolder("X-Mock=true")
+	headersEntry.SetMinRowsVisible(2)
+
+	delayEntry := newMillisecondsEntry(m.Delay)
+	jitterEntry := newMillisecondsEntry(m.Jitter)
+	errorRateEntry := widget.NewEntry()
+	errorRateEntry.SetPlaceHolder("0")
+	if m.ErrorRate > 0 {
+		errorRateEntry.SetText(strconv.FormatFloat(m.ErrorRate*100, 'f', -1, 64))
+	}
+	errorEntry := widget.NewMultiLineEntry()
+	errorEntry.SetText(m.ErrorTemplate)
+	errorEntry.SetPlaceHolder(`Optional, e.g. {"error":"out of stock"}`)
+	errorEntry.SetMinRowsVisible(2)
+
+	// Replies and headers are only offered where the server supports them
+	serverSelect.OnChanged = func(name string) {
+		caps := tm.providerCapabilities(providerTypes[name])
+		patternEntry.SetPlaceHolder(caps.PatternHint())
+		for _, entry := range []*widget.Entry{replyEntry, errorEntry} {
+			if caps.Reply {
+				entry.Enable()
+			} else {
+				entry.Disable()
+			}
+		}
+		if caps.Headers {
+			headersEntry.Enable()
+		} else {
+			headersEntry.Disable()
+		}
+	}
+	for name, id := range serverIDs {
+		if id == m.ServerID {
+			serverSelect.SetSelected(name)
+		}
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Server", serverSelect),
+		widget.NewFormItem("Subject pattern", patternEntry),
+		widget.NewFormItem("Reply", replyEntry),
+		widget.NewFormItem("Event subject", eventSubjectEntry),
+		widget.NewFormItem("Event", eventEntry),
+		widget.NewFormItem("Headers", headersEntry),
+		widget.NewFormItem("Delay (ms)", delayEntry),
+		widget.NewFormItem("Jitter (ms)", jitterEntry),
+		widget.NewFormItem("Error rate (%)", errorRateEntry),
+		widget.NewFormItem("Error reply", errorEntry),
+	}
+	items[3].HintText = "Sent to the reply subject of requests (NATS request/reply, AMQP reply-to)"
+	items[5].HintText = "Templates see {{.subject}}, {{.payload}}, {{var \"header.Name\"}} and {{var \"json.field\"}}"
+	items[6].HintText = "One Name=value per line, added to replies and events"
+	items[10].HintText = "Sent instead of the reply to failed messages; empty sends nothing"
+
+	title := "Add Responder"
+	if m.ID > 0 {
+		title = "Edit Responder"
+	}
+	responderDialog := components.FormDialog(title, "Save", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if serverSelect.Selected == "" {
+			components.ErrorDialog(fmt.Errorf("select a server"), tm.window)
+			return
+		}
+		headers, err := templating.ParseVariables(headersEntry.Text)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("invalid headers: %w", err), tm.window)
+			return
+		}
+		delay, err := parseMilliseconds("delay", delayEntry.Text)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		jitter, err := parseMilliseconds("jitter", jitterEntry.Text)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		errorRate := 0.0
+		if text := strings.TrimSpace(errorRateEntry.Text); text != "" {
+			percent, err := strconv.ParseFloat(text, 64)
+			if err != nil || percent < 0 || percent > 100 {
+				components.ErrorDialog(fmt.Errorf("error rate must be a percentage from 0 to 100"), tm.window)
+				return
+			}
+			errorRate = percent / 100
+		}
+
+		m.Name = strings.TrimSpace(nameEntry.Text)
+		m.ServerID = serverIDs[serverSelect.Selected]
+		m.Pattern = strings.TrimSpace(patternEntry.Text)
+		m.ReplyTemplate = replyEntry.Text
+		m.EventSubject = strings.TrimSpace(eventSubjectEntry.Text)
+		m.EventTemplate = eventEntry.Text
+		m.Headers = headers
+		m.Delay = delay
+		m.Jitter = jitter
+		m.ErrorRate = errorRate
+		m.ErrorTemplate = errorEntry.Text
+
+		// Settings the server doesn't support are dropped rather than refused
+		if replyEntry.Disabled() {
+			m.ReplyTemplate, m.ErrorTemplate = "", ""
+		}
+		if headersEntry.Disabled() {
+			m.Headers = nil
+		}
+
+		if _, err := tm.responderService.SaveResponder(m); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		if tm.responderService.IsRunning(m.ID) {
+			dialog.ShowInformation("Responder Saved", "Restart the responder to apply the changes.", tm.window)
+		}
+		onSaved()
+	}, tm.window)
+	responderDialog.Resize(fyne.NewSize(600, 720))
+	responderDialog.Show()
+}
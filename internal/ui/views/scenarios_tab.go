@@ -0,0 +1,244 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/scenario"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const scenariosTabName = "Scenarios"
+
+// fileServerOption runs each scenario on the server named in its file
+const fileServerOption = "Server named in each file"
+
+// AddScenariosTab adds (or selects) a tab that runs scenario files against a
+// server and shows what passed and failed
+func (tm *TabManager) AddScenariosTab() {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == scenariosTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	servers, err := tm.serverService.GetAllServers()
+	if err != nil {
+		components.ErrorDialog(err, tm.window)
+		return
+	}
+	var scenarios []*scenario.Scenario
+	var results []scenario.Result
+	var cancel context.CancelFunc
+
+	options := []string{fileServerOption}
+	for _, server := range servers {
+		options = append(options, server.Name)
+	}
+	serverSelect := widget.NewSelect(options, nil)
+	serverSelect.SetSelected(fileServerOption)
+
+	filesBox := container.NewVBox()
+	resultsLabel := widget.NewLabel("Open a scenario file to run it.")
+	resultsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	resultsLabel.Wrapping = fyne.TextWrapWord
+	statusLabel := widget.NewLabel("")
+
+	var renderFiles func()
+	renderFiles = func() {
+		filesBox.RemoveAll()
+		for i, sc := range scenarios {
+			text := fmt.Sprintf("%s (%d steps)", sc.Name, len(sc.Steps))
+			if sc.Server != "" {
+				text += " on " + sc.Server
+			}
+			removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				scenarios = append(scenarios[:i], scenarios[i+1:]...)
+				renderFiles()
+			})
+			filesBox.Add(container.NewBorder(nil, nil, nil, removeButton, widget.NewLabel(text)))
+		}
+		filesBox.Refresh()
+	}
+
+	openButton := widget.NewButtonWithIcon("Open...", theme.FolderOpenIcon(), func() {
+		openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				components.ErrorDialog(fmt.Errorf("failed to read scenario: %w", err), tm.window)
+				return
+			}
+			sc, err := scenario.Parse(data)
+			if err != nil {
+				components.ErrorDialog(fmt.Errorf("%s: %w", reader.URI().Name(), err), tm.window)
+				return
+			}
+			sc.File = reader.URI().Path()
+			if sc.Name == "" {
+				sc.Name = reader.URI().Name()
+			}
+			scenarios = append(scenarios, sc)
+			renderFiles()
+		}, tm.window)
+		openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".yaml", ".yml"}))
+		openDialog.Show()
+	})
+
+	var runButton, stopButton *widget.Button
+	runButton = widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		if len(scenarios) == 0 {
+			components.ErrorDialog(fmt.Errorf("open a scenario file first"), tm.window)
+			return
+		}
+
+		// Resolve every server up front, as the CLI does
+		targets := make([]models.Server, len(scenarios))
+		for i, sc := range scenarios {
+			name := serverSelect.Selected
+			if name == fileServerOption {
+				name = sc.Server
+			}
+			found := false
+			for _, server := range servers {
+				if server.Name == name {
+					targets[i], found = server, true
+				}
+			}
+			if !found {
+				components.ErrorDialog(fmt.Errorf("scenario %s: choose a server, the file names %q", sc.Name, name), tm.window)
+				return
+			}
+		}
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		runButton.Disable()
+		stopButton.Enable()
+		results = nil
+		resultsLabel.SetText("")
+		statusLabel.SetText("Running...")
+
+		run := append([]*scenario.Scenario(nil), scenarios...)
+		go func() {
+			var runResults []scenario.Result
+			var runErr error
+			for i, sc := range run {
+				fyne.Do(func() {
+					statusLabel.SetText(fmt.Sprintf("Running %s on %s...", sc.Name, targets[i].Name))
+				})
+				result, err := tm.scenarioService.Run(ctx, targets[i], sc)
+				if err != nil {
+					runErr = err
+					break
+				}
+				runResults = append(runResults, result)
+				report := scenario.Format(runResults)
+				fyne.Do(func() {
+					resultsLabel.SetText(report)
+				})
+				if ctx.Err() != nil {
+					break
+				}
+			}
+
+			fyne.Do(func() {
+				results = runResults
+				runButton.Enable()
+				stopButton.Disable()
+				failed := 0
+				for _, r := range runResults {
+					if !r.Passed() {
+						failed++
+					}
+				}
+				switch {
+				case runErr != nil:
+					statusLabel.SetText("Stopped")
+					components.ErrorDialog(runErr, tm.window)
+				case ctx.Err() != nil:
+					statusLabel.SetText("Stopped")
+				case failed > 0:
+					statusLabel.SetText(fmt.Sprintf("%d of %d scenarios failed", failed, len(runResults)))
+				default:
+					statusLabel.SetText(fmt.Sprintf("All %d scenarios passed", len(runResults)))
+				}
+			})
+		}()
+	})
+	stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	stopButton.Disable()
+
+	junitButton := widget.NewButtonWithIcon("Export JUnit", theme.DocumentSaveIcon(), func() {
+		if len(results) == 0 {
+			components.ErrorDialog(fmt.Errorf("no scenario results to export"), tm.window)
+			return
+		}
+		data, err := scenario.JUnit(results)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if _, err := writer.Write(data); err != nil {
+				components.ErrorDialog(fmt.Errorf("failed to export results: %w", err), tm.window)
+			}
+		}, tm.window)
+		saveDialog.SetFileName("scenarios.junit.xml")
+		saveDialog.Show()
+	})
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(scenariosTabName)
+	})
+
+	top := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Scenarios"), layout.NewSpacer(), openButton, closeButton),
+		filesBox,
+		container.NewBorder(nil, nil, widget.NewLabel("Server"), nil, serverSelect),
+		container.NewHBox(runButton, stopButton, junitButton),
+		statusLabel,
+	)
+	content := container.NewBorder(top, nil, nil, nil, container.NewVScroll(resultsLabel))
+
+	tab := container.NewTabItemWithIcon(scenariosTabName, theme.ConfirmIcon(), content)
+	tm.cleanups[tab] = func() {
+		if cancel != nil {
+			cancel()
+		}
+	}
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
@@ -0,0 +1,204 @@
+package views
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/icons"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+const schemasTabName = "Schemas"
+
+// AddSchemasTab adds (or selects) the tab for managing protobuf schemas, subject
+// mappings and the Avro Schema Registry of a server
+func (tm *TabManager) AddSchemasTab(server models.Server) {
+	for _, tab := range tm.tabContainer.Items {
+		if tab.Text == schemasTabName {
+			tm.tabContainer.Select(tab)
+			return
+		}
+	}
+
+	content := container.NewVBox()
+	tm.renderSchemas(server, content)
+
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		tm.removeTabByName(schemasTabName)
+	})
+
+	panel := container.NewBorder(
+		container.NewHBox(
+			widget.NewLabel(fmt.Sprintf("Schemas for %s", server.Name)),
+			closeButton,
+		),
+		nil, nil, nil,
+		container.NewVScroll(content),
+	)
+
+	tab := container.NewTabItemWithIcon(schemasTabName, theme.FileIcon(), panel)
+	tm.tabContainer.Append(tab)
+	tm.tabContainer.Select(tab)
+}
+
+// renderSchemas (re)builds the schema and mapping lists
+func (tm *TabManager) renderSchemas(server models.Server, content *fyne.Container) {
+	refresh := func() { tm.renderSchemas(server, content) }
+	content.Objects = nil
+
+	schemasHeader := widget.NewLabel("Schema Files")
+	schemasHeader.TextStyle = fyne.TextStyle{Bold: true}
+	content.Add(schemasHeader)
+
+	schemas, err := tm.codecService.GetProtoSchemas(server.ID)
+	if err != nil {
+		content.Add(widget.NewLabel(fmt.Sprintf("Error loading schemas: %v", err)))
+	}
+	if len(schemas) == 0 {
+		content.Add(widget.NewLabel("No schemas registered"))
+	}
+	for _, schema := range schemas {
+		schema := schema
+		kind := ".proto"
+		if schema.DescriptorSet {
+			kind = "descriptor set"
+		}
+		deleteButton := widget.NewButtonWithIcon("", icons.TrashBinIcon(), func() {
+			if err := tm.codecService.DeleteProtoSchema(server.ID, schema.ID); err != nil {
+				components.ErrorDialog(err, tm.window)
+			}
+			refresh()
+		})
+		content.Add(container.NewBorder(nil, nil, nil, deleteButton,
+			widget.NewLabel(fmt.Sprintf("%s (%s, %d bytes)", schema.Name, kind, len(schema.Content)))))
+	}
+
+	addProtoButton := widget.NewButtonWithIcon("Add .proto File", theme.ContentAddIcon(), func() {
+		tm.showAddSchemaDialog(server.ID, false, refresh)
+	})
+	addSetButton := widget.NewButtonWithIcon("Add Descriptor Set", theme.ContentAddIcon(), func() {
+		tm.showAddSchemaDialog(server.ID, true, refresh)
+	})
+	content.Add(container.NewHBox(addProtoButton, addSetButton))
+	content.Add(widget.NewSeparator())
+
+	mappingsHeader := widget.NewLabel("Subject Mappings")
+	mappingsHeader.TextStyle = fyne.TextStyle{Bold: true}
+	content.Add(mappingsHeader)
+
+	mappings, err := tm.codecService.GetProtoMappings(server.ID)
+	if err != nil {
+		content.Add(widget.NewLabel(fmt.Sprintf("Error loading mappings: %v", err)))
+	}
+	if len(mappings) == 0 {
+		content.Add(widget.NewLabel("No mappings; the first matching pattern wins"))
+	}
+	for _, mapping := range mappings {
+		mapping := mapping
+		deleteButton := widget.NewButtonWithIcon("", icons.TrashBinIcon(), func() {
+			if err := tm.codecService.DeleteProtoMapping(server.ID, mapping.ID); err != nil {
+				components.ErrorDialog(err, tm.window)
+			}
+			refresh()
+		})
+		content.Add(container.NewBorder(nil, nil, nil, deleteButton,
+			widget.NewLabel(fmt.Sprintf("%s -> %s", mapping.SubjectPattern, mapping.MessageType))))
+	}
+
+	messageTypes, err := tm.codecService.GetMessageTypes(server.ID)
+	if err != nil {
+		content.Add(widget.NewLabel(fmt.Sprintf("Error compiling schemas: %v", err)))
+	}
+
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder("Subject pattern (e.g., orders.>)")
+	typeSelect := widget.NewSelect(messageTypes, func(string) {})
+	typeSelect.PlaceHolder = "Message type"
+
+	addMappingButton := widget.NewButtonWithIcon("Add Mapping", theme.ContentAddIcon(), func() {
+		if patternEntry.Text == "" || typeSelect.Selected == "" {
+			return
+		}
+		if err := tm.codecService.SaveProtoMapping(server.ID, patternEntry.Text, typeSelect.Selected); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		refresh()
+	})
+
+	content.Add(container.NewBorder(nil, nil, nil,
+		container.NewHBox(typeSelect, addMappingButton),
+		patternEntry))
+	content.Add(widget.NewSeparator())
+
+	registryHeader := widget.NewLabel("Avro Schema Registry")
+	registryHeader.TextStyle = fyne.TextStyle{Bold: true}
+	content.Add(registryHeader)
+	content.Add(widget.NewLabel("Unmapped subjects with Confluent-framed payloads are decoded with Avro; publishing uses the latest \"<subject>-value\" schema"))
+
+	registryURL, err := tm.codecService.GetSchemaRegistryURL(server.ID)
+	if err != nil {
+		content.Add(widget.NewLabel(fmt.Sprintf("Error loading schema registry: %v", err)))
+	}
+
+	registryEntry := widget.NewEntry()
+	registryEntry.SetPlaceHolder("Schema Registry URL (e.g., http://localhost:8081)")
+	registryEntry.SetText(registryURL)
+
+	saveRegistryButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		if err := tm.codecService.SetSchemaRegistryURL(server.ID, registryEntry.Text); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		refresh()
+	})
+
+	content.Add(container.NewBorder(nil, nil, nil, saveRegistryButton, registryEntry))
+	content.Refresh()
+}
+
+// showAddSchemaDialog lets the user pick a .proto file or a compiled descriptor set
+func (tm *TabManager) showAddSchemaDialog(serverID int, descriptorSet bool, onSaved func()) {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			components.ErrorDialog(fmt.Errorf("failed to read schema: %w", err), tm.window)
+			return
+		}
+
+		// .proto files are imported by their base name
+		name := reader.URI().Name()
+		if !descriptorSet && !strings.HasSuffix(name, ".proto") {
+			components.ErrorDialog(fmt.Errorf("%s is not a .proto file", name), tm.window)
+			return
+		}
+
+		if err := tm.codecService.SaveProtoSchema(serverID, name, content, descriptorSet); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		onSaved()
+	}, tm.window)
+
+	if !descriptorSet {
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".proto"}))
+	}
+	fileDialog.Show()
+}
@@ -0,0 +1,153 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/scripting"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// scriptStatusInterval is how often a subscription tab refreshes its script counts
+const scriptStatusInterval = time.Second
+
+// scriptPlaceholder shows the script API in empty editors
+const scriptPlaceholder = `// msg.subject, msg.data (string) and msg.headers can be changed
+if (msg.subject.endsWith(".heartbeat")) return false; // drop
+const order = JSON.parse(msg.data);
+if (order.total > 1000) publish("orders.large", order);
+log("order", order.id);`
+
+// newScriptTimeoutEntry returns an entry for a script timeout in milliseconds
+func newScriptTimeoutEntry(timeout time.Duration) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(strconv.FormatInt(scripting.DefaultTimeout.Milliseconds(), 10))
+	if timeout > 0 {
+		entry.SetText(strconv.FormatInt(timeout.Milliseconds(), 10))
+	}
+	return entry
+}
+
+// parseScriptTimeout reads a timeout in milliseconds; empty means the default
+func parseScriptTimeout(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	ms, err := strconv.Atoi(text)
+	if err != nil || ms <= 0 {
+		return 0, fmt.Errorf("script timeout must be a positive number of milliseconds")
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// formatScriptStats describes the runs of a script for a tab's status line
+func formatScriptStats(stats scripting.Stats) string {
+	text := fmt.Sprintf("Script: %d runs, %d dropped, %d errors", stats.Runs, stats.Dropped, stats.Errors)
+	if stats.LastLog != "" {
+		text += fmt.Sprintf(" | log: %s", stats.LastLog)
+	}
+	if stats.LastError != "" {
+		text += fmt.Sprintf(" | last error: %s", stats.LastError)
+	}
+	return text
+}
+
+// showScriptDialog edits a message script. The script can be tried on a
+// sample message; onSave is called with an empty script to remove it.
+func (tm *TabManager) showScriptDialog(title, script string, timeout time.Duration, onSave func(script string, timeout time.Duration) error) {
+	scriptEntry := widget.NewMultiLineEntry()
+	scriptEntry.SetText(script)
+	scriptEntry.SetPlaceHolder(scriptPlaceholder)
+	scriptEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	scriptEntry.SetMinRowsVisible(10)
+	timeoutEntry := newScriptTimeoutEntry(timeout)
+
+	sampleSubject := widget.NewEntry()
+	sampleSubject.SetPlaceHolder("orders.created")
+	samplePayload := widget.NewEntry()
+	samplePayload.SetPlaceHolder(`{"id":1,"total":1500}`)
+	output := widget.NewLabel("")
+	output.Wrapping = fyne.TextWrapWord
+
+	testButton := widget.NewButtonWithIcon("Test", theme.MediaPlayIcon(), func() {
+		scriptTimeout, err := parseScriptTimeout(timeoutEntry.Text)
+		if err != nil {
+			output.SetText(err.Error())
+			return
+		}
+		compiled, err := scripting.Compile(scriptEntry.Text, scriptTimeout)
+		if err != nil {
+			output.SetText(err.Error())
+			return
+		}
+
+		// Publishes and logs are only listed; nothing leaves the dialog
+		var lines []string
+		result, err := compiled.Run(scripting.Message{Subject: sampleSubject.Text, Data: []byte(samplePayload.Text)}, scripting.Host{
+			Publish: func(subject string, data []byte, headers map[string]string) error {
+				lines = append(lines, fmt.Sprintf("publish %s: %s", subject, data))
+				return nil
+			},
+			Log: func(line string) {
+				lines = append(lines, "log: "+line)
+			},
+		})
+		switch {
+		case err != nil:
+			lines = append(lines, "error: "+err.Error())
+		case result.Drop:
+			lines = append(lines, "message dropped")
+		default:
+			lines = append(lines, fmt.Sprintf("message %s: %s", result.Message.Subject, result.Message.Data))
+		}
+		output.SetText(strings.Join(lines, "\n"))
+	})
+
+	var scriptDialog dialog.Dialog
+	save := func(script string) {
+		scriptTimeout, err := parseScriptTimeout(timeoutEntry.Text)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		if err := onSave(script, scriptTimeout); err != nil {
+			components.ErrorDialog(err, tm.window)
+			return
+		}
+		scriptDialog.Hide()
+	}
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		save(scriptEntry.Text)
+	})
+	saveButton.Importance = widget.HighImportance
+	removeButton := widget.NewButtonWithIcon("Remove Script", theme.DeleteIcon(), func() {
+		save("")
+	})
+
+	help := widget.NewLabel("The script runs on each message before it is listed. Return false to drop the message; publish(subject, data, headers) sends a message on the same server and log(...) reports to the status line.")
+	help.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		help,
+		container.NewVBox(
+			widget.NewForm(widget.NewFormItem("Timeout (ms)", timeoutEntry)),
+			container.NewBorder(nil, nil, widget.NewLabel("Try on"), testButton, container.NewGridWithColumns(2, sampleSubject, samplePayload)),
+			output,
+			container.NewHBox(removeButton, saveButton),
+		),
+		nil, nil,
+		container.NewVScroll(scriptEntry),
+	)
+
+	scriptDialog = dialog.NewCustom(title, "Close", content, tm.window)
+	scriptDialog.Resize(fyne.NewSize(720, 560))
+	scriptDialog.Show()
+}
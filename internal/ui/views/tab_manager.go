@@ -1,35 +1,72 @@
 package views
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/filter"
+	"github.com/devalexandre/broker-ui/internal/jsonschema"
 	"github.com/devalexandre/broker-ui/internal/messaging"
 	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/payload"
+	"github.com/devalexandre/broker-ui/internal/scripting"
 	"github.com/devalexandre/broker-ui/internal/services"
 	"github.com/devalexandre/broker-ui/internal/ui/components"
 )
 
 type TabManager struct {
-	tabContainer   *container.AppTabs
-	messageService *services.MessageService
-	serverService  *services.ServerService
-	window         fyne.Window
+	tabContainer      *container.AppTabs
+	messageService    *services.MessageService
+	serverService     *services.ServerService
+	codecService      *services.CodecService
+	templateService   *services.TemplateService
+	collectionService *services.CollectionService
+	alertService      *services.AlertService
+	bridgeService     *services.BridgeService
+	responderService  *services.ResponderService
+	scenarioService   *services.ScenarioService
+	topologyService   *services.TopologyService
+	window            fyne.Window
+	cleanups          map[*container.TabItem]func()
+	// highlighted holds the original icon of tabs flagged by an alert
+	highlighted map[*container.TabItem]fyne.Resource
+	// refreshAlertLog reloads the Alerts tab while it is open
+	refreshAlertLog func()
 }
 
+// messageListCapacityKey is the preference holding the subscription list capacity
+const messageListCapacityKey = "messageListCapacity"
+
 // NewTabManager creates a new tab manager
-func NewTabManager(messageService *services.MessageService, serverService *services.ServerService, window fyne.Window) *TabManager {
-	return &TabManager{
-		tabContainer:   container.NewAppTabs(),
-		messageService: messageService,
-		serverService:  serverService,
-		window:         window,
+func NewTabManager(messageService *services.MessageService, serverService *services.ServerService, codecService *services.CodecService, templateService *services.TemplateService, collectionService *services.CollectionService, alertService *services.AlertService, bridgeService *services.BridgeService, responderService *services.ResponderService, scenarioService *services.ScenarioService, topologyService *services.TopologyService, window fyne.Window) *TabManager {
+	tm := &TabManager{
+		tabContainer:      container.NewAppTabs(),
+		messageService:    messageService,
+		serverService:     serverService,
+		codecService:      codecService,
+		templateService:   templateService,
+		collectionService: collectionService,
+		alertService:      alertService,
+		bridgeService:     bridgeService,
+		responderService:  responderService,
+		scenarioService:   scenarioService,
+		topologyService:   topologyService,
+		window:            window,
+		cleanups:          make(map[*container.TabItem]func()),
+		highlighted:       make(map[*container.TabItem]fyne.Resource),
 	}
+	tm.tabContainer.OnSelected = tm.clearHighlight
+	return tm
 }
 
 // GetTabContainer returns the tab container
@@ -59,10 +96,22 @@ Developed by [Alexandre E Souza](https://www.linkedin.com/in/devevantelista)
 
 // ClearTabs removes all tabs
 func (tm *TabManager) ClearTabs() {
+	for _, tab := range tm.tabContainer.Items {
+		tm.cleanupTab(tab)
+	}
 	tm.tabContainer.Items = []*container.TabItem{}
 	tm.tabContainer.Refresh()
 }
 
+// cleanupTab releases resources held by a tab, such as background goroutines
+func (tm *TabManager) cleanupTab(tab *container.TabItem) {
+	if cleanup, ok := tm.cleanups[tab]; ok {
+		cleanup()
+		delete(tm.cleanups, tab)
+	}
+	delete(tm.highlighted, tab)
+}
+
 // RefreshServerTabs reloads all tabs for a specific server
 func (tm *TabManager) RefreshServerTabs(serverID int) {
 	// Get server info
@@ -122,8 +171,8 @@ func (tm *TabManager) RefreshServerTabs(serverID int) {
 // AddServerConfigTab adds a configuration tab for the server
 func (tm *TabManager) AddServerConfigTab(server models.Server) {
 	menu := components.ServerMenu(
-		func() { tm.showAddTopicDialog(server.ID) },
-		func() { tm.showAddSubscriptionDialog(server.ID) },
+		func() { tm.showAddTopicDialog(server, "", func(string) { tm.RefreshServerTabs(server.ID) }) },
+		func() { tm.showAddSubscriptionDialog(server, "", func(string) { tm.RefreshServerTabs(server.ID) }) },
 		func() { tm.disconnectServer(server.ID) },
 	)
 
@@ -131,10 +180,26 @@ func (tm *TabManager) AddServerConfigTab(server models.Server) {
 		tm.showEditServerDialog(server)
 	})
 
+	schemasButton := widget.NewButtonWithIcon("Schemas", theme.FileIcon(), func() {
+		tm.AddSchemasTab(server)
+	})
+
+	probeButton := widget.NewButtonWithIcon("Latency Probe", theme.HistoryIcon(), func() {
+		tm.AddLatencyProbeTab(server)
+	})
+
+	explorerButton := widget.NewButtonWithIcon("Explorer", theme.SearchIcon(), func() {
+		tm.AddExplorerTab(server)
+	})
+
+	capabilitiesLabel := widget.NewLabel(formatCapabilities(tm.providerCapabilities(server.ProviderType)))
+	capabilitiesLabel.Wrapping = fyne.TextWrapWord
+
 	panel := container.NewVBox(
 		menu,
 		widget.NewLabel(fmt.Sprintf("Connected to %s (%s)", server.Name, server.URL)),
-		editButton,
+		capabilitiesLabel,
+		container.NewHBox(editButton, schemasButton, probeButton, explorerButton),
 	)
 
 	configTab := container.NewTabItem("Config", panel)
@@ -142,42 +207,107 @@ func (tm *TabManager) AddServerConfigTab(server models.Server) {
 	tm.tabContainer.Select(configTab)
 }
 
-// AddDashboardTab adds a dashboard monitoring tab
-func (tm *TabManager) AddDashboardTab(subscriptions []models.Subscription) {
-	dashboardContainer := container.NewVBox(
-		widget.NewLabel("Message Monitoring Dashboard"),
-	)
-
-	metrics := make(map[string]*widget.Label)
-	for _, sub := range subscriptions {
-		label := widget.NewLabel(fmt.Sprintf("Sub: %s - Messages received: 0", sub.SubName))
-		metrics[sub.SubName] = label
-		dashboardContainer.Add(label)
-	}
-
-	dashboardTab := container.NewTabItem("Dashboard", dashboardContainer)
-	tm.tabContainer.Append(dashboardTab)
-
-	// Start monitoring
-	go tm.monitorMessages(metrics)
-}
-
 // AddTopicTab adds a tab for publishing messages to a topic
 func (tm *TabManager) AddTopicTab(topic models.Topic) {
 	messageContainer := container.NewVBox()
 
+	subjectKind := "subject"
+	if provider, ok := tm.serverService.GetMessagingProvider(topic.ServerID); ok {
+		subjectKind = messaging.CapabilitiesOf(provider).SubjectKind
+	}
 	subjectEntry := widget.NewEntry()
 	subjectEntry.SetText(topic.TopicName)
-	subjectEntry.SetPlaceHolder("Enter subject to publish to...")
+	subjectEntry.SetPlaceHolder(fmt.Sprintf("Enter %s to publish to...", subjectKind))
 
 	messageEntry := widget.NewMultiLineEntry()
 	messageEntry.SetPlaceHolder("Enter message payload here...")
 
-	sendButton := widget.NewButton("Send", func() {
-		subject := subjectEntry.Text
-		payload := messageEntry.Text
-		if subject == "" {
-			subject = topic.TopicName
+	// File input keeps the raw bytes of the chosen file
+	var fileData []byte
+	fileLabel := widget.NewLabel("No file selected")
+	fileButton := widget.NewButtonWithIcon("Choose File...", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				components.ErrorDialog(fmt.Errorf("failed to read file: %w", err), tm.window)
+				return
+			}
+			fileData = data
+			fileLabel.SetText(fmt.Sprintf("%s (%d bytes)", reader.URI().Name(), len(data)))
+		}, tm.window)
+	})
+	fileRow := container.NewHBox(fileButton, fileLabel)
+	fileRow.Hide()
+
+	encodings := make([]string, len(payload.InputEncodings))
+	for i, encoding := range payload.InputEncodings {
+		encodings[i] = string(encoding)
+	}
+	encodingSelect := widget.NewSelect(encodings, func(value string) {
+		if payload.InputEncoding(value) == payload.InputFile {
+			messageEntry.Hide()
+			fileRow.Show()
+		} else {
+			fileRow.Hide()
+			messageEntry.Show()
+		}
+	})
+	encodingSelect.SetSelected(string(payload.InputText))
+
+	// Payloads are validated against the topic's JSON Schema, if one is attached
+	schema := compileJSONSchema(topic.JSONSchema)
+	schemaLabel := widget.NewLabel("")
+	updateSchemaLabel := func() {
+		switch {
+		case schema == nil:
+			schemaLabel.SetText("JSON Schema: none")
+		case topic.RejectInvalid:
+			schemaLabel.SetText("JSON Schema: invalid payloads are refused")
+		default:
+			schemaLabel.SetText("JSON Schema: invalid payloads need confirmation")
+		}
+	}
+	updateSchemaLabel()
+
+	schemaButton := widget.NewButtonWithIcon("JSON Schema...", theme.DocumentIcon(), func() {
+		tm.showJSONSchemaDialog("Topic JSON Schema", topic.JSONSchema, &topic.RejectInvalid, func(text string, rejectInvalid bool) error {
+			if err := tm.messageService.SetTopicSchema(topic.ID, text, rejectInvalid); err != nil {
+				return err
+			}
+			topic.JSONSchema = text
+			topic.RejectInvalid = rejectInvalid
+			schema = compileJSONSchema(text)
+			updateSchemaLabel()
+			return nil
+		})
+	})
+
+	// Templates are rendered from the message entry with the topic's sequence number
+	templates := tm.newTemplatePanel(topic.ID, messageEntry, func() {
+		encodingSelect.SetSelected(string(payload.InputText))
+	})
+
+	// publish encodes and sends a payload; seq is the template sequence number, or 0
+	publish := func(subject string, data []byte, encoding payload.InputEncoding, seq int64) {
+		// JSON text is encoded with the schema mapped to the subject, if any
+		text := data
+		codecName := ""
+		if encoding == payload.InputText && len(data) > 0 {
+			var err error
+			data, codecName, err = tm.codecService.Encode(topic.ServerID, subject, data)
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return
+			}
 		}
 
 		provider, ok := tm.serverService.GetMessagingProvider(topic.ServerID)
@@ -186,15 +316,102 @@ func (tm *TabManager) AddTopicTab(topic models.Topic) {
 			return
 		}
 
-		err := tm.messageService.PublishMessage(provider, subject, payload)
+		err := tm.messageService.PublishMessage(provider, subject, data)
 		if err != nil {
 			components.ErrorDialog(err, tm.window)
 			return
 		}
 
-		messageContainer.Add(widget.NewLabel(payload))
+		sent := payload.Summary(data)
+		if codecName != "" {
+			sent = fmt.Sprintf("[%s] %s", codecName, payload.Summary(text))
+		}
+		messageContainer.Add(widget.NewLabel(sent))
 		messageContainer.Refresh()
-		messageEntry.SetText("")
+
+		// Templates stay in the entry so they can be sent again
+		if seq > 0 {
+			tm.templateService.MarkSent(topic.ID, seq)
+		} else if encoding != payload.InputFile {
+			messageEntry.SetText("")
+		}
+	}
+
+	// send validates a payload against the topic's JSON Schema before publishing it
+	send := func(subject string, data []byte, encoding payload.InputEncoding, seq int64) {
+		if schema == nil || len(data) == 0 {
+			publish(subject, data, encoding, seq)
+			return
+		}
+
+		violations := schemaViolations(schema, data)
+		switch {
+		case len(violations) == 0:
+			publish(subject, data, encoding, seq)
+		case topic.RejectInvalid:
+			components.ErrorDialog(fmt.Errorf("payload violates the topic JSON Schema:\n%s", formatViolations(violations)), tm.window)
+		default:
+			components.ConfirmDialog(
+				"Schema Violations",
+				fmt.Sprintf("The payload violates the topic JSON Schema:\n%s\n\nSend anyway?", formatViolations(violations)),
+				func(confirmed bool) {
+					if confirmed {
+						publish(subject, data, encoding, seq)
+					}
+				},
+				tm.window,
+			).Show()
+		}
+	}
+
+	// buildPayload returns the subject and payload to send, rendering templates
+	buildPayload := func() (string, []byte, payload.InputEncoding, int64, bool) {
+		subject := subjectEntry.Text
+		if subject == "" {
+			subject = topic.TopicName
+		}
+
+		encoding := payload.InputEncoding(encodingSelect.Selected)
+		if encoding == payload.InputFile {
+			return subject, fileData, encoding, 0, true
+		}
+
+		if encoding == payload.InputText && templates.Enabled() {
+			data, seq, err := templates.Render()
+			if err != nil {
+				components.ErrorDialog(err, tm.window)
+				return "", nil, encoding, 0, false
+			}
+			return subject, data, encoding, seq, true
+		}
+
+		data, err := payload.DecodeInput(messageEntry.Text, encoding)
+		if err != nil {
+			components.ErrorDialog(err, tm.window)
+			return "", nil, encoding, 0, false
+		}
+		return subject, data, encoding, 0, true
+	}
+
+	sendButton := widget.NewButton("Send", func() {
+		if subject, data, encoding, seq, ok := buildPayload(); ok {
+			send(subject, data, encoding, seq)
+		}
+	})
+
+	// The preview sends exactly the payload shown
+	previewButton := widget.NewButtonWithIcon("Preview", theme.VisibilityIcon(), func() {
+		subject, data, encoding, seq, ok := buildPayload()
+		if !ok {
+			return
+		}
+		tm.showPayloadPreview(subject, data, func() {
+			send(subject, data, encoding, seq)
+		})
+	})
+
+	benchmarkButton := widget.NewButtonWithIcon("Benchmark...", theme.MediaFastForwardIcon(), func() {
+		tm.AddBenchmarkTab(topic)
 	})
 
 	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
@@ -206,11 +423,14 @@ func (tm *TabManager) AddTopicTab(topic models.Topic) {
 			widget.NewLabel(fmt.Sprintf("Publisher: %s", topic.TopicName)),
 			closeButton,
 		),
-		widget.NewLabel("Subject:"),
+		container.NewHBox(schemaButton, schemaLabel),
+		widget.NewLabel(kindTitle(subjectKind)+":"),
 		subjectEntry,
-		widget.NewLabel("Message:"),
+		container.NewHBox(widget.NewLabel("Message:"), encodingSelect),
+		templates.Content(),
 		messageEntry,
-		sendButton,
+		fileRow,
+		container.NewHBox(sendButton, previewButton, benchmarkButton),
 		widget.NewSeparator(),
 		widget.NewLabel("Sent Messages:"),
 		messageContainer,
@@ -223,28 +443,192 @@ func (tm *TabManager) AddTopicTab(topic models.Topic) {
 
 // AddSubscriptionTab adds a tab for receiving messages from a subscription
 func (tm *TabManager) AddSubscriptionTab(subscription models.Subscription) {
-	messageContainer := container.NewVBox()
-	messageChan := make(chan string, 100)
-
 	provider, ok := tm.serverService.GetMessagingProvider(subscription.ServerID)
 	if !ok {
 		log.Printf("No messaging provider connection for server %d", subscription.ServerID)
 		return
 	}
 
-	// Start subscription
+	queue, err := messaging.NewMessageQueue(messaging.QueueOptions{Policy: subscription.BackpressurePolicy})
+	if err != nil {
+		log.Printf("Error creating queue for sub %s: %v", subscription.SubName, err)
+		return
+	}
+
+	capacity := fyne.CurrentApp().Preferences().IntWithFallback(messageListCapacityKey, components.DefaultMessageListCapacity)
+	messageList := components.NewMessageList(capacity)
+	messageList.OnCapacityChanged = func(capacity int) {
+		fyne.CurrentApp().Preferences().SetInt(messageListCapacityKey, capacity)
+	}
+
+	messageDetail := components.NewMessageDetail(tm.window)
+	messageList.OnSelected = messageDetail.Show
+
+	// Incoming messages are checked against the subscription's JSON Schema, if any
+	var schema atomic.Pointer[jsonschema.Schema]
+	schema.Store(compileJSONSchema(subscription.JSONSchema))
+	schemaButton := widget.NewButtonWithIcon("JSON Schema...", theme.DocumentIcon(), func() {
+		tm.showJSONSchemaDialog("Subscription JSON Schema", subscription.JSONSchema, nil, func(text string, _ bool) error {
+			if err := tm.messageService.SetSubscriptionSchema(subscription.ID, text); err != nil {
+				return err
+			}
+			subscription.JSONSchema = text
+			schema.Store(compileJSONSchema(text))
+			return nil
+		})
+	})
+
+	// Client-side filters hide or highlight messages without resubscribing
+	applyFilters := func(rules []filter.Rule) error {
+		set, err := filter.Compile(rules)
+		if err != nil {
+			return err
+		}
+		if set.Len() == 0 {
+			messageList.SetFilter(nil)
+		} else {
+			messageList.SetFilter(set.Match)
+		}
+		return nil
+	}
+	if err := applyFilters(subscription.Filters); err != nil {
+		log.Printf("Ignoring filters of sub %s: %v", subscription.SubName, err)
+	}
+	activeFilters := subscription.Filters
+	filtersButton := widget.NewButtonWithIcon("Filters...", theme.SearchIcon(), func() {
+		tm.showFiltersDialog(subscription.SubName, activeFilters, func(rules []filter.Rule, save bool) error {
+			if save {
+				if err := tm.messageService.SetSubscriptionFilters(subscription.ID, rules); err != nil {
+					return err
+				}
+			}
+			if err := applyFilters(rules); err != nil {
+				return err
+			}
+			activeFilters = rules
+			return nil
+		})
+	})
+
+	alertsButton := widget.NewButtonWithIcon("Alerts...", theme.WarningIcon(), func() {
+		tm.showAlertRulesDialog(subscription)
+	})
+
+	// An optional script runs on each message before it is decoded and listed
+	var script atomic.Pointer[scripting.Script]
+	scriptStatus := widget.NewLabel("")
+	scriptStatus.Truncation = fyne.TextTruncateEllipsis
+	loadScript := func() {
+		if strings.TrimSpace(subscription.Script) == "" {
+			script.Store(nil)
+			scriptStatus.Hide()
+			return
+		}
+		compiled, err := scripting.Compile(subscription.Script, subscription.ScriptTimeout)
+		if err != nil {
+			log.Printf("Ignoring script of sub %s: %v", subscription.SubName, err)
+			script.Store(nil)
+			scriptStatus.SetText("Script not loaded: " + err.Error())
+			scriptStatus.Show()
+			return
+		}
+		script.Store(compiled)
+		scriptStatus.SetText(formatScriptStats(compiled.Stats()))
+		scriptStatus.Show()
+	}
+	loadScript()
+	scriptButton := widget.NewButtonWithIcon("Script...", theme.ComputerIcon(), func() {
+		tm.showScriptDialog(fmt.Sprintf("Script: %s", subscription.SubName), subscription.Script, subscription.ScriptTimeout,
+			func(text string, timeout time.Duration) error {
+				if err := tm.messageService.SetSubscriptionScript(subscription.ID, text, timeout); err != nil {
+					return err
+				}
+				subscription.Script, subscription.ScriptTimeout = text, timeout
+				loadScript()
+				return nil
+			})
+	})
+	scriptHost := scripting.Host{
+		Publish: func(subject string, data []byte, headers map[string]string) error {
+			return tm.messageService.PublishMessageWithHeaders(provider, subject, data, headers)
+		},
+		Log: func(line string) {
+			log.Printf("Script of sub %s: %s", subscription.SubName, line)
+		},
+	}
+	if err := tm.alertService.Watch(subscription); err != nil {
+		log.Printf("Error loading alert rules for sub %s: %v", subscription.SubName, err)
+	}
+
+	// Start subscription. A failure, such as a saved pattern the provider no
+	// longer accepts, is shown in the tab.
+	subscribeStatus := widget.NewLabel("")
+	subscribeStatus.Wrapping = fyne.TextWrapWord
+	subscribeStatus.Importance = widget.DangerImportance
+	subscribeStatus.Hide()
 	go func() {
-		err := tm.messageService.Subscribe(provider, subscription.SubName, subscription.SubjectPattern, messageChan)
+		err := tm.messageService.Subscribe(provider, subscription.SubName, subscription.SubjectPattern, queue)
 		if err != nil {
 			log.Printf("Error subscribing to %s: %v", subscription.SubjectPattern, err)
+			if err := queue.Close(); err != nil {
+				log.Printf("Error closing queue for sub %s: %v", subscription.SubName, err)
+			}
+			fyne.Do(func() {
+				subscribeStatus.SetText(fmt.Sprintf("Not subscribed: %v", err))
+				subscribeStatus.Show()
+			})
 		}
 	}()
 
-	// Monitor messages
+	// Monitor messages; the list batches them onto the UI thread
 	go func() {
-		for payload := range messageChan {
-			messageContainer.Add(widget.NewLabel(payload))
-			messageContainer.Refresh()
+		for {
+			msg, ok := queue.Pop()
+			if !ok {
+				return
+			}
+			if compiled := script.Load(); compiled != nil {
+				result, err := compiled.Run(scripting.Message{Subject: msg.Subject, Data: msg.Data, Headers: msg.Headers}, scriptHost)
+				if err == nil && result.Drop {
+					continue
+				}
+				// Errors are counted in the status line and the message is kept
+				msg.Subject, msg.Data, msg.Headers = result.Message.Subject, result.Message.Data, result.Message.Headers
+			}
+			if err := tm.codecService.Decode(subscription.ServerID, &msg); err != nil {
+				log.Printf("Error decoding message on %s: %v", msg.Subject, err)
+			}
+			if contract := schema.Load(); contract != nil {
+				body := msg.Data
+				if msg.Decoded != nil {
+					body = msg.Decoded
+				}
+				msg.Violations = schemaViolations(contract, body)
+				if len(msg.Violations) > 0 {
+					tm.messageService.RecordViolation(subscription.SubName)
+				}
+			}
+			messageList.Add(msg)
+		}
+	}()
+
+	// Refresh the script counts while the tab is open
+	scriptCtx, stopScriptStatus := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(scriptStatusInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-scriptCtx.Done():
+				return
+			case <-ticker.C:
+				if compiled := script.Load(); compiled != nil {
+					text := formatScriptStats(compiled.Stats())
+					fyne.Do(func() {
+						scriptStatus.SetText(text)
+					})
+				}
+			}
 		}
 	}()
 
@@ -252,41 +636,69 @@ func (tm *TabManager) AddSubscriptionTab(subscription models.Subscription) {
 		tm.showDeleteSubscriptionDialog(subscription)
 	})
 
-	content := container.NewVBox(
-		container.NewHBox(
-			widget.NewLabel(fmt.Sprintf("Sub: %s (Pattern: %s)", subscription.SubName, subscription.SubjectPattern)),
-			closeButton,
+	messagesSplit := container.NewHSplit(messageList.Content(), messageDetail.Content())
+	messagesSplit.Offset = 0.55
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(
+				widget.NewLabel(fmt.Sprintf("Sub: %s (Pattern: %s, Policy: %s)", subscription.SubName, subscription.SubjectPattern, queue.Policy())),
+				schemaButton,
+				filtersButton,
+				alertsButton,
+				scriptButton,
+				closeButton,
+			),
+			subscribeStatus,
 		),
-		messageContainer,
+		scriptStatus, nil, nil,
+		messagesSplit,
 	)
 
 	subName := fmt.Sprintf("sub-%v", subscription.SubName)
 	tab := container.NewTabItemWithIcon(subName, theme.ViewRefreshIcon(), content)
+	tm.cleanups[tab] = func() {
+		messageList.Stop()
+		stopScriptStatus()
+		tm.alertService.Unwatch(subscription.SubName)
+		err := tm.messageService.Unsubscribe(provider, subscription.SubName, subscription.SubjectPattern)
+		if err != nil {
+			log.Printf("Error unsubscribing from %s: %v", subscription.SubjectPattern, err)
+		}
+	}
 	tm.tabContainer.Append(tab)
 }
 
 // Helper methods for dialogs and operations
-func (tm *TabManager) showAddTopicDialog(serverID int) {
+
+// showAddTopicDialog asks for a topic to save, pre-filled with name, and
+// calls onSaved with the saved name
+func (tm *TabManager) showAddTopicDialog(server models.Server, name string, onSaved func(name string)) {
+	caps := tm.providerCapabilities(server.ProviderType)
 	entry := widget.NewEntry()
-	entry.SetPlaceHolder("Enter topic name...")
+	entry.SetPlaceHolder(fmt.Sprintf("Enter %s name...", caps.SubjectKind))
+	entry.SetText(name)
 
 	dialog := components.FormDialog(
 		"Add Topic",
 		"Confirm",
 		"Cancel",
 		[]*widget.FormItem{
-			widget.NewFormItem("Topic Name", entry),
+			widget.NewFormItem(kindTitle(caps.SubjectKind)+" Name", entry),
 		},
 		func(confirmed bool) {
 			if confirmed && entry.Text != "" {
-				err := tm.messageService.SaveTopic(serverID, entry.Text)
+				if err := caps.ValidateSubject(entry.Text); err != nil {
+					components.ErrorDialog(err, tm.window)
+					return
+				}
+				err := tm.messageService.SaveTopic(server.ID, entry.Text)
 				if err != nil {
 					log.Printf("Error saving topic: %v", err)
 					components.ErrorDialog(err, tm.window)
 					return
 				}
-				// Refresh the server tabs to show the new topic
-				tm.RefreshServerTabs(serverID)
+				onSaved(entry.Text)
 			}
 		},
 		tm.window,
@@ -294,12 +706,29 @@ func (tm *TabManager) showAddTopicDialog(serverID int) {
 	dialog.Show()
 }
 
-func (tm *TabManager) showAddSubscriptionDialog(serverID int) {
+// showAddSubscriptionDialog asks for a subscription to save, pre-filled with
+// pattern, and calls onSaved with the saved subscription name
+func (tm *TabManager) showAddSubscriptionDialog(server models.Server, pattern string, onSaved func(subName string)) {
+	caps := tm.providerCapabilities(server.ProviderType)
 	nameEntry := widget.NewEntry()
 	nameEntry.SetPlaceHolder("Enter subscription name...")
 
 	subjectEntry := widget.NewEntry()
-	subjectEntry.SetPlaceHolder("Enter subject pattern (e.g., user.*, orders.>, specific.subject)")
+	subjectEntry.SetPlaceHolder(caps.PatternHint())
+	subjectEntry.SetText(pattern)
+
+	// Only providers with wildcards subscribe to patterns
+	subjectLabel := kindTitle(caps.SubjectKind)
+	if caps.Wildcards == messaging.WildcardsNATS {
+		subjectLabel += " Pattern"
+	}
+
+	policies := make([]string, len(messaging.BackpressurePolicies))
+	for i, policy := range messaging.BackpressurePolicies {
+		policies[i] = string(policy)
+	}
+	policySelect := widget.NewSelect(policies, func(value string) {})
+	policySelect.SetSelected(string(messaging.PolicyDropOldest))
 
 	dialog := components.FormDialog(
 		"Add Subscription",
@@ -307,18 +736,23 @@ func (tm *TabManager) showAddSubscriptionDialog(serverID int) {
 		"Cancel",
 		[]*widget.FormItem{
 			widget.NewFormItem("Subscription Name", nameEntry),
-			widget.NewFormItem("Subject Pattern", subjectEntry),
+			widget.NewFormItem(subjectLabel, subjectEntry),
+			widget.NewFormItem("When Full", policySelect),
 		},
 		func(confirmed bool) {
 			if confirmed && nameEntry.Text != "" && subjectEntry.Text != "" {
-				err := tm.messageService.SaveSubscription(serverID, nameEntry.Text, subjectEntry.Text)
+				if err := caps.ValidatePattern(subjectEntry.Text); err != nil {
+					components.ErrorDialog(err, tm.window)
+					return
+				}
+				policy := messaging.BackpressurePolicy(policySelect.Selected)
+				err := tm.messageService.SaveSubscription(server.ID, nameEntry.Text, subjectEntry.Text, policy)
 				if err != nil {
 					log.Printf("Error saving subscription: %v", err)
 					components.ErrorDialog(err, tm.window)
 					return
 				}
-				// Refresh the server tabs to show the new subscription
-				tm.RefreshServerTabs(serverID)
+				onSaved(nameEntry.Text)
 			}
 		},
 		tm.window,
@@ -381,6 +815,9 @@ func (tm *TabManager) showDeleteSubscriptionDialog(subscription models.Subscript
 		func(confirmed bool) {
 			if confirmed {
 				tm.messageService.DeleteSubscription(subscription.SubName, subscription.ServerID)
+				if err := tm.alertService.DeleteRules(subscription.ID); err != nil {
+					log.Printf("Error deleting alert rules for sub %s: %v", subscription.SubName, err)
+				}
 				tm.removeTabByName(fmt.Sprintf("sub-%v", subscription.SubName))
 			}
 		},
@@ -399,6 +836,7 @@ func (tm *TabManager) disconnectServer(serverID int) {
 func (tm *TabManager) removeTabByName(tabName string) {
 	for i, tab := range tm.tabContainer.Items {
 		if tab.Text == tabName {
+			tm.cleanupTab(tab)
 			tm.tabContainer.Remove(tab)
 			if i < len(tm.tabContainer.Items) {
 				tm.tabContainer.Select(tm.tabContainer.Items[i])
@@ -408,14 +846,3 @@ func (tm *TabManager) removeTabByName(tabName string) {
 	}
 	tm.tabContainer.Refresh()
 }
-
-func (tm *TabManager) monitorMessages(metrics map[string]*widget.Label) {
-	for {
-		counts := tm.messageService.GetDashboardCounts()
-		for subName, label := range metrics {
-			count := counts[subName]
-			label.SetText(fmt.Sprintf("Sub: %s - Messages received: %d", subName, count))
-		}
-		time.Sleep(1 * time.Second)
-	}
-}
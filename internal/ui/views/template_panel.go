@@ -0,0 +1,223 @@
+package views
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/devalexandre/broker-ui/internal/models"
+	"github.com/devalexandre/broker-ui/internal/payload"
+	"github.com/devalexandre/broker-ui/internal/templating"
+	"github.com/devalexandre/broker-ui/internal/ui/components"
+)
+
+// templateHelp lists the placeholders available in payload templates
+const templateHelp = `Placeholders: {{uuid}}, {{now}}, {{now "2006-01-02"}}, {{nowUnix}}, {{nowUnixMilli}}, {{seq}},
+{{randInt 1 100}}, {{randFloat 0 1}}, {{randString 8}}, {{randChoice "a" "b"}}, {{randBool}},
+{{name}}, {{firstName}}, {{lastName}}, {{email}}, {{word}}, {{env "X"}} and variables as {{.name}}`
+
+// templatePanel manages the saved payload templates of a publisher tab. The
+// message entry holds the template body while rendering is enabled.
+type templatePanel struct {
+	tm           *TabManager
+	topicID      int
+	messageEntry *widget.Entry
+	onUse        func()
+
+	templates      []models.PayloadTemplate
+	templateSelect *widget.Select
+	renderCheck    *widget.Check
+	variablesEntry *widget.Entry
+	content        fyne.CanvasObject
+}
+
+// newTemplatePanel creates the template controls; onUse is called when a template is loaded
+func (tm *TabManager) newTemplatePanel(topicID int, messageEntry *widget.Entry, onUse func()) *templatePanel {
+	p := &templatePanel{
+		tm:           tm,
+		topicID:      topicID,
+		messageEntry: messageEntry,
+		onUse:        onUse,
+	}
+
+	p.variablesEntry = widget.NewMultiLineEntry()
+	p.variablesEntry.SetPlaceHolder("Variables, one name=value per line")
+	p.variablesEntry.SetMinRowsVisible(3)
+
+	helpLabel := widget.NewLabel(templateHelp)
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	options := container.NewVBox(helpLabel, p.variablesEntry)
+	options.Hide()
+
+	p.renderCheck = widget.NewCheck("Render as template", func(checked bool) {
+		if checked {
+			options.Show()
+		} else {
+			options.Hide()
+		}
+	})
+
+	p.templateSelect = widget.NewSelect(nil, p.load)
+	p.templateSelect.PlaceHolder = "Saved templates"
+
+	saveButton := widget.NewButtonWithIcon("Save Template", theme.DocumentSaveIcon(), p.showSaveDialog)
+	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), p.deleteSelected)
+
+	p.content = container.NewVBox(
+		container.NewBorder(nil, nil, p.renderCheck, container.NewHBox(saveButton, deleteButton), p.templateSelect),
+		options,
+	)
+
+	p.reload("")
+	return p
+}
+
+// Content returns the canvas object to place in a layout
+func (p *templatePanel) Content() fyne.CanvasObject {
+	return p.content
+}
+
+// Enabled reports whether the message entry is rendered as a template
+func (p *templatePanel) Enabled() bool {
+	return p.renderCheck.Checked
+}
+
+// Render renders the message entry with the next sequence number of the topic
+func (p *templatePanel) Render() ([]byte, int64, error) {
+	vars, err := templating.ParseVariables(p.variablesEntry.Text)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid variables: %w", err)
+	}
+	return p.tm.templateService.Render(p.topicID, p.messageEntry.Text, vars)
+}
+
+// reload refreshes the saved templates, keeping selected when given
+func (p *templatePanel) reload(selected string) {
+	templates, err := p.tm.templateService.GetTemplates(p.topicID)
+	if err != nil {
+		components.ErrorDialog(fmt.Errorf("failed to load templates: %w", err), p.tm.window)
+		return
+	}
+	p.templates = templates
+
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	p.templateSelect.SetOptions(names)
+	if selected != "" {
+		p.templateSelect.SetSelected(selected)
+	} else {
+		p.templateSelect.ClearSelected()
+	}
+}
+
+// load fills the message entry and variables from a saved template
+func (p *templatePanel) load(name string) {
+	for _, t := range p.templates {
+		if t.Name == name {
+			p.messageEntry.SetText(t.Body)
+			p.variablesEntry.SetText(templating.FormatVariables(t.Variables))
+			p.renderCheck.SetChecked(true)
+			if p.onUse != nil {
+				p.onUse()
+			}
+			return
+		}
+	}
+}
+
+// showSaveDialog saves the message entry and variables as a named template
+func (p *templatePanel) showSaveDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Enter template name...")
+	nameEntry.SetText(p.templateSelect.Selected)
+
+	dialog := components.FormDialog(
+		"Save Template",
+		"Save",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Template Name", nameEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed || nameEntry.Text == "" {
+				return
+			}
+
+			vars, err := templating.ParseVariables(p.variablesEntry.Text)
+			if err != nil {
+				components.ErrorDialog(fmt.Errorf("invalid variables: %w", err), p.tm.window)
+				return
+			}
+			if err := p.tm.templateService.SaveTemplate(p.topicID, nameEntry.Text, p.messageEntry.Text, vars); err != nil {
+				components.ErrorDialog(err, p.tm.window)
+				return
+			}
+			p.reload(nameEntry.Text)
+		},
+		p.tm.window,
+	)
+	dialog.Show()
+}
+
+// deleteSelected deletes the selected template after confirmation
+func (p *templatePanel) deleteSelected() {
+	name := p.templateSelect.Selected
+	for _, t := range p.templates {
+		if t.Name != name {
+			continue
+		}
+
+		templateID := t.ID
+		components.ConfirmDialog(
+			"Delete Template",
+			fmt.Sprintf("Are you sure you want to delete the template %q?", name),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := p.tm.templateService.DeleteTemplate(templateID); err != nil {
+					components.ErrorDialog(err, p.tm.window)
+					return
+				}
+				p.reload("")
+			},
+			p.tm.window,
+		).Show()
+		return
+	}
+}
+
+// showPayloadPreview shows a rendered payload and calls onSend if the user sends it
+func (tm *TabManager) showPayloadPreview(subject string, data []byte, onSend func()) {
+	format := payload.DetectFormat(data)
+	pretty, err := payload.Pretty(data, format)
+	if err != nil {
+		pretty = payload.Summary(data)
+	}
+
+	previewEntry := widget.NewMultiLineEntry()
+	previewEntry.SetText(pretty)
+	previewEntry.Wrapping = fyne.TextWrapOff
+	previewEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	previewEntry.SetMinRowsVisible(14)
+
+	info := widget.NewLabel(fmt.Sprintf("Subject: %s - %d bytes (%s)", subject, len(data), format))
+
+	previewDialog := dialog.NewCustomConfirm("Payload Preview", "Send", "Close",
+		container.NewBorder(info, nil, nil, nil, previewEntry),
+		func(confirmed bool) {
+			if confirmed {
+				onSend()
+			}
+		},
+		tm.window,
+	)
+	previewDialog.Resize(fyne.NewSize(640, 480))
+	previewDialog.Show()
+}
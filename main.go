@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/ed25519"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"github.com/devalexandre/broker-ui/internal/cli"
 	"github.com/devalexandre/broker-ui/internal/database"
 	"github.com/devalexandre/broker-ui/internal/ui/views"
 	"github.com/fynelabs/fyneselfupdate"
@@ -13,8 +18,16 @@ import (
 )
 
 func main() {
+	// Commands run headless and never open a window
+	if cli.Handles(os.Args[1:]) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := cli.Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+
 	// Initialize database
-	db, err := database.New("./nats_servers.db")
+	db, err := database.New(cli.DefaultDBPath)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}